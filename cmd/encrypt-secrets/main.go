@@ -0,0 +1,50 @@
+package main
+
+import (
+	"auth-project/conf"
+	"auth-project/src/infrastructure/encryption"
+	"auth-project/src/infrastructure/storage"
+	"context"
+	"flag"
+	"log"
+)
+
+// Encrypts plaintext sensitive columns of existing rows and re-encrypts values that were encrypted
+// with an older key version or without the binding to the row. The service reads the legacy values
+// only while encryption.accept_legacy_values is set, it is unset once this command reports no legacy values.
+func main() {
+	dryRun := flag.Bool("dry-run", false, "only count the rows that need to be encrypted")
+	flag.Parse()
+
+	// Init configs
+	conf.InitConfig()
+
+	// the legacy values are read by the command regardless of the config
+	envelopeCipher := encryption.MustLoadEnvelopeCipher(true)
+
+	// Init database connection
+	db := storage.InitDB()
+	defer db.Close()
+
+	ctx := context.Background()
+
+	legacy := 0
+	for _, column := range sensitiveColumns {
+		updated, total, err := encryptColumn(ctx, db, envelopeCipher, column, *dryRun)
+		if err != nil {
+			log.Fatalf("error encrypting %s.%s: %s", column.table, column.name, err.Error())
+		}
+
+		if *dryRun {
+			log.Printf("%d of %d %s.%s values need to be encrypted", updated, total, column.table, column.name)
+			legacy += updated
+			continue
+		}
+
+		log.Printf("%d of %d %s.%s values encrypted", updated, total, column.table, column.name)
+	}
+
+	if legacy == 0 {
+		log.Printf("no legacy values left, encryption.accept_legacy_values can be unset")
+	}
+}
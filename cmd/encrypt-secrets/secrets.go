@@ -0,0 +1,77 @@
+package main
+
+import (
+	"auth-project/src/infrastructure/encryption"
+	"context"
+	"fmt"
+	"github.com/uptrace/bun"
+)
+
+// sensitiveColumn is the encrypted column of the table, the values are bound to the primary key of the row
+type sensitiveColumn struct {
+	table string
+	name  string
+	pk    string
+}
+
+var sensitiveColumns = []sensitiveColumn{
+	{table: "users", name: "google_secret", pk: "id"},
+	{table: "kyc_submissions", name: "id_card", pk: "id"},
+	{table: "user_configs", name: "id_card", pk: "user_id"},
+}
+
+// encryptColumn encrypts the values of the column which are not encrypted with the current key version
+// and the binding to the row, it returns the number of such values and of all values
+func encryptColumn(ctx context.Context, db bun.IDB, envelopeCipher *encryption.EnvelopeCipher,
+	column sensitiveColumn, dryRun bool) (int, int, error) {
+
+	var rows []struct {
+		ID    string `bun:"id"`
+		Value string `bun:"value"`
+	}
+	err := db.NewSelect().
+		Table(column.table).
+		ColumnExpr("? AS id, ? AS value", bun.Ident(column.pk), bun.Ident(column.name)).
+		Where("? IS NOT NULL", bun.Ident(column.name)).
+		Scan(ctx, &rows)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	updated := 0
+	for _, row := range rows {
+		if envelopeCipher.IsCurrent(row.Value) {
+			continue
+		}
+
+		field := encryption.Field{Table: column.table, Column: column.name, RowID: row.ID}
+
+		plaintext, err := envelopeCipher.Decrypt(row.Value, field)
+		if err != nil {
+			return updated, len(rows), fmt.Errorf("decrypting row %s: %w", row.ID, err)
+		}
+
+		value, err := envelopeCipher.Encrypt(plaintext, field)
+		if err != nil {
+			return updated, len(rows), fmt.Errorf("encrypting row %s: %w", row.ID, err)
+		}
+
+		updated++
+		if dryRun {
+			continue
+		}
+
+		// the previous value in the condition protects against concurrent changes
+		_, err = db.NewUpdate().
+			Table(column.table).
+			Set("? = ?", bun.Ident(column.name), value).
+			Where("? = ?", bun.Ident(column.pk), row.ID).
+			Where("? = ?", bun.Ident(column.name), row.Value).
+			Exec(ctx)
+		if err != nil {
+			return updated, len(rows), fmt.Errorf("updating row %s: %w", row.ID, err)
+		}
+	}
+
+	return updated, len(rows), nil
+}
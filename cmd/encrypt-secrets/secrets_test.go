@@ -0,0 +1,122 @@
+package main
+
+import (
+	"auth-project/src/infrastructure/encryption"
+	"auth-project/src/infrastructure/storage/storagetest"
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/uptrace/bun"
+)
+
+var usersColumn = sensitiveColumn{table: "users", name: "google_secret", pk: "id"}
+
+func newCipher(t *testing.T, currentVersion int) *encryption.EnvelopeCipher {
+	t.Helper()
+
+	ec, err := encryption.NewEnvelopeCipher(map[int][]byte{
+		1: bytes.Repeat([]byte{1}, 32),
+		2: bytes.Repeat([]byte{2}, 32),
+	}, currentVersion, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ec
+}
+
+func insertUser(t *testing.T, db *bun.DB, id, googleSecret string) {
+	t.Helper()
+
+	_, err := db.ExecContext(context.Background(),
+		"INSERT INTO users (id, referral_link, google_secret) VALUES (?, ?, ?)", id, id, googleSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func googleSecret(t *testing.T, db *bun.DB, id string) string {
+	t.Helper()
+
+	var value string
+	err := db.NewSelect().Table("users").Column("google_secret").Where("id = ?", id).
+		Scan(context.Background(), &value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return value
+}
+
+func TestEncryptColumn(t *testing.T) {
+	ctx := context.Background()
+	db := storagetest.NewSQLite(t)
+
+	oldCipher := newCipher(t, 1)
+	envelopeCipher := newCipher(t, 2)
+
+	outdated, err := oldCipher.Encrypt("outdated", encryption.Field{Table: "users", Column: "google_secret", RowID: "u2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := envelopeCipher.Encrypt("current", encryption.Field{Table: "users", Column: "google_secret", RowID: "u3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	insertUser(t, db, "u1", "plaintext")
+	insertUser(t, db, "u2", outdated)
+	insertUser(t, db, "u3", current)
+
+	updated, total, err := encryptColumn(ctx, db, envelopeCipher, usersColumn, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated != 2 || total != 3 {
+		t.Fatalf("dry run: got %d of %d, want 2 of 3", updated, total)
+	}
+	if googleSecret(t, db, "u1") != "plaintext" {
+		t.Fatal("dry run updated the row")
+	}
+
+	updated, total, err = encryptColumn(ctx, db, envelopeCipher, usersColumn, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated != 2 || total != 3 {
+		t.Fatalf("got %d of %d, want 2 of 3", updated, total)
+	}
+
+	// the service does not accept the legacy values after the migration
+	strictCipher, err := encryption.NewEnvelopeCipher(map[int][]byte{
+		1: bytes.Repeat([]byte{1}, 32),
+		2: bytes.Repeat([]byte{2}, 32),
+	}, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for id, want := range map[string]string{"u1": "plaintext", "u2": "outdated", "u3": "current"} {
+		value := googleSecret(t, db, id)
+		if !envelopeCipher.IsCurrent(value) {
+			t.Fatalf("%s is not encrypted with the current key", id)
+		}
+
+		got, err := strictCipher.Decrypt(value, encryption.Field{Table: "users", Column: "google_secret", RowID: id})
+		if err != nil {
+			t.Fatalf("%s: %s", id, err.Error())
+		}
+		if got != want {
+			t.Fatalf("%s: got %q, want %q", id, got, want)
+		}
+	}
+
+	updated, _, err = encryptColumn(ctx, db, envelopeCipher, usersColumn, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated != 0 {
+		t.Fatalf("%d values left after the migration", updated)
+	}
+}
@@ -4,10 +4,22 @@ import (
 	"auth-project/conf"
 	"auth-project/src/infrastructure/authentication"
 	"auth-project/src/infrastructure/delivery/http"
+	"auth-project/src/infrastructure/encryption"
+	"auth-project/src/infrastructure/scheduler"
+	"auth-project/src/infrastructure/sending/email"
+	"auth-project/src/infrastructure/sending/sms"
+	"auth-project/src/infrastructure/storage/files"
+	"auth-project/src/infrastructure/storage/sessions"
 	"auth-project/src/registry"
+	"context"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/spf13/viper"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"auth-project/src/infrastructure/storage"
 	"github.com/gofiber/fiber/v2/middleware/logger"
@@ -20,22 +32,76 @@ func main() {
 	// Get environment
 	env := viper.GetString("env")
 
-	// Init Postgres connection
-	db := storage.InitPostgres()
+	// Init encryption of sensitive columns
+	fieldCipher := encryption.MustLoadEnvelopeCipher(false)
+
+	// Init database connection
+	db := storage.InitDB()
 	defer db.Close()
 
-	// Init Redis connection
-	rdb := storage.InitRedis(env)
-	defer rdb.Close()
+	// Init the session store, redis is connected only if the sessions are kept there
+	var sessionStore sessions.Store
+	switch viper.GetString("session_store.driver") {
+	case sessions.DriverDatabase:
+		databaseStore := sessions.NewDatabaseStore(db)
+		go scheduler.Every(context.Background(), viper.GetDuration("session_store.purge_interval"),
+			"purge expired session keys", databaseStore.PurgeExpired)
+		sessionStore = databaseStore
+	case sessions.DriverMemory:
+		sessionStore = sessions.NewMemoryStore()
+	default:
+		rdb := storage.InitRedis(env)
+		defer rdb.Close()
+		sessionStore = sessions.NewRedisStore(rdb)
+	}
+
+	// Init the key pair of the tokens
+	authentication.SetRSAKeys(authentication.MustLoadRSA(
+		viper.GetString("jwt.private_key_path"),
+		viper.GetString("jwt.public_key_path")))
 
 	// Init a new jwt configurator
 	jwtConf := authentication.NewJwtConfigurator(
 		viper.GetDuration("jwt.access_token_min_lifetime"),
 		viper.GetDuration("jwt.refresh_token_min_lifetime"),
-		viper.GetDuration("jwt.two_factor_auth_token_min_lifetime"))
+		viper.GetDuration("jwt.two_factor_auth_token_min_lifetime"),
+		viper.GetDuration("oauth.client_token_lifetime"))
+
+	// Init the totp settings
+	totpConf := authentication.NewTotpConfig(
+		viper.GetInt("totp.digits"),
+		viper.GetDuration("totp.period"),
+		viper.GetString("totp.algorithm"),
+		viper.GetInt64("totp.skew"))
+
+	// Init the senders, the local runs could write the emails and the sms to the log
+	if viper.GetString("sending.driver") == "log" {
+		email.SetSender(email.LogSender{})
+		sms.SetSender(sms.LogSender{})
+	}
+
+	// Init a new password hasher
+	passwordHasher, err := authentication.NewPasswordHasher(
+		viper.GetString("password.algorithm"),
+		viper.GetInt("password.bcrypt_cost"),
+		authentication.Argon2Params{
+			Memory:      viper.GetUint32("password.argon2.memory"),
+			Iterations:  viper.GetUint32("password.argon2.iterations"),
+			Parallelism: uint8(viper.GetUint("password.argon2.parallelism")),
+			SaltLength:  viper.GetUint32("password.argon2.salt_length"),
+			KeyLength:   viper.GetUint32("password.argon2.key_length"),
+		})
+	if err != nil {
+		panic(err)
+	}
 
-	// Init a new fiber application
-	app := fiber.New()
+	// Init a new file storage
+	fileStorage := files.NewStorage()
+
+	// Init a new fiber application, the body limit allows the upload of the kyc documents
+	app := fiber.New(fiber.Config{
+		BodyLimit: viper.GetInt("http.body_limit"),
+	})
 
 	app.Use(recover.New())
 
@@ -43,15 +109,50 @@ func main() {
 		app.Use(logger.New())
 	}
 
+	// Init the worker of the background jobs of the requests, e.g. the security notifications
+	worker := scheduler.NewWorker(
+		viper.GetInt("notification.workers"),
+		viper.GetInt("notification.queue_size"),
+		viper.GetDuration("notification.timeout"))
+
 	// Init a new registry
-	r := registry.NewRegistry(db, rdb, jwtConf)
+	r := registry.NewRegistry(db, sessionStore, jwtConf, totpConf, passwordHasher, fileStorage, fieldCipher, worker,
+		storage.NewErrorClassifier())
 
 	app = http.NewRouter(app, r.NewAPIController())
 
+	// Init background jobs
+	go scheduler.Every(context.Background(), viper.GetDuration("contact_change.apply_interval"),
+		"apply contact changes", r.NewContactChangeInteractor().ApplyContactChanges)
+	go scheduler.Every(context.Background(), viper.GetDuration("account_deletion.purge_interval"),
+		"purge deleted accounts", r.NewAccountInteractor().PurgeDeletedAccounts)
+	go scheduler.Every(context.Background(), viper.GetDuration("session_store.reconcile_interval"),
+		"reconcile sessions", r.NewAuthInteractor().ReconcileSessions)
+
 	app.Name(viper.GetString("project_name"))
 
-	err := app.Listen(viper.GetString("http.port"))
+	// Shut down on the signal, the queued background jobs are finished before the database is closed
+	go func() {
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+		<-quit
+
+		err := app.Shutdown()
+		if err != nil {
+			log.Printf("error shutting down the server: %s", err.Error())
+		}
+	}()
+
+	err = app.Listen(viper.GetString("http.port"))
 	if err != nil {
 		panic(err)
 	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), viper.GetDuration("notification.timeout")+5*time.Second)
+	defer cancel()
+
+	err = worker.Shutdown(ctx)
+	if err != nil {
+		log.Printf("error waiting for the background jobs: %s", err.Error())
+	}
 }
@@ -17,6 +17,17 @@ func InitConfig() {
 	fillErrWithViper()
 }
 
+// InitConfigFile reads the yaml config of the path, e.g. the example config in the tests
+func InitConfigFile(path string) {
+	viper.SetConfigFile(path)
+	viper.SetConfigType("yaml")
+	if err := viper.ReadInConfig(); err != nil {
+		log.Fatalf("error initializing configs: %s", err.Error())
+	}
+
+	fillErrWithViper()
+}
+
 func fillErrWithViper() {
 	model.TokenTimeSendErr = "code was sent less than a " + viper.GetDuration("2fa.send_timeout").String() + " ago"
 }
@@ -3,14 +3,17 @@ module auth-project
 go 1.16
 
 require (
-	github.com/dgryski/dgoogauth v0.0.0-20190221195224-5a805980a5f3
+	github.com/alicebob/miniredis/v2 v2.23.0
+	github.com/fasthttp/websocket v1.5.0
 	github.com/go-playground/validator/v10 v10.10.1
 	github.com/go-redis/redis/v8 v8.11.5
+	github.com/go-sql-driver/mysql v1.6.0
 	github.com/gofiber/fiber/v2 v2.29.0
 	github.com/gofiber/websocket/v2 v2.0.18
 	github.com/golang-jwt/jwt/v4 v4.3.0
 	github.com/lindell/go-burner-email-providers v1.0.45
 	github.com/matoous/go-nanoid/v2 v2.0.0
+	github.com/mattn/go-sqlite3 v1.14.16
 	github.com/nyaruka/phonenumbers v1.0.74
 	github.com/rs/xid v1.3.0
 	github.com/sendgrid/rest v2.6.9+incompatible // indirect
@@ -19,7 +22,9 @@ require (
 	github.com/spf13/viper v1.10.1
 	github.com/twilio/twilio-go v0.19.0
 	github.com/uptrace/bun v1.1.1
+	github.com/uptrace/bun/dialect/mysqldialect v1.1.1
 	github.com/uptrace/bun/dialect/pgdialect v1.1.1
+	github.com/uptrace/bun/dialect/sqlitedialect v1.1.1
 	github.com/uptrace/bun/driver/pgdriver v1.1.1
 	golang.org/x/crypto v0.0.0-20220315160706-3147a52a75dd
 )
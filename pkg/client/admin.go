@@ -0,0 +1,62 @@
+package client
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+	"net/http"
+)
+
+// the admin functions need the admin role, the api key or the machine client needs the admin scope as well
+
+func (c *Client) GetKycSubmissions(ctx context.Context, listReq *model.KycSubmissionListReq) (*model.KycSubmissionListResp, error) {
+	var resp model.KycSubmissionListResp
+	err := c.do(ctx, &request{method: http.MethodGet, path: apiPrefix + "/admin/kyc/submissions",
+		query: queryValues(listReq), auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) GetKycSubmission(ctx context.Context, submissionID string) (*model.KycSubmission, error) {
+	var resp model.KycSubmission
+	err := c.do(ctx, &request{method: http.MethodGet, path: apiPath("/admin/kyc/submissions/%s", submissionID),
+		auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetKycDocument returns the document with the content
+func (c *Client) GetKycDocument(ctx context.Context, submissionID, documentID string) (*model.KycDocument, error) {
+	data, header, err := c.doRaw(ctx, &request{method: http.MethodGet,
+		path: apiPath("/admin/kyc/submissions/%s/documents/%s", submissionID, documentID), auth: authAccess})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.KycDocument{
+		ID:           documentID,
+		SubmissionID: submissionID,
+		ContentType:  header.Get("Content-Type"),
+		Size:         int64(len(data)),
+		Content:      data,
+	}, nil
+}
+
+func (c *Client) ApproveKycSubmission(ctx context.Context, submissionID string, reviewReq *model.KycReviewReq) error {
+	return c.do(ctx, &request{method: http.MethodPost, path: apiPath("/admin/kyc/submissions/%s/approve", submissionID),
+		body: reviewReq, auth: authAccess}, nil)
+}
+
+func (c *Client) RejectKycSubmission(ctx context.Context, submissionID string, reviewReq *model.KycReviewReq) error {
+	return c.do(ctx, &request{method: http.MethodPost, path: apiPath("/admin/kyc/submissions/%s/reject", submissionID),
+		body: reviewReq, auth: authAccess}, nil)
+}
+
+// FlagUser blocks or unblocks the user
+func (c *Client) FlagUser(ctx context.Context, userID string, flagReq *model.UserFlagReq) error {
+	return c.do(ctx, &request{method: http.MethodPut, path: apiPath("/admin/users/%s/flag", userID),
+		body: flagReq, auth: authAccess}, nil)
+}
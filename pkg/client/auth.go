@@ -0,0 +1,181 @@
+package client
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+	"errors"
+	"github.com/fasthttp/websocket"
+	"net/http"
+	"strings"
+)
+
+// signInRequest returns the sign in request, the token of the trusted device lets to skip two-factor auth
+func (c *Client) signInRequest(path string, body interface{}) *request {
+	req := &request{method: http.MethodPost, path: apiPrefix + path, body: body}
+
+	if token := c.TrustedDeviceToken(); token != "" {
+		req.header = http.Header{model.TrustedDeviceHeader: []string{token}}
+	}
+	return req
+}
+
+// signIn sends the sign in request and keeps the given tokens, if AuthResp.TwoFactorAuthToken is set,
+// then the sign in is completed by VerifyTwoFactorAuthCode
+func (c *Client) signIn(ctx context.Context, req *request) (*model.AuthResp, error) {
+	var resp model.AuthResp
+	err := c.do(ctx, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	c.keepAuthResp(&resp)
+	return &resp, nil
+}
+
+// Authenticate signs in by the login and the password
+func (c *Client) Authenticate(ctx context.Context, authReq *model.AuthenticationReq) (*model.AuthResp, error) {
+	return c.signIn(ctx, c.signInRequest("/auth/authenticate", authReq))
+}
+
+// SendMagicLink sends the single-use sign in link to the email
+func (c *Client) SendMagicLink(ctx context.Context, magicLinkSendReq *model.MagicLinkSendReq) error {
+	return c.do(ctx, &request{method: http.MethodPost, path: apiPrefix + "/auth/magic-link/send",
+		body: magicLinkSendReq}, nil)
+}
+
+// AuthenticateByMagicLink signs in by the token of the magic link
+func (c *Client) AuthenticateByMagicLink(ctx context.Context, magicLinkAuthReq *model.MagicLinkAuthReq) (*model.AuthResp, error) {
+	return c.signIn(ctx, c.signInRequest("/auth/magic-link/verify", magicLinkAuthReq))
+}
+
+// SendLoginCode sends the one-time login code to the email or the phone
+func (c *Client) SendLoginCode(ctx context.Context, otpSendReq *model.OtpSendReq) error {
+	return c.do(ctx, &request{method: http.MethodPost, path: apiPrefix + "/auth/otp/send", body: otpSendReq}, nil)
+}
+
+// AuthenticateByLoginCode signs in by the one-time login code
+func (c *Client) AuthenticateByLoginCode(ctx context.Context, otpAuthReq *model.OtpAuthReq) (*model.AuthResp, error) {
+	return c.signIn(ctx, c.signInRequest("/auth/otp/verify", otpAuthReq))
+}
+
+// RefreshToken replaces the token pair by the refresh token, it is called by the client itself
+// when the access token is rejected
+func (c *Client) RefreshToken(ctx context.Context) (*model.AuthResp, error) {
+	return c.signIn(ctx, &request{method: http.MethodPost, path: apiPrefix + "/auth/refresh", auth: authRefresh})
+}
+
+// Reauthenticate proves the credentials again before the sensitive operations
+func (c *Client) Reauthenticate(ctx context.Context, reauthenticateReq *model.ReauthenticateReq) (*model.AuthResp, error) {
+	return c.signIn(ctx, &request{method: http.MethodPost, path: apiPrefix + "/auth/reauthenticate",
+		body: reauthenticateReq, auth: authAccess})
+}
+
+// RevokeSessions follows the "this wasn't me" link of the security notification
+func (c *Client) RevokeSessions(ctx context.Context, revokeSessionsReq *model.RevokeSessionsReq) error {
+	return c.do(ctx, &request{method: http.MethodPost, path: apiPrefix + "/auth/revoke-sessions",
+		body: revokeSessionsReq}, nil)
+}
+
+// ReSendTwoFactorAuthCode sends the code of the two-factor auth method again during the sign in
+func (c *Client) ReSendTwoFactorAuthCode(ctx context.Context,
+	reSendReq *model.TwoFactorAuthReSendReq) (*model.TwoFactorAuthCodeResp, error) {
+	var resp model.TwoFactorAuthCodeResp
+	err := c.do(ctx, &request{method: http.MethodPost, path: apiPrefix + "/2fa/re-send", body: reSendReq,
+		auth: authTwoFactor}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// VerifyTwoFactorAuthCode completes the sign in by the code of the two-factor auth method
+func (c *Client) VerifyTwoFactorAuthCode(ctx context.Context, verifyReq *model.Verify2faCodeReq) (*model.AuthResp, error) {
+	return c.signIn(ctx, &request{method: http.MethodPost, path: apiPrefix + "/2fa/verify", body: verifyReq,
+		auth: authTwoFactor})
+}
+
+// ConfirmQrCodeAuth signs in the other device which shows the qr code, the current user must be signed in
+func (c *Client) ConfirmQrCodeAuth(ctx context.Context, qrCodeToken string) error {
+	return c.do(ctx, &request{method: http.MethodPost, path: apiPath("/auth/qr-code/%s", qrCodeToken),
+		auth: authAccess}, nil)
+}
+
+// qrCodeAuthMessage any message of the qr code sign in websocket
+type qrCodeAuthMessage struct {
+	model.QrCodeAuthResp
+	model.AuthResp
+	Message string `json:"message"`
+}
+
+// QrCodeAuth signs in by the qr code, onQrCode gets the qr code to show, it is confirmed on the signed-in device
+// by ConfirmQrCodeAuth, the call waits for the confirmation or the timeout of the service
+func (c *Client) QrCodeAuth(ctx context.Context, onQrCode func(qrCode *model.QrCodeAuthResp)) (*model.AuthResp, error) {
+
+	wsURL := strings.Replace(c.baseURL, "http", "ws", 1) + apiPrefix + "/auth/qr-code/websocket"
+
+	header := http.Header{}
+	if c.tenantID != "" {
+		header.Set(model.TenantHeader, c.tenantID)
+	}
+	if c.userAgent != "" {
+		header.Set("User-Agent", c.userAgent)
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, wsURL, header)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	// the connection is closed to interrupt the read on the cancel of the context
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-done:
+		}
+	}()
+
+	for {
+		var msg qrCodeAuthMessage
+		err = conn.ReadJSON(&msg)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+
+		switch {
+		case msg.QrCodeToken != "":
+			onQrCode(&msg.QrCodeAuthResp)
+		case msg.AccessToken != "":
+			c.keepAuthResp(&msg.AuthResp)
+			return &msg.AuthResp, nil
+		case msg.Message != "":
+			return nil, errors.New("qr code auth: " + msg.Message)
+		}
+	}
+}
+
+// GetJwks returns the public keys to verify the access tokens
+func (c *Client) GetJwks(ctx context.Context) (*model.JWKS, error) {
+	var resp model.JWKS
+	err := c.do(ctx, &request{method: http.MethodGet, path: "/.well-known/jwks.json"}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetTenant returns the branding of the tenant
+func (c *Client) GetTenant(ctx context.Context) (*model.TenantBrandingResp, error) {
+	var resp model.TenantBrandingResp
+	err := c.do(ctx, &request{method: http.MethodGet, path: apiPrefix + "/tenant"}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,418 @@
+// Package client is the Go client of the auth service api, the requests and the responses are the model types.
+// The client keeps the token pair of the signed-in user and refreshes it by /auth/refresh when the access
+// token is rejected, the api key could be used instead of the token pair by the machine clients.
+package client
+
+import (
+	"auth-project/src/domain/model"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const apiPrefix = "/api/v1"
+
+// Tokens the token pair of the signed-in user
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// Error the error answered by the service
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("auth: %d %s", e.StatusCode, e.Message)
+}
+
+// StatusCode returns the http status of the error answered by the service or 0 for the other errors
+func StatusCode(err error) int {
+	if e, ok := err.(*Error); ok {
+		return e.StatusCode
+	}
+	return 0
+}
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	tenantID   string
+	userAgent  string
+	apiKey     string
+	onTokens   func(Tokens)
+
+	mx                 sync.RWMutex
+	tokens             Tokens
+	twoFactorAuthToken string
+	// twoFactorAuthSetupToken is given instead of the token pair if the tenant requires 2fa which is not set up
+	twoFactorAuthSetupToken string
+	trustedDeviceToken      string
+
+	// refreshMx lets only one request refresh the token pair
+	refreshMx sync.Mutex
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sets the http client, e.g. the client of the httptest server
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTenant sends the requests on behalf of the tenant, the service accepts it on the host of the tenant,
+// otherwise the origin of the requests has to be trusted
+func WithTenant(tenantID string) Option {
+	return func(c *Client) {
+		c.tenantID = tenantID
+	}
+}
+
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithTokens sets the token pair kept from the previous sign in
+func WithTokens(tokens Tokens) Option {
+	return func(c *Client) {
+		c.tokens = tokens
+	}
+}
+
+// WithApiKey authorizes the requests by the api key, the token pair is not used then
+func WithApiKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithTrustedDeviceToken sets the token of the trusted device kept from the previous two-factor auth
+func WithTrustedDeviceToken(token string) Option {
+	return func(c *Client) {
+		c.trustedDeviceToken = token
+	}
+}
+
+// WithTokensHook sets the function called when the token pair is changed by the sign in or the refresh,
+// so the caller could save it
+func WithTokensHook(onTokens func(Tokens)) Option {
+	return func(c *Client) {
+		c.onTokens = onTokens
+	}
+}
+
+// New returns the client of the service at baseURL, e.g. https://auth.example.com
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Tokens returns the current token pair
+func (c *Client) Tokens() Tokens {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	return c.tokens
+}
+
+// SetTokens replaces the token pair
+func (c *Client) SetTokens(tokens Tokens) {
+	c.mx.Lock()
+	c.tokens = tokens
+	c.mx.Unlock()
+
+	if c.onTokens != nil {
+		c.onTokens(tokens)
+	}
+}
+
+// TrustedDeviceToken returns the token of the trusted device given by the two-factor auth
+func (c *Client) TrustedDeviceToken() string {
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+	return c.trustedDeviceToken
+}
+
+// keepAuthResp keeps the tokens of the sign in resp, either the token pair, the 2fa token or the 2fa set-up token
+func (c *Client) keepAuthResp(resp *model.AuthResp) {
+	c.mx.Lock()
+	if resp.TwoFactorAuthToken != "" {
+		c.twoFactorAuthToken = resp.TwoFactorAuthToken
+	}
+	if resp.TwoFactorAuthSetupToken != "" {
+		c.twoFactorAuthSetupToken = resp.TwoFactorAuthSetupToken
+	}
+	if resp.TrustedDeviceToken != "" {
+		c.trustedDeviceToken = resp.TrustedDeviceToken
+	}
+	c.mx.Unlock()
+
+	if resp.AccessToken != "" {
+		c.mx.Lock()
+		c.twoFactorAuthToken = ""
+		c.twoFactorAuthSetupToken = ""
+		c.mx.Unlock()
+
+		c.SetTokens(Tokens{AccessToken: resp.AccessToken, RefreshToken: resp.RefreshToken})
+	}
+}
+
+type authType int
+
+const (
+	authNone authType = iota
+	authAccess
+	authTwoFactor
+	authRefresh
+	// the token pair or, before the session is created, the 2fa set-up token
+	authSetUp
+)
+
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	auth   authType
+
+	// body is sent as json, unless rawBody is set
+	body        interface{}
+	rawBody     []byte
+	contentType string
+	header      http.Header
+}
+
+// do sends the request and decodes the json resp into out
+func (c *Client) do(ctx context.Context, req *request, out interface{}) error {
+
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, err = io.Copy(ioutil.Discard, resp.Body)
+		return err
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// doRaw sends the request and returns the resp body as it is
+func (c *Client) doRaw(ctx context.Context, req *request) ([]byte, http.Header, error) {
+
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, resp.Header, nil
+}
+
+// send sends the request, the access token rejected by the service is refreshed once
+func (c *Client) send(ctx context.Context, req *request) (*http.Response, error) {
+
+	body := req.rawBody
+	contentType := req.contentType
+	if body == nil && req.body != nil {
+		var err error
+		body, err = json.Marshal(req.body)
+		if err != nil {
+			return nil, err
+		}
+		contentType = "application/json"
+	}
+
+	for attempt := 0; ; attempt++ {
+		accessToken := c.Tokens().AccessToken
+
+		httpReq, err := c.newHTTPRequest(ctx, req, body, contentType)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode < http.StatusBadRequest {
+			return resp, nil
+		}
+
+		// the set-up token is not refreshed, only the access token sent instead of it
+		refreshable := req.auth == authAccess || (req.auth == authSetUp && accessToken != "")
+		if resp.StatusCode == http.StatusUnauthorized && refreshable && c.apiKey == "" && attempt == 0 {
+			refreshed := c.refreshTokens(ctx, accessToken)
+			if refreshed {
+				resp.Body.Close()
+				continue
+			}
+		}
+
+		return nil, readError(resp)
+	}
+}
+
+func (c *Client) newHTTPRequest(ctx context.Context, req *request, body []byte,
+	contentType string) (*http.Request, error) {
+
+	u := c.baseURL + req.path
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, req.method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, values := range req.header {
+		for _, value := range values {
+			httpReq.Header.Add(key, value)
+		}
+	}
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	if c.tenantID != "" {
+		httpReq.Header.Set(model.TenantHeader, c.tenantID)
+	}
+	if c.userAgent != "" {
+		httpReq.Header.Set("User-Agent", c.userAgent)
+	}
+
+	c.mx.RLock()
+	defer c.mx.RUnlock()
+
+	switch req.auth {
+	case authAccess:
+		if c.apiKey != "" {
+			httpReq.Header.Set(model.ApiKeyHeader, c.apiKey)
+		} else if c.tokens.AccessToken != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+c.tokens.AccessToken)
+		}
+	case authTwoFactor:
+		httpReq.Header.Set("Authorization", "Bearer "+c.twoFactorAuthToken)
+	case authRefresh:
+		httpReq.Header.Set("Authorization", "Bearer "+c.tokens.RefreshToken)
+	case authSetUp:
+		if c.tokens.AccessToken != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+c.tokens.AccessToken)
+		} else if c.twoFactorAuthSetupToken != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+c.twoFactorAuthSetupToken)
+		}
+	}
+
+	return httpReq, nil
+}
+
+// refreshTokens refreshes the token pair if it is still the one with the rejected access token
+func (c *Client) refreshTokens(ctx context.Context, rejectedAccessToken string) bool {
+
+	c.refreshMx.Lock()
+	defer c.refreshMx.Unlock()
+
+	tokens := c.Tokens()
+	if tokens.AccessToken != rejectedAccessToken {
+		return true
+	}
+	if tokens.RefreshToken == "" {
+		return false
+	}
+
+	_, err := c.RefreshToken(ctx)
+	return err == nil
+}
+
+func readError(resp *http.Response) error {
+	defer resp.Body.Close()
+
+	data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	message := strings.TrimSpace(string(data))
+	var msg model.MessageResp
+	if json.Unmarshal(data, &msg) == nil && msg.Message != "" {
+		message = msg.Message
+	}
+	if message == "" {
+		message = http.StatusText(resp.StatusCode)
+	}
+
+	return &Error{StatusCode: resp.StatusCode, Message: message}
+}
+
+// queryValues returns the query params of the request by the query tags of the model
+func queryValues(req interface{}) url.Values {
+	values := url.Values{}
+
+	v := reflect.Indirect(reflect.ValueOf(req))
+	if !v.IsValid() {
+		return values
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Tag.Get("query")
+		if name == "" {
+			continue
+		}
+
+		switch f := v.Field(i); f.Kind() {
+		case reflect.String:
+			if f.String() != "" {
+				values.Set(name, f.String())
+			}
+		case reflect.Int, reflect.Int64:
+			if f.Int() != 0 {
+				values.Set(name, strconv.FormatInt(f.Int(), 10))
+			}
+		case reflect.Bool:
+			if f.Bool() {
+				values.Set(name, "true")
+			}
+		}
+	}
+
+	return values
+}
+
+func apiPath(format string, args ...interface{}) string {
+	escaped := make([]interface{}, len(args))
+	for i, arg := range args {
+		escaped[i] = url.PathEscape(fmt.Sprint(arg))
+	}
+	return apiPrefix + fmt.Sprintf(format, escaped...)
+}
@@ -0,0 +1,336 @@
+package client
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// fakeServer answers the sign in, the two-factor auth, the refresh and the profile like the service,
+// the refresh token is single-use and replaces the token pair
+type fakeServer struct {
+	*httptest.Server
+
+	mx            sync.Mutex
+	accessToken   string
+	refreshToken  string
+	pairs         int
+	refreshes     int
+	tenantIDs     []string
+	trustedTokens []string
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	s := &fakeServer{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPrefix+"/auth/authenticate", s.authenticate)
+	mux.HandleFunc(apiPrefix+"/2fa/verify", s.verify)
+	mux.HandleFunc(apiPrefix+"/auth/refresh", s.refresh)
+	mux.HandleFunc(apiPrefix+"/users/my-profile", s.profile)
+
+	s.Server = httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func unauthorized(w http.ResponseWriter) {
+	writeJSON(w, http.StatusUnauthorized, model.MessageResp{Message: "unauthorized"})
+}
+
+// newPair issues the next token pair, s.mx must be held
+func (s *fakeServer) newPair() *model.AuthResp {
+	s.pairs++
+	s.accessToken = fmt.Sprintf("at%d", s.pairs)
+	s.refreshToken = fmt.Sprintf("rt%d", s.pairs)
+	return &model.AuthResp{AccessToken: s.accessToken, RefreshToken: s.refreshToken}
+}
+
+// expire rejects the current access token, like the expired one
+func (s *fakeServer) expire() {
+	s.mx.Lock()
+	s.accessToken = ""
+	s.mx.Unlock()
+}
+
+// revoke rejects the current token pair, like the signed out session
+func (s *fakeServer) revoke() {
+	s.mx.Lock()
+	s.accessToken, s.refreshToken = "", ""
+	s.mx.Unlock()
+}
+
+func (s *fakeServer) refreshCount() int {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.refreshes
+}
+
+func (s *fakeServer) authenticate(w http.ResponseWriter, r *http.Request) {
+	var req model.AuthenticationReq
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, model.MessageResp{Message: err.Error()})
+		return
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.tenantIDs = append(s.tenantIDs, r.Header.Get(model.TenantHeader))
+	s.trustedTokens = append(s.trustedTokens, r.Header.Get(model.TrustedDeviceHeader))
+
+	switch {
+	case req.Password != "password":
+		unauthorized(w)
+	case req.Email == "2fa@example.com" && r.Header.Get(model.TrustedDeviceHeader) != "device":
+		writeJSON(w, http.StatusOK, &model.AuthResp{TwoFactorAuthToken: "2fa", TwoFactorAuthType: "email"})
+	default:
+		writeJSON(w, http.StatusOK, s.newPair())
+	}
+}
+
+func (s *fakeServer) verify(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer 2fa" {
+		unauthorized(w)
+		return
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	resp := s.newPair()
+	resp.TrustedDeviceToken = "device"
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *fakeServer) refresh(w http.ResponseWriter, r *http.Request) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if s.refreshToken == "" || r.Header.Get("Authorization") != "Bearer "+s.refreshToken {
+		unauthorized(w)
+		return
+	}
+
+	s.refreshes++
+	writeJSON(w, http.StatusOK, s.newPair())
+}
+
+func (s *fakeServer) profile(w http.ResponseWriter, r *http.Request) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if s.accessToken == "" || r.Header.Get("Authorization") != "Bearer "+s.accessToken {
+		unauthorized(w)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &model.UserGetMyProfileResp{})
+}
+
+func TestAuthenticate(t *testing.T) {
+	s := newFakeServer(t)
+
+	var hooked []Tokens
+	c := New(s.URL, WithHTTPClient(s.Client()), WithTenant("acme"), WithTokensHook(func(tokens Tokens) {
+		hooked = append(hooked, tokens)
+	}))
+	ctx := context.Background()
+
+	_, err := c.Authenticate(ctx, &model.AuthenticationReq{Email: "user@example.com", Password: "wrong"})
+	if StatusCode(err) != http.StatusUnauthorized {
+		t.Fatalf("got %v, want the 401 error", err)
+	}
+	if e, ok := err.(*Error); !ok || e.Message != "unauthorized" {
+		t.Fatalf("got %#v, want the message of the service", err)
+	}
+
+	resp, err := c.Authenticate(ctx, &model.AuthenticationReq{Email: "user@example.com", Password: "password"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.AccessToken != "at1" {
+		t.Fatalf("got access token %q, want at1", resp.AccessToken)
+	}
+
+	want := Tokens{AccessToken: "at1", RefreshToken: "rt1"}
+	if c.Tokens() != want {
+		t.Fatalf("got tokens %+v, want %+v", c.Tokens(), want)
+	}
+	if len(hooked) != 1 || hooked[0] != want {
+		t.Fatalf("got hooked %+v, want [%+v]", hooked, want)
+	}
+	if s.tenantIDs[0] != "acme" {
+		t.Fatalf("got tenant %q, want acme", s.tenantIDs[0])
+	}
+
+	_, err = c.GetMyProfile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAuthenticateTwoFactorAuth(t *testing.T) {
+	s := newFakeServer(t)
+	c := New(s.URL, WithHTTPClient(s.Client()))
+	ctx := context.Background()
+
+	authReq := &model.AuthenticationReq{Email: "2fa@example.com", Password: "password"}
+	resp, err := c.Authenticate(ctx, authReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.TwoFactorAuthToken != "2fa" || resp.AccessToken != "" {
+		t.Fatalf("got %+v, want the 2fa token only", resp)
+	}
+	if c.Tokens() != (Tokens{}) {
+		t.Fatalf("got tokens %+v before the 2fa", c.Tokens())
+	}
+
+	// the 2fa token is sent by the client itself
+	_, err = c.VerifyTwoFactorAuthCode(ctx, &model.Verify2faCodeReq{Code2fa: "123456", TrustDevice: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Tokens().AccessToken != "at1" || c.TrustedDeviceToken() != "device" {
+		t.Fatalf("got tokens %+v, trusted device %q", c.Tokens(), c.TrustedDeviceToken())
+	}
+
+	// the trusted device skips the 2fa of the next sign in
+	resp, err = c.Authenticate(ctx, authReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.AccessToken != "at2" {
+		t.Fatalf("got %+v, want the token pair", resp)
+	}
+	if s.trustedTokens[1] != "device" {
+		t.Fatalf("got trusted device header %q, want device", s.trustedTokens[1])
+	}
+}
+
+func TestRefreshOnUnauthorized(t *testing.T) {
+	s := newFakeServer(t)
+
+	var hooked []Tokens
+	c := New(s.URL, WithHTTPClient(s.Client()), WithTokensHook(func(tokens Tokens) {
+		hooked = append(hooked, tokens)
+	}))
+	ctx := context.Background()
+
+	_, err := c.Authenticate(ctx, &model.AuthenticationReq{Email: "user@example.com", Password: "password"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.expire()
+
+	// the rejected access token is refreshed and the request is sent again
+	_, err = c.GetMyProfile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := s.refreshCount(); n != 1 {
+		t.Fatalf("got %d refreshes, want 1", n)
+	}
+
+	want := Tokens{AccessToken: "at2", RefreshToken: "rt2"}
+	if c.Tokens() != want {
+		t.Fatalf("got tokens %+v, want %+v", c.Tokens(), want)
+	}
+	if len(hooked) != 2 || hooked[1] != want {
+		t.Fatalf("got hooked %+v, want the refreshed pair last", hooked)
+	}
+}
+
+func TestRefreshOnceForConcurrentRequests(t *testing.T) {
+	s := newFakeServer(t)
+	c := New(s.URL, WithHTTPClient(s.Client()))
+	ctx := context.Background()
+
+	_, err := c.Authenticate(ctx, &model.AuthenticationReq{Email: "user@example.com", Password: "password"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.expire()
+
+	// the requests rejected with the same access token wait for the refresh of the first one,
+	// the refresh token is single-use, so the second refresh would be rejected
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.GetMyProfile(ctx)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := s.refreshCount(); n != 1 {
+		t.Fatalf("got %d refreshes, want 1", n)
+	}
+}
+
+func TestRefreshRejected(t *testing.T) {
+	s := newFakeServer(t)
+	c := New(s.URL, WithHTTPClient(s.Client()))
+	ctx := context.Background()
+
+	_, err := c.Authenticate(ctx, &model.AuthenticationReq{Email: "user@example.com", Password: "password"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.revoke()
+
+	_, err = c.GetMyProfile(ctx)
+	if StatusCode(err) != http.StatusUnauthorized {
+		t.Fatalf("got %v, want the 401 error", err)
+	}
+
+	// the rejected pair is kept, the caller signs in again
+	if c.Tokens().AccessToken != "at1" {
+		t.Fatalf("got tokens %+v, want the rejected pair", c.Tokens())
+	}
+
+	_, err = c.RefreshToken(ctx)
+	if StatusCode(err) != http.StatusUnauthorized {
+		t.Fatalf("got %v, want the 401 error", err)
+	}
+}
+
+func TestApiKeyIsNotRefreshed(t *testing.T) {
+	s := newFakeServer(t)
+	c := New(s.URL, WithHTTPClient(s.Client()), WithApiKey("key"),
+		WithTokens(Tokens{AccessToken: "at0", RefreshToken: "rt0"}))
+
+	_, err := c.GetMyProfile(context.Background())
+	if StatusCode(err) != http.StatusUnauthorized {
+		t.Fatalf("got %v, want the 401 error", err)
+	}
+	if n := s.refreshCount(); n != 0 {
+		t.Fatalf("got %d refreshes, want 0", n)
+	}
+}
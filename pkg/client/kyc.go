@@ -0,0 +1,60 @@
+package client
+
+import (
+	"auth-project/src/domain/model"
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+)
+
+func (c *Client) GetMyKyc(ctx context.Context) (*model.KycStatusResp, error) {
+	var resp model.KycStatusResp
+	err := c.do(ctx, &request{method: http.MethodGet, path: apiPrefix + "/kyc/", auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SubmitKyc submits the documents for the kyc level, the type of each document is one of model.KycDocumentTypes
+func (c *Client) SubmitKyc(ctx context.Context, submitReq *model.KycSubmitReq,
+	documents []model.KycDocument) (*model.KycSubmission, error) {
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	err := w.WriteField("level", strconv.Itoa(submitReq.Level))
+	if err != nil {
+		return nil, err
+	}
+	err = w.WriteField("id_card", submitReq.IDCard)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, document := range documents {
+		fw, err := w.CreateFormFile(document.Type, document.Type)
+		if err != nil {
+			return nil, err
+		}
+		_, err = fw.Write(document.Content)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = w.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var resp model.KycSubmission
+	err = c.do(ctx, &request{method: http.MethodPost, path: apiPrefix + "/kyc/submissions", rawBody: body.Bytes(),
+		contentType: w.FormDataContentType(), auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
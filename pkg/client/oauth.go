@@ -0,0 +1,135 @@
+package client
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+func (c *Client) GetApiKeys(ctx context.Context) ([]model.ApiKey, error) {
+	var resp []model.ApiKey
+	err := c.do(ctx, &request{method: http.MethodGet, path: apiPrefix + "/api-keys/", auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// CreateApiKey creates the personal access token, the key is given only once
+func (c *Client) CreateApiKey(ctx context.Context, createReq *model.ApiKeyCreateReq) (*model.ApiKeyCreateResp, error) {
+	var resp model.ApiKeyCreateResp
+	err := c.do(ctx, &request{method: http.MethodPost, path: apiPrefix + "/api-keys/", body: createReq,
+		auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) RevokeApiKey(ctx context.Context, keyID string) error {
+	return c.do(ctx, &request{method: http.MethodDelete, path: apiPath("/api-keys/%s", keyID), auth: authAccess}, nil)
+}
+
+func (c *Client) GetOAuthClients(ctx context.Context) ([]model.OAuthClient, error) {
+	var resp []model.OAuthClient
+	err := c.do(ctx, &request{method: http.MethodGet, path: apiPrefix + "/oauth/clients", auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// CreateOAuthClient registers the machine client, the secret is given only once
+func (c *Client) CreateOAuthClient(ctx context.Context, createReq *model.OAuthClientCreateReq) (*model.OAuthClientSecretResp, error) {
+	var resp model.OAuthClientSecretResp
+	err := c.do(ctx, &request{method: http.MethodPost, path: apiPrefix + "/oauth/clients", body: createReq,
+		auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RotateOAuthClientSecret replaces the secret of the client, it needs the recent authentication
+func (c *Client) RotateOAuthClientSecret(ctx context.Context, clientID string) (*model.OAuthClientSecretResp, error) {
+	var resp model.OAuthClientSecretResp
+	err := c.do(ctx, &request{method: http.MethodPost, path: apiPath("/oauth/clients/%s/secret", clientID),
+		auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) RevokeOAuthClient(ctx context.Context, clientID string) error {
+	return c.do(ctx, &request{method: http.MethodDelete, path: apiPath("/oauth/clients/%s", clientID),
+		auth: authAccess}, nil)
+}
+
+// ClientCredentials the credentials of the machine client
+type ClientCredentials struct {
+	ClientID     string
+	ClientSecret string
+}
+
+// clientRequest returns the form request authorized by the basic auth of the client (RFC 6749 section 2.3.1)
+func clientRequest(path string, credentials ClientCredentials, form url.Values) *request {
+	req := &http.Request{Header: http.Header{}}
+	req.SetBasicAuth(url.QueryEscape(credentials.ClientID), url.QueryEscape(credentials.ClientSecret))
+
+	return &request{
+		method:      http.MethodPost,
+		path:        apiPrefix + path,
+		rawBody:     []byte(form.Encode()),
+		contentType: "application/x-www-form-urlencoded",
+		header:      req.Header,
+	}
+}
+
+// ClientCredentialsToken issues the access token of the machine client, the scopes could narrow the ones
+// of the client, the token could be used by WithTokens
+func (c *Client) ClientCredentialsToken(ctx context.Context, credentials ClientCredentials,
+	scopes ...string) (*model.OAuthTokenResp, error) {
+
+	form := url.Values{"grant_type": []string{model.OAuthGrantTypeClientCred}}
+	if len(scopes) > 0 {
+		form.Set("scope", strings.Join(scopes, " "))
+	}
+
+	var resp model.OAuthTokenResp
+	err := c.do(ctx, clientRequest("/oauth/token", credentials, form), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// IntrospectToken tells whether the token is active, the client needs the introspect scope
+func (c *Client) IntrospectToken(ctx context.Context, credentials ClientCredentials,
+	token, tokenTypeHint string) (*model.OAuthIntrospectionResp, error) {
+
+	form := url.Values{"token": []string{token}}
+	if tokenTypeHint != "" {
+		form.Set("token_type_hint", tokenTypeHint)
+	}
+
+	var resp model.OAuthIntrospectionResp
+	err := c.do(ctx, clientRequest("/oauth/introspect", credentials, form), &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RevokeToken revokes the token on behalf of the client
+func (c *Client) RevokeToken(ctx context.Context, credentials ClientCredentials, token, tokenTypeHint string) error {
+
+	form := url.Values{"token": []string{token}}
+	if tokenTypeHint != "" {
+		form.Set("token_type_hint", tokenTypeHint)
+	}
+
+	return c.do(ctx, clientRequest("/oauth/revoke", credentials, form), nil)
+}
@@ -0,0 +1,69 @@
+package client
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+	"net/http"
+)
+
+func (c *Client) GetReferrals(ctx context.Context, listReq *model.ReferralListReq) (*model.ReferralListResp, error) {
+	var resp model.ReferralListResp
+	err := c.do(ctx, &request{method: http.MethodGet, path: apiPrefix + "/referrals/", query: queryValues(listReq),
+		auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) GetReferralTree(ctx context.Context, treeReq *model.ReferralTreeReq) ([]*model.ReferralTreeNode, error) {
+	var resp []*model.ReferralTreeNode
+	err := c.do(ctx, &request{method: http.MethodGet, path: apiPrefix + "/referrals/tree", query: queryValues(treeReq),
+		auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) GetReferralCount(ctx context.Context, treeReq *model.ReferralTreeReq) (*model.ReferralCountResp, error) {
+	var resp model.ReferralCountResp
+	err := c.do(ctx, &request{method: http.MethodGet, path: apiPrefix + "/referrals/count", query: queryValues(treeReq),
+		auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) GetReferralStats(ctx context.Context, statsReq *model.ReferralStatsReq) (*model.ReferralStatsResp, error) {
+	var resp model.ReferralStatsResp
+	err := c.do(ctx, &request{method: http.MethodGet, path: apiPrefix + "/referrals/stats", query: queryValues(statsReq),
+		auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RegenerateReferralCode replaces the referral code with the random one
+func (c *Client) RegenerateReferralCode(ctx context.Context) (*model.ReferralLinkResp, error) {
+	var resp model.ReferralLinkResp
+	err := c.do(ctx, &request{method: http.MethodPost, path: apiPrefix + "/referrals/code/regenerate",
+		auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SetReferralCode sets the vanity referral code
+func (c *Client) SetReferralCode(ctx context.Context, codeReq *model.ReferralCodeReq) (*model.ReferralLinkResp, error) {
+	var resp model.ReferralLinkResp
+	err := c.do(ctx, &request{method: http.MethodPut, path: apiPrefix + "/referrals/code", body: codeReq,
+		auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
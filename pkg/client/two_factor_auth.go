@@ -0,0 +1,99 @@
+package client
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+	"net/http"
+)
+
+// GetGoogleTwoFactorAuthQrCode returns the qr code to set up google authenticator
+func (c *Client) GetGoogleTwoFactorAuthQrCode(ctx context.Context) (*model.GoogleTwoFactorAuthQrCodeResp, error) {
+	var resp model.GoogleTwoFactorAuthQrCodeResp
+	err := c.do(ctx, &request{method: http.MethodGet, path: apiPrefix + "/2fa/google/qr-code", auth: authSetUp}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SetUpTwoFactorAuth enrolls the two-factor auth method, it needs the recent authentication,
+// after the set-up by the 2fa set-up token the user signs in again
+func (c *Client) SetUpTwoFactorAuth(ctx context.Context, setUpReq *model.TwoFactorAuthSetUpReq) (*model.TwoFactorAuthSetUpResp, error) {
+	var resp model.TwoFactorAuthSetUpResp
+	err := c.do(ctx, &request{method: http.MethodPut, path: apiPrefix + "/2fa/set-up", body: setUpReq,
+		auth: authSetUp}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DeleteTwoFactorAuth removes the two-factor auth method, it needs the recent authentication
+func (c *Client) DeleteTwoFactorAuth(ctx context.Context, deleteReq *model.TwoFactorAuthDeleteReq) error {
+	return c.do(ctx, &request{method: http.MethodDelete, path: apiPrefix + "/2fa/delete", body: deleteReq,
+		auth: authAccess}, nil)
+}
+
+func (c *Client) SetDefaultTwoFactorAuthType(ctx context.Context, defaultReq *model.TwoFactorAuthDefaultReq) error {
+	return c.do(ctx, &request{method: http.MethodPut, path: apiPrefix + "/2fa/default", body: defaultReq,
+		auth: authAccess}, nil)
+}
+
+// GetRecoveryCodesCount returns the number of the unused recovery codes
+func (c *Client) GetRecoveryCodesCount(ctx context.Context) (int, error) {
+	var resp model.RecoveryCodesCountResp
+	err := c.do(ctx, &request{method: http.MethodGet, path: apiPrefix + "/2fa/recovery-codes", auth: authAccess}, &resp)
+	if err != nil {
+		return 0, err
+	}
+	return resp.RecoveryCodesLeft, nil
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes, it needs the recent authentication
+func (c *Client) RegenerateRecoveryCodes(ctx context.Context) ([]string, error) {
+	var resp model.RecoveryCodesResp
+	err := c.do(ctx, &request{method: http.MethodPost, path: apiPrefix + "/2fa/recovery-codes", auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.RecoveryCodes, nil
+}
+
+func (c *Client) GetTrustedDevices(ctx context.Context) ([]model.TrustedDevice, error) {
+	var resp []model.TrustedDevice
+	err := c.do(ctx, &request{method: http.MethodGet, path: apiPrefix + "/2fa/trusted-devices", auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) DeleteTrustedDevice(ctx context.Context, deviceID string) error {
+	return c.do(ctx, &request{method: http.MethodDelete, path: apiPath("/2fa/trusted-devices/%s", deviceID),
+		auth: authAccess}, nil)
+}
+
+func (c *Client) DeleteTrustedDevices(ctx context.Context) error {
+	return c.do(ctx, &request{method: http.MethodDelete, path: apiPrefix + "/2fa/trusted-devices", auth: authAccess}, nil)
+}
+
+// Send2faCode sends the verification code by the default two-factor auth method
+func (c *Client) Send2faCode(ctx context.Context) (*model.Code2faSentResp, error) {
+	var resp model.Code2faSentResp
+	err := c.do(ctx, &request{method: http.MethodPost, path: apiPrefix + "/code/send", auth: authSetUp}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SendTarget2faCode sends the verification code to the email or the phone being set up
+func (c *Client) SendTarget2faCode(ctx context.Context, sendReq *model.SendTarget2faCodeReq) (*model.Code2faSentResp, error) {
+	var resp model.Code2faSentResp
+	err := c.do(ctx, &request{method: http.MethodPost, path: apiPrefix + "/code/target-send", body: sendReq,
+		auth: authSetUp}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
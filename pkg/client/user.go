@@ -0,0 +1,167 @@
+package client
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+	"net/http"
+	"net/url"
+)
+
+// SignUp creates the user by the code sent by SignUpSendCode
+func (c *Client) SignUp(ctx context.Context, signUpReq *model.SignUpReq) error {
+	return c.do(ctx, &request{method: http.MethodPost, path: apiPrefix + "/users/sign-up", body: signUpReq}, nil)
+}
+
+// SignUpSendCode sends the sign-up code to the email or the phone
+func (c *Client) SignUpSendCode(ctx context.Context, sendCodeReq *model.SignUpSend2faCodeReq) error {
+	return c.do(ctx, &request{method: http.MethodPost, path: apiPrefix + "/users/sign-up/send-code",
+		body: sendCodeReq}, nil)
+}
+
+// SendResetPasswordCode sends the code to reset the forgotten password
+func (c *Client) SendResetPasswordCode(ctx context.Context, sendCodeReq *model.Send2faCodeForResetUserPasswordReq) error {
+	return c.do(ctx, &request{method: http.MethodPost, path: apiPrefix + "/users/reset-password/send-code",
+		body: sendCodeReq}, nil)
+}
+
+// ResetPassword sets the new password by the code sent by SendResetPasswordCode
+func (c *Client) ResetPassword(ctx context.Context, resetReq *model.VerifyResetUserPassword2faСodeReq) error {
+	return c.do(ctx, &request{method: http.MethodPost, path: apiPrefix + "/users/reset-password/verify-code",
+		body: resetReq}, nil)
+}
+
+// ChangeMyPassword changes the password, it needs the recent authentication
+func (c *Client) ChangeMyPassword(ctx context.Context, changeReq *model.UserChangePasswordReq) error {
+	return c.do(ctx, &request{method: http.MethodPost, path: apiPrefix + "/users/change-password",
+		body: changeReq, auth: authAccess}, nil)
+}
+
+func (c *Client) GetMyProfile(ctx context.Context) (*model.UserGetMyProfileResp, error) {
+	var resp model.UserGetMyProfileResp
+	err := c.do(ctx, &request{method: http.MethodGet, path: apiPrefix + "/users/my-profile", auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) UpdateMyInfo(ctx context.Context, updReq *model.UserUpdateInfoReq) (*model.UserUpdResp, error) {
+	var resp model.UserUpdResp
+	err := c.do(ctx, &request{method: http.MethodPut, path: apiPrefix + "/users/myself/info", body: updReq,
+		auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) UpdateMyUserName(ctx context.Context, updReq *model.UserNameUpdateReq) (*model.UserNameResp, error) {
+	var resp model.UserNameResp
+	err := c.do(ctx, &request{method: http.MethodPut, path: apiPrefix + "/users/myself/user-name", body: updReq,
+		auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// CheckUserName tells whether the user name could be taken
+func (c *Client) CheckUserName(ctx context.Context, checkReq *model.UserNameCheckReq) (*model.UserNameResp, error) {
+	var resp model.UserNameResp
+	err := c.do(ctx, &request{method: http.MethodGet, path: apiPrefix + "/users/user-name/check",
+		query: queryValues(checkReq)}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateMyEmail requests the change of the email, it is applied after the grace period
+func (c *Client) UpdateMyEmail(ctx context.Context, updReq *model.UserEmailUpdateReq) (*model.ContactChange, error) {
+	var resp model.ContactChange
+	err := c.do(ctx, &request{method: http.MethodPut, path: apiPrefix + "/users/myself/email", body: updReq,
+		auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UpdateMyPhone requests the change of the phone, it is applied after the grace period
+func (c *Client) UpdateMyPhone(ctx context.Context, updReq *model.UserPhoneUpdateReq) (*model.ContactChange, error) {
+	var resp model.ContactChange
+	err := c.do(ctx, &request{method: http.MethodPut, path: apiPrefix + "/users/myself/phone", body: updReq,
+		auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SendContactChangeCode sends the code confirming the contact change by the current method
+func (c *Client) SendContactChangeCode(ctx context.Context,
+	sendCodeReq *model.ContactChangeSendCodeReq) (*model.Code2faSentResp, error) {
+	var resp model.Code2faSentResp
+	err := c.do(ctx, &request{method: http.MethodPost, path: apiPrefix + "/users/myself/contact-changes/send-code",
+		body: sendCodeReq, auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) GetContactChanges(ctx context.Context) ([]model.ContactChange, error) {
+	var resp []model.ContactChange
+	err := c.do(ctx, &request{method: http.MethodGet, path: apiPrefix + "/users/myself/contact-changes",
+		auth: authAccess}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *Client) CancelContactChange(ctx context.Context, changeID string) error {
+	return c.do(ctx, &request{method: http.MethodDelete, path: apiPath("/users/myself/contact-changes/%s", changeID),
+		auth: authAccess}, nil)
+}
+
+// DeleteMyAccount deletes the account after the grace period, it needs the recent authentication
+func (c *Client) DeleteMyAccount(ctx context.Context) error {
+	return c.do(ctx, &request{method: http.MethodDelete, path: apiPrefix + "/users/me", auth: authAccess}, nil)
+}
+
+// ExportMyData returns the personal data in the format model.AccountExportFormatJSON or model.AccountExportFormatZIP
+func (c *Client) ExportMyData(ctx context.Context, format string) ([]byte, error) {
+	data, _, err := c.doRaw(ctx, &request{method: http.MethodGet, path: apiPrefix + "/users/me/export",
+		query: url.Values{"format": []string{format}}, auth: authAccess})
+	return data, err
+}
+
+// GetMySessions returns the live sessions of the user, the current one is marked
+func (c *Client) GetMySessions(ctx context.Context) ([]model.Session, error) {
+	var resp []model.Session
+	err := c.do(ctx, &request{method: http.MethodGet, path: apiPrefix + "/users/myself/sessions", auth: authAccess}, &resp)
+	return resp, err
+}
+
+// SignOut signs out the current session and forgets the token pair
+func (c *Client) SignOut(ctx context.Context) error {
+	err := c.do(ctx, &request{method: http.MethodPost, path: apiPrefix + "/users/sign-out", auth: authAccess}, nil)
+	if err != nil {
+		return err
+	}
+
+	c.SetTokens(Tokens{})
+	return nil
+}
+
+// SignOutAll signs out all sessions of the user and forgets the token pair
+func (c *Client) SignOutAll(ctx context.Context) error {
+	err := c.do(ctx, &request{method: http.MethodPost, path: apiPrefix + "/users/sign-out/all", auth: authAccess}, nil)
+	if err != nil {
+		return err
+	}
+
+	c.SetTokens(Tokens{})
+	return nil
+}
@@ -0,0 +1,76 @@
+package verifier
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"strings"
+)
+
+type contextKey struct{}
+
+// ClaimsLocalsKey the key of the claims in the fiber locals
+const ClaimsLocalsKey = "auth_claims"
+
+// ContextWithClaims returns the context holding the claims of the verified token
+func ContextWithClaims(ctx context.Context, claims *model.AccessClaims) context.Context {
+	return context.WithValue(ctx, contextKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims put by the net/http middleware
+func ClaimsFromContext(ctx context.Context) (*model.AccessClaims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*model.AccessClaims)
+	return claims, ok
+}
+
+// FiberClaims returns the claims put by the fiber middleware
+func FiberClaims(ctx *fiber.Ctx) (*model.AccessClaims, bool) {
+	claims, ok := ctx.Locals(ClaimsLocalsKey).(*model.AccessClaims)
+	return claims, ok
+}
+
+// Middleware is the net/http middleware, it answers 401 to the requests without the valid access token
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, err := bearerToken(r.Header.Get("Authorization"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		claims, err := v.Verify(r.Context(), token)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(ContextWithClaims(r.Context(), claims)))
+	})
+}
+
+// FiberMiddleware is the fiber middleware, it answers 401 to the requests without the valid access token
+func (v *Verifier) FiberMiddleware() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		token, err := bearerToken(ctx.Get(fiber.HeaderAuthorization))
+		if err != nil {
+			return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+		}
+
+		claims, err := v.Verify(ctx.UserContext(), token)
+		if err != nil {
+			return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+		}
+
+		ctx.Locals(ClaimsLocalsKey, claims)
+		return ctx.Next()
+	}
+}
+
+func bearerToken(header string) (string, error) {
+	parts := strings.Split(header, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return "", ErrTokenMissing
+	}
+	return parts[1], nil
+}
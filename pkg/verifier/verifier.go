@@ -0,0 +1,221 @@
+// Package verifier validates the access tokens of the auth service in the other services,
+// the tokens are verified locally by the public keys of the JWKS endpoint, the keys are cached.
+// The revocation of the sessions is not seen by the local check, use the introspection endpoint for it.
+package verifier
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v4"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultJwksPath = "/.well-known/jwks.json"
+
+	defaultCacheTTL = 5 * time.Minute
+	// minRefreshInterval limits the fetches of the key set by the tokens with the unknown key id
+	minRefreshInterval = 10 * time.Second
+)
+
+var (
+	ErrTokenMissing = errors.New("authorization token missing")
+	ErrTokenInvalid = errors.New("invalid token")
+	ErrTokenExpired = errors.New("token expired")
+	ErrKeyNotFound  = errors.New("signing key not found")
+)
+
+// Config the settings of the verifier, Issuer, Audience and TenantID are checked only if they are set
+type Config struct {
+	// JwksURL the url of the key set, e.g. https://auth.example.com/.well-known/jwks.json
+	JwksURL string
+
+	Issuer   string
+	Audience string
+	TenantID string
+
+	// CacheTTL how long the keys are kept, 5 minutes by default
+	CacheTTL   time.Duration
+	HTTPClient *http.Client
+}
+
+type Verifier struct {
+	config Config
+
+	mx        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+func New(config Config) *Verifier {
+	if config.CacheTTL <= 0 {
+		config.CacheTTL = defaultCacheTTL
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &Verifier{
+		config: config,
+		keys:   make(map[string]*rsa.PublicKey),
+	}
+}
+
+// Verify checks the signature, the expiration and the type of the access token and returns its claims,
+// the tokens of the sessions and of the machine clients are accepted
+func (v *Verifier) Verify(ctx context.Context, accessToken string) (*model.AccessClaims, error) {
+
+	var claims model.AccessClaims
+	tkn, err := jwt.ParseWithClaims(accessToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method.Alg() != jwt.SigningMethodRS512.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		return v.getKey(ctx, kid)
+	})
+	if err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			return nil, ErrKeyNotFound
+		}
+		return nil, ErrTokenInvalid
+	}
+
+	if !tkn.Valid || !claims.Authorized || claims.UserID == "" || claims.AtID == "" {
+		return nil, ErrTokenInvalid
+	}
+
+	if claims.Type != model.AccessTokenTypeAuth && claims.Type != model.AccessTokenTypeClient {
+		return nil, ErrTokenInvalid
+	}
+
+	if claims.Exp < time.Now().UTC().Unix() {
+		return nil, ErrTokenExpired
+	}
+
+	if v.config.Issuer != "" && claims.Issuer != v.config.Issuer {
+		return nil, ErrTokenInvalid
+	}
+
+	if v.config.Audience != "" && !claims.VerifyAudience(v.config.Audience, true) {
+		return nil, ErrTokenInvalid
+	}
+
+	if v.config.TenantID != "" && claims.TenantID != v.config.TenantID {
+		return nil, ErrTokenInvalid
+	}
+
+	return &claims, nil
+}
+
+// getKey returns the cached key, the key set is fetched again if it is outdated or the key is unknown
+func (v *Verifier) getKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+
+	v.mx.RLock()
+	key, ok := v.keys[kid]
+	fetchedAt := v.fetchedAt
+	v.mx.RUnlock()
+
+	if ok && time.Since(fetchedAt) < v.config.CacheTTL {
+		return key, nil
+	}
+
+	if !ok && time.Since(fetchedAt) < minRefreshInterval {
+		return nil, ErrKeyNotFound
+	}
+
+	err := v.refresh(ctx)
+	if err != nil {
+		// the outdated key is better than nothing while the key set is unavailable
+		if ok {
+			return key, nil
+		}
+		return nil, err
+	}
+
+	v.mx.RLock()
+	defer v.mx.RUnlock()
+
+	key, ok = v.keys[kid]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+
+	return key, nil
+}
+
+func (v *Verifier) refresh(ctx context.Context) error {
+
+	v.mx.Lock()
+	defer v.mx.Unlock()
+
+	// another request could already refresh the keys
+	if time.Since(v.fetchedAt) < minRefreshInterval {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.config.JwksURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := v.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch jwks: unexpected status %d", resp.StatusCode)
+	}
+
+	var jwks model.JWKS
+	err = json.NewDecoder(resp.Body).Decode(&jwks)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(jwks.Keys))
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+
+		key, err := parseRSAKey(jwk)
+		if err != nil {
+			return err
+		}
+		keys[jwk.Kid] = key
+	}
+
+	v.keys = keys
+	v.fetchedAt = time.Now()
+
+	return nil
+}
+
+func parseRSAKey(jwk model.JWK) (*rsa.PublicKey, error) {
+
+	n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
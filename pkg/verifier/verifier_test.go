@@ -0,0 +1,249 @@
+package verifier
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// jwksServer serves the public keys of the set, the keys could be rotated by the test
+type jwksServer struct {
+	*httptest.Server
+
+	mx      sync.Mutex
+	keys    map[string]*rsa.PrivateKey
+	fetches int32
+}
+
+func newJwksServer(t *testing.T) *jwksServer {
+	s := &jwksServer{keys: make(map[string]*rsa.PrivateKey)}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&s.fetches, 1)
+
+		s.mx.Lock()
+		defer s.mx.Unlock()
+
+		jwks := model.JWKS{Keys: make([]model.JWK, 0, len(s.keys))}
+		for kid, key := range s.keys {
+			jwks.Keys = append(jwks.Keys, model.JWK{
+				Kty: "RSA",
+				Use: "sig",
+				Alg: jwt.SigningMethodRS512.Alg(),
+				Kid: kid,
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		_ = json.NewEncoder(w).Encode(jwks)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// rotate replaces the key set by the new key with the given id and returns the key
+func (s *jwksServer) rotate(t *testing.T, kid string) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.mx.Lock()
+	s.keys = map[string]*rsa.PrivateKey{kid: key}
+	s.mx.Unlock()
+
+	return key
+}
+
+func (s *jwksServer) fetchCount() int {
+	return int(atomic.LoadInt32(&s.fetches))
+}
+
+func newClaims() *model.AccessClaims {
+	return &model.AccessClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:   "auth",
+			Audience: jwt.ClaimStrings{"api"},
+		},
+		Authorized: true,
+		AtID:       "at",
+		UserID:     "usr",
+		SessionID:  "ses",
+		Exp:        time.Now().Add(time.Minute).Unix(),
+		Type:       model.AccessTokenTypeAuth,
+	}
+}
+
+func sign(t *testing.T, key *rsa.PrivateKey, kid string, claims *model.AccessClaims) string {
+	tkn := jwt.NewWithClaims(jwt.SigningMethodRS512, claims)
+	tkn.Header["kid"] = kid
+
+	signed, err := tkn.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestVerifyFetchesKeys(t *testing.T) {
+	s := newJwksServer(t)
+	key := s.rotate(t, "k1")
+	v := New(Config{JwksURL: s.URL, Issuer: "auth", Audience: "api"})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		claims, err := v.Verify(ctx, sign(t, key, "k1", newClaims()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if claims.UserID != "usr" {
+			t.Fatalf("got user %q, want usr", claims.UserID)
+		}
+	}
+
+	// the key is cached
+	if n := s.fetchCount(); n != 1 {
+		t.Fatalf("got %d fetches, want 1", n)
+	}
+}
+
+func TestVerifyKeyRotation(t *testing.T) {
+	s := newJwksServer(t)
+	oldKey := s.rotate(t, "k1")
+	v := New(Config{JwksURL: s.URL})
+	ctx := context.Background()
+
+	_, err := v.Verify(ctx, sign(t, oldKey, "k1", newClaims()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newKey := s.rotate(t, "k2")
+
+	// the unknown key id does not fetch the key set again within minRefreshInterval
+	_, err = v.Verify(ctx, sign(t, newKey, "k2", newClaims()))
+	if err != ErrKeyNotFound {
+		t.Fatalf("got %v, want ErrKeyNotFound", err)
+	}
+	if n := s.fetchCount(); n != 1 {
+		t.Fatalf("got %d fetches, want 1", n)
+	}
+
+	v.mx.Lock()
+	v.fetchedAt = time.Now().Add(-minRefreshInterval)
+	v.mx.Unlock()
+
+	// the rotated key is fetched by its unknown id
+	_, err = v.Verify(ctx, sign(t, newKey, "k2", newClaims()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := s.fetchCount(); n != 2 {
+		t.Fatalf("got %d fetches, want 2", n)
+	}
+
+	// the key removed from the set is not known anymore
+	_, err = v.Verify(ctx, sign(t, oldKey, "k1", newClaims()))
+	if err != ErrKeyNotFound {
+		t.Fatalf("got %v, want ErrKeyNotFound", err)
+	}
+}
+
+func TestVerifyRejectsClaims(t *testing.T) {
+	s := newJwksServer(t)
+	key := s.rotate(t, "k1")
+	v := New(Config{JwksURL: s.URL, Issuer: "auth", Audience: "api", TenantID: "default"})
+	ctx := context.Background()
+
+	cases := []struct {
+		name   string
+		modify func(claims *model.AccessClaims)
+		want   error
+	}{
+		{"expired", func(claims *model.AccessClaims) {
+			claims.Exp = time.Now().Add(-time.Minute).Unix()
+		}, ErrTokenExpired},
+		{"audience", func(claims *model.AccessClaims) {
+			claims.Audience = jwt.ClaimStrings{"other"}
+		}, ErrTokenInvalid},
+		{"issuer", func(claims *model.AccessClaims) {
+			claims.Issuer = "other"
+		}, ErrTokenInvalid},
+		{"tenant", func(claims *model.AccessClaims) {
+			claims.TenantID = "other"
+		}, ErrTokenInvalid},
+		{"type", func(claims *model.AccessClaims) {
+			claims.Type = model.AccessTokenTypeTwoFactorAuth
+		}, ErrTokenInvalid},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			claims := newClaims()
+			claims.TenantID = "default"
+			c.modify(claims)
+
+			_, err := v.Verify(ctx, sign(t, key, "k1", claims))
+			if err != c.want {
+				t.Fatalf("got %v, want %v", err, c.want)
+			}
+		})
+	}
+
+	// the token signed by another key is rejected
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = v.Verify(ctx, sign(t, otherKey, "k1", newClaims()))
+	if err != ErrTokenInvalid {
+		t.Fatalf("got %v, want ErrTokenInvalid", err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	s := newJwksServer(t)
+	key := s.rotate(t, "k1")
+	v := New(Config{JwksURL: s.URL})
+
+	handler := v.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := ClaimsFromContext(r.Context())
+		if !ok {
+			t.Fatal("claims are not in the context")
+		}
+		_, _ = w.Write([]byte(claims.UserID))
+	}))
+
+	for _, c := range []struct {
+		header string
+		want   int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer invalid", http.StatusUnauthorized},
+		{"Bearer " + sign(t, key, "k1", newClaims()), http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if c.header != "" {
+			req.Header.Set("Authorization", c.header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != c.want {
+			t.Fatalf("header %q: got %d, want %d", c.header, rec.Code, c.want)
+		}
+	}
+}
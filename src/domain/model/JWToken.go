@@ -1,11 +1,35 @@
 package model
 
-import "github.com/golang-jwt/jwt/v4"
+import (
+	"github.com/golang-jwt/jwt/v4"
+	"time"
+)
 
 const (
-	PostfixRefreshToken          = "_refresh"
+	PostfixRefreshToken = "_refresh"
+	PrefixRevokedToken  = "revoked_"
+	// the index of the live sessions of the user in the session store
+	PrefixUserSessions           = "user_sessions_"
 	AccessTokenTypeAuth          = "auth"
 	AccessTokenTypeTwoFactorAuth = "two_factor_auth"
+	// the token of the user who must set up 2fa by the tenant's policy, it is accepted only by the set-up functions
+	AccessTokenTypeTwoFactorAuthSetup = "two_factor_auth_setup"
+	// the tokens of the machine clients, they are not bound to the sessions and limited by the scopes
+	AccessTokenTypeApiKey = "api_key"
+	AccessTokenTypeClient = "client"
+)
+
+// authentication method references of the amr claim (RFC 8176)
+const (
+	AuthMethodPassword     = "pwd"
+	AuthMethodOtp          = "otp"
+	AuthMethodSms          = "sms"
+	AuthMethodEmail        = "email"
+	AuthMethodRecoveryCode = "rcd"
+	AuthMethodQrCode       = "qr"
+	// the second factor is skipped by the token of the trusted device
+	AuthMethodTrustedDevice = "swk"
+	AuthMethodMultiFactor   = "mfa"
 )
 
 // AccessClaims a custom access token claims structure.
@@ -17,6 +41,18 @@ type AccessClaims struct {
 	SessionID  string `json:"session_id" validate:"required"`
 	Exp        int64  `json:"exp" validate:"required"`
 	Type       string `json:"type" validate:"required"`
+
+	// AuthTime is the moment the user last proved the credentials, it is not changed by the refresh
+	AuthTime int64    `json:"auth_time,omitempty"`
+	Amr      []string `json:"amr,omitempty"`
+
+	Role     string `json:"role,omitempty"`
+	KycLevel int    `json:"kyc_level"`
+	TenantID string `json:"tid,omitempty"`
+
+	// Scope and ClientID are set only for the machine clients, the space-delimited scopes (RFC 6749)
+	Scope    string `json:"scope,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
 }
 
 // RefreshClaims a custom refresh token claims structure.
@@ -26,9 +62,55 @@ type RefreshClaims struct {
 	SessionID string `json:"session_id" validate:"required"`
 	UserID    string `json:"usr_id" validate:"required"`
 	Exp       int64  `json:"exp" validate:"required"`
+
+	AuthTime int64    `json:"auth_time,omitempty"`
+	Amr      []string `json:"amr,omitempty"`
+}
+
+// MagicLinkClaims a custom magic link token claims structure.
+type MagicLinkClaims struct {
+	jwt.RegisteredClaims
+	TkID   string `json:"tk_id" validate:"required"`
+	Value  string `json:"value" validate:"required"`
+	UserID string `json:"usr_id" validate:"required"`
+	Exp    int64  `json:"exp" validate:"required"`
+}
+
+// TrustedDeviceClaims a custom trusted device token claims structure.
+type TrustedDeviceClaims struct {
+	jwt.RegisteredClaims
+	DvID   string `json:"dv_id" validate:"required"`
+	UserID string `json:"usr_id" validate:"required"`
+	Exp    int64  `json:"exp" validate:"required"`
+}
+
+// AuthDetails describes when and how the user was authenticated
+type AuthDetails struct {
+	AuthTime int64
+	Amr      []string
+}
+
+// NewAuthDetails returns details of the authentication passed right now
+func NewAuthDetails(amr ...string) *AuthDetails {
+	return &AuthDetails{
+		AuthTime: time.Now().UTC().Unix(),
+		Amr:      amr,
+	}
+}
+
+// UserClaims describes the user for the downstream services, it is read again on each refresh
+type UserClaims struct {
+	Role     string
+	KycLevel int
+
+	// the tenant of the user, Issuer and Audience are set by its settings
+	TenantID string
+	Issuer   string
+	Audience string
 }
 
 type TokenDetails struct {
+	UserID       string
 	SessionID    string
 	AccessToken  string
 	RefreshToken string
@@ -36,6 +118,10 @@ type TokenDetails struct {
 	RtID         string
 	AtExpires    int64
 	RtExpires    int64
+
+	// TrustedDeviceToken is given only if the user asked to trust the device
+	TrustedDeviceToken   string
+	TrustedDeviceExpires int64
 }
 
 type AccessTokenDetails struct {
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+const (
+	AccountExportFormatJSON = "json"
+	AccountExportFormatZIP  = "zip"
+)
+
+// UserDataExport entity of the archive with the user's data
+type UserDataExport struct {
+	Profile        *UserGetMyProfileResp `json:"profile"`
+	Sessions       []Session             `json:"sessions"`
+	Referrals      []UserReferralGet     `json:"referrals"`
+	SecurityEvents []SecurityEvent       `json:"security_events"`
+
+	ExportedAt time.Time `json:"exported_at"`
+}
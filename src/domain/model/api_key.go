@@ -0,0 +1,149 @@
+package model
+
+import (
+	"github.com/uptrace/bun"
+	"strings"
+	"time"
+)
+
+const (
+	// the prefixes of the secrets make them recognizable by the leak scanners
+	ApiKeyPrefix             = "apk_"
+	OAuthClientSecretPrefix  = "aps_"
+	OAuthClientIDPrefix      = "apc_"
+	ApiKeyHeader             = "X-API-Key"
+	OAuthGrantTypeClientCred = "client_credentials"
+
+	// ScopeRead allows the safe methods, ScopeWrite the others, ScopeAdmin the admin functions of the owner,
+	// ScopeIntrospect allows the resource server to introspect and revoke the tokens of the tenant
+	ScopeRead       = "read"
+	ScopeWrite      = "write"
+	ScopeAdmin      = "admin"
+	ScopeIntrospect = "introspect"
+
+	// the token type hints of the introspection and the revocation (RFC 7009)
+	TokenTypeHintAccessToken  = "access_token"
+	TokenTypeHintRefreshToken = "refresh_token"
+)
+
+// Scopes the scopes which could be given to the api keys and the clients
+var Scopes = []string{ScopeRead, ScopeWrite, ScopeAdmin, ScopeIntrospect}
+
+// ApiKey entity of the personal access token, only the hash of the key is kept
+type ApiKey struct {
+	bun.BaseModel `bun:"table:api_keys,alias:apk"`
+
+	ID       string   `json:"id" bun:"id,pk"`
+	TenantID string   `json:"-"`
+	UserID   string   `json:"-"`
+	Name     string   `json:"name"`
+	Prefix   string   `json:"prefix"`
+	KeyHash  string   `json:"-"`
+	Scopes   []string `json:"scopes" bun:",array"`
+
+	ExpiresAT  time.Time `json:"expires_at"`
+	LastUsedAt time.Time `json:"last_used_at" bun:"last_used_at,nullzero"`
+	LastUsedIP string    `json:"last_used_ip" bun:"last_used_ip,nullzero"`
+	RevokedAt  time.Time `json:"revoked_at" bun:"revoked_at,nullzero"`
+	CreatedAt  time.Time `json:"created_at" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+}
+
+// OAuthClient entity of the machine client, it gets the tokens of its owner by the client credentials grant
+type OAuthClient struct {
+	bun.BaseModel `bun:"table:oauth_clients,alias:oac"`
+
+	ID         string   `json:"client_id" bun:"id,pk"`
+	TenantID   string   `json:"-"`
+	UserID     string   `json:"-"`
+	Name       string   `json:"name"`
+	SecretHash string   `json:"-"`
+	Scopes     []string `json:"scopes" bun:",array"`
+
+	LastUsedAt time.Time `json:"last_used_at" bun:"last_used_at,nullzero"`
+	RevokedAt  time.Time `json:"revoked_at" bun:"revoked_at,nullzero"`
+	CreatedAt  time.Time `json:"created_at" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+}
+
+// ApiKeyCreateReq entity of the create api key request, the lifetime is limited by api_key.max_lifetime
+type ApiKeyCreateReq struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+// ApiKeyCreateResp entity of the create api key resp, the key is shown only once
+type ApiKeyCreateResp struct {
+	ApiKey
+	Key string `json:"key"`
+}
+
+// OAuthClientCreateReq entity of the create client request
+type OAuthClientCreateReq struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// OAuthClientSecretResp entity of the client with the secret, the secret is shown only once
+type OAuthClientSecretResp struct {
+	OAuthClient
+	ClientSecret string `json:"client_secret"`
+}
+
+// OAuthTokenReq entity of the token request (RFC 6749), the credentials could be sent by basic auth as well
+type OAuthTokenReq struct {
+	GrantType    string `json:"grant_type" form:"grant_type"`
+	ClientID     string `json:"client_id" form:"client_id"`
+	ClientSecret string `json:"client_secret" form:"client_secret"`
+	Scope        string `json:"scope" form:"scope"`
+}
+
+// OAuthTokenResp entity of the token resp (RFC 6749)
+type OAuthTokenResp struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// OAuthTokenCheckReq entity of the introspection (RFC 7662) and the revocation (RFC 7009) requests,
+// the credentials of the client could be sent by basic auth as well
+type OAuthTokenCheckReq struct {
+	Token         string `json:"token" form:"token"`
+	TokenTypeHint string `json:"token_type_hint" form:"token_type_hint"`
+	ClientID      string `json:"client_id" form:"client_id"`
+	ClientSecret  string `json:"client_secret" form:"client_secret"`
+}
+
+// OAuthIntrospectionResp entity of the introspection resp (RFC 7662), only Active is set for the inactive token,
+// Type, SessionID, TenantID and Role are the extensions
+type OAuthIntrospectionResp struct {
+	Active    bool     `json:"active"`
+	Scope     string   `json:"scope,omitempty"`
+	ClientID  string   `json:"client_id,omitempty"`
+	TokenType string   `json:"token_type,omitempty"`
+	Exp       int64    `json:"exp,omitempty"`
+	Sub       string   `json:"sub,omitempty"`
+	Aud       []string `json:"aud,omitempty"`
+	Iss       string   `json:"iss,omitempty"`
+	Jti       string   `json:"jti,omitempty"`
+
+	Type      string `json:"type,omitempty"`
+	SessionID string `json:"sid,omitempty"`
+	TenantID  string `json:"tid,omitempty"`
+	Role      string `json:"role,omitempty"`
+}
+
+// ParseScope splits the space-delimited scope (RFC 6749)
+func ParseScope(scope string) []string {
+	return strings.Fields(scope)
+}
+
+// HasScope checks the scope among the granted ones
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -10,3 +10,33 @@ type AuthenticationReq struct {
 type AuthQrCodeReq struct {
 	Token string `json:"token"`
 }
+
+// MagicLinkSendReq entity for send magic link request
+type MagicLinkSendReq struct {
+	Email string `json:"email"`
+}
+
+// MagicLinkAuthReq entity for auth by magic link request
+type MagicLinkAuthReq struct {
+	Token string `json:"token"`
+}
+
+// OtpSendReq entity for send one-time login code request
+type OtpSendReq struct {
+	Login     string `json:"login"`
+	LoginType string `json:"login_type"`
+}
+
+// OtpAuthReq entity for auth by one-time login code request
+type OtpAuthReq struct {
+	Login     string `json:"login"`
+	LoginType string `json:"login_type"`
+	Code2fa   string `json:"code_2fa"`
+}
+
+// ReauthenticateReq entity for reauthenticate request
+type ReauthenticateReq struct {
+	Password    string `json:"password"`
+	Code2fa     string `json:"code_2fa"`
+	Code2faType string `json:"code_2fa_type"`
+}
@@ -0,0 +1,33 @@
+package model
+
+import (
+	"errors"
+	"github.com/uptrace/bun"
+	"time"
+)
+
+// ErrContactTaken the new email or phone already belongs to another user
+var ErrContactTaken = errors.New("contact already in use")
+
+// Base entity
+type ContactChange struct {
+	bun.BaseModel `bun:"table:contact_changes,alias:cch"`
+
+	ID        string `json:"id" bun:"id,pk"`
+	UserID    string `json:"-"`
+	Type      string `json:"type"`
+	OldValue  string `json:"old_value" bun:",nullzero"`
+	NewValue  string `json:"new_value"`
+	UserAgent string `json:"-" bun:",nullzero"`
+	ClientIP  string `json:"-" bun:",nullzero"`
+
+	ApplyAt    time.Time `json:"apply_at"`
+	AppliedAt  time.Time `json:"-" bun:"applied_at,nullzero"`
+	CanceledAt time.Time `json:"-" bun:"canceled_at,nullzero"`
+	CreatedAt  time.Time `json:"created_at" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+}
+
+// ContactChangeSendCodeReq entity of to send the code confirming the contact change by the current method request
+type ContactChangeSendCodeReq struct {
+	Code2faType string `json:"code_2fa_type"`
+}
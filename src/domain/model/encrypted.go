@@ -0,0 +1,5 @@
+package model
+
+// EncryptedString is the value of the sensitive column as it is stored, the repositories encrypt it
+// bound to its table, column and row and decrypt it only where the plaintext is needed
+type EncryptedString string
@@ -0,0 +1,106 @@
+package model
+
+import (
+	"github.com/uptrace/bun"
+	"time"
+)
+
+const (
+	KycStatusPending  = "pending"
+	KycStatusApproved = "approved"
+	KycStatusRejected = "rejected"
+
+	KycDocumentIDFront        = "id_front"
+	KycDocumentIDBack         = "id_back"
+	KycDocumentSelfie         = "selfie"
+	KycDocumentProofOfAddress = "proof_of_address"
+)
+
+// KycDocumentTypes the documents accepted with the kyc submission, they are the names of the form files
+var KycDocumentTypes = []string{
+	KycDocumentIDFront,
+	KycDocumentIDBack,
+	KycDocumentSelfie,
+	KycDocumentProofOfAddress,
+}
+
+// Base entity
+type UserConfig struct {
+	bun.BaseModel `bun:"table:user_configs,alias:ucf"`
+
+	UserID     string          `json:"-" bun:"user_id,pk"`
+	KycLevel   int             `json:"kyc_level"`
+	IDCard     EncryptedString `json:"-" bun:"id_card,nullzero"`
+	AccFlagged bool            `json:"acc_flagged"`
+}
+
+// KycSubmission entity of the kyc data sent by the user for the review
+type KycSubmission struct {
+	bun.BaseModel `bun:"table:kyc_submissions,alias:kyc"`
+
+	ID            string          `json:"id" bun:"id,pk"`
+	UserID        string          `json:"user_id"`
+	Status        string          `json:"status"`
+	Level         int             `json:"level"`
+	IDCard        EncryptedString `json:"-" bun:"id_card,nullzero"`
+	ReviewerID    string          `json:"reviewer_id,omitempty" bun:",nullzero"`
+	ReviewComment string          `json:"review_comment,omitempty" bun:",nullzero"`
+
+	ReviewedAt time.Time `json:"reviewed_at" bun:"reviewed_at,nullzero"`
+	CreatedAt  time.Time `json:"created_at" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+
+	Documents []KycDocument `json:"documents" bun:"rel:has-many,join:id=submission_id"`
+}
+
+// KycDocument entity of the file attached to the kyc submission, the content is kept in the file storage
+type KycDocument struct {
+	bun.BaseModel `bun:"table:kyc_documents,alias:kdc"`
+
+	ID           string    `json:"id" bun:"id,pk"`
+	SubmissionID string    `json:"-"`
+	Type         string    `json:"type"`
+	StorageKey   string    `json:"-"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	CreatedAt    time.Time `json:"created_at" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+
+	Content []byte `json:"-" bun:"-"`
+}
+
+// KycSubmitReq entity of the kyc submission form, the documents are sent as the form files
+type KycSubmitReq struct {
+	Level  int    `form:"level"`
+	IDCard string `form:"id_card"`
+}
+
+// KycStatusResp entity of the user's kyc status resp
+type KycStatusResp struct {
+	KycLevel    int             `json:"kyc_level"`
+	Submissions []KycSubmission `json:"submissions"`
+}
+
+// KycSubmissionListReq entity of the kyc submissions list request
+type KycSubmissionListReq struct {
+	Status string `query:"status"`
+	Limit  int    `query:"limit"`
+	Offset int    `query:"offset"`
+}
+
+// KycSubmissionListResp entity of the kyc submissions list resp
+type KycSubmissionListResp struct {
+	Submissions []KycSubmission `json:"submissions"`
+	Total       int             `json:"total"`
+	Limit       int             `json:"limit"`
+	Offset      int             `json:"offset"`
+}
+
+// KycReviewReq entity of the kyc review request, Level is set to the user only on the approval
+type KycReviewReq struct {
+	Level   int    `json:"level"`
+	Comment string `json:"comment"`
+}
+
+// UserFlagReq entity of the flag account request
+type UserFlagReq struct {
+	Flagged bool `json:"flagged"`
+}
@@ -0,0 +1,50 @@
+package model
+
+import (
+	"github.com/uptrace/bun"
+	"time"
+)
+
+const (
+	SecurityEventNewLogin             = "new_login"
+	SecurityEventPasswordChanged      = "password_changed"
+	SecurityEventEmailChanged         = "email_changed"
+	SecurityEventPhoneChanged         = "phone_changed"
+	SecurityEventEmailChangeRequested = "email_change_requested"
+	SecurityEventPhoneChangeRequested = "phone_change_requested"
+	SecurityEventContactChangeFailed  = "contact_change_failed"
+	SecurityEventAccountDeleted       = "account_deleted"
+	SecurityEventTwoFactorAuthChanged = "two_factor_auth_changed"
+)
+
+// SecurityEvent entity of the account activity log
+type SecurityEvent struct {
+	bun.BaseModel `bun:"table:security_events,alias:sev"`
+
+	ID        string `json:"id" bun:"id,pk"`
+	UserID    string `json:"-"`
+	Event     string `json:"event"`
+	UserAgent string `json:"user_agent" bun:",nullzero"`
+	ClientIP  string `json:"client_ip" bun:",nullzero"`
+
+	CreatedAt time.Time `json:"created_at" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+}
+
+// SecurityNotification entity of the message which tells the user about the activity in the account
+type SecurityNotification struct {
+	TenantID  string
+	UserID    string
+	Event     string
+	UserAgent string
+	ClientIP  string
+	Time      time.Time
+
+	// RevokePath is the "this wasn't me" link which signs out all sessions,
+	// it is relative to the front host of the tenant
+	RevokePath string
+}
+
+// RevokeSessionsReq entity for revoke sessions by the link from the notification request
+type RevokeSessionsReq struct {
+	Token string `json:"token"`
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"github.com/uptrace/bun"
+	"time"
+)
+
+// Base entity
+type RecoveryCode struct {
+	bun.BaseModel `bun:"table:recovery_codes,alias:rcd"`
+
+	ID       string `json:"id" bun:"id,pk"`
+	UserID   string `json:"user_id"`
+	CodeHash string `json:"-"`
+	IsUsed   bool   `json:"is_used"`
+
+	UsedAt    time.Time `json:"used_at" bun:"used_at,nullzero"`
+	CreatedAt time.Time `json:"created_at" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+}
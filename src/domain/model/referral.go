@@ -0,0 +1,101 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	ReferralStatsPeriodDay   = "day"
+	ReferralStatsPeriodWeek  = "week"
+	ReferralStatsPeriodMonth = "month"
+)
+
+// ErrReferralLinkTaken the referral code already belongs to another user
+var ErrReferralLinkTaken = errors.New("referral code already in use")
+
+// ReferredUser entity of the user signed up by the referral link, Level is 1 for the direct referrals
+type ReferredUser struct {
+	ID           string    `bun:"id"`
+	FullName     string    `bun:"full_name"`
+	ReferralLink string    `bun:"referral_link"`
+	Referral     string    `bun:"referral"`
+	Level        int       `bun:"level"`
+	CreatedAt    time.Time `bun:"created_at"`
+}
+
+// ReferralStat entity of the number of the referrals signed up in the period
+type ReferralStat struct {
+	PeriodStart time.Time `json:"period_start" bun:"period_start"`
+	Count       int       `json:"count" bun:"count"`
+}
+
+// ReferralListReq entity of the referrals list request
+type ReferralListReq struct {
+	Limit  int `query:"limit"`
+	Offset int `query:"offset"`
+}
+
+// ReferralTreeReq entity of the referrals tree and count request
+type ReferralTreeReq struct {
+	Depth int `query:"depth"`
+}
+
+// ReferralStatsReq entity of the referral statistics request, the dates are in the 2006-01-02 format
+type ReferralStatsReq struct {
+	Period string `query:"period"`
+	From   string `query:"from"`
+	To     string `query:"to"`
+}
+
+// ReferralCodeReq entity of the vanity referral code request
+type ReferralCodeReq struct {
+	Code string `json:"code"`
+}
+
+// ReferralUserResp entity of the referral with the fields safe to show to the referrer
+type ReferralUserResp struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// ReferralListResp entity of the referrals list resp
+type ReferralListResp struct {
+	Referrals []ReferralUserResp `json:"referrals"`
+	Total     int                `json:"total"`
+	Limit     int                `json:"limit"`
+	Offset    int                `json:"offset"`
+}
+
+// ReferralTreeNode entity of the referrals tree resp
+type ReferralTreeNode struct {
+	ReferralUserResp
+	Referrals []*ReferralTreeNode `json:"referrals"`
+}
+
+// ReferralLevelCount entity of the number of the referrals on the level
+type ReferralLevelCount struct {
+	Level int `json:"level"`
+	Count int `json:"count"`
+}
+
+// ReferralCountResp entity of the referral counts resp
+type ReferralCountResp struct {
+	Direct int                  `json:"direct"`
+	Total  int                  `json:"total"`
+	Levels []ReferralLevelCount `json:"levels"`
+}
+
+// ReferralStatsResp entity of the referral statistics resp
+type ReferralStatsResp struct {
+	Period string         `json:"period"`
+	From   time.Time      `json:"from"`
+	To     time.Time      `json:"to"`
+	Stats  []ReferralStat `json:"stats"`
+}
+
+// ReferralLinkResp entity of the referral code resp
+type ReferralLinkResp struct {
+	ReferralLink string `json:"referral_link"`
+}
@@ -0,0 +1,77 @@
+package model
+
+// MessageResp entity of the resp without data
+type MessageResp struct {
+	Message string `json:"message"`
+}
+
+// AuthResp entity of the sign in resp, either the token pair is given or, if two-factor auth is required,
+// the 2fa token with the enrolled methods, the code is sent to the target of the default method,
+// if the tenant's policy requires 2fa which is not set up, the 2fa set-up token is given instead of the session
+type AuthResp struct {
+	AccessToken        string `json:"access_token,omitempty"`
+	RefreshToken       string `json:"refresh_token,omitempty"`
+	TrustedDeviceToken string `json:"trusted_device_token,omitempty"`
+
+	TwoFactorAuthToken         string                `json:"2fa_auth_token,omitempty"`
+	TwoFactorAuthType          string                `json:"2fa_type,omitempty"`
+	TwoFactorAuthMethods       []TwoFactorAuthMethod `json:"2fa_methods,omitempty"`
+	TwoFactorAuthTarget        string                `json:"2fa_target,omitempty"`
+	TwoFactorAuthSetupRequired bool                  `json:"2fa_setup_required,omitempty"`
+	TwoFactorAuthSetupToken    string                `json:"2fa_setup_token,omitempty"`
+}
+
+// TwoFactorAuthCodeResp entity of the re-send 2fa code resp
+type TwoFactorAuthCodeResp struct {
+	TwoFactorAuthType   string `json:"2fa_type"`
+	TwoFactorAuthTarget string `json:"2fa_target"`
+}
+
+// Code2faSentResp entity of the send 2fa code resp, the target is not set for google authenticator
+type Code2faSentResp struct {
+	Code2faType   string `json:"code_2fa_type"`
+	Code2faTarget string `json:"code_2fa_target,omitempty"`
+}
+
+// GoogleTwoFactorAuthQrCodeResp entity of the google authenticator set-up resp, the qr code is png
+type GoogleTwoFactorAuthQrCodeResp struct {
+	QrCode []byte `json:"qr_code"`
+	Secret string `json:"secret"`
+}
+
+// TwoFactorAuthSetUpResp entity of the 2fa set-up resp, the recovery codes are given with the first method
+type TwoFactorAuthSetUpResp struct {
+	Message       string   `json:"message"`
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+// RecoveryCodesCountResp entity of the recovery codes count resp
+type RecoveryCodesCountResp struct {
+	RecoveryCodesLeft int `json:"recovery_codes_left"`
+}
+
+// RecoveryCodesResp entity of the regenerate recovery codes resp
+type RecoveryCodesResp struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// QrCodeAuthResp entity of the qr code sent by the websocket of the qr code sign in
+type QrCodeAuthResp struct {
+	QrCode      []byte `json:"qr_code"`
+	QrCodeToken string `json:"qr_code_token"`
+}
+
+// JWK entity of the public RSA key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JWKS entity of the key set used to verify the tokens (RFC 7517)
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
@@ -1,22 +1,29 @@
 package model
 
 import (
+	"errors"
 	"github.com/uptrace/bun"
 	"time"
 )
 
+// ErrSessionNotFound the tokens of the session are not kept by the session store, it is revoked or expired
+var ErrSessionNotFound = errors.New("session not found")
+
 // Base entity
 type Session struct {
 	bun.BaseModel `bun:"table:sessions,alias:ssn"`
 
 	SessionID string `json:"session_id" bun:"session_id,pk"`
+	TenantID  string `json:"tenant_id" bun:",nullzero,default:'default'"`
 
 	UserAgent string `json:"user_agent"`
 	ClientIP  string `json:"client_ip"`
 	IsLogout  bool   `json:"is_logout"`
+	// Current is set in the list of the live sessions to the session of the token of the request
+	Current bool `json:"current,omitempty" bun:"-"`
 
 	ExpiresAT time.Time `json:"expires_at"`
-	CreatedAt time.Time `json:"created_at" bun:"created_at,nullzero,notnull,default:now()"`
+	CreatedAt time.Time `json:"created_at" bun:"created_at,nullzero,notnull,default:current_timestamp"`
 	UpdatedAt time.Time `json:"updated_at" bun:"updated_at,nullzero"`
 
 	User   *User  `json:"user" bun:"rel:belongs-to"`
@@ -0,0 +1,87 @@
+package model
+
+import (
+	"context"
+	"errors"
+	"github.com/uptrace/bun"
+	"time"
+)
+
+const (
+	// DefaultTenantID the tenant of the requests which are not resolved to any other one,
+	// all the users created before the tenants belong to it
+	DefaultTenantID = "default"
+
+	TenantHeader = "X-Tenant-ID"
+)
+
+// ErrTenantNotFound the tenant is unknown or inactive
+var ErrTenantNotFound = errors.New("tenant not found")
+
+// Base entity
+type Tenant struct {
+	bun.BaseModel `bun:"table:tenants,alias:tnt"`
+
+	ID    string   `json:"id" bun:"id,pk"`
+	Name  string   `json:"name" bun:",nullzero"`
+	Hosts []string `json:"hosts" bun:",array"`
+
+	// branding
+	LogoURL          string `json:"logo_url" bun:",nullzero"`
+	PrimaryColor     string `json:"primary_color" bun:",nullzero"`
+	SupportEmail     string `json:"support_email" bun:",nullzero"`
+	FrontHost        string `json:"front_host" bun:",nullzero"`
+	EmailFromName    string `json:"-" bun:",nullzero"`
+	EmailFromAddress string `json:"-" bun:",nullzero"`
+	SmsFrom          string `json:"-" bun:",nullzero"`
+
+	JwtIssuer   string `json:"-" bun:",nullzero"`
+	JwtAudience string `json:"-" bun:",nullzero"`
+
+	// 2fa policy, TwoFactorAuthTypes are the allowed methods, all of them are allowed if it is empty
+	TwoFactorAuthRequired bool     `json:"2fa_required" bun:"two_factor_auth_required"`
+	TwoFactorAuthTypes    []string `json:"2fa_types" bun:"two_factor_auth_types,array"`
+
+	IsActive  bool      `json:"-"`
+	CreatedAt time.Time `json:"-" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+	UpdatedAt time.Time `json:"-" bun:"updated_at,nullzero"`
+}
+
+// IsTwoFactorAuthTypeAllowed checks the method against the tenant's 2fa policy
+func (t *Tenant) IsTwoFactorAuthTypeAllowed(twoFactorAuthType string) bool {
+	if len(t.TwoFactorAuthTypes) == 0 {
+		return true
+	}
+
+	for _, allowed := range t.TwoFactorAuthTypes {
+		if allowed == twoFactorAuthType {
+			return true
+		}
+	}
+	return false
+}
+
+// TenantID returns the tenant resolved for the request, the default tenant is used out of the request
+func TenantID(ctx context.Context) string {
+	tenantID, ok := ctx.Value("tenant_id").(string)
+	if !ok || tenantID == "" {
+		return DefaultTenantID
+	}
+	return tenantID
+}
+
+// WithTenantID returns the context of the tenant for the work out of the request
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, "tenant_id", tenantID)
+}
+
+// TenantBrandingResp entity of the tenant's branding resp
+type TenantBrandingResp struct {
+	ID                    string   `json:"id"`
+	Name                  string   `json:"name"`
+	LogoURL               string   `json:"logo_url"`
+	PrimaryColor          string   `json:"primary_color"`
+	SupportEmail          string   `json:"support_email"`
+	TwoFactorAuthRequired bool     `json:"2fa_required"`
+	TwoFactorAuthTypes    []string `json:"2fa_types"`
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"errors"
 	"github.com/uptrace/bun"
 	"time"
 )
@@ -11,30 +12,42 @@ const (
 	TokenReasonResetPassword = "reset_password"
 	TokenReasonSignUp        = "sign_up"
 	TokenReasonAuthByQrCode  = "auth_qr_code"
+	TokenReasonMagicLink     = "magic_link"
+	TokenReasonLogin         = "login"
+	TokenReasonRevoke        = "revoke_sessions"
+	TokenReasonContactChange = "contact_change"
 
 	TokenTypeGoogle = "google"
 	TokenTypeEmail  = "email"
 	TokenTypePhone  = "phone"
+
+	TokenTypeRecovery = "recovery"
 )
 
 var (
 	TokenTimeSendErr = ""
+
+	// ErrTokenAttempts the code of the target can not be verified until the failed attempts are out of the window
+	ErrTokenAttempts = errors.New("too many attempts, request a new code later")
 )
 
 // Base entity
 type Token struct {
 	bun.BaseModel `bun:"table:tokens,alias:tkn"`
 
-	ID     string `json:"id" bun:"id,pk"`
-	UserID string `json:"user_id" bun:",nullzero"`
-	Target string `json:"target" bun:",nullzero"`
-	Value  string `json:"value" bun:",nullzero"`
-	Reason string `json:"reason" bun:",nullzero"`
-	Type   string `json:"type" bun:",nullzero"`
-	IsUsed bool   `json:"is_used"`
+	ID       string `json:"id" bun:"id,pk"`
+	TenantID string `json:"tenant_id" bun:",nullzero,default:'default'"`
+	UserID   string `json:"user_id" bun:",nullzero"`
+	Target   string `json:"target" bun:",nullzero"`
+	Value    string `json:"value" bun:",nullzero"`
+	Reason   string `json:"reason" bun:",nullzero"`
+	Type     string `json:"type" bun:",nullzero"`
+	IsUsed   bool   `json:"is_used"`
+	// Attempts is the number of the failed verifications while the token is live
+	Attempts int `json:"-" bun:",notnull"`
 
 	ExpiresAT time.Time `json:"expires_at"`
-	CreatedAt time.Time `json:"created_at" bun:"created_at,nullzero,notnull,default:now()"`
+	CreatedAt time.Time `json:"created_at" bun:"created_at,nullzero,notnull,default:current_timestamp"`
 }
 
 // SendTarget2faCodeReq entity for send target 2fa code request
@@ -55,6 +68,7 @@ type Send2faCodeData struct {
 type Verify2faCodeReq struct {
 	Code2fa     string `json:"code_2fa"`
 	Code2faType string `json:"code_2fa_type"`
+	TrustDevice bool   `json:"trust_device"`
 }
 
 // VerifyCodeData entity for verify 2fa code data for function
@@ -0,0 +1,26 @@
+package model
+
+import (
+	"github.com/uptrace/bun"
+	"time"
+)
+
+// TrustedDeviceCookieName the cookie where the browser keeps the trusted device token
+const TrustedDeviceCookieName = "trusted_device"
+
+// TrustedDeviceHeader the header where the other clients send the trusted device token
+const TrustedDeviceHeader = "X-Trusted-Device"
+
+// Base entity
+type TrustedDevice struct {
+	bun.BaseModel `bun:"table:trusted_devices,alias:tdv"`
+
+	ID        string `json:"id" bun:"id,pk"`
+	UserID    string `json:"-"`
+	UserAgent string `json:"user_agent"`
+	ClientIP  string `json:"client_ip"`
+
+	ExpiresAT  time.Time `json:"expires_at"`
+	LastUsedAt time.Time `json:"last_used_at" bun:"last_used_at,nullzero"`
+	CreatedAt  time.Time `json:"created_at" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+}
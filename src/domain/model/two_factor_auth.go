@@ -7,8 +7,7 @@ type TwoFactorAuthCodeVerifyReq struct {
 
 // TwoFactorAuthDeleteReq entity for delete 2fa code request
 type TwoFactorAuthDeleteReq struct {
-	Password string `json:"password"`
-	Type     string `json:"type"`
+	Type string `json:"type"`
 }
 
 // TwoFactorAuthSetUpReq entity for set up 2fa code request
@@ -17,3 +16,20 @@ type TwoFactorAuthSetUpReq struct {
 	Secret      string `json:"secret"`
 	Code2faType string `json:"code_2fa_type"`
 }
+
+// TwoFactorAuthMethod entity of the enrolled 2fa method
+type TwoFactorAuthMethod struct {
+	Type      string `json:"type"`
+	Target    string `json:"target,omitempty"`
+	IsDefault bool   `json:"is_default"`
+}
+
+// TwoFactorAuthReSendReq entity for re-send 2fa code request
+type TwoFactorAuthReSendReq struct {
+	Code2faType string `json:"code_2fa_type"`
+}
+
+// TwoFactorAuthDefaultReq entity for set default 2fa method request
+type TwoFactorAuthDefaultReq struct {
+	Code2faType string `json:"code_2fa_type"`
+}
@@ -1,39 +1,122 @@
 package model
 
 import (
+	"errors"
 	"github.com/uptrace/bun"
 	"time"
 )
 
 const (
-	UserRole = "user"
+	UserRole  = "user"
+	AdminRole = "admin"
 )
 
+// ErrUserNameTaken the user name already belongs to another user
+var ErrUserNameTaken = errors.New("user name already in use")
+
+// ErrUserNotFound the user does not exist
+var ErrUserNotFound = errors.New("user not identified")
+
 // Base entity
 type User struct {
 	bun.BaseModel `bun:"table:users,alias:usr"`
 
-	ID               string    `json:"id" bun:"id,pk"`
-	FullName         string    `json:"full_name" bun:",nullzero"`
-	UserName         string    `json:"user_name" bun:",nullzero"`
-	Email            string    `json:"email" bun:",nullzero"`
-	Password         string    `json:"password" bun:",nullzero"`
-	Phone            string    `json:"phone" bun:",nullzero"`
-	Hash             string    `json:"hash" bun:",nullzero"`
-	ReferralLink     string    `json:"referral_link" bun:",nullzero"`
-	Role             string    `json:"role" bun:",nullzero"`
-	IsActive         bool      `json:"is_active"`
-	IsEmailVerified  bool      `json:"is_email_verified"`
-	IsPhoneVerified  bool      `json:"is_phone_verified"`
-	IsGoogleVerified bool      `json:"is_google_verified"`
-	GoogleSecret     string    `json:"google_secret" bun:",nullzero"`
-	CreatedAt        time.Time `json:"created_at" bun:"created_at,nullzero,notnull,default:now()"`
-	UpdatedAt        time.Time `json:"updated_at" bun:"updated_at,nullzero"`
+	ID                string          `json:"id" bun:"id,pk"`
+	TenantID          string          `json:"tenant_id" bun:",nullzero,default:'default'"`
+	FullName          string          `json:"full_name" bun:",nullzero"`
+	UserName          string          `json:"user_name" bun:",nullzero"`
+	Email             string          `json:"email" bun:",nullzero"`
+	Password          string          `json:"password" bun:",nullzero"`
+	Phone             string          `json:"phone" bun:",nullzero"`
+	Hash              string          `json:"hash" bun:",nullzero"`
+	ReferralLink      string          `json:"referral_link" bun:",nullzero"`
+	Role              string          `json:"role" bun:",nullzero,default:'user'"`
+	IsActive          bool            `json:"is_active"`
+	IsEmailVerified   bool            `json:"is_email_verified"`
+	IsPhoneVerified   bool            `json:"is_phone_verified"`
+	IsGoogleVerified  bool            `json:"is_google_verified"`
+	GoogleSecret      EncryptedString `json:"-" bun:",nullzero"`
+	TotpLastStep      int64           `json:"-"`
+	Default2faType    string          `json:"default_2fa_type" bun:"default_2fa_type,nullzero"`
+	EmailVerifiedAt   time.Time       `json:"email_verified_at" bun:",nullzero"`
+	PhoneVerifiedAt   time.Time       `json:"phone_verified_at" bun:",nullzero"`
+	UserNameChangedAt time.Time       `json:"-" bun:",nullzero"`
+	DeletedAt         time.Time       `json:"-" bun:",nullzero"`
+	CreatedAt         time.Time       `json:"created_at" bun:"created_at,nullzero,notnull,default:current_timestamp"`
+	UpdatedAt         time.Time       `json:"updated_at" bun:"updated_at,nullzero"`
 
 	ReferralUser *User  `json:"referral_user" bun:"rel:belongs-to,join:referral=referral_link"`
 	Referral     string `json:"referral" bun:",nullzero"`
 }
 
+// TwoFactorAuthTypes returns the enrolled two-factor auth methods, the default one goes first
+func (u *User) TwoFactorAuthTypes() []string {
+	var types []string
+	if u.IsGoogleVerified {
+		types = append(types, TokenTypeGoogle)
+	}
+	if u.IsPhoneVerified {
+		types = append(types, TokenTypePhone)
+	}
+	if u.IsEmailVerified {
+		types = append(types, TokenTypeEmail)
+	}
+
+	for i, t := range types {
+		if t == u.Default2faType {
+			types[0], types[i] = types[i], types[0]
+			break
+		}
+	}
+
+	return types
+}
+
+// DefaultTwoFactorAuthType returns the method chosen by the user, if it is not chosen
+// or no longer enrolled, then the first one of google, phone, email is used
+func (u *User) DefaultTwoFactorAuthType() string {
+	types := u.TwoFactorAuthTypes()
+	if len(types) == 0 {
+		return ""
+	}
+	return types[0]
+}
+
+func (u *User) HasTwoFactorAuth() bool {
+	return u.IsGoogleVerified || u.IsPhoneVerified || u.IsEmailVerified
+}
+
+func (u *User) HasTwoFactorAuthType(twoFactorAuthType string) bool {
+	for _, t := range u.TwoFactorAuthTypes() {
+		if t == twoFactorAuthType {
+			return true
+		}
+	}
+	return false
+}
+
+// TwoFactorAuthMethods returns the enrolled methods with the targets where the codes are sent
+func (u *User) TwoFactorAuthMethods() []TwoFactorAuthMethod {
+	types := u.TwoFactorAuthTypes()
+
+	methods := make([]TwoFactorAuthMethod, 0, len(types))
+	for i, t := range types {
+		method := TwoFactorAuthMethod{
+			Type:      t,
+			IsDefault: i == 0,
+		}
+		switch t {
+		case TokenTypePhone:
+			method.Target = u.Phone
+		case TokenTypeEmail:
+			method.Target = u.Email
+		}
+		methods = append(methods, method)
+	}
+
+	return methods
+}
+
 // UserGetMyProfileResp entity for get my profile resp
 type UserGetMyProfileResp struct {
 	ID               string `json:"id"`
@@ -46,6 +129,10 @@ type UserGetMyProfileResp struct {
 	IsEmailVerified  bool   `json:"is_email_verified"`
 	IsPhoneVerified  bool   `json:"is_phone_verified"`
 	IsGoogleVerified bool   `json:"is_google_verified"`
+	Default2faType   string `json:"default_2fa_type"`
+
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	PhoneVerifiedAt *time.Time `json:"phone_verified_at"`
 
 	CreatedAt time.Time `json:"created_at"`
 
@@ -83,21 +170,42 @@ type UserUpdateInfoReq struct {
 	FullName string `json:"full_name"`
 }
 
-// UserPhoneUpdateReq entity of the update phone request
+// UserPhoneUpdateReq entity of the update phone request,
+// Code2fa is sent to the new phone, CurrentCode2fa is the code of the current 2fa method or the current contact
 type UserPhoneUpdateReq struct {
-	Code2fa string `json:"code_2fa"`
-	Phone   string `json:"phone"`
+	Code2fa            string `json:"code_2fa"`
+	Phone              string `json:"phone"`
+	CurrentCode2fa     string `json:"current_code_2fa"`
+	CurrentCode2faType string `json:"current_code_2fa_type"`
 }
 
-// UserEmailUpdateReq entity of the update email request
+// UserEmailUpdateReq entity of the update email request,
+// Code2fa is sent to the new email, CurrentCode2fa is the code of the current 2fa method or the current contact
 type UserEmailUpdateReq struct {
-	Code2fa string `json:"code_2fa"`
-	Email   string `json:"email"`
+	Code2fa            string `json:"code_2fa"`
+	Email              string `json:"email"`
+	CurrentCode2fa     string `json:"current_code_2fa"`
+	CurrentCode2faType string `json:"current_code_2fa_type"`
+}
+
+// UserNameUpdateReq entity of the update user name request
+type UserNameUpdateReq struct {
+	UserName string `json:"user_name"`
+}
+
+// UserNameCheckReq entity of the user name availability request
+type UserNameCheckReq struct {
+	UserName string `query:"user_name"`
+}
+
+// UserNameResp entity of the user name resp, Available is set only by the availability check
+type UserNameResp struct {
+	UserName  string `json:"user_name"`
+	Available bool   `json:"available"`
 }
 
 // UserChangePasswordReq entity of the change password request
 type UserChangePasswordReq struct {
-	Code2fa     string `json:"code_2fa"`
 	OldPassword string `json:"old_password"`
 	NewPassword string `json:"new_password"`
 }
@@ -113,6 +221,11 @@ type UserSessionData struct {
 	UserID    string `redis:"user_id"`
 	UserAgent string `json:"user_agent"`
 	ClientIp  string `json:"client_ip"`
+
+	// Amr methods already passed in the current sign in
+	Amr []string `json:"-"`
+	// TrustedDeviceToken allows to skip the second factor on the device
+	TrustedDeviceToken string `json:"-"`
 }
 
 // SignUpReq entity of the sign-up request
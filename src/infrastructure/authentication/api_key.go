@@ -0,0 +1,36 @@
+package authentication
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/tools"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+)
+
+// GenerateApiKey returns the new personal access token, the prefix lets the leak scanners find it
+func GenerateApiKey() string {
+	return model.ApiKeyPrefix + tools.RandStr(40, "alphanum")
+}
+
+// GenerateClientID returns the new public id of the machine client
+func GenerateClientID() string {
+	return model.OAuthClientIDPrefix + tools.RandStr(20, "alphanum")
+}
+
+// GenerateClientSecret returns the new secret of the machine client
+func GenerateClientSecret() string {
+	return model.OAuthClientSecretPrefix + tools.RandStr(40, "alphanum")
+}
+
+// HashSecret returns the hash of the api key or the client secret to store, the secrets are random,
+// so a fast hash is enough and lets to find the key by the hash
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// CompareSecret compares the secret with the stored hash in constant time
+func CompareSecret(secret, hash string) bool {
+	return subtle.ConstantTimeCompare([]byte(HashSecret(secret)), []byte(hash)) == 1
+}
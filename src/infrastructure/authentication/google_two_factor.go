@@ -1,48 +1,199 @@
 package authentication
 
 import (
-	"auth-project/tools"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
 	"encoding/base32"
+	"encoding/binary"
 	"errors"
-	"github.com/dgryski/dgoogauth"
-	"github.com/skip2/go-qrcode"
-	"github.com/spf13/viper"
+	"fmt"
+	"hash"
+	"math"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/skip2/go-qrcode"
 )
 
-func VerifyGoogleTwoFactorAuthCode(token string, secret string) error {
+const (
+	TotpAlgorithmSHA1   = "SHA1"
+	TotpAlgorithmSHA256 = "SHA256"
+	TotpAlgorithmSHA512 = "SHA512"
+)
+
+// TotpConfig parameters of the RFC 6238 time-based one-time password
+type TotpConfig struct {
+	Digits    int
+	Period    time.Duration
+	Algorithm string
+	Skew      int64
+}
+
+// NewTotpConfig returns the totp settings, the invalid ones are replaced by the defaults of RFC 6238,
+// authenticator apps get them from the qr code, so after the change users have to set up 2fa again
+func NewTotpConfig(digits int, period time.Duration, algorithm string, skew int64) *TotpConfig {
+	tc := &TotpConfig{
+		Digits:    digits,
+		Period:    period,
+		Algorithm: strings.ToUpper(algorithm),
+		Skew:      skew,
+	}
+
+	if tc.Digits < 6 || tc.Digits > 8 {
+		tc.Digits = 6
+	}
+	if tc.Period < time.Second {
+		tc.Period = 30 * time.Second
+	}
+	if tc.Algorithm != TotpAlgorithmSHA256 && tc.Algorithm != TotpAlgorithmSHA512 {
+		tc.Algorithm = TotpAlgorithmSHA1
+	}
+	if tc.Skew < 0 {
+		tc.Skew = 0
+	}
+
+	return tc
+}
+
+// Step returns the RFC 6238 time step number for the moment
+func (tc *TotpConfig) Step(t time.Time) int64 {
+	return t.Unix() / int64(tc.Period/time.Second)
+}
+
+// GenerateCode returns the RFC 4226 one-time password for the time step
+func (tc *TotpConfig) GenerateCode(secret []byte, step int64) string {
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+
+	mac := hmac.New(tc.hashFunc(), secret)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	// dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	binCode := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	code := binCode % uint32(math.Pow10(tc.Digits))
+
+	return fmt.Sprintf("%0*d", tc.Digits, code)
+}
+
+// Validate checks the code in the window of ±Skew steps around the moment and
+// returns the matched step, codes of steps not after lastUsedStep are rejected
+func (tc *TotpConfig) Validate(code string, secret []byte, t time.Time, lastUsedStep int64) (int64, error) {
+	code = strings.TrimSpace(code)
+	if len(code) != tc.Digits {
+		return 0, errors.New("invalid token")
+	}
+
+	current := tc.Step(t)
+	for step := current - tc.Skew; step <= current+tc.Skew; step++ {
+		if subtle.ConstantTimeCompare([]byte(tc.GenerateCode(secret, step)), []byte(code)) != 1 {
+			continue
+		}
+
+		if step <= lastUsedStep {
+			return 0, errors.New("token already used")
+		}
 
-	// setup the one-time-password configuration.
-	otpConfig := &dgoogauth.OTPConfig{
-		Secret:      strings.TrimSpace(secret),
-		WindowSize:  3,
-		HotpCounter: 0,
+		return step, nil
 	}
 
-	trimmedToken := strings.TrimSpace(token)
+	return 0, errors.New("invalid token")
+}
 
-	// Validate token
-	ok, err := otpConfig.Authenticate(trimmedToken)
+func (tc *TotpConfig) hashFunc() func() hash.Hash {
+	switch tc.Algorithm {
+	case TotpAlgorithmSHA256:
+		return sha256.New
+	case TotpAlgorithmSHA512:
+		return sha512.New
+	default:
+		return sha1.New
+	}
+}
+
+// secretSize returns the secret length recommended by RFC 6238 for the algorithm
+func (tc *TotpConfig) secretSize() int {
+	switch tc.Algorithm {
+	case TotpAlgorithmSHA256:
+		return 32
+	case TotpAlgorithmSHA512:
+		return 64
+	default:
+		return 20
+	}
+}
+
+// DecodeTotpSecret decodes the base32 secret, padding and spaces are optional
+func DecodeTotpSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(secret), " ", ""))
+	secret = strings.TrimRight(secret, "=")
+
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
 	if err != nil {
-		return err
+		return nil, errors.New("invalid secret")
 	}
 
-	if !ok {
-		return errors.New("invalid token")
+	return key, nil
+}
+
+// VerifyGoogleTwoFactorAuthCode validates the code and returns the time step it was issued for,
+// the step must be stored as the last used one to prevent replays
+func (tc *TotpConfig) VerifyGoogleTwoFactorAuthCode(token string, secret string, lastUsedStep int64) (int64, error) {
+
+	key, err := DecodeTotpSecret(secret)
+	if err != nil {
+		return 0, err
 	}
 
-	return nil
+	return tc.Validate(token, key, time.Now().UTC(), lastUsedStep)
 }
 
-func GenerateGoogleTwoFactorAuthQrCode(usrEmail string) ([]byte, string, error) {
+// GenerateGoogleTwoFactorAuthQrCode generates the secret and the qr code of it, issuer is the name shown by the app
+func (tc *TotpConfig) GenerateGoogleTwoFactorAuthQrCode(issuer, account string) ([]byte, string, error) {
+
+	key := make([]byte, tc.secretSize())
+	_, err := rand.Read(key)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(key)
 
-	secret := base32.StdEncoding.EncodeToString([]byte(tools.RandStr(16, "alphanum")))
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", tc.Algorithm)
+	query.Set("digits", strconv.Itoa(tc.Digits))
+	query.Set("period", strconv.Itoa(int(tc.Period/time.Second)))
 
-	authLink := "otpauth://totp/" + usrEmail + "?secret=" + secret + "&issuer=" + viper.GetString("project_name")
-	qrCodeByte, err := qrcode.Encode(authLink, qrcode.Medium, 256)
+	qrCodeByte, err := qrcode.Encode(totpURI(issuer, account, query), qrcode.Medium, 256)
 	if err != nil {
 		return nil, "", err
 	}
 
 	return qrCodeByte, secret, nil
 }
+
+// totpURI returns the otpauth uri of the key uri format, the issuer and the account are escaped apart,
+// so the colon in them does not shift the separator of the label
+func totpURI(issuer, account string, query url.Values) string {
+	label := escapeLabelPart(account)
+	if issuer != "" {
+		label = escapeLabelPart(issuer) + ":" + label
+	}
+
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// escapeLabelPart escapes the part of the label, the colon is kept by url.PathEscape, so it is escaped here
+func escapeLabelPart(part string) string {
+	return strings.ReplaceAll(url.PathEscape(part), ":", "%3A")
+}
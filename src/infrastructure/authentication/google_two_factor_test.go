@@ -0,0 +1,102 @@
+package authentication
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// the test vectors of RFC 6238 appendix B
+func TestTotpRFC6238Vectors(t *testing.T) {
+	secrets := map[string][]byte{
+		TotpAlgorithmSHA1:   []byte("12345678901234567890"),
+		TotpAlgorithmSHA256: []byte("12345678901234567890123456789012"),
+		TotpAlgorithmSHA512: []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+	}
+
+	vectors := []struct {
+		unix      int64
+		algorithm string
+		code      string
+	}{
+		{59, TotpAlgorithmSHA1, "94287082"},
+		{59, TotpAlgorithmSHA256, "46119246"},
+		{59, TotpAlgorithmSHA512, "90693936"},
+		{1111111109, TotpAlgorithmSHA1, "07081804"},
+		{1111111109, TotpAlgorithmSHA256, "68084774"},
+		{1111111109, TotpAlgorithmSHA512, "25091201"},
+		{1111111111, TotpAlgorithmSHA1, "14050471"},
+		{1111111111, TotpAlgorithmSHA256, "67062674"},
+		{1111111111, TotpAlgorithmSHA512, "99943326"},
+		{1234567890, TotpAlgorithmSHA1, "89005924"},
+		{1234567890, TotpAlgorithmSHA256, "91819424"},
+		{1234567890, TotpAlgorithmSHA512, "93441116"},
+		{2000000000, TotpAlgorithmSHA1, "69279037"},
+		{2000000000, TotpAlgorithmSHA256, "90698825"},
+		{2000000000, TotpAlgorithmSHA512, "38618901"},
+		{20000000000, TotpAlgorithmSHA1, "65353130"},
+		{20000000000, TotpAlgorithmSHA256, "77737706"},
+		{20000000000, TotpAlgorithmSHA512, "47863826"},
+	}
+
+	for _, v := range vectors {
+		tc := NewTotpConfig(8, 30*time.Second, v.algorithm, 0)
+		moment := time.Unix(v.unix, 0).UTC()
+
+		code := tc.GenerateCode(secrets[v.algorithm], tc.Step(moment))
+		if code != v.code {
+			t.Errorf("%s at %d: got %s, want %s", v.algorithm, v.unix, code, v.code)
+		}
+
+		_, err := tc.Validate(v.code, secrets[v.algorithm], moment, 0)
+		if err != nil {
+			t.Errorf("%s at %d: %s", v.algorithm, v.unix, err.Error())
+		}
+	}
+}
+
+func TestTotpValidateRejectsReplay(t *testing.T) {
+	tc := NewTotpConfig(6, 30*time.Second, TotpAlgorithmSHA1, 1)
+	secret := []byte("12345678901234567890")
+	moment := time.Unix(1234567890, 0).UTC()
+
+	code := tc.GenerateCode(secret, tc.Step(moment))
+	step, err := tc.Validate(code, secret, moment, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = tc.Validate(code, secret, moment, step)
+	if err == nil {
+		t.Fatal("the used code is accepted again")
+	}
+
+	// the code of the previous step is in the window, but it is older than the used one
+	previous := tc.GenerateCode(secret, step-1)
+	_, err = tc.Validate(previous, secret, moment, step)
+	if err == nil {
+		t.Fatal("the code older than the used one is accepted")
+	}
+}
+
+func TestTotpURIEscapesLabel(t *testing.T) {
+	uri := totpURI("Acme: Corp", "a:b@example.com", url.Values{"secret": []string{"ABC"}})
+
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	label := strings.TrimPrefix(parsed.EscapedPath(), "/")
+	parts := strings.Split(label, ":")
+	if len(parts) != 2 {
+		t.Fatalf("label %q is ambiguous", label)
+	}
+
+	issuer, _ := url.PathUnescape(parts[0])
+	account, _ := url.PathUnescape(parts[1])
+	if issuer != "Acme: Corp" || account != "a:b@example.com" {
+		t.Fatalf("got issuer %q and account %q", issuer, account)
+	}
+}
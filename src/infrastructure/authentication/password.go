@@ -0,0 +1,201 @@
+package authentication
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	PasswordAlgorithmBcrypt   = "bcrypt"
+	PasswordAlgorithmArgon2id = "argon2id"
+)
+
+var ErrPasswordHashFormat = errors.New("unknown password hash format")
+
+// PasswordHasher hashes and verifies user passwords and reports
+// when a stored hash was produced with outdated parameters.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(encodedHash, password string) (bool, error)
+	NeedsRehash(encodedHash string) bool
+}
+
+// Argon2Params parameters of the Argon2id key derivation function.
+type Argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+type passwordHasher struct {
+	algorithm  string
+	bcryptCost int
+	argon2     Argon2Params
+}
+
+// NewPasswordHasher returns the hasher of the algorithm, argon2id by default, the unknown algorithm is an error,
+// so the misspelled config does not change the hashes silently
+func NewPasswordHasher(algorithm string, bcryptCost int, argon2Params Argon2Params) (PasswordHasher, error) {
+
+	switch algorithm {
+	case "":
+		algorithm = PasswordAlgorithmArgon2id
+	case PasswordAlgorithmBcrypt, PasswordAlgorithmArgon2id:
+	default:
+		return nil, fmt.Errorf("unknown password algorithm %q", algorithm)
+	}
+
+	if bcryptCost < bcrypt.MinCost || bcryptCost > bcrypt.MaxCost {
+		bcryptCost = bcrypt.DefaultCost
+	}
+
+	if argon2Params.Memory == 0 {
+		argon2Params.Memory = 64 * 1024
+	}
+	if argon2Params.Iterations == 0 {
+		argon2Params.Iterations = 3
+	}
+	if argon2Params.Parallelism == 0 {
+		argon2Params.Parallelism = 2
+	}
+	if argon2Params.SaltLength == 0 {
+		argon2Params.SaltLength = 16
+	}
+	if argon2Params.KeyLength == 0 {
+		argon2Params.KeyLength = 32
+	}
+
+	return &passwordHasher{
+		algorithm:  algorithm,
+		bcryptCost: bcryptCost,
+		argon2:     argon2Params,
+	}, nil
+}
+
+// Hash hashes the password with the configured algorithm. Argon2id hashes
+// are encoded in the PHC string format, e.g.
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+func (ph *passwordHasher) Hash(password string) (string, error) {
+
+	if ph.algorithm == PasswordAlgorithmBcrypt {
+		hash, err := bcrypt.GenerateFromPassword([]byte(password), ph.bcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hash), nil
+	}
+
+	salt := make([]byte, ph.argon2.SaltLength)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, ph.argon2.Iterations, ph.argon2.Memory, ph.argon2.Parallelism,
+		ph.argon2.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, ph.argon2.Memory, ph.argon2.Iterations, ph.argon2.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify compares the password with a bcrypt or Argon2id encoded hash
+func (ph *passwordHasher) Verify(encodedHash, password string) (bool, error) {
+
+	switch {
+	case strings.HasPrefix(encodedHash, "$argon2id$"):
+		params, salt, key, err := decodeArgon2idHash(encodedHash)
+		if err != nil {
+			return false, err
+		}
+
+		otherKey := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism,
+			params.KeyLength)
+
+		return subtle.ConstantTimeCompare(key, otherKey) == 1, nil
+
+	case strings.HasPrefix(encodedHash, "$2"):
+		err := bcrypt.CompareHashAndPassword([]byte(encodedHash), []byte(password))
+		if err != nil {
+			if err == bcrypt.ErrMismatchedHashAndPassword {
+				return false, nil
+			}
+			return false, err
+		}
+		return true, nil
+
+	default:
+		return false, ErrPasswordHashFormat
+	}
+}
+
+// NeedsRehash reports whether the hash was produced by another algorithm
+// or with parameters different from the configured ones
+func (ph *passwordHasher) NeedsRehash(encodedHash string) bool {
+
+	if ph.algorithm == PasswordAlgorithmBcrypt {
+		cost, err := bcrypt.Cost([]byte(encodedHash))
+		if err != nil {
+			return true
+		}
+		return cost != ph.bcryptCost
+	}
+
+	params, salt, _, err := decodeArgon2idHash(encodedHash)
+	if err != nil {
+		return true
+	}
+
+	return params.Memory != ph.argon2.Memory ||
+		params.Iterations != ph.argon2.Iterations ||
+		params.Parallelism != ph.argon2.Parallelism ||
+		params.KeyLength != ph.argon2.KeyLength ||
+		uint32(len(salt)) != ph.argon2.SaltLength
+}
+
+func decodeArgon2idHash(encodedHash string) (*Argon2Params, []byte, []byte, error) {
+
+	parts := strings.Split(encodedHash, "$")
+	if len(parts) != 6 || parts[1] != PasswordAlgorithmArgon2id {
+		return nil, nil, nil, ErrPasswordHashFormat
+	}
+
+	var version int
+	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil {
+		return nil, nil, nil, ErrPasswordHashFormat
+	}
+	if version != argon2.Version {
+		return nil, nil, nil, errors.New("incompatible argon2 version")
+	}
+
+	params := &Argon2Params{}
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil {
+		return nil, nil, nil, ErrPasswordHashFormat
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, nil, nil, ErrPasswordHashFormat
+	}
+	params.SaltLength = uint32(len(salt))
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, nil, nil, ErrPasswordHashFormat
+	}
+	params.KeyLength = uint32(len(key))
+
+	return params, salt, key, nil
+}
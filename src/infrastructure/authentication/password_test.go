@@ -0,0 +1,123 @@
+package authentication
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testArgon2Params keep the tests fast, the defaults take 64 MiB per hash
+var testArgon2Params = Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func newTestHasher(t *testing.T, algorithm string, bcryptCost int, params Argon2Params) PasswordHasher {
+	t.Helper()
+
+	ph, err := NewPasswordHasher(algorithm, bcryptCost, params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ph
+}
+
+func TestArgon2idPHCFormat(t *testing.T) {
+	ph := newTestHasher(t, PasswordAlgorithmArgon2id, 0, testArgon2Params)
+
+	hash, err := ph.Hash("Password1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+		t.Fatalf("got hash %q, want the PHC string of the params", hash)
+	}
+
+	params, salt, key, err := decodeArgon2idHash(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *params != testArgon2Params || len(salt) != 16 || len(key) != 32 {
+		t.Fatalf("got params %+v, salt %d, key %d bytes, want %+v", *params, len(salt), len(key), testArgon2Params)
+	}
+
+	cases := []struct {
+		name     string
+		hash     string
+		password string
+		ok       bool
+		err      error
+	}{
+		{"password", hash, "Password1", true, nil},
+		{"wrong password", hash, "Password2", false, nil},
+		{"unknown format", "plain", "Password1", false, ErrPasswordHashFormat},
+		{"missing part", "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA", "Password1", false, ErrPasswordHashFormat},
+		{"bad params", "$argon2id$v=19$m=x$c2FsdA$a2V5", "Password1", false, ErrPasswordHashFormat},
+		{"bad salt", "$argon2id$v=19$m=1024,t=1,p=1$!$a2V5", "Password1", false, ErrPasswordHashFormat},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ok, err := ph.Verify(c.hash, c.password)
+			if ok != c.ok || err != c.err {
+				t.Fatalf("got %v, %v, want %v, %v", ok, err, c.ok, c.err)
+			}
+		})
+	}
+
+	// the other version of argon2 is not verified
+	_, err = ph.Verify(strings.Replace(hash, "v=19", "v=16", 1), "Password1")
+	if err == nil {
+		t.Fatal("the hash of another argon2 version is verified")
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	argon2Hash, err := newTestHasher(t, PasswordAlgorithmArgon2id, 0, testArgon2Params).Hash("Password1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcryptHash, err := newTestHasher(t, PasswordAlgorithmBcrypt, bcrypt.MinCost, Argon2Params{}).Hash("Password1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name       string
+		algorithm  string
+		bcryptCost int
+		modify     func(params *Argon2Params)
+		hash       string
+		want       bool
+	}{
+		{"same argon2 params", PasswordAlgorithmArgon2id, 0, nil, argon2Hash, false},
+		{"memory", PasswordAlgorithmArgon2id, 0, func(p *Argon2Params) { p.Memory = 2048 }, argon2Hash, true},
+		{"iterations", PasswordAlgorithmArgon2id, 0, func(p *Argon2Params) { p.Iterations = 2 }, argon2Hash, true},
+		{"parallelism", PasswordAlgorithmArgon2id, 0, func(p *Argon2Params) { p.Parallelism = 2 }, argon2Hash, true},
+		{"salt length", PasswordAlgorithmArgon2id, 0, func(p *Argon2Params) { p.SaltLength = 32 }, argon2Hash, true},
+		{"key length", PasswordAlgorithmArgon2id, 0, func(p *Argon2Params) { p.KeyLength = 64 }, argon2Hash, true},
+		{"bcrypt to argon2id", PasswordAlgorithmArgon2id, 0, nil, bcryptHash, true},
+		{"same bcrypt cost", PasswordAlgorithmBcrypt, bcrypt.MinCost, nil, bcryptHash, false},
+		{"bcrypt cost", PasswordAlgorithmBcrypt, bcrypt.MinCost + 1, nil, bcryptHash, true},
+		{"argon2id to bcrypt", PasswordAlgorithmBcrypt, bcrypt.MinCost, nil, argon2Hash, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			params := testArgon2Params
+			if c.modify != nil {
+				c.modify(&params)
+			}
+
+			ph := newTestHasher(t, c.algorithm, c.bcryptCost, params)
+			if got := ph.NeedsRehash(c.hash); got != c.want {
+				t.Fatalf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestUnknownPasswordAlgorithm(t *testing.T) {
+	_, err := NewPasswordHasher("md5", 0, Argon2Params{})
+	if err == nil {
+		t.Fatal("the unknown algorithm is accepted")
+	}
+}
@@ -0,0 +1,29 @@
+package authentication
+
+import (
+	"auth-project/tools"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// GenerateRecoveryCodes returns single-use backup codes in the xxxxx-xxxxx form
+func GenerateRecoveryCodes(count int) []string {
+	codes := make([]string, count)
+	for i := range codes {
+		code := strings.ToLower(tools.RandStr(10, "alphanum"))
+		codes[i] = code[:5] + "-" + code[5:]
+	}
+	return codes
+}
+
+// HashRecoveryCode returns the hash of the code to store, the codes are random,
+// so a fast hash is enough and lets to find the code by the hash
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	code = strings.ReplaceAll(code, " ", "")
+
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
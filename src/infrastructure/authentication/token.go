@@ -2,40 +2,53 @@ package authentication
 
 import (
 	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/matoous/go-nanoid/v2"
 	"io/ioutil"
-	"os"
+	"math/big"
 	"time"
 
 	"auth-project/src/domain/model"
 	"github.com/go-playground/validator/v10"
 )
 
+// the key pair of the tokens, it is set by SetRSAKeys on start
 var (
-	path, _               = os.Getwd()
-	privateKey, publicKey = MustLoadRSA(path+"/rsa_keys/private_key.pem", path+"/rsa_keys/public_key.pem")
+	privateKey *rsa.PrivateKey
+	publicKey  *rsa.PublicKey
+	// keyID is put in the header of the tokens, so the verifiers find the key in the key set
+	keyID string
 )
 
 type JwtConfigurator struct {
 	AccessTokenMaxAge        time.Duration
 	RefreshTokenMaxAge       time.Duration
 	TwoFactorAuthTokenMaxAge time.Duration
+	ClientTokenMaxAge        time.Duration
 	SigningMethod            *jwt.SigningMethodRSA
 }
 
-func NewJwtConfigurator(atMaxAge, rtMaxAge, TwoFactorAuthTokenMaxAge time.Duration) *JwtConfigurator {
+func NewJwtConfigurator(atMaxAge, rtMaxAge, TwoFactorAuthTokenMaxAge, clientTokenMaxAge time.Duration) *JwtConfigurator {
 
 	return &JwtConfigurator{
 		AccessTokenMaxAge:        atMaxAge,
 		RefreshTokenMaxAge:       rtMaxAge,
 		TwoFactorAuthTokenMaxAge: TwoFactorAuthTokenMaxAge,
+		ClientTokenMaxAge:        clientTokenMaxAge,
 		SigningMethod:            jwt.SigningMethodRS512,
 	}
 }
 
+// SetRSAKeys sets the key pair which signs and verifies the tokens, it must be called before the tokens are used
+func SetRSAKeys(private *rsa.PrivateKey, public *rsa.PublicKey) {
+	privateKey, publicKey = private, public
+	keyID = KeyThumbprint(public)
+}
+
 func MustLoadRSA(privateKeyFilename, publicKeyFilename string) (*rsa.PrivateKey, *rsa.PublicKey) {
 	b, err := ioutil.ReadFile(privateKeyFilename)
 	if err != nil {
@@ -60,10 +73,45 @@ func MustLoadRSA(privateKeyFilename, publicKeyFilename string) (*rsa.PrivateKey,
 	return private, public
 }
 
-func (jc *JwtConfigurator) GenerateTokenPair(userID, sessionID string) (*model.TokenDetails, error) {
+// newToken returns the token to sign, the header holds the id of the signing key
+func (jc *JwtConfigurator) newToken(claims jwt.Claims) *jwt.Token {
+	token := jwt.NewWithClaims(jc.SigningMethod, claims)
+	token.Header["kid"] = keyID
+	return token
+}
+
+// JWKS returns the public key set to verify the tokens (RFC 7517)
+func (jc *JwtConfigurator) JWKS() *model.JWKS {
+	return &model.JWKS{
+		Keys: []model.JWK{{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: jc.SigningMethod.Alg(),
+			Kid: keyID,
+			N:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		}},
+	}
+}
+
+// KeyThumbprint returns the thumbprint of the public key (RFC 7638) used as the key id
+func KeyThumbprint(key *rsa.PublicKey) string {
+	e := base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes())
+	n := base64.RawURLEncoding.EncodeToString(key.N.Bytes())
+
+	// the members are in the lexicographic order without spaces
+	sum := sha256.Sum256([]byte(`{"e":"` + e + `","kty":"RSA","n":"` + n + `"}`))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// GenerateTokenPair generates the token pair, auth details are put in both tokens
+// so that they are kept after the refresh, user claims are put in the access token only
+func (jc *JwtConfigurator) GenerateTokenPair(userID, sessionID string,
+	authDetails *model.AuthDetails, userClaims *model.UserClaims) (*model.TokenDetails, error) {
 	var err error
 
 	td := new(model.TokenDetails)
+	td.UserID = userID
 	td.AtExpires = time.Now().UTC().Add(jc.AccessTokenMaxAge).Unix()
 	td.AtID, err = gonanoid.New()
 	if err != nil {
@@ -82,9 +130,18 @@ func (jc *JwtConfigurator) GenerateTokenPair(userID, sessionID string) (*model.T
 		UserID:     userID,
 		Exp:        td.AtExpires,
 		Type:       model.AccessTokenTypeAuth,
+		AuthTime:   authDetails.AuthTime,
+		Amr:        authDetails.Amr,
+		Role:       userClaims.Role,
+		KycLevel:   userClaims.KycLevel,
+		TenantID:   userClaims.TenantID,
+	}
+	accessClaims.Issuer = userClaims.Issuer
+	if userClaims.Audience != "" {
+		accessClaims.Audience = jwt.ClaimStrings{userClaims.Audience}
 	}
 
-	token := jwt.NewWithClaims(jc.SigningMethod, accessClaims)
+	token := jc.newToken(accessClaims)
 
 	td.AccessToken, err = token.SignedString(privateKey)
 	if err != nil {
@@ -96,9 +153,11 @@ func (jc *JwtConfigurator) GenerateTokenPair(userID, sessionID string) (*model.T
 		SessionID: sessionID,
 		UserID:    userID,
 		Exp:       td.RtExpires,
+		AuthTime:  authDetails.AuthTime,
+		Amr:       authDetails.Amr,
 	}
 
-	refreshToken := jwt.NewWithClaims(jc.SigningMethod, refreshClaims)
+	refreshToken := jc.newToken(refreshClaims)
 
 	td.RefreshToken, err = refreshToken.SignedString(privateKey)
 	if err != nil {
@@ -169,7 +228,9 @@ func (jc *JwtConfigurator) GetRefreshTokenClaims(refreshToken string) (*model.Re
 	return &claims, nil
 }
 
-func (jc *JwtConfigurator) GenerateTwoFactorAuthToken(userID, sessionID string) (*model.AccessTokenDetails, error) {
+// GenerateTwoFactorAuthToken generates the token for two-factor auth, amr holds the methods of the first factor
+func (jc *JwtConfigurator) GenerateTwoFactorAuthToken(userID, sessionID string,
+	amr []string) (*model.AccessTokenDetails, error) {
 	var err error
 
 	td := new(model.AccessTokenDetails)
@@ -186,9 +247,85 @@ func (jc *JwtConfigurator) GenerateTwoFactorAuthToken(userID, sessionID string)
 		UserID:     userID,
 		Exp:        td.AtExpires,
 		Type:       model.AccessTokenTypeTwoFactorAuth,
+		Amr:        amr,
 	}
 
-	token := jwt.NewWithClaims(jc.SigningMethod, claims)
+	token := jc.newToken(claims)
+
+	td.AccessToken, err = token.SignedString(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return td, nil
+}
+
+// GenerateTwoFactorAuthSetupToken generates the token which allows only the 2fa set-up, it is given
+// instead of the session if the tenant's policy requires 2fa, the auth time lets the set-up pass the step-up check
+func (jc *JwtConfigurator) GenerateTwoFactorAuthSetupToken(userID, sessionID string,
+	authDetails *model.AuthDetails) (*model.AccessTokenDetails, error) {
+	var err error
+
+	td := new(model.AccessTokenDetails)
+	td.AtExpires = time.Now().UTC().Add(jc.TwoFactorAuthTokenMaxAge).Unix()
+	td.AtID, err = gonanoid.New()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := model.AccessClaims{
+		Authorized: false,
+		AtID:       td.AtID,
+		SessionID:  sessionID,
+		UserID:     userID,
+		Exp:        td.AtExpires,
+		Type:       model.AccessTokenTypeTwoFactorAuthSetup,
+		AuthTime:   authDetails.AuthTime,
+		Amr:        authDetails.Amr,
+	}
+
+	token := jc.newToken(claims)
+
+	td.AccessToken, err = token.SignedString(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return td, nil
+}
+
+// GenerateClientAccessToken generates the access token of the machine client by the client credentials grant,
+// the token has no session and no refresh token, the client id is put as the session id
+func (jc *JwtConfigurator) GenerateClientAccessToken(clientID, userID, scope string,
+	userClaims *model.UserClaims) (*model.AccessTokenDetails, error) {
+	var err error
+
+	td := new(model.AccessTokenDetails)
+	td.AtExpires = time.Now().UTC().Add(jc.ClientTokenMaxAge).Unix()
+	td.AtID, err = gonanoid.New()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := model.AccessClaims{
+		Authorized: true,
+		AtID:       td.AtID,
+		SessionID:  clientID,
+		UserID:     userID,
+		Exp:        td.AtExpires,
+		Type:       model.AccessTokenTypeClient,
+		Role:       userClaims.Role,
+		KycLevel:   userClaims.KycLevel,
+		TenantID:   userClaims.TenantID,
+		Scope:      scope,
+		ClientID:   clientID,
+	}
+	claims.Issuer = userClaims.Issuer
+	if userClaims.Audience != "" {
+		claims.Audience = jwt.ClaimStrings{userClaims.Audience}
+	}
+
+	token := jc.newToken(claims)
 
 	td.AccessToken, err = token.SignedString(privateKey)
 	if err != nil {
@@ -197,3 +334,94 @@ func (jc *JwtConfigurator) GenerateTwoFactorAuthToken(userID, sessionID string)
 
 	return td, nil
 }
+
+// GenerateMagicLinkToken signs the token of the link with the random value of the stored token,
+// the value is compared on the sign in
+func (jc *JwtConfigurator) GenerateMagicLinkToken(tokenID, value, userID string, expiresAt time.Time) (string, error) {
+
+	claims := model.MagicLinkClaims{
+		TkID:   tokenID,
+		Value:  value,
+		UserID: userID,
+		Exp:    expiresAt.Unix(),
+	}
+
+	token := jc.newToken(claims)
+
+	return token.SignedString(privateKey)
+}
+
+func (jc *JwtConfigurator) GetMagicLinkTokenClaims(magicLinkToken string) (*model.MagicLinkClaims, error) {
+
+	var claims model.MagicLinkClaims
+	tkn, err := jwt.ParseWithClaims(magicLinkToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		_, ok := token.Method.(*jwt.SigningMethodRSA)
+		if !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return publicKey, nil
+	})
+	if err != nil {
+		return nil, errors.New("invalid token")
+	}
+
+	if !tkn.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	err = validator.New().Struct(&claims)
+	if err != nil {
+		return nil, errors.New("invalid token")
+	}
+
+	if claims.Exp < time.Now().UTC().Unix() {
+		return nil, errors.New("token expired")
+	}
+
+	return &claims, nil
+}
+
+func (jc *JwtConfigurator) GenerateTrustedDeviceToken(deviceID, userID string, expiresAt time.Time) (string, error) {
+
+	claims := model.TrustedDeviceClaims{
+		DvID:   deviceID,
+		UserID: userID,
+		Exp:    expiresAt.Unix(),
+	}
+
+	token := jc.newToken(claims)
+
+	return token.SignedString(privateKey)
+}
+
+func (jc *JwtConfigurator) GetTrustedDeviceTokenClaims(trustedDeviceToken string) (*model.TrustedDeviceClaims, error) {
+
+	var claims model.TrustedDeviceClaims
+	tkn, err := jwt.ParseWithClaims(trustedDeviceToken, &claims, func(token *jwt.Token) (interface{}, error) {
+		_, ok := token.Method.(*jwt.SigningMethodRSA)
+		if !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+
+		return publicKey, nil
+	})
+	if err != nil {
+		return nil, errors.New("invalid token")
+	}
+
+	if !tkn.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	err = validator.New().Struct(&claims)
+	if err != nil {
+		return nil, errors.New("invalid token")
+	}
+
+	if claims.Exp < time.Now().UTC().Unix() {
+		return nil, errors.New("token expired")
+	}
+
+	return &claims, nil
+}
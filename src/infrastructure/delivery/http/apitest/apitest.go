@@ -0,0 +1,170 @@
+// Package apitest runs the service in the tests: the router of the api on the loopback listener,
+// the sqlite database with all the migrations, the session store of the chosen driver and the senders
+// capturing the emails and the sms instead of SendGrid and Twilio
+package apitest
+
+import (
+	"auth-project/conf"
+	"auth-project/src/infrastructure/authentication"
+	"auth-project/src/infrastructure/delivery/http"
+	"auth-project/src/infrastructure/encryption"
+	"auth-project/src/infrastructure/scheduler"
+	"auth-project/src/infrastructure/sending/email"
+	"auth-project/src/infrastructure/sending/sms"
+	"auth-project/src/infrastructure/storage"
+	"auth-project/src/infrastructure/storage/files"
+	"auth-project/src/infrastructure/storage/sessions"
+	"auth-project/src/infrastructure/storage/storagetest"
+	"auth-project/src/registry"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"net"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+	"github.com/uptrace/bun"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// initOnce reads the config and generates the keys of the tokens once for all servers of the test binary,
+// the goroutines of the previous servers, e.g. of the websockets, could still read the config
+var initOnce sync.Once
+
+// Server the running service
+type Server struct {
+	URL string
+
+	DB           *bun.DB
+	SessionStore sessions.Store
+	Totp         *authentication.TotpConfig
+
+	Emails *Emails
+	Sms    *Sms
+}
+
+// New starts the service with the session store of the driver, memory, redis or database, redis runs on miniredis.
+// The config is the example one with the overrides of the tests, the config and the senders are global,
+// so the servers must not run in parallel. The service is shut down with the test
+func New(t testing.TB, sessionStoreDriver string) *Server {
+	t.Helper()
+
+	initOnce.Do(func() {
+		initConfig(t)
+		initRSAKeys(t)
+	})
+
+	db := storagetest.NewSQLite(t)
+
+	var sessionStore sessions.Store
+	switch sessionStoreDriver {
+	case sessions.DriverDatabase:
+		sessionStore = sessions.NewDatabaseStore(db)
+	case sessions.DriverRedis:
+		mr := miniredis.RunT(t)
+		rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() {
+			_ = rdb.Close()
+		})
+		sessionStore = sessions.NewRedisStore(rdb)
+	default:
+		sessionStore = sessions.NewMemoryStore()
+	}
+
+	jwtConf := authentication.NewJwtConfigurator(
+		viper.GetDuration("jwt.access_token_min_lifetime"),
+		viper.GetDuration("jwt.refresh_token_min_lifetime"),
+		viper.GetDuration("jwt.two_factor_auth_token_min_lifetime"),
+		viper.GetDuration("oauth.client_token_lifetime"))
+
+	totpConf := authentication.NewTotpConfig(
+		viper.GetInt("totp.digits"),
+		viper.GetDuration("totp.period"),
+		viper.GetString("totp.algorithm"),
+		viper.GetInt64("totp.skew"))
+
+	passwordHasher, err := authentication.NewPasswordHasher(authentication.PasswordAlgorithmBcrypt, bcrypt.MinCost,
+		authentication.Argon2Params{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	emails, smsMessages := &Emails{}, &Sms{}
+	email.SetSender(emails)
+	sms.SetSender(smsMessages)
+
+	worker := scheduler.NewWorker(1, 0, 5*time.Second)
+
+	r := registry.NewRegistry(db, sessionStore, jwtConf, totpConf, passwordHasher,
+		files.NewLocalStorage(t.TempDir()), encryption.MustLoadEnvelopeCipher(false), worker,
+		storage.NewErrorClassifier())
+
+	app := http.NewRouter(fiber.New(fiber.Config{
+		BodyLimit:             viper.GetInt("http.body_limit"),
+		DisableStartupMessage: true,
+	}), r.NewAPIController())
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = app.Listener(ln)
+	}()
+
+	// the background jobs are finished before the database is closed by its cleanup, the listener is closed
+	// instead of app.Shutdown, which races with the hijacked connections of the websockets in fasthttp
+	t.Cleanup(func() {
+		_ = ln.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = worker.Shutdown(ctx)
+	})
+
+	return &Server{
+		URL:          "http://" + ln.Addr().String(),
+		DB:           db,
+		SessionStore: sessionStore,
+		Totp:         totpConf,
+		Emails:       emails,
+		Sms:          smsMessages,
+	}
+}
+
+// initConfig reads the example config and overrides the settings which need the outer services
+// or slow down the tests
+func initConfig(t testing.TB) {
+	_, file, _, _ := runtime.Caller(0)
+
+	conf.InitConfigFile(filepath.Join(filepath.Dir(file), "..", "..", "..", "..", "..", "conf", "config.yml.example"))
+
+	key := make([]byte, 32)
+	_, err := rand.Read(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	viper.Set("env", "test")
+	viper.Set("db.driver", "sqlite")
+	viper.Set("encryption.keys", map[string]string{"1": base64.StdEncoding.EncodeToString(key)})
+	// the codes are sent again right away
+	viper.Set("2fa.send_timeout", "0s")
+}
+
+func initRSAKeys(t testing.TB) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authentication.SetRSAKeys(key, &key.PublicKey)
+}
@@ -0,0 +1,126 @@
+package apitest
+
+import (
+	"auth-project/src/infrastructure/sending/email"
+	"net/url"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+var (
+	codeRegexp      = regexp.MustCompile(`verification code is: (\d+)`)
+	magicLinkRegexp = regexp.MustCompile(`sign in to .*: \S+\?token=(\S+)`)
+)
+
+// Emails captures the emails sent by the service
+type Emails struct {
+	mx       sync.Mutex
+	messages []email.Message
+}
+
+func (e *Emails) Send(m *email.Message) error {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+
+	e.messages = append(e.messages, *m)
+	return nil
+}
+
+// To returns the emails sent to the address in the order of sending
+func (e *Emails) To(address string) []email.Message {
+	e.mx.Lock()
+	defer e.mx.Unlock()
+
+	var messages []email.Message
+	for _, m := range e.messages {
+		if m.ToAddress == address {
+			messages = append(messages, m)
+		}
+	}
+	return messages
+}
+
+// Code returns the verification code of the last email to the address with it
+func (e *Emails) Code(t testing.TB, address string) string {
+	t.Helper()
+
+	messages := e.To(address)
+	for i := len(messages) - 1; i >= 0; i-- {
+		if match := codeRegexp.FindStringSubmatch(messages[i].PlainTextContent); match != nil {
+			return match[1]
+		}
+	}
+
+	t.Fatalf("no verification code was sent to %s", address)
+	return ""
+}
+
+// MagicLinkToken returns the token of the last sign in link sent to the address
+func (e *Emails) MagicLinkToken(t testing.TB, address string) string {
+	t.Helper()
+
+	messages := e.To(address)
+	for i := len(messages) - 1; i >= 0; i-- {
+		if match := magicLinkRegexp.FindStringSubmatch(messages[i].PlainTextContent); match != nil {
+			token, err := url.QueryUnescape(match[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			return token
+		}
+	}
+
+	t.Fatalf("no sign in link was sent to %s", address)
+	return ""
+}
+
+// SmsMessage the sms sent by the service
+type SmsMessage struct {
+	From string
+	To   string
+	Body string
+}
+
+// Sms captures the sms sent by the service
+type Sms struct {
+	mx       sync.Mutex
+	messages []SmsMessage
+}
+
+func (s *Sms) Send(from, to, body string) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.messages = append(s.messages, SmsMessage{From: from, To: to, Body: body})
+	return nil
+}
+
+// To returns the sms sent to the phone in the order of sending
+func (s *Sms) To(phone string) []SmsMessage {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	var messages []SmsMessage
+	for _, m := range s.messages {
+		if m.To == phone {
+			messages = append(messages, m)
+		}
+	}
+	return messages
+}
+
+// Code returns the verification code of the last sms to the phone with it
+func (s *Sms) Code(t testing.TB, phone string) string {
+	t.Helper()
+
+	messages := s.To(phone)
+	for i := len(messages) - 1; i >= 0; i-- {
+		if match := codeRegexp.FindStringSubmatch(messages[i].Body); match != nil {
+			return match[1]
+		}
+	}
+
+	t.Fatalf("no verification code was sent to %s", phone)
+	return ""
+}
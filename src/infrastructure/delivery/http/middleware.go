@@ -1,18 +1,67 @@
 package http
 
 import (
+	"auth-project/src/domain/model"
 	"auth-project/src/interface/controller"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/websocket/v2"
+	"time"
 )
 
-// allows you to perform functions where authorization is required
+// resolves the tenant of the request, must go before the other middlewares
+func tenantMiddleware(c controller.APIController) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		err := c.Tenant.ResolveTenant(ctx)
+		if err != nil {
+			return err
+		}
+		return ctx.Next()
+	}
+}
+
+// allows you to perform functions where authorization is required,
+// the api keys and the machine clients need the read scope for the safe methods and the write scope for the others
 func authMiddleware(c controller.APIController) fiber.Handler {
 	return func(ctx *fiber.Ctx) error {
 		err := c.Auth.ValidateAccessToken(ctx)
 		if err != nil {
 			return err
 		}
+
+		if isMachineToken(ctx) {
+			scope := model.ScopeWrite
+			if ctx.Method() == fiber.MethodGet || ctx.Method() == fiber.MethodHead {
+				scope = model.ScopeRead
+			}
+			if !hasTokenScope(ctx, scope) {
+				return fiber.NewError(fiber.StatusForbidden, "insufficient scope")
+			}
+		}
+		return ctx.Next()
+	}
+}
+
+// allows functions only for the tokens of the user's sessions, not for the api keys and the machine clients,
+// must go after authMiddleware
+func requireUserToken() fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		if isMachineToken(ctx) {
+			return fiber.NewError(fiber.StatusForbidden, "not allowed for api keys and clients")
+		}
+		return ctx.Next()
+	}
+}
+
+// allows the 2fa set-up functions for the token given by the tenant's 2fa policy instead of the session,
+// the other tokens are checked by authMiddleware
+func twoFactorAuthSetupMiddleware(c controller.APIController) fiber.Handler {
+	auth := authMiddleware(c)
+
+	return func(ctx *fiber.Ctx) error {
+		err := c.Auth.ValidateTwoFactorAuthSetupToken(ctx)
+		if err != nil {
+			return auth(ctx)
+		}
 		return ctx.Next()
 	}
 }
@@ -28,6 +77,58 @@ func twoFactorAuthMiddleware(c controller.APIController) fiber.Handler {
 	}
 }
 
+// allows sensitive functions only if the user has proven the credentials not earlier than maxAge ago,
+// otherwise the client has to call /auth/reauthenticate, must go after authMiddleware
+func requireRecentAuth(maxAge time.Duration) fiber.Handler {
+	if maxAge <= 0 {
+		maxAge = 5 * time.Minute
+	}
+
+	return func(ctx *fiber.Ctx) error {
+		authTime, ok := ctx.Context().Value("token_auth_time").(int64)
+		if !ok {
+			return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+		}
+
+		if authTime == 0 || time.Since(time.Unix(authTime, 0)) > maxAge {
+			return fiber.NewError(fiber.StatusForbidden, "recent authentication required")
+		}
+		return ctx.Next()
+	}
+}
+
+// allows functions only for the users with one of the roles, must go after authMiddleware
+func requireRole(roles ...string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		role, ok := ctx.Context().Value("token_user_role").(string)
+		if !ok {
+			return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+		}
+
+		// the api keys and the machine clients act with the role of the owner only by the admin scope
+		if isMachineToken(ctx) && !hasTokenScope(ctx, model.ScopeAdmin) {
+			return fiber.NewError(fiber.StatusForbidden, "insufficient scope")
+		}
+
+		for _, r := range roles {
+			if r == role {
+				return ctx.Next()
+			}
+		}
+		return fiber.NewError(fiber.StatusForbidden, "forbidden")
+	}
+}
+
+func isMachineToken(ctx *fiber.Ctx) bool {
+	tokenType, _ := ctx.Context().Value("token_type").(string)
+	return tokenType == model.AccessTokenTypeApiKey || tokenType == model.AccessTokenTypeClient
+}
+
+func hasTokenScope(ctx *fiber.Ctx, scope string) bool {
+	scopes, _ := ctx.Context().Value("token_scopes").([]string)
+	return model.HasScope(scopes, scope)
+}
+
 func webSocketMiddleware() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// IsWebSocketUpgrade returns true if the client
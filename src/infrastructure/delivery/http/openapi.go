@@ -0,0 +1,456 @@
+package http
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/infrastructure/openapi"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+)
+
+// the security schemes of the document
+const (
+	secBearer   = "bearerAuth"
+	secApiKey   = "apiKey"
+	sec2fa      = "twoFactorAuthToken"
+	sec2faSetup = "twoFactorAuthSetupToken"
+	secRefresh  = "refreshToken"
+	secClient   = "clientBasic"
+	errResponse = "Error"
+)
+
+var (
+	// the tokens of the user's sessions, the api keys and the tokens of the machine clients
+	secAccess = []string{secBearer, secApiKey}
+	// the tokens of the user's sessions only
+	secSession = []string{secBearer}
+	// the 2fa set-up functions accept the set-up token given by the tenant's 2fa policy as well
+	secSetUp = []string{secBearer, sec2faSetup}
+)
+
+// apiDoc describes the route in the OpenAPI document, the request and the response are given by the model values
+type apiDoc struct {
+	id      string
+	tag     string
+	summary string
+	// the alternative security schemes, the route is public if it is empty
+	security []string
+
+	query interface{}
+	// body is the json body, the form body if form is set, or *openapi.Schema of the multipart body
+	body interface{}
+	form bool
+
+	status int
+	resp   interface{}
+	// raw is the content type of the response which is not json
+	raw string
+	// the error statuses besides 400 and 500, 401 is added for the secured routes
+	errors []int
+}
+
+// apiDocs documents the routes of NewRouter by "METHOD path", the path is without the trailing slash
+// as fiber keeps it, the routes missing here are still put in the document without the schemas,
+// and the entries of the removed routes are reported
+var apiDocs = map[string]apiDoc{
+	"GET /.well-known/jwks.json": {id: "getJwks", tag: "keys", resp: model.JWKS{},
+		summary: "The public key set to verify the access tokens"},
+	"GET " + APIv1 + "/tenant": {id: "getTenant", tag: "tenant", resp: model.TenantBrandingResp{},
+		summary: "The branding of the tenant resolved by the host or the X-Tenant-ID header"},
+
+	"POST " + APIv1 + "/auth/authenticate": {id: "authenticate", tag: "auth", body: model.AuthenticationReq{},
+		resp: model.AuthResp{}, errors: []int{401, 403},
+		summary: "Signs in by the login and the password, gives the token pair or the 2fa token"},
+	"POST " + APIv1 + "/auth/refresh": {id: "refreshToken", tag: "auth", security: []string{secRefresh},
+		resp: model.AuthResp{}, summary: "Gives the new token pair of the session by the refresh token"},
+	"POST " + APIv1 + "/auth/reauthenticate": {id: "reauthenticate", tag: "auth", security: secSession,
+		body: model.ReauthenticateReq{}, resp: model.AuthResp{}, errors: []int{403},
+		summary: "Checks the password and/or 2fa code again, gives the token pair with the fresh auth time"},
+	"POST " + APIv1 + "/auth/revoke-sessions": {id: "revokeSessions", tag: "auth", body: model.RevokeSessionsReq{},
+		resp: model.MessageResp{}, errors: []int{401},
+		summary: `Signs out all sessions by the token of the "this wasn't me" link`},
+	"POST " + APIv1 + "/auth/magic-link/send": {id: "sendMagicLink", tag: "auth", body: model.MagicLinkSendReq{},
+		resp: model.MessageResp{}, errors: []int{429}, summary: "Sends the single-use sign in link to the email"},
+	"POST " + APIv1 + "/auth/magic-link/verify": {id: "authenticateByMagicLink", tag: "auth",
+		body: model.MagicLinkAuthReq{}, resp: model.AuthResp{}, errors: []int{401},
+		summary: "Signs in by the token of the magic link"},
+	"POST " + APIv1 + "/auth/otp/send": {id: "sendLoginCode", tag: "auth", body: model.OtpSendReq{},
+		resp: model.MessageResp{}, errors: []int{429}, summary: "Sends the one-time login code to the phone or the email"},
+	"POST " + APIv1 + "/auth/otp/verify": {id: "authenticateByLoginCode", tag: "auth", body: model.OtpAuthReq{},
+		resp: model.AuthResp{}, errors: []int{401}, summary: "Signs in by the one-time login code"},
+	"POST " + APIv1 + "/auth/qr-code/:qrCodeToken": {id: "confirmQrCodeAuth", tag: "auth", security: secSession,
+		resp: model.MessageResp{}, summary: "Confirms the sign in of the device showing the qr code"},
+	"GET " + APIv1 + "/auth/qr-code/websocket": {id: "qrCodeAuthWebsocket", tag: "auth", status: fiber.StatusSwitchingProtocols,
+		resp: model.QrCodeAuthResp{}, summary: "The websocket of the qr code sign in, it sends QrCodeAuthResp, " +
+			"then AuthResp once the qr code is confirmed, or MessageResp on the failure"},
+
+	"POST " + APIv1 + "/users/sign-up": {id: "signUp", tag: "users", body: model.SignUpReq{},
+		resp: model.MessageResp{}, errors: []int{409}, summary: "Signs up the user"},
+	"POST " + APIv1 + "/users/sign-up/send-code": {id: "signUpSendCode", tag: "users", body: model.SignUpSend2faCodeReq{},
+		resp: model.MessageResp{}, errors: []int{429}, summary: "Sends the code which confirms the contact of the sign up"},
+	"POST " + APIv1 + "/users/change-password": {id: "changeMyPassword", tag: "users", security: secAccess,
+		body: model.UserChangePasswordReq{}, resp: model.MessageResp{}, errors: []int{403},
+		summary: "Changes the password, requires the recent authentication"},
+	"POST " + APIv1 + "/users/reset-password/send-code": {id: "sendResetPasswordCode", tag: "users",
+		body: model.Send2faCodeForResetUserPasswordReq{}, resp: model.MessageResp{}, errors: []int{429},
+		summary: "Sends the code which resets the password"},
+	"POST " + APIv1 + "/users/reset-password/verify-code": {id: "resetPassword", tag: "users",
+		body: model.VerifyResetUserPassword2faСodeReq{}, resp: model.MessageResp{},
+		summary: "Sets the new password by the reset code"},
+	"GET " + APIv1 + "/users/my-profile": {id: "getMyProfile", tag: "users", security: secAccess,
+		resp: model.UserGetMyProfileResp{}, summary: "The profile of the user"},
+	"PUT " + APIv1 + "/users/myself/info": {id: "updateMyInfo", tag: "users", security: secSession,
+		body: model.UserUpdateInfoReq{}, resp: model.UserUpdResp{}, summary: "Updates the information of the user"},
+	"PUT " + APIv1 + "/users/myself/user-name": {id: "updateMyUserName", tag: "users", security: secSession,
+		body: model.UserNameUpdateReq{}, resp: model.UserNameResp{}, errors: []int{409, 429},
+		summary: "Changes the user name"},
+	"GET " + APIv1 + "/users/user-name/check": {id: "checkUserName", tag: "users", query: model.UserNameCheckReq{},
+		resp: model.UserNameResp{}, summary: "Tells whether the user name is available"},
+	"PUT " + APIv1 + "/users/myself/email": {id: "updateMyEmail", tag: "users", security: secAccess,
+		body: model.UserEmailUpdateReq{}, resp: model.ContactChange{}, errors: []int{403, 409},
+		summary: "Requests the change of the email, requires the recent authentication"},
+	"PUT " + APIv1 + "/users/myself/phone": {id: "updateMyPhone", tag: "users", security: secAccess,
+		body: model.UserPhoneUpdateReq{}, resp: model.ContactChange{}, errors: []int{403, 409},
+		summary: "Requests the change of the phone, requires the recent authentication"},
+	"POST " + APIv1 + "/users/myself/contact-changes/send-code": {id: "sendContactChangeCode", tag: "users",
+		security: secSession, body: model.ContactChangeSendCodeReq{}, resp: model.Code2faSentResp{}, errors: []int{429},
+		summary: "Sends the code which confirms the contact change by the current contact"},
+	"GET " + APIv1 + "/users/myself/contact-changes": {id: "getContactChanges", tag: "users", security: secSession,
+		resp: []model.ContactChange{}, summary: "The pending contact changes"},
+	"DELETE " + APIv1 + "/users/myself/contact-changes/:changeID": {id: "cancelContactChange", tag: "users",
+		security: secSession, resp: model.MessageResp{}, errors: []int{404}, summary: "Cancels the pending contact change"},
+	"DELETE " + APIv1 + "/users/me": {id: "deleteMyAccount", tag: "users", security: secAccess,
+		resp: model.MessageResp{}, errors: []int{403},
+		summary: "Deletes the account after the grace period, requires the recent authentication"},
+	"GET " + APIv1 + "/users/me/export": {id: "exportMyData", tag: "users", security: secSession,
+		query: struct {
+			Format string `query:"format"`
+		}{}, resp: model.UserDataExport{}, raw: "application/zip",
+		summary: "Exports the data of the user as json or as the zip archive by the format"},
+	"GET " + APIv1 + "/users/myself/sessions": {id: "getMySessions", tag: "users", security: secSession,
+		resp: []model.Session{}, summary: "The live sessions of the user, the ones refreshed the latest first"},
+	"POST " + APIv1 + "/users/sign-out": {id: "signOut", tag: "users", security: secSession,
+		resp: model.MessageResp{}, summary: "Signs out the session"},
+	"POST " + APIv1 + "/users/sign-out/all": {id: "signOutAll", tag: "users", security: secSession,
+		resp: model.MessageResp{}, summary: "Signs out all sessions of the user"},
+
+	"GET " + APIv1 + "/api-keys": {id: "getApiKeys", tag: "api-keys", security: secSession,
+		resp: []model.ApiKey{}, summary: "The api keys of the user"},
+	"POST " + APIv1 + "/api-keys": {id: "createApiKey", tag: "api-keys", security: secSession,
+		body: model.ApiKeyCreateReq{}, status: fiber.StatusCreated, resp: model.ApiKeyCreateResp{},
+		summary: "Creates the api key, the key is given only once"},
+	"DELETE " + APIv1 + "/api-keys/:keyID": {id: "revokeApiKey", tag: "api-keys", security: secSession,
+		resp: model.MessageResp{}, errors: []int{404}, summary: "Revokes the api key"},
+
+	"POST " + APIv1 + "/oauth/token": {id: "issueClientToken", tag: "oauth", security: []string{secClient},
+		body: model.OAuthTokenReq{}, form: true, resp: model.OAuthTokenResp{},
+		summary: "Issues the access token by the client credentials grant (RFC 6749)"},
+	"POST " + APIv1 + "/oauth/introspect": {id: "introspectToken", tag: "oauth", security: []string{secClient},
+		body: model.OAuthTokenCheckReq{}, form: true, resp: model.OAuthIntrospectionResp{}, errors: []int{403},
+		summary: "Tells whether the token is active (RFC 7662), the client needs the introspect scope"},
+	"POST " + APIv1 + "/oauth/revoke": {id: "revokeToken", tag: "oauth", security: []string{secClient},
+		body: model.OAuthTokenCheckReq{}, form: true, resp: model.MessageResp{}, errors: []int{403},
+		summary: "Revokes the token (RFC 7009)"},
+	"GET " + APIv1 + "/oauth/clients": {id: "getOAuthClients", tag: "oauth", security: secSession,
+		resp: []model.OAuthClient{}, summary: "The machine clients of the user"},
+	"POST " + APIv1 + "/oauth/clients": {id: "createOAuthClient", tag: "oauth", security: secSession,
+		body: model.OAuthClientCreateReq{}, status: fiber.StatusCreated, resp: model.OAuthClientSecretResp{},
+		summary: "Registers the machine client, the secret is given only once"},
+	"POST " + APIv1 + "/oauth/clients/:clientID/secret": {id: "rotateOAuthClientSecret", tag: "oauth",
+		security: secSession, resp: model.OAuthClientSecretResp{}, errors: []int{403, 404},
+		summary: "Replaces the secret of the client, requires the recent authentication"},
+	"DELETE " + APIv1 + "/oauth/clients/:clientID": {id: "revokeOAuthClient", tag: "oauth", security: secSession,
+		resp: model.MessageResp{}, errors: []int{404}, summary: "Revokes the machine client"},
+
+	"GET " + APIv1 + "/referrals": {id: "getReferrals", tag: "referrals", security: secAccess,
+		query: model.ReferralListReq{}, resp: model.ReferralListResp{}, summary: "The users invited by the user"},
+	"GET " + APIv1 + "/referrals/tree": {id: "getReferralTree", tag: "referrals", security: secAccess,
+		query: model.ReferralTreeReq{}, resp: []model.ReferralTreeNode{}, summary: "The tree of the referrals"},
+	"GET " + APIv1 + "/referrals/count": {id: "getReferralCount", tag: "referrals", security: secAccess,
+		query: model.ReferralTreeReq{}, resp: model.ReferralCountResp{}, summary: "The number of the referrals by the levels"},
+	"GET " + APIv1 + "/referrals/stats": {id: "getReferralStats", tag: "referrals", security: secAccess,
+		query: model.ReferralStatsReq{}, resp: model.ReferralStatsResp{}, summary: "The sign ups of the referrals by the periods"},
+	"POST " + APIv1 + "/referrals/code/regenerate": {id: "regenerateReferralCode", tag: "referrals",
+		security: secSession, resp: model.ReferralLinkResp{}, summary: "Replaces the referral code with the random one"},
+	"PUT " + APIv1 + "/referrals/code": {id: "setReferralCode", tag: "referrals", security: secSession,
+		body: model.ReferralCodeReq{}, resp: model.ReferralLinkResp{}, errors: []int{409},
+		summary: "Sets the vanity referral code"},
+
+	"GET " + APIv1 + "/kyc": {id: "getMyKyc", tag: "kyc", security: secAccess, resp: model.KycStatusResp{},
+		summary: "The kyc level and the submissions of the user"},
+	"POST " + APIv1 + "/kyc/submissions": {id: "submitKyc", tag: "kyc", security: secSession, body: kycSubmitSchema(),
+		status: fiber.StatusCreated, resp: model.KycSubmission{}, errors: []int{409},
+		summary: "Submits the documents for the kyc level"},
+
+	"GET " + APIv1 + "/admin/kyc/submissions": {id: "getKycSubmissions", tag: "admin", security: secAccess,
+		query: model.KycSubmissionListReq{}, resp: model.KycSubmissionListResp{}, errors: []int{403},
+		summary: "The kyc submissions to review"},
+	"GET " + APIv1 + "/admin/kyc/submissions/:submissionID": {id: "getKycSubmission", tag: "admin",
+		security: secAccess, resp: model.KycSubmission{}, errors: []int{403, 404}, summary: "The kyc submission"},
+	"GET " + APIv1 + "/admin/kyc/submissions/:submissionID/documents/:documentID": {id: "getKycDocument",
+		tag: "admin", security: secAccess, raw: "application/octet-stream", errors: []int{403, 404},
+		summary: "The content of the kyc document"},
+	"POST " + APIv1 + "/admin/kyc/submissions/:submissionID/approve": {id: "approveKycSubmission", tag: "admin",
+		security: secAccess, body: model.KycReviewReq{}, resp: model.MessageResp{}, errors: []int{403, 404, 409},
+		summary: "Approves the kyc submission, the user gets its level"},
+	"POST " + APIv1 + "/admin/kyc/submissions/:submissionID/reject": {id: "rejectKycSubmission", tag: "admin",
+		security: secAccess, body: model.KycReviewReq{}, resp: model.MessageResp{}, errors: []int{403, 404, 409},
+		summary: "Rejects the kyc submission"},
+	"PUT " + APIv1 + "/admin/users/:userID/flag": {id: "flagUser", tag: "admin", security: secAccess,
+		body: model.UserFlagReq{}, resp: model.MessageResp{}, errors: []int{403, 404},
+		summary: "Blocks or unblocks the account"},
+
+	"GET " + APIv1 + "/2fa/google/qr-code": {id: "generateGoogleQrCode", tag: "2fa", security: secSetUp,
+		resp: model.GoogleTwoFactorAuthQrCodeResp{}, summary: "The qr code to set up google authenticator"},
+	"POST " + APIv1 + "/2fa/re-send": {id: "reSendTwoFactorAuthCode", tag: "2fa", security: []string{sec2fa},
+		body: model.TwoFactorAuthReSendReq{}, resp: model.TwoFactorAuthCodeResp{}, errors: []int{409, 429},
+		summary: "Sends the 2fa code of the sign in again, by the other method if it is requested"},
+	"POST " + APIv1 + "/2fa/verify": {id: "verifyTwoFactorAuthCode", tag: "2fa", security: []string{sec2fa},
+		body: model.Verify2faCodeReq{}, resp: model.AuthResp{}, summary: "Completes the sign in by the 2fa code"},
+	"PUT " + APIv1 + "/2fa/set-up": {id: "setUpTwoFactorAuth", tag: "2fa", security: secSetUp,
+		body: model.TwoFactorAuthSetUpReq{}, resp: model.TwoFactorAuthSetUpResp{}, errors: []int{403},
+		summary: "Enables the 2fa method, requires the recent authentication"},
+	"DELETE " + APIv1 + "/2fa/delete": {id: "deleteTwoFactorAuth", tag: "2fa", security: secAccess,
+		body: model.TwoFactorAuthDeleteReq{}, resp: model.MessageResp{}, errors: []int{403},
+		summary: "Disables the 2fa method, requires the recent authentication"},
+	"PUT " + APIv1 + "/2fa/default": {id: "setDefaultTwoFactorAuthType", tag: "2fa", security: secSession,
+		body: model.TwoFactorAuthDefaultReq{}, resp: model.MessageResp{}, summary: "Chooses the default 2fa method"},
+	"GET " + APIv1 + "/2fa/recovery-codes": {id: "getRecoveryCodesCount", tag: "2fa", security: secSession,
+		resp: model.RecoveryCodesCountResp{}, summary: "The number of the unused recovery codes"},
+	"POST " + APIv1 + "/2fa/recovery-codes": {id: "regenerateRecoveryCodes", tag: "2fa", security: secAccess,
+		resp: model.RecoveryCodesResp{}, errors: []int{403},
+		summary: "Replaces the recovery codes, requires the recent authentication"},
+	"GET " + APIv1 + "/2fa/trusted-devices": {id: "getTrustedDevices", tag: "2fa", security: secSession,
+		resp: []model.TrustedDevice{}, summary: "The devices which skip 2fa"},
+	"DELETE " + APIv1 + "/2fa/trusted-devices": {id: "deleteTrustedDevices", tag: "2fa", security: secSession,
+		resp: model.MessageResp{}, summary: "Revokes the trust of all devices"},
+	"DELETE " + APIv1 + "/2fa/trusted-devices/:deviceID": {id: "deleteTrustedDevice", tag: "2fa",
+		security: secSession, resp: model.MessageResp{}, errors: []int{404}, summary: "Revokes the trust of the device"},
+
+	"POST " + APIv1 + "/code/send": {id: "send2faCode", tag: "code", security: secSetUp,
+		resp: model.Code2faSentResp{}, errors: []int{429}, summary: "Sends the code by the default 2fa method"},
+	"POST " + APIv1 + "/code/target-send": {id: "sendTarget2faCode", tag: "code", security: secSetUp,
+		body: model.SendTarget2faCodeReq{}, resp: model.Code2faSentResp{}, errors: []int{429},
+		summary: "Sends the code to the phone or the email being set up"},
+}
+
+// kycSubmitSchema the multipart body of the kyc submission, the files are named by the document types
+func kycSubmitSchema() *openapi.Schema {
+	schema := &openapi.Schema{
+		Type: "object",
+		Properties: map[string]*openapi.Schema{
+			"level":   {Type: "integer", Format: "int32"},
+			"id_card": {Type: "string"},
+		},
+		Required: []string{"level"},
+	}
+
+	for _, docType := range model.KycDocumentTypes {
+		schema.Properties[docType] = &openapi.Schema{Type: "string", Format: "binary"}
+	}
+
+	return schema
+}
+
+// newOpenAPIDocument documents the routes registered in the app by apiDocs
+func newOpenAPIDocument(app *fiber.App) *openapi.Document {
+
+	doc := openapi.NewDocument(openapi.Info{
+		Title:   viper.GetString("project_name"),
+		Version: viper.GetString("openapi.version"),
+		Description: "The tenant is resolved by the host or by the " + model.TenantHeader + " header, " +
+			"the header of the other tenant is accepted from its front host or the trusted origins. " +
+			"The errors are given as the plain text with the http status.",
+	})
+
+	doc.Components.SecuritySchemes = map[string]*openapi.SecurityScheme{
+		secBearer: {Type: "http", Scheme: "bearer", BearerFormat: "JWT",
+			Description: "The access token of the session or of the machine client, the api key is accepted as well"},
+		secApiKey: {Type: "apiKey", In: "header", Name: model.ApiKeyHeader, Description: "The api key"},
+		sec2fa: {Type: "http", Scheme: "bearer", BearerFormat: "JWT",
+			Description: "The 2fa token given by the sign in"},
+		sec2faSetup: {Type: "http", Scheme: "bearer", BearerFormat: "JWT",
+			Description: "The 2fa set-up token given by the sign in if the tenant requires 2fa which is not set up"},
+		secRefresh: {Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "The refresh token"},
+		secClient: {Type: "http", Scheme: "basic",
+			Description: "The client id and the secret, they are accepted in the form body as well"},
+	}
+	doc.Components.Responses[errResponse] = &openapi.Response{
+		Description: "The error message",
+		Content: map[string]*openapi.MediaType{
+			fiber.MIMETextPlainCharsetUTF8: {Schema: &openapi.Schema{Type: "string"}},
+		},
+	}
+
+	// the middlewares registered by Use are copied to the stacks of all methods with the same handlers,
+	// the router has no CONNECT routes, so they are found by the CONNECT stack
+	middlewares := make(map[*fiber.Handler]bool)
+	for _, stack := range app.Stack() {
+		for _, route := range stack {
+			if route.Method == fiber.MethodConnect && len(route.Handlers) > 0 {
+				middlewares[&route.Handlers[0]] = true
+			}
+		}
+	}
+
+	tags := make(map[string]bool)
+	documented := make(map[string]bool)
+
+	for _, stack := range app.Stack() {
+		for _, route := range stack {
+			if len(route.Handlers) == 0 || middlewares[&route.Handlers[0]] {
+				continue
+			}
+
+			key := route.Method + " " + route.Path
+			d, ok := apiDocs[key]
+			if !ok {
+				d = apiDoc{summary: "Undocumented"}
+			}
+
+			if doc.AddOperation(route.Method, openAPIPath(route.Path), newOperation(doc, d, route.Params)) {
+				documented[key] = true
+				if d.tag != "" {
+					tags[d.tag] = true
+				}
+			}
+		}
+	}
+
+	for key := range apiDocs {
+		if !documented[key] {
+			log.Printf("openapi: the route %s is documented but not registered", key)
+		}
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, openapi.Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+
+	return doc
+}
+
+func newOperation(doc *openapi.Document, d apiDoc, params []string) *openapi.Operation {
+
+	op := &openapi.Operation{
+		Summary:     d.summary,
+		OperationID: d.id,
+		Responses:   make(map[string]*openapi.Response),
+	}
+	if d.tag != "" {
+		op.Tags = []string{d.tag}
+	}
+
+	for _, param := range params {
+		op.Parameters = append(op.Parameters, &openapi.Parameter{
+			Name: param, In: "path", Required: true, Schema: &openapi.Schema{Type: "string"},
+		})
+	}
+	if d.query != nil {
+		op.Parameters = append(op.Parameters, doc.ParametersOf(d.query, "query", "query")...)
+	}
+
+	if d.body != nil {
+		contentType := fiber.MIMEApplicationJSON
+		schema, ok := d.body.(*openapi.Schema)
+		switch {
+		case ok:
+			contentType = fiber.MIMEMultipartForm
+		case d.form:
+			contentType = fiber.MIMEApplicationForm
+			schema = doc.SchemaOf(d.body)
+		default:
+			schema = doc.SchemaOf(d.body)
+		}
+
+		op.RequestBody = &openapi.RequestBody{
+			Required: true,
+			Content:  map[string]*openapi.MediaType{contentType: {Schema: schema}},
+		}
+	}
+
+	status := d.status
+	if status == 0 {
+		status = fiber.StatusOK
+	}
+	resp := &openapi.Response{Description: http.StatusText(status), Content: make(map[string]*openapi.MediaType)}
+	if d.resp != nil {
+		resp.Content[fiber.MIMEApplicationJSON] = &openapi.MediaType{Schema: doc.SchemaOf(d.resp)}
+	}
+	if d.raw != "" {
+		resp.Content[d.raw] = &openapi.MediaType{Schema: &openapi.Schema{Type: "string", Format: "binary"}}
+	}
+	op.Responses[strconv.Itoa(status)] = resp
+
+	errors := append([]int{fiber.StatusBadRequest, fiber.StatusInternalServerError}, d.errors...)
+	if len(d.security) > 0 {
+		errors = append(errors, fiber.StatusUnauthorized)
+	}
+	for _, code := range errors {
+		op.Responses[strconv.Itoa(code)] = openapi.ResponseRef(errResponse)
+	}
+
+	security := make([]openapi.SecurityRequirement, 0, len(d.security))
+	for _, scheme := range d.security {
+		security = append(security, openapi.SecurityRequirement{scheme: {}})
+	}
+	op.Security = &security
+
+	return op
+}
+
+// openAPIPath turns the fiber path params into the OpenAPI ones, /users/:userID is /users/{userID}
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "{" + strings.TrimSuffix(strings.TrimPrefix(segment, ":"), "?") + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// docsPage the docs UI, it renders the document by Swagger UI
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>API docs</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="docs"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: "openapi.json", dom_id: "#docs"});
+  </script>
+</body>
+</html>`
+
+// registerOpenAPI serves the document of the routes registered so far and the docs UI
+func registerOpenAPI(app *fiber.App) {
+
+	body, err := json.Marshal(newOpenAPIDocument(app))
+	if err != nil {
+		panic(err)
+	}
+
+	app.Get(APIv1+"/openapi.json", func(ctx *fiber.Ctx) error {
+		ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return ctx.Status(fiber.StatusOK).Send(body)
+	})
+
+	if viper.GetBool("openapi.docs_ui") {
+		app.Get(APIv1+"/docs", func(ctx *fiber.Ctx) error {
+			ctx.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+			return ctx.Status(fiber.StatusOK).SendString(docsPage)
+		})
+	}
+}
@@ -1,30 +1,44 @@
 package http
 
 import (
+	"auth-project/src/domain/model"
 	"auth-project/src/interface/controller"
-	"github.com/go-redis/redis/v8"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/websocket/v2"
+	"github.com/spf13/viper"
 )
 
 const (
 	APIv1 = "/api/v1"
 )
 
-type AuthStore struct {
-	Rdb *redis.Client
-}
-
 func NewRouter(app *fiber.App, c controller.APIController) *fiber.App {
 
+	recentAuth := requireRecentAuth(viper.GetDuration("step_up.max_age"))
+	userToken := requireUserToken()
+
+	app.Use(tenantMiddleware(c))
+
+	app.Get("/.well-known/jwks.json", c.Auth.GetJwks)
+
+	app.Get(APIv1+"/tenant", c.Tenant.GetTenant)
+
 	authApi := app.Group(APIv1 + "/auth")
 
 	authApi.Post("/authenticate", c.Auth.Authenticate)
 	authApi.Post("/refresh", c.Auth.RefreshToken)
+	authApi.Post("/reauthenticate", authMiddleware(c), userToken, c.Auth.Reauthenticate)
+	authApi.Post("/revoke-sessions", c.Auth.RevokeSessions)
+
+	authApi.Post("/magic-link/send", c.Auth.SendMagicLink)
+	authApi.Post("/magic-link/verify", c.Auth.AuthenticateByMagicLink)
+
+	authApi.Post("/otp/send", c.Auth.SendLoginCode)
+	authApi.Post("/otp/verify", c.Auth.AuthenticateByLoginCode)
 
 	qrCodeAuth := authApi.Group("/qr-code")
 
-	qrCodeAuth.Post("/:qrCodeToken", authMiddleware(c), c.QrCodeAuth.CreateAuthTokenByAuthQrCode)
+	qrCodeAuth.Post("/:qrCodeToken", authMiddleware(c), userToken, c.QrCodeAuth.CreateAuthTokenByAuthQrCode)
 	qrCodeAuth.Get("/websocket", webSocketMiddleware(), websocket.New(c.QrCodeAuth.QrCodeAuthWebsocket))
 
 	userApi := app.Group(APIv1 + "/users")
@@ -32,34 +46,97 @@ func NewRouter(app *fiber.App, c controller.APIController) *fiber.App {
 	userApi.Post("/sign-up", c.User.SignUp)
 	userApi.Post("/sign-up/send-code", c.User.SignUpSendOTP)
 
-	userApi.Post("/change-password", authMiddleware(c), c.User.ChangeMyPassword)
+	userApi.Post("/change-password", authMiddleware(c), recentAuth, c.User.ChangeMyPassword)
 
 	userApi.Post("/reset-password/send-code", c.User.SendCodeForResetUserPassword)
 	userApi.Post("/reset-password/verify-code", c.User.VerifyResetUserPasswordCode)
 
 	userApi.Get("/my-profile", authMiddleware(c), c.User.GetMyProfile)
 
-	userApi.Put("/myself/info", authMiddleware(c), c.User.UpdateMyselfInfo)
-	userApi.Put("/myself/email", authMiddleware(c), c.User.UpdateMyselfEmail)
-	userApi.Put("/myself/phone", authMiddleware(c), c.User.UpdateMyselfPhone)
+	userApi.Put("/myself/info", authMiddleware(c), userToken, c.User.UpdateMyselfInfo)
+	userApi.Put("/myself/user-name", authMiddleware(c), userToken, c.User.UpdateMyselfUserName)
+	userApi.Get("/user-name/check", c.User.CheckUserName)
+	userApi.Put("/myself/email", authMiddleware(c), recentAuth, c.ContactChange.UpdateMyselfEmail)
+	userApi.Put("/myself/phone", authMiddleware(c), recentAuth, c.ContactChange.UpdateMyselfPhone)
+
+	userApi.Post("/myself/contact-changes/send-code", authMiddleware(c), userToken, c.ContactChange.SendContactChangeCode)
+	userApi.Get("/myself/contact-changes", authMiddleware(c), userToken, c.ContactChange.GetContactChanges)
+	userApi.Delete("/myself/contact-changes/:changeID", authMiddleware(c), userToken, c.ContactChange.CancelContactChange)
+
+	userApi.Delete("/me", authMiddleware(c), recentAuth, c.Account.DeleteMyAccount)
+	userApi.Get("/me/export", authMiddleware(c), userToken, c.Account.ExportMyData)
+
+	userApi.Get("/myself/sessions", authMiddleware(c), userToken, c.User.GetMySessions)
+	userApi.Post("/sign-out", authMiddleware(c), userToken, c.User.SignOut)
+	userApi.Post("/sign-out/all", authMiddleware(c), userToken, c.User.SignOutAll)
+
+	apiKeyApi := app.Group(APIv1+"/api-keys", authMiddleware(c), userToken)
+
+	apiKeyApi.Get("/", c.ApiKey.GetApiKeys)
+	apiKeyApi.Post("/", c.ApiKey.CreateApiKey)
+	apiKeyApi.Delete("/:keyID", c.ApiKey.RevokeApiKey)
 
-	userApi.Post("/sign-out", authMiddleware(c), c.User.SignOut)
-	userApi.Post("/sign-out/all", authMiddleware(c), c.User.SignOutAll)
+	oauthApi := app.Group(APIv1 + "/oauth")
+
+	oauthApi.Post("/token", c.OAuthClient.IssueToken)
+	oauthApi.Post("/introspect", c.Auth.IntrospectToken)
+	oauthApi.Post("/revoke", c.Auth.RevokeToken)
+
+	oauthApi.Get("/clients", authMiddleware(c), userToken, c.OAuthClient.GetOAuthClients)
+	oauthApi.Post("/clients", authMiddleware(c), userToken, c.OAuthClient.CreateOAuthClient)
+	oauthApi.Post("/clients/:clientID/secret", authMiddleware(c), userToken, recentAuth, c.OAuthClient.RotateOAuthClientSecret)
+	oauthApi.Delete("/clients/:clientID", authMiddleware(c), userToken, c.OAuthClient.RevokeOAuthClient)
+
+	referralApi := app.Group(APIv1 + "/referrals")
+
+	referralApi.Get("/", authMiddleware(c), c.Referral.GetReferrals)
+	referralApi.Get("/tree", authMiddleware(c), c.Referral.GetReferralTree)
+	referralApi.Get("/count", authMiddleware(c), c.Referral.GetReferralCount)
+	referralApi.Get("/stats", authMiddleware(c), c.Referral.GetReferralStats)
+
+	referralApi.Post("/code/regenerate", authMiddleware(c), userToken, c.Referral.RegenerateReferralLink)
+	referralApi.Put("/code", authMiddleware(c), userToken, c.Referral.SetReferralCode)
+
+	kycApi := app.Group(APIv1 + "/kyc")
+
+	kycApi.Get("/", authMiddleware(c), c.Kyc.GetMyKyc)
+	kycApi.Post("/submissions", authMiddleware(c), userToken, c.Kyc.SubmitKyc)
+
+	adminApi := app.Group(APIv1+"/admin", authMiddleware(c), requireRole(model.AdminRole))
+
+	adminApi.Get("/kyc/submissions", c.Kyc.GetKycSubmissions)
+	adminApi.Get("/kyc/submissions/:submissionID", c.Kyc.GetKycSubmission)
+	adminApi.Get("/kyc/submissions/:submissionID/documents/:documentID", c.Kyc.GetKycDocument)
+	adminApi.Post("/kyc/submissions/:submissionID/approve", c.Kyc.ApproveKycSubmission)
+	adminApi.Post("/kyc/submissions/:submissionID/reject", c.Kyc.RejectKycSubmission)
+
+	adminApi.Put("/users/:userID/flag", c.Kyc.FlagUser)
 
 	twoFactorAuthApi := app.Group(APIv1 + "/2fa")
 
-	twoFactorAuthApi.Get("/google/qr-code", authMiddleware(c), c.TwoFactorAuth.GenerateGoogleTwoFactorAuthQrCode)
+	twoFactorAuthApi.Get("/google/qr-code", twoFactorAuthSetupMiddleware(c), userToken, c.TwoFactorAuth.GenerateGoogleTwoFactorAuthQrCode)
 
 	twoFactorAuthApi.Post("/re-send", twoFactorAuthMiddleware(c), c.TwoFactorAuth.ReSendTwoFactorAuthCode)
 	twoFactorAuthApi.Post("/verify", twoFactorAuthMiddleware(c), c.TwoFactorAuth.VerifyTwoFactorAuthCode)
 
-	twoFactorAuthApi.Put("/set-up", authMiddleware(c), c.TwoFactorAuth.SetUpTwoFactorAuth)
-	twoFactorAuthApi.Delete("/delete", authMiddleware(c), c.TwoFactorAuth.DeleteTwoFactorAuth)
+	twoFactorAuthApi.Put("/set-up", twoFactorAuthSetupMiddleware(c), recentAuth, c.TwoFactorAuth.SetUpTwoFactorAuth)
+	twoFactorAuthApi.Delete("/delete", authMiddleware(c), recentAuth, c.TwoFactorAuth.DeleteTwoFactorAuth)
+	twoFactorAuthApi.Put("/default", authMiddleware(c), userToken, c.TwoFactorAuth.SetDefaultTwoFactorAuthType)
+
+	twoFactorAuthApi.Get("/recovery-codes", authMiddleware(c), userToken, c.TwoFactorAuth.GetRecoveryCodesCount)
+	twoFactorAuthApi.Post("/recovery-codes", authMiddleware(c), recentAuth, c.TwoFactorAuth.RegenerateRecoveryCodes)
+
+	twoFactorAuthApi.Get("/trusted-devices", authMiddleware(c), userToken, c.TrustedDevice.GetTrustedDevices)
+	twoFactorAuthApi.Delete("/trusted-devices", authMiddleware(c), userToken, c.TrustedDevice.DeleteTrustedDevices)
+	twoFactorAuthApi.Delete("/trusted-devices/:deviceID", authMiddleware(c), userToken, c.TrustedDevice.DeleteTrustedDevice)
 
 	otpApi := app.Group(APIv1 + "/code")
 
-	otpApi.Post("/send", authMiddleware(c), c.Token.Send2faCode)
-	otpApi.Post("/target-send", authMiddleware(c), c.Token.SendTarget2faCode)
+	otpApi.Post("/send", twoFactorAuthSetupMiddleware(c), userToken, c.Token.Send2faCode)
+	otpApi.Post("/target-send", twoFactorAuthSetupMiddleware(c), userToken, c.Token.SendTarget2faCode)
+
+	// must go after the other routes, the document describes the routes registered so far
+	registerOpenAPI(app)
 
 	return app
 }
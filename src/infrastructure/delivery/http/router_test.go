@@ -0,0 +1,476 @@
+package http_test
+
+import (
+	"archive/zip"
+	"auth-project/pkg/client"
+	"auth-project/src/domain/model"
+	"auth-project/src/infrastructure/authentication"
+	"auth-project/src/infrastructure/delivery/http/apitest"
+	"auth-project/src/infrastructure/storage/sessions"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const password = "Password1"
+
+// forEachStore runs the test against the service with each session store
+func forEachStore(t *testing.T, test func(t *testing.T, srv *apitest.Server)) {
+	for _, driver := range []string{sessions.DriverMemory, sessions.DriverRedis, sessions.DriverDatabase} {
+		t.Run(driver, func(t *testing.T) {
+			test(t, apitest.New(t, driver))
+		})
+	}
+}
+
+func newClient(srv *apitest.Server, opts ...client.Option) *client.Client {
+	return client.New(srv.URL, opts...)
+}
+
+// signUp registers the user by the code sent to the email or the phone
+func signUp(t *testing.T, srv *apitest.Server, login, loginType string) {
+	t.Helper()
+
+	ctx := context.Background()
+	c := newClient(srv)
+
+	err := c.SignUpSendCode(ctx, &model.SignUpSend2faCodeReq{Login: login, LoginType: loginType})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code := srv.Emails.Code
+	if loginType == model.TokenTypePhone {
+		code = srv.Sms.Code
+	}
+
+	err = c.SignUp(ctx, &model.SignUpReq{
+		Login:     login,
+		LoginType: loginType,
+		Code2fa:   code(t, login),
+		Password:  password,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// signIn signs in the user without two-factor auth
+func signIn(t *testing.T, srv *apitest.Server, login string) *client.Client {
+	t.Helper()
+
+	c := newClient(srv)
+	resp, err := c.Authenticate(context.Background(), &model.AuthenticationReq{Email: login, Password: password})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.AccessToken == "" {
+		t.Fatalf("got %+v, want the token pair", resp)
+	}
+
+	return c
+}
+
+func assertSignedIn(t *testing.T, c *client.Client) {
+	t.Helper()
+
+	_, err := c.GetMyProfile(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertSignedOut(t *testing.T, c *client.Client) {
+	t.Helper()
+
+	_, err := c.GetMyProfile(context.Background())
+	if client.StatusCode(err) != http.StatusUnauthorized {
+		t.Fatalf("got %v, want the 401 error", err)
+	}
+}
+
+func TestSignUpAndSignIn(t *testing.T) {
+	forEachStore(t, func(t *testing.T, srv *apitest.Server) {
+		ctx := context.Background()
+
+		signUp(t, srv, "user@gmail.com", model.TokenTypeEmail)
+		signUp(t, srv, "+12025550123", model.TokenTypePhone)
+
+		// the login is the email or the phone
+		assertSignedIn(t, signIn(t, srv, "user@gmail.com"))
+		assertSignedIn(t, signIn(t, srv, "+12025550123"))
+
+		_, err := newClient(srv).Authenticate(ctx, &model.AuthenticationReq{Email: "user@gmail.com",
+			Password: "Wrong1234"})
+		if client.StatusCode(err) != http.StatusUnauthorized {
+			t.Fatalf("got %v, want the 401 error", err)
+		}
+
+		// the code is single-use
+		err = newClient(srv).SignUp(ctx, &model.SignUpReq{
+			Login:     "user@gmail.com",
+			LoginType: model.TokenTypeEmail,
+			Code2fa:   srv.Emails.Code(t, "user@gmail.com"),
+			Password:  password,
+		})
+		if err == nil {
+			t.Fatal("the used code signed up the user again")
+		}
+	})
+}
+
+func TestSignInWithTwoFactorAuth(t *testing.T) {
+	cases := []struct {
+		name      string
+		login     string
+		loginType string
+		code2fa   string
+	}{
+		{"email", "user@gmail.com", model.TokenTypeEmail, model.TokenTypeEmail},
+		{"phone", "+12025550123", model.TokenTypePhone, model.TokenTypePhone},
+		{"google", "user@gmail.com", model.TokenTypeEmail, model.TokenTypeGoogle},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			forEachStore(t, func(t *testing.T, srv *apitest.Server) {
+				ctx := context.Background()
+
+				signUp(t, srv, tc.login, tc.loginType)
+				c := signIn(t, srv, tc.login)
+
+				// the code of the next sign in, the google code is given for the next time step,
+				// since the step of the set-up code is used
+				var nextCode func() string
+
+				setUpReq := &model.TwoFactorAuthSetUpReq{Code2faType: tc.code2fa}
+				switch tc.code2fa {
+				case model.TokenTypeGoogle:
+					qrCode, err := c.GetGoogleTwoFactorAuthQrCode(ctx)
+					if err != nil {
+						t.Fatal(err)
+					}
+					secret, err := authentication.DecodeTotpSecret(qrCode.Secret)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					step := srv.Totp.Step(time.Now())
+					setUpReq.Secret = qrCode.Secret
+					setUpReq.Code2fa = srv.Totp.GenerateCode(secret, step)
+					nextCode = func() string {
+						return srv.Totp.GenerateCode(secret, step+1)
+					}
+
+				case model.TokenTypeEmail:
+					_, err := c.SendTarget2faCode(ctx, &model.SendTarget2faCodeReq{Target: tc.login,
+						Code2faType: tc.code2fa})
+					if err != nil {
+						t.Fatal(err)
+					}
+					setUpReq.Code2fa = srv.Emails.Code(t, tc.login)
+					nextCode = func() string {
+						return srv.Emails.Code(t, tc.login)
+					}
+
+				case model.TokenTypePhone:
+					_, err := c.SendTarget2faCode(ctx, &model.SendTarget2faCodeReq{Target: tc.login,
+						Code2faType: tc.code2fa})
+					if err != nil {
+						t.Fatal(err)
+					}
+					setUpReq.Code2fa = srv.Sms.Code(t, tc.login)
+					nextCode = func() string {
+						return srv.Sms.Code(t, tc.login)
+					}
+				}
+
+				setUpResp, err := c.SetUpTwoFactorAuth(ctx, setUpReq)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(setUpResp.RecoveryCodes) == 0 {
+					t.Fatal("no recovery codes were given")
+				}
+
+				// the password alone does not sign in anymore
+				device := newClient(srv)
+				resp, err := device.Authenticate(ctx, &model.AuthenticationReq{Email: tc.login, Password: password})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.AccessToken != "" || resp.TwoFactorAuthToken == "" || resp.TwoFactorAuthType != tc.code2fa {
+					t.Fatalf("got %+v, want the 2fa token of %s", resp, tc.code2fa)
+				}
+
+				_, err = device.VerifyTwoFactorAuthCode(ctx, &model.Verify2faCodeReq{Code2fa: "000000",
+					Code2faType: tc.code2fa})
+				if err == nil {
+					t.Fatal("the wrong code was accepted")
+				}
+
+				_, err = device.VerifyTwoFactorAuthCode(ctx, &model.Verify2faCodeReq{Code2fa: nextCode(),
+					Code2faType: tc.code2fa, TrustDevice: true})
+				if err != nil {
+					t.Fatal(err)
+				}
+				assertSignedIn(t, device)
+
+				// the trusted device skips two-factor auth
+				resp, err = device.Authenticate(ctx, &model.AuthenticationReq{Email: tc.login, Password: password})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.AccessToken == "" {
+					t.Fatalf("got %+v, want the token pair on the trusted device", resp)
+				}
+			})
+		})
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	forEachStore(t, func(t *testing.T, srv *apitest.Server) {
+		ctx := context.Background()
+
+		signUp(t, srv, "user@gmail.com", model.TokenTypeEmail)
+		c := signIn(t, srv, "user@gmail.com")
+		stolen := c.Tokens()
+
+		_, err := c.RefreshToken(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.Tokens() == stolen {
+			t.Fatal("the refresh did not replace the token pair")
+		}
+		assertSignedIn(t, c)
+
+		// the replaced refresh token is rejected and signs out the session, it could be stolen
+		_, err = newClient(srv, client.WithTokens(stolen)).RefreshToken(ctx)
+		if client.StatusCode(err) != http.StatusUnauthorized {
+			t.Fatalf("got %v, want the 401 error", err)
+		}
+		assertSignedOut(t, c)
+
+		// the other sessions are kept
+		assertSignedIn(t, signIn(t, srv, "user@gmail.com"))
+	})
+}
+
+func TestQrCodeAuth(t *testing.T) {
+	forEachStore(t, func(t *testing.T, srv *apitest.Server) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		signUp(t, srv, "user@gmail.com", model.TokenTypeEmail)
+		phone := signIn(t, srv, "user@gmail.com")
+
+		// the phone signed in confirms the qr code shown by the other device
+		confirmed := make(chan error, 1)
+		desktop := newClient(srv)
+		resp, err := desktop.QrCodeAuth(ctx, func(qrCode *model.QrCodeAuthResp) {
+			go func() {
+				confirmed <- phone.ConfirmQrCodeAuth(ctx, qrCode.QrCodeToken)
+			}()
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = <-confirmed; err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.AccessToken == "" {
+			t.Fatalf("got %+v, want the token pair", resp)
+		}
+		assertSignedIn(t, desktop)
+	})
+}
+
+func TestSignOut(t *testing.T) {
+	forEachStore(t, func(t *testing.T, srv *apitest.Server) {
+		ctx := context.Background()
+
+		signUp(t, srv, "user@gmail.com", model.TokenTypeEmail)
+		first := signIn(t, srv, "user@gmail.com")
+		second := signIn(t, srv, "user@gmail.com")
+		third := signIn(t, srv, "user@gmail.com")
+
+		// the signed out session is not refreshed
+		tokens := first.Tokens()
+		err := first.SignOut(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertSignedOut(t, newClient(srv, client.WithTokens(tokens)))
+		assertSignedIn(t, second)
+
+		tokens = third.Tokens()
+		err = second.SignOutAll(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertSignedOut(t, newClient(srv, client.WithTokens(tokens)))
+
+		sessions, err := signIn(t, srv, "user@gmail.com").GetMySessions(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sessions) != 1 {
+			t.Fatalf("got %d live sessions, want the new one only", len(sessions))
+		}
+	})
+}
+
+func TestMagicLinkAuth(t *testing.T) {
+	srv := apitest.New(t, sessions.DriverMemory)
+	ctx := context.Background()
+
+	// the other tenant is resolved by the host of its own
+	srvURL, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	acmeHost := "localhost:" + srvURL.Port()
+	_, err = srv.DB.NewInsert().Model(&model.Tenant{ID: "acme", Name: "acme", Hosts: []string{acmeHost},
+		IsActive: true}).Exec(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signUp(t, srv, "user@gmail.com", model.TokenTypeEmail)
+
+	err = newClient(srv).SendMagicLink(ctx, &model.MagicLinkSendReq{Email: "user@gmail.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	linkReq := &model.MagicLinkAuthReq{Token: srv.Emails.MagicLinkToken(t, "user@gmail.com")}
+
+	// the link of one tenant is not accepted by the others
+	_, err = client.New("http://"+acmeHost).AuthenticateByMagicLink(ctx, linkReq)
+	if client.StatusCode(err) != http.StatusUnauthorized {
+		t.Fatalf("got %v, want the 401 error", err)
+	}
+
+	c := newClient(srv)
+	resp, err := c.AuthenticateByMagicLink(ctx, linkReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.AccessToken == "" {
+		t.Fatalf("got %+v, want the token pair", resp)
+	}
+	assertSignedIn(t, c)
+
+	// the link is single-use
+	_, err = newClient(srv).AuthenticateByMagicLink(ctx, linkReq)
+	if client.StatusCode(err) != http.StatusUnauthorized {
+		t.Fatalf("got %v, want the 401 error", err)
+	}
+}
+
+func TestExportMyData(t *testing.T) {
+	srv := apitest.New(t, sessions.DriverDatabase)
+	ctx := context.Background()
+
+	signUp(t, srv, "user@gmail.com", model.TokenTypeEmail)
+	c := signIn(t, srv, "user@gmail.com")
+	profile, err := c.GetMyProfile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the user signed up by the referral link of the user
+	err = newClient(srv).SignUpSendCode(ctx, &model.SignUpSend2faCodeReq{Login: "friend@gmail.com",
+		LoginType: model.TokenTypeEmail})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = newClient(srv).SignUp(ctx, &model.SignUpReq{
+		Login:     "friend@gmail.com",
+		LoginType: model.TokenTypeEmail,
+		Code2fa:   srv.Emails.Code(t, "friend@gmail.com"),
+		Password:  password,
+		Referral:  profile.ReferralLink,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	friend, err := signIn(t, srv, "friend@gmail.com").GetMyProfile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the sign in from the other device is kept in the security events
+	_, err = newClient(srv, client.WithUserAgent("other device")).Authenticate(ctx,
+		&model.AuthenticationReq{Email: "user@gmail.com", Password: password})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := c.ExportMyData(ctx, model.AccountExportFormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var export model.UserDataExport
+	err = json.Unmarshal(data, &export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if export.Profile == nil || export.Profile.ID != profile.ID || export.Profile.Email != "user@gmail.com" {
+		t.Fatalf("got profile %+v, want the one of the user", export.Profile)
+	}
+	if len(export.Sessions) != 2 || export.Sessions[0].UserID != profile.ID {
+		t.Fatalf("got sessions %+v, want the sessions of both devices", export.Sessions)
+	}
+	if len(export.Referrals) != 1 || export.Referrals[0].ID != friend.ID {
+		t.Fatalf("got referrals %+v, want the friend", export.Referrals)
+	}
+	if len(export.SecurityEvents) != 1 || export.SecurityEvents[0].Event != model.SecurityEventNewLogin {
+		t.Fatalf("got security events %+v, want the sign in from the other device", export.SecurityEvents)
+	}
+	if export.ExportedAt.IsZero() {
+		t.Fatal("the export time is not set")
+	}
+
+	// the secrets are not exported
+	for _, secret := range []string{`"password"`, `"hash"`, `"google_secret"`, "$2a$"} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("the export contains %s", secret)
+		}
+	}
+
+	// the archive holds the same export
+	archive, err := c.ExportMyData(ctx, model.AccountExportFormatZIP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(zr.File) != 1 {
+		t.Fatalf("got %d files in the archive, want 1", len(zr.File))
+	}
+	f, err := zr.File[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var archived model.UserDataExport
+	err = json.NewDecoder(f).Decode(&archived)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if archived.Profile == nil || archived.Profile.ID != profile.ID || len(archived.Referrals) != 1 {
+		t.Fatalf("got archived export %+v, want the one of the user", archived)
+	}
+}
@@ -0,0 +1,247 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// encryptedPrefix marks values produced by EnvelopeCipher, format:
+// enc:v2:<key version>:<base64 wrapped data key>:<base64 ciphertext>,
+// legacyPrefix marks the values of the first format, which are not bound to the field
+const (
+	encryptedPrefix = "enc:v2:"
+	legacyPrefix    = "enc:v1:"
+)
+
+// ErrLegacyValue the value is plaintext or is not bound to the field, it is read only while
+// encryption.accept_legacy_values is set, cmd/encrypt-secrets encrypts such values
+var ErrLegacyValue = errors.New("value is not encrypted with the field binding, run cmd/encrypt-secrets")
+
+// Field is the column value of the row, the value encrypted for the field is not decrypted for another field,
+// so the ciphertexts can not be swapped between the rows or the columns
+type Field struct {
+	Table  string
+	Column string
+	RowID  string
+}
+
+func (f Field) associatedData() []byte {
+	return []byte(f.Table + "." + f.Column + ":" + f.RowID)
+}
+
+// FieldCipher encrypts and decrypts the values of the sensitive columns
+type FieldCipher interface {
+	Encrypt(plaintext string, field Field) (string, error)
+	Decrypt(value string, field Field) (string, error)
+}
+
+// EnvelopeCipher encrypts every value with a fresh random data key (DEK),
+// the data key itself is encrypted with a versioned key-encryption key (KEK)
+type EnvelopeCipher struct {
+	keys           map[int][]byte
+	currentVersion int
+	// acceptLegacy allows the plaintext and the first format values while they are migrated
+	acceptLegacy bool
+}
+
+func NewEnvelopeCipher(keys map[int][]byte, currentVersion int, acceptLegacy bool) (*EnvelopeCipher, error) {
+
+	for version, key := range keys {
+		if len(key) != 32 {
+			return nil, fmt.Errorf("encryption key version %d must be 32 bytes", version)
+		}
+	}
+
+	if _, ok := keys[currentVersion]; !ok {
+		return nil, fmt.Errorf("encryption key version %d not found", currentVersion)
+	}
+
+	return &EnvelopeCipher{keys, currentVersion, acceptLegacy}, nil
+}
+
+// MustLoadEnvelopeCipher loads base64 encoded key-encryption keys from the config,
+// the legacy values are accepted if acceptLegacy or encryption.accept_legacy_values is set
+func MustLoadEnvelopeCipher(acceptLegacy bool) *EnvelopeCipher {
+
+	keys := make(map[int][]byte)
+	for version, encodedKey := range viper.GetStringMapString("encryption.keys") {
+		v, err := strconv.Atoi(version)
+		if err != nil {
+			panic(fmt.Errorf("invalid encryption key version %q", version))
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil {
+			panic(fmt.Errorf("invalid encryption key version %d: %w", v, err))
+		}
+
+		keys[v] = key
+	}
+
+	ec, err := NewEnvelopeCipher(keys, viper.GetInt("encryption.current_key_version"),
+		acceptLegacy || viper.GetBool("encryption.accept_legacy_values"))
+	if err != nil {
+		panic(err)
+	}
+
+	return ec
+}
+
+func (ec *EnvelopeCipher) Encrypt(plaintext string, field Field) (string, error) {
+
+	dek := make([]byte, 32)
+	_, err := rand.Read(dek)
+	if err != nil {
+		return "", err
+	}
+
+	ciphertext, err := seal(dek, []byte(plaintext), field.associatedData())
+	if err != nil {
+		return "", err
+	}
+
+	wrappedDek, err := seal(ec.keys[ec.currentVersion], dek, field.associatedData())
+	if err != nil {
+		return "", err
+	}
+
+	return encryptedPrefix + strconv.Itoa(ec.currentVersion) + ":" +
+		base64.RawStdEncoding.EncodeToString(wrappedDek) + ":" +
+		base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt decrypts the value of the field, the legacy values are returned only if they are accepted
+func (ec *EnvelopeCipher) Decrypt(value string, field Field) (string, error) {
+
+	var associatedData []byte
+	switch {
+	case strings.HasPrefix(value, encryptedPrefix):
+		associatedData = field.associatedData()
+	case !ec.acceptLegacy:
+		return "", ErrLegacyValue
+	case strings.HasPrefix(value, legacyPrefix):
+	default:
+		return value, nil
+	}
+
+	version, wrappedDek, ciphertext, err := parse(value)
+	if err != nil {
+		return "", err
+	}
+
+	kek, ok := ec.keys[version]
+	if !ok {
+		return "", fmt.Errorf("encryption key version %d not found", version)
+	}
+
+	dek, err := open(kek, wrappedDek, associatedData)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := open(dek, ciphertext, associatedData)
+	if err != nil {
+		return "", err
+	}
+
+	return string(plaintext), nil
+}
+
+// IsCurrent reports whether the value is encrypted with the field binding and the current key version
+func (ec *EnvelopeCipher) IsCurrent(value string) bool {
+
+	if !IsEncrypted(value) {
+		return false
+	}
+
+	version, _, _, err := parse(value)
+	if err != nil {
+		return false
+	}
+
+	return version == ec.currentVersion
+}
+
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, encryptedPrefix)
+}
+
+func parse(value string) (int, []byte, []byte, error) {
+
+	// the prefixes of both formats have the same length
+	parts := strings.Split(value[len(encryptedPrefix):], ":")
+	if len(parts) != 3 {
+		return 0, nil, nil, errors.New("invalid encrypted value")
+	}
+
+	version, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, nil, nil, errors.New("invalid encrypted value")
+	}
+
+	wrappedDek, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return 0, nil, nil, errors.New("invalid encrypted value")
+	}
+
+	ciphertext, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return 0, nil, nil, errors.New("invalid encrypted value")
+	}
+
+	return version, wrappedDek, ciphertext, nil
+}
+
+// seal encrypts data with AES-256-GCM, the nonce is prepended to the result
+func seal(key, data, associatedData []byte) ([]byte, error) {
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, data, associatedData), nil
+}
+
+func open(key, data, associatedData []byte) ([]byte, error) {
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("invalid encrypted value")
+	}
+
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], associatedData)
+	if err != nil {
+		return nil, errors.New("decryption failed")
+	}
+
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,57 @@
+package encryption
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEnvelopeCipherBindsField(t *testing.T) {
+	ec, err := NewEnvelopeCipher(map[int][]byte{1: bytes.Repeat([]byte{1}, 32)}, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	field := Field{Table: "users", Column: "google_secret", RowID: "u1"}
+	value, err := ec.Encrypt("secret", field)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext, err := ec.Decrypt(value, field)
+	if err != nil || plaintext != "secret" {
+		t.Fatalf("got %q, %v", plaintext, err)
+	}
+
+	for _, other := range []Field{
+		{Table: "users", Column: "google_secret", RowID: "u2"},
+		{Table: "user_configs", Column: "id_card", RowID: "u1"},
+	} {
+		_, err = ec.Decrypt(value, other)
+		if err == nil {
+			t.Fatalf("value of %v is decrypted for %v", field, other)
+		}
+	}
+}
+
+func TestEnvelopeCipherLegacyValues(t *testing.T) {
+	keys := map[int][]byte{1: bytes.Repeat([]byte{1}, 32)}
+	field := Field{Table: "users", Column: "google_secret", RowID: "u1"}
+
+	strict, err := NewEnvelopeCipher(keys, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = strict.Decrypt("plaintext", field)
+	if err != ErrLegacyValue {
+		t.Fatalf("got %v, want ErrLegacyValue", err)
+	}
+
+	migrating, err := NewEnvelopeCipher(keys, 1, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := migrating.Decrypt("plaintext", field)
+	if err != nil || plaintext != "plaintext" {
+		t.Fatalf("got %q, %v", plaintext, err)
+	}
+}
@@ -0,0 +1,153 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	bytesType = reflect.TypeOf([]byte(nil))
+)
+
+// SchemaOf returns the schema of the value by its json encoding, the named structs are put
+// in the components and referred, so the same model is described once
+func (d *Document) SchemaOf(v interface{}) *Schema {
+	if v == nil {
+		return nil
+	}
+	return d.schemaOf(reflect.TypeOf(v))
+}
+
+func (d *Document) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == bytesType:
+		// encoding/json gives the bytes in base64
+		return &Schema{Type: "string", Format: "byte"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: d.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+
+		name := t.Name()
+		if _, ok := d.Components.Schemas[name]; !ok {
+			// the placeholder stops the recursion of the self-referencing models
+			d.Components.Schemas[name] = &Schema{}
+			*d.Components.Schemas[name] = *d.structSchema(t)
+		}
+		return Ref(name)
+	default:
+		// interface{} is any value
+		return &Schema{}
+	}
+}
+
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	d.addFields(s, t)
+	return s
+}
+
+// addFields puts the fields in the schema as encoding/json does, the embedded structs without
+// the json name are flattened
+func (d *Document) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts := parseTag(tag)
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				d.addFields(s, ft)
+				continue
+			}
+		}
+
+		if f.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		fs := d.schemaOf(f.Type)
+		if f.Type.Kind() == reflect.Ptr && fs.Ref == "" {
+			fs.Nullable = true
+		}
+		s.Properties[name] = fs
+
+		if !strings.Contains(opts, "omitempty") && strings.Contains(f.Tag.Get("validate"), "required") {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// ParametersOf returns the parameters of the struct fields by the tag, e.g. the query parameters
+// parsed by fiber's QueryParser
+func (d *Document) ParametersOf(v interface{}, in, tag string) []*Parameter {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var params []*Parameter
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name, _ := parseTag(f.Tag.Get(tag))
+		if name == "" || name == "-" {
+			continue
+		}
+
+		params = append(params, &Parameter{
+			Name:     name,
+			In:       in,
+			Required: strings.Contains(f.Tag.Get("validate"), "required"),
+			Schema:   d.schemaOf(f.Type),
+		})
+	}
+
+	return params
+}
+
+func parseTag(tag string) (name, opts string) {
+	if i := strings.Index(tag, ","); i >= 0 {
+		return tag[:i], tag[i+1:]
+	}
+	return tag, ""
+}
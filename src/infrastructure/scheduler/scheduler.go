@@ -0,0 +1,33 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const defaultInterval = time.Minute
+
+// Every runs the job periodically until the context is done, the job errors are logged
+// and do not stop the next runs
+func Every(ctx context.Context, interval time.Duration, name string, job func(ctx context.Context) error) {
+
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := job(ctx)
+			if err != nil {
+				log.Printf("error running %s job: %s", name, err.Error())
+			}
+		}
+	}
+}
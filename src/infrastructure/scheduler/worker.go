@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWorkers    = 4
+	defaultQueueSize  = 100
+	defaultJobTimeout = 30 * time.Second
+)
+
+type task struct {
+	ctx  context.Context
+	name string
+	job  func(ctx context.Context) error
+}
+
+// Worker runs the background jobs of the requests by a fixed number of goroutines,
+// each job is bounded by the timeout and Shutdown waits for the queued ones
+type Worker struct {
+	tasks   chan task
+	timeout time.Duration
+
+	wg     sync.WaitGroup
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewWorker starts the goroutines of the worker, the values ≤ 0 fall back to the defaults
+func NewWorker(workers, queueSize int, timeout time.Duration) *Worker {
+
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+	if timeout <= 0 {
+		timeout = defaultJobTimeout
+	}
+
+	w := &Worker{
+		tasks:   make(chan task, queueSize),
+		timeout: timeout,
+	}
+
+	w.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go w.run()
+	}
+
+	return w
+}
+
+// Go queues the job, ctx must not be the context of the request, since the job outlives it,
+// the job is dropped and logged if the queue is full or the worker is shut down
+func (w *Worker) Go(ctx context.Context, name string, job func(ctx context.Context) error) {
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		log.Printf("error queueing %s job: the worker is shut down", name)
+		return
+	}
+
+	select {
+	case w.tasks <- task{ctx, name, job}:
+	default:
+		log.Printf("error queueing %s job: the queue is full", name)
+	}
+}
+
+// Shutdown stops accepting the jobs and waits for the queued ones until ctx is done
+func (w *Worker) Shutdown(ctx context.Context) error {
+
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.tasks)
+	}
+	w.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *Worker) run() {
+	defer w.wg.Done()
+
+	for t := range w.tasks {
+		ctx, cancel := context.WithTimeout(t.ctx, w.timeout)
+		err := t.job(ctx)
+		cancel()
+		if err != nil {
+			log.Printf("error running %s job: %s", t.name, err.Error())
+		}
+	}
+}
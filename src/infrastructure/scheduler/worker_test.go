@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerShutdownWaitsForQueuedJobs(t *testing.T) {
+	w := NewWorker(2, 10, time.Second)
+
+	var done int32
+	for i := 0; i < 5; i++ {
+		w.Go(context.Background(), "test", func(ctx context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&done, 1)
+			return nil
+		})
+	}
+
+	err := w.Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&done) != 5 {
+		t.Fatalf("got %d finished jobs, want 5", done)
+	}
+
+	// the jobs after the shutdown are dropped
+	w.Go(context.Background(), "test", func(ctx context.Context) error {
+		atomic.AddInt32(&done, 1)
+		return nil
+	})
+	if atomic.LoadInt32(&done) != 5 {
+		t.Fatal("the job is run after the shutdown")
+	}
+}
+
+func TestWorkerBoundsJobs(t *testing.T) {
+	w := NewWorker(1, 1, 20*time.Millisecond)
+
+	deadline := make(chan bool, 1)
+	w.Go(context.WithValue(context.Background(), "tenant_id", "acme"), "test", func(ctx context.Context) error {
+		<-ctx.Done()
+		deadline <- ctx.Value("tenant_id") == "acme"
+		return nil
+	})
+
+	select {
+	case kept := <-deadline:
+		if !kept {
+			t.Fatal("the job lost the values of its context")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the job is not bounded by the timeout")
+	}
+
+	err := w.Shutdown(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
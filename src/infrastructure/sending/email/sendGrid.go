@@ -1,22 +1,65 @@
 package email
 
 import (
+	"auth-project/src/domain/model"
 	"errors"
 	"github.com/sendgrid/sendgrid-go"
 	"github.com/sendgrid/sendgrid-go/helpers/mail"
 	"github.com/spf13/viper"
+	"html"
+	"log"
 )
 
-func SendEmail(name, address, subject, plainTextContent, htmlContent string) error {
-	apiKey := viper.GetString("sendgrid.api_key")
+// Message the email to deliver
+type Message struct {
+	FromName         string
+	FromAddress      string
+	ToName           string
+	ToAddress        string
+	Subject          string
+	PlainTextContent string
+	HtmlContent      string
+}
+
+// Sender delivers the emails, it is the sendgrid client unless it is replaced by SetSender
+type Sender interface {
+	Send(message *Message) error
+}
+
+var sender Sender = sendGridSender{}
+
+// SetSender replaces the sender of the emails, e.g. by LogSender for the local runs
+func SetSender(s Sender) {
+	sender = s
+}
+
+// SendEmail sends the email from the tenant's sender, the project's sender is used if the tenant has no own one
+func SendEmail(tenant *model.Tenant, name, address, subject, plainTextContent, htmlContent string) error {
 	fromName := viper.GetString("sendgrid.from_name")
 	fromAddress := viper.GetString("sendgrid.from_address")
+	if tenant.EmailFromAddress != "" {
+		fromName, fromAddress = tenant.EmailFromName, tenant.EmailFromAddress
+	}
+
+	return sender.Send(&Message{
+		FromName:         fromName,
+		FromAddress:      fromAddress,
+		ToName:           name,
+		ToAddress:        address,
+		Subject:          subject,
+		PlainTextContent: plainTextContent,
+		HtmlContent:      htmlContent,
+	})
+}
+
+type sendGridSender struct{}
 
-	from := mail.NewEmail(fromName, fromAddress)
-	to := mail.NewEmail(name, address)
+func (sendGridSender) Send(m *Message) error {
+	from := mail.NewEmail(m.FromName, m.FromAddress)
+	to := mail.NewEmail(m.ToName, m.ToAddress)
 
-	message := mail.NewSingleEmail(from, subject, to, plainTextContent, htmlContent)
-	_, err := sendgrid.NewSendClient(apiKey).Send(message)
+	message := mail.NewSingleEmail(from, m.Subject, to, m.PlainTextContent, m.HtmlContent)
+	_, err := sendgrid.NewSendClient(viper.GetString("sendgrid.api_key")).Send(message)
 	if err != nil {
 		return errors.New("error sending email")
 	}
@@ -24,8 +67,37 @@ func SendEmail(name, address, subject, plainTextContent, htmlContent string) err
 	return nil
 }
 
-func CreateEmailBodyVerificationCode(code string) (plainTextContent, htmlContent string) {
-	plainTextContent = "Your " + viper.GetString("project_name") + " verification code is: " + code
-	htmlContent = "Your " + viper.GetString("project_name") + " verification code is: " + code
+// LogSender writes the emails to the log instead of sending them
+type LogSender struct{}
+
+func (LogSender) Send(m *Message) error {
+	log.Printf("email from %s to %s, %s: %s", m.FromAddress, m.ToAddress, m.Subject, m.PlainTextContent)
+	return nil
+}
+
+func CreateEmailBodyVerificationCode(brand, code string) (plainTextContent, htmlContent string) {
+	plainTextContent = "Your " + brand + " verification code is: " + code
+	htmlContent = "Your " + html.EscapeString(brand) + " verification code is: " + code
+	return
+}
+
+func CreateEmailBodyMagicLink(brand, link string) (plainTextContent, htmlContent string) {
+	plainTextContent = "Follow the link to sign in to " + brand + ": " + link
+	htmlContent = "Follow the link to sign in to " + html.EscapeString(brand) + ": " +
+		"<a href=\"" + html.EscapeString(link) + "\">" + html.EscapeString(link) + "</a>"
+	return
+}
+
+func CreateEmailBodySecurityNotification(brand, description, device, ip, date, revokeLink string) (plainTextContent, htmlContent string) {
+	plainTextContent = description + " in your " + brand + " account.\n" +
+		"Device: " + device + "\nIP: " + ip + "\nTime: " + date
+	htmlContent = html.EscapeString(description) + " in your " + html.EscapeString(brand) + " account.<br>" +
+		"Device: " + html.EscapeString(device) + "<br>IP: " + html.EscapeString(ip) + "<br>Time: " + date
+
+	if revokeLink != "" {
+		plainTextContent += "\nIf this wasn't you, follow the link to sign out of all sessions: " + revokeLink
+		htmlContent += "<br>If this wasn't you, follow the link to sign out of all sessions: " +
+			"<a href=\"" + html.EscapeString(revokeLink) + "\">" + html.EscapeString(revokeLink) + "</a>"
+	}
 	return
 }
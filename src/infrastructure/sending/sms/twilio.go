@@ -1,25 +1,48 @@
 package sms
 
 import (
+	"auth-project/src/domain/model"
 	"errors"
 	"github.com/spf13/viper"
 	"github.com/twilio/twilio-go"
 	openapi "github.com/twilio/twilio-go/rest/api/v2010"
+	"log"
 )
 
-func SendSms(sendTo, smsBody string) error {
-	accountSid := viper.GetString("twilio_sms.accountSid")
-	authToken := viper.GetString("twilio_sms.authToken")
+// Sender delivers the sms, it is the twilio client unless it is replaced by SetSender
+type Sender interface {
+	Send(from, to, body string) error
+}
+
+var sender Sender = twilioSender{}
+
+// SetSender replaces the sender of the sms, e.g. by LogSender for the local runs
+func SetSender(s Sender) {
+	sender = s
+}
+
+// SendSms sends the message from the tenant's number, the project's number is used if the tenant has no own one
+func SendSms(tenant *model.Tenant, sendTo, smsBody string) error {
+	from := tenant.SmsFrom
+	if from == "" {
+		from = viper.GetString("twilio_sms.phone")
+	}
+
+	return sender.Send(from, sendTo, smsBody)
+}
 
+type twilioSender struct{}
+
+func (twilioSender) Send(from, to, body string) error {
 	client := twilio.NewRestClientWithParams(twilio.RestClientParams{
-		Username: accountSid,
-		Password: authToken,
+		Username: viper.GetString("twilio_sms.accountSid"),
+		Password: viper.GetString("twilio_sms.authToken"),
 	})
 
 	params := &openapi.CreateMessageParams{}
-	params.SetTo(sendTo)
-	params.SetFrom(viper.GetString("twilio_sms.phone"))
-	params.SetBody(smsBody)
+	params.SetTo(to)
+	params.SetFrom(from)
+	params.SetBody(body)
 
 	_, err := client.ApiV2010.CreateMessage(params)
 	if err != nil {
@@ -29,6 +52,19 @@ func SendSms(sendTo, smsBody string) error {
 	return nil
 }
 
-func CreateSmsBodyVerificationCode(code string) string {
-	return "Your " + viper.GetString("project_name") + " verification code is: " + code
+// LogSender writes the sms to the log instead of sending them
+type LogSender struct{}
+
+func (LogSender) Send(from, to, body string) error {
+	log.Printf("sms from %s to %s: %s", from, to, body)
+	return nil
+}
+
+func CreateSmsBodyVerificationCode(brand, code string) string {
+	return "Your " + brand + " verification code is: " + code
+}
+
+func CreateSmsBodySecurityNotification(brand, description, date string) string {
+	return description + " in your " + brand + " account at " + date +
+		". If this wasn't you, change your password."
 }
@@ -3,18 +3,52 @@ package storage
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"github.com/go-redis/redis/v8"
+	"github.com/go-sql-driver/mysql"
+	_ "github.com/mattn/go-sqlite3"
+	"time"
 
 	"github.com/spf13/viper"
 
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/mysqldialect"
 	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
 	"github.com/uptrace/bun/driver/pgdriver"
 )
 
-func InitPostgres() *bun.DB {
+// InitDB opens the database of db.driver, postgres is used by default
+func InitDB() *bun.DB {
 
-	db := bun.NewDB(sql.OpenDB(
+	var db *bun.DB
+	switch viper.GetString("db.driver") {
+	case "", "postgres":
+		db = initPostgres()
+	case "sqlite":
+		db = initSqlite()
+	case "mysql":
+		db = initMysql()
+	default:
+		panic(fmt.Errorf("unknown db.driver %q", viper.GetString("db.driver")))
+	}
+
+	//db.AddQueryHook(bundebug.NewQueryHook(
+	//	bundebug.WithVerbose(true),
+	//	bundebug.FromEnv("BUNDEBUG"),
+	//))
+
+	err := db.Ping()
+	if err != nil {
+		panic(err)
+	}
+
+	return db
+}
+
+func initPostgres() *bun.DB {
+
+	return bun.NewDB(sql.OpenDB(
 		pgdriver.NewConnector(
 			pgdriver.WithAddr(viper.GetString("db.host")+viper.GetString("db.port")),
 			pgdriver.WithUser(viper.GetString("db.user")),
@@ -23,18 +57,40 @@ func InitPostgres() *bun.DB {
 			pgdriver.WithInsecure(true),
 		),
 	), pgdialect.New())
+}
 
-	//db.AddQueryHook(bundebug.NewQueryHook(
-	//	bundebug.WithVerbose(true),
-	//	bundebug.FromEnv("BUNDEBUG"),
-	//))
+// initSqlite opens the database file of db.path, the file is created if it does not exist
+func initSqlite() *bun.DB {
 
-	err := db.Ping()
+	sqldb, err := sql.Open("sqlite3",
+		"file:"+viper.GetString("db.path")+"?_foreign_keys=on&_journal_mode=WAL&_busy_timeout=5000")
 	if err != nil {
 		panic(err)
 	}
 
-	return db
+	return bun.NewDB(sqldb, sqlitedialect.New())
+}
+
+// initMysql connects to mysql, the rows found by the updates are counted as affected like in postgres,
+// since the repositories check the updates by the affected rows
+func initMysql() *bun.DB {
+
+	cfg := mysql.NewConfig()
+	cfg.Net = "tcp"
+	cfg.Addr = viper.GetString("db.host") + viper.GetString("db.port")
+	cfg.User = viper.GetString("db.user")
+	cfg.Passwd = viper.GetString("db.pass")
+	cfg.DBName = viper.GetString("db.name")
+	cfg.ParseTime = true
+	cfg.ClientFoundRows = true
+	cfg.Loc = time.UTC
+
+	connector, err := mysql.NewConnector(cfg)
+	if err != nil {
+		panic(err)
+	}
+
+	return bun.NewDB(sql.OpenDB(connector), mysqldialect.New())
 }
 
 func InitRedis(env string) *redis.Client {
@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+)
+
+// OnConflictUpdate returns the clause of the insert which updates the row with the same keys,
+// mysql takes the conflict target from the unique keys of the table
+func OnConflictUpdate(db bun.IDB, keys string) string {
+	if db.Dialect().Name() == dialect.MySQL {
+		return "DUPLICATE KEY UPDATE"
+	}
+	return fmt.Sprintf("CONFLICT (%s) DO UPDATE", keys)
+}
+
+// InsertedValue returns the value of the column proposed for the insert, it is used in the set of OnConflictUpdate
+func InsertedValue(db bun.IDB, column string) bun.Safe {
+	if db.Dialect().Name() == dialect.MySQL {
+		return bun.Safe(fmt.Sprintf("VALUES(%s)", column))
+	}
+	return bun.Safe("EXCLUDED." + column)
+}
+
+// CurrentValue returns the value of the column of the existing row, it is used in the set of OnConflictUpdate,
+// the alias of the table is not set to the insert by mysql
+func CurrentValue(db bun.IDB, alias, column string) bun.Safe {
+	if db.Dialect().Name() == dialect.MySQL {
+		return bun.Safe(column)
+	}
+	return bun.Safe(alias + "." + column)
+}
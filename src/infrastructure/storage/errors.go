@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"errors"
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-sqlite3"
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+const (
+	// pgUniqueViolation is the SQLSTATE of the duplicate unique key, the other codes of the class 23 are
+	// the violations of the other constraints, e.g. not null or foreign key
+	pgUniqueViolation = "23505"
+	// mysqlErrDupEntry is the mysql error of the duplicate unique key
+	mysqlErrDupEntry = 1062
+)
+
+// pgError is the error of the postgres driver, pgdriver.Error, read by the fields of the protocol
+type pgError interface {
+	Field(k byte) string
+}
+
+var _ pgError = pgdriver.Error{}
+
+// ErrorClassifier tells the kind of the database errors, so the repositories do not depend on the drivers
+type ErrorClassifier interface {
+	IsUniqueViolation(err error) bool
+}
+
+type driverErrors struct{}
+
+// NewErrorClassifier returns the classifier of the errors of the drivers of db.driver
+func NewErrorClassifier() ErrorClassifier {
+	return driverErrors{}
+}
+
+// IsUniqueViolation tells whether the error is the violation of the unique constraint
+func (driverErrors) IsUniqueViolation(err error) bool {
+	var pgErr pgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Field('C') == pgUniqueViolation
+	}
+
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlErrDupEntry
+	}
+
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique ||
+			sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+	}
+
+	return false
+}
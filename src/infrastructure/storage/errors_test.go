@@ -0,0 +1,73 @@
+package storage_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"auth-project/src/infrastructure/storage"
+	"auth-project/src/infrastructure/storage/storagetest"
+	"github.com/go-sql-driver/mysql"
+	"github.com/mattn/go-sqlite3"
+)
+
+func TestIsUniqueViolation(t *testing.T) {
+	db := storagetest.NewSQLite(t)
+	ctx := context.Background()
+	dbErrors := storage.NewErrorClassifier()
+
+	_, err := db.ExecContext(ctx, "INSERT INTO session_keys (id, value, expires_at) VALUES ('k', 'v', CURRENT_TIMESTAMP)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.ExecContext(ctx, "INSERT INTO session_keys (id, value, expires_at) VALUES ('k', 'v', CURRENT_TIMESTAMP)")
+	if !dbErrors.IsUniqueViolation(err) {
+		t.Fatalf("the duplicate key is not classified: %v", err)
+	}
+
+	if dbErrors.IsUniqueViolation(errors.New("other")) || dbErrors.IsUniqueViolation(nil) {
+		t.Fatal("the other error is classified as the unique violation")
+	}
+}
+
+// pgError has the fields of pgdriver.Error, which is not constructed outside of the driver
+type pgError map[byte]string
+
+func (e pgError) Field(k byte) string {
+	return e[k]
+}
+
+func (e pgError) Error() string {
+	return "SQLSTATE=" + e['C']
+}
+
+func TestIsUniqueViolationByDriver(t *testing.T) {
+	dbErrors := storage.NewErrorClassifier()
+
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"postgres unique", pgError{'C': "23505"}, true},
+		{"postgres not null", pgError{'C': "23502"}, false},
+		{"postgres foreign key", pgError{'C': "23503"}, false},
+		{"postgres check", pgError{'C': "23514"}, false},
+		{"mysql duplicate entry", &mysql.MySQLError{Number: 1062}, true},
+		{"mysql not null", &mysql.MySQLError{Number: 1048}, false},
+		{"mysql foreign key", &mysql.MySQLError{Number: 1452}, false},
+		{"sqlite unique", sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintUnique}, true},
+		{"sqlite not null", sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintNotNull}, false},
+		{"wrapped", fmt.Errorf("update user name: %w", pgError{'C': "23505"}), true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := dbErrors.IsUniqueViolation(c.err); got != c.want {
+				t.Fatalf("got %v, want %v", got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"github.com/spf13/viper"
+)
+
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+)
+
+// ErrNotFound the file does not exist in the storage
+var ErrNotFound = errors.New("file not found")
+
+// Storage keeps the uploaded files, the keys are the slash separated paths
+type Storage interface {
+	Put(ctx context.Context, key string, content []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// NewStorage returns the storage chosen by file_storage.driver
+func NewStorage() Storage {
+	switch viper.GetString("file_storage.driver") {
+	case DriverS3:
+		return NewS3Storage(
+			viper.GetString("file_storage.s3.endpoint"),
+			viper.GetString("file_storage.s3.region"),
+			viper.GetString("file_storage.s3.bucket"),
+			viper.GetString("file_storage.s3.access_key"),
+			viper.GetString("file_storage.s3.secret_key"))
+	default:
+		return NewLocalStorage(viper.GetString("file_storage.local.dir"))
+	}
+}
@@ -0,0 +1,71 @@
+package files
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type localStorage struct {
+	dir string
+}
+
+// NewLocalStorage keeps the files in the directory of the local filesystem
+func NewLocalStorage(dir string) Storage {
+	return &localStorage{dir}
+}
+
+func (ls *localStorage) Put(ctx context.Context, key string, content []byte, contentType string) error {
+
+	path, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, content, 0600)
+}
+
+func (ls *localStorage) Get(ctx context.Context, key string) ([]byte, error) {
+
+	path, err := ls.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return content, err
+}
+
+func (ls *localStorage) Delete(ctx context.Context, key string) error {
+
+	path, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// path maps the key to the file inside the storage directory, the keys leaving the directory are rejected
+func (ls *localStorage) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", errors.New("invalid file key")
+	}
+	return filepath.Join(ls.dir, filepath.FromSlash(clean)), nil
+}
@@ -0,0 +1,167 @@
+package files
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	s3Service       = "s3"
+	s3SignAlgorithm = "AWS4-HMAC-SHA256"
+	s3DateFormat    = "20060102"
+	s3TimeFormat    = "20060102T150405Z"
+)
+
+type s3Storage struct {
+	endpoint  string
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+
+	client *http.Client
+}
+
+// NewS3Storage keeps the files in the bucket of the S3-compatible service,
+// the requests use the path-style addressing and the signature version 4
+func NewS3Storage(endpoint, region, bucket, accessKey, secretKey string) Storage {
+	return &s3Storage{
+		endpoint:  strings.TrimRight(endpoint, "/"),
+		region:    region,
+		bucket:    bucket,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (ss *s3Storage) Put(ctx context.Context, key string, content []byte, contentType string) error {
+
+	resp, err := ss.do(ctx, http.MethodPut, key, content, contentType)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return s3Error(resp)
+	}
+	return nil
+}
+
+func (ss *s3Storage) Get(ctx context.Context, key string) ([]byte, error) {
+
+	resp, err := ss.do(ctx, http.MethodGet, key, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return ioutil.ReadAll(resp.Body)
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	default:
+		return nil, s3Error(resp)
+	}
+}
+
+func (ss *s3Storage) Delete(ctx context.Context, key string) error {
+
+	resp, err := ss.do(ctx, http.MethodDelete, key, nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return s3Error(resp)
+	}
+	return nil
+}
+
+func (ss *s3Storage) do(ctx context.Context, method, key string, content []byte,
+	contentType string) (*http.Response, error) {
+
+	u, err := url.Parse(ss.endpoint + "/" + ss.bucket + "/" + strings.TrimLeft(key, "/"))
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	ss.sign(req, content, time.Now().UTC())
+
+	return ss.client.Do(req)
+}
+
+// sign adds the authorization header of the signature version 4
+func (ss *s3Storage) sign(req *http.Request, content []byte, now time.Time) {
+
+	payloadHash := sha256Hex(content)
+	req.Header.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + now.Format(s3TimeFormat) + "\n"
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := now.Format(s3DateFormat) + "/" + ss.region + "/" + s3Service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		s3SignAlgorithm,
+		now.Format(s3TimeFormat),
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+ss.secretKey), now.Format(s3DateFormat))
+	key = hmacSHA256(key, ss.region)
+	key = hmacSHA256(key, s3Service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3SignAlgorithm, ss.accessKey, scope, signedHeaders, signature))
+}
+
+func s3Error(resp *http.Response) error {
+	body, _ := ioutil.ReadAll(resp.Body)
+	return fmt.Errorf("s3 storage error: %s %s", resp.Status, strings.TrimSpace(string(body)))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,2 @@
+-- mysql generates no uuids, the ids are generated by the service
+SELECT 1;
\ No newline at end of file
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS users (
+    id VARCHAR(255) PRIMARY KEY NOT NULL,
+    email VARCHAR(255),
+    phone VARCHAR(255),
+    user_name VARCHAR(255),
+    full_name VARCHAR(255),
+    password VARCHAR(255),
+    hash VARCHAR(255),
+    referral_link VARCHAR(255) UNIQUE NOT NULL,
+    referral VARCHAR(255),
+    role VARCHAR(255) NOT NULL DEFAULT 'user',
+    is_active BOOLEAN NOT NULL DEFAULT FALSE,
+    is_email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    is_phone_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    is_google_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    google_secret TEXT,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6),
+    UNIQUE KEY users_email_key (email),
+    UNIQUE KEY users_phone_key (phone)
+);
\ No newline at end of file
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS tokens (
+    id VARCHAR(255) PRIMARY KEY NOT NULL,
+    user_id VARCHAR(255),
+    target VARCHAR(255) NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    reason VARCHAR(255) NOT NULL,
+    type VARCHAR(255) NOT NULL,
+    is_used BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    expires_at DATETIME(6) NOT NULL
+);
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id VARCHAR(255) PRIMARY KEY NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    code_hash VARCHAR(255) NOT NULL,
+    is_used BOOLEAN NOT NULL DEFAULT FALSE,
+    used_at DATETIME(6),
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
\ No newline at end of file
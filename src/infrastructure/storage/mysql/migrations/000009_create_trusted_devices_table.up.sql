@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS trusted_devices (
+    id VARCHAR(255) PRIMARY KEY NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    user_agent TEXT,
+    client_ip VARCHAR(255),
+    expires_at DATETIME(6) NOT NULL,
+    last_used_at DATETIME(6),
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS security_events (
+    id VARCHAR(255) PRIMARY KEY NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    event VARCHAR(255) NOT NULL,
+    user_agent TEXT,
+    client_ip VARCHAR(255),
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN phone_verified_at;
+ALTER TABLE users DROP COLUMN email_verified_at;
+
+DROP TABLE IF EXISTS contact_changes;
\ No newline at end of file
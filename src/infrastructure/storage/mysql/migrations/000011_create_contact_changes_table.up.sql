@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS contact_changes (
+    id VARCHAR(255) PRIMARY KEY NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    type VARCHAR(255) NOT NULL,
+    old_value VARCHAR(255),
+    new_value VARCHAR(255) NOT NULL,
+    user_agent TEXT,
+    client_ip VARCHAR(255),
+    apply_at DATETIME(6) NOT NULL,
+    applied_at DATETIME(6),
+    canceled_at DATETIME(6),
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- mysql has no partial indexes
+CREATE INDEX contact_changes_apply_at_idx ON contact_changes (apply_at);
+
+ALTER TABLE users ADD COLUMN email_verified_at DATETIME(6);
+ALTER TABLE users ADD COLUMN phone_verified_at DATETIME(6);
+
+UPDATE users SET email_verified_at = COALESCE(updated_at, created_at) WHERE email IS NOT NULL AND is_active = TRUE;
+UPDATE users SET phone_verified_at = COALESCE(updated_at, created_at) WHERE phone IS NOT NULL AND is_active = TRUE;
\ No newline at end of file
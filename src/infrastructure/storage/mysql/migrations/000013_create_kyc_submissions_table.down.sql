@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS kyc_documents;
+DROP TABLE IF EXISTS kyc_submissions;
+
+ALTER TABLE user_configs MODIFY kyc_level NUMERIC;
\ No newline at end of file
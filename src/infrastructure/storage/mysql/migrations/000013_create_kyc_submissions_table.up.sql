@@ -0,0 +1,28 @@
+UPDATE user_configs SET kyc_level = 0 WHERE kyc_level IS NULL;
+ALTER TABLE user_configs MODIFY kyc_level INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS kyc_submissions (
+    id VARCHAR(255) PRIMARY KEY NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    status VARCHAR(255) NOT NULL DEFAULT 'pending',
+    level INTEGER NOT NULL,
+    id_card VARCHAR(255),
+    reviewer_id VARCHAR(255),
+    review_comment TEXT,
+    reviewed_at DATETIME(6),
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX kyc_submissions_status_idx ON kyc_submissions (status, created_at);
+
+CREATE TABLE IF NOT EXISTS kyc_documents (
+    id VARCHAR(255) PRIMARY KEY NOT NULL,
+    submission_id VARCHAR(255) NOT NULL,
+    type VARCHAR(255) NOT NULL,
+    storage_key VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (submission_id) REFERENCES kyc_submissions (id) ON DELETE CASCADE
+);
\ No newline at end of file
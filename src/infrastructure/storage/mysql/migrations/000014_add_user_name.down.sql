@@ -0,0 +1,3 @@
+DROP INDEX users_user_name_lower_idx ON users;
+
+ALTER TABLE users DROP COLUMN user_name_changed_at;
\ No newline at end of file
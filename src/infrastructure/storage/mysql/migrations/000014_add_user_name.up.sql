@@ -0,0 +1,6 @@
+ALTER TABLE users ADD COLUMN user_name_changed_at DATETIME(6);
+
+UPDATE users SET user_name = NULL WHERE user_name = '';
+
+-- the unique indexes of mysql allow many nulls
+CREATE UNIQUE INDEX users_user_name_lower_idx ON users ((LOWER(user_name)));
\ No newline at end of file
@@ -0,0 +1,14 @@
+DROP INDEX tokens_tenant_target_idx ON tokens;
+DROP INDEX users_tenant_user_name_lower_idx ON users;
+DROP INDEX users_tenant_phone_idx ON users;
+DROP INDEX users_tenant_email_idx ON users;
+
+CREATE UNIQUE INDEX users_user_name_lower_idx ON users ((LOWER(user_name)));
+CREATE UNIQUE INDEX users_phone_key ON users (phone);
+CREATE UNIQUE INDEX users_email_key ON users (email);
+
+ALTER TABLE tokens DROP FOREIGN KEY tokens_tenant_id_fkey, DROP COLUMN tenant_id;
+ALTER TABLE sessions DROP FOREIGN KEY sessions_tenant_id_fkey, DROP COLUMN tenant_id;
+ALTER TABLE users DROP FOREIGN KEY users_tenant_id_fkey, DROP COLUMN tenant_id;
+
+DROP TABLE IF EXISTS tenants;
\ No newline at end of file
@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS tenants (
+    id VARCHAR(255) PRIMARY KEY NOT NULL,
+    name VARCHAR(255),
+    hosts JSON NOT NULL DEFAULT (JSON_ARRAY()),
+    logo_url TEXT,
+    primary_color VARCHAR(255),
+    support_email VARCHAR(255),
+    front_host VARCHAR(255),
+    email_from_name VARCHAR(255),
+    email_from_address VARCHAR(255),
+    sms_from VARCHAR(255),
+    jwt_issuer VARCHAR(255),
+    jwt_audience VARCHAR(255),
+    two_factor_auth_required BOOLEAN NOT NULL DEFAULT FALSE,
+    two_factor_auth_types JSON NOT NULL DEFAULT (JSON_ARRAY()),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    updated_at DATETIME(6)
+);
+
+INSERT IGNORE INTO tenants (id) VALUES ('default');
+
+ALTER TABLE users ADD COLUMN tenant_id VARCHAR(255) NOT NULL DEFAULT 'default',
+    ADD CONSTRAINT users_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES tenants (id);
+ALTER TABLE sessions ADD COLUMN tenant_id VARCHAR(255) NOT NULL DEFAULT 'default',
+    ADD CONSTRAINT sessions_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES tenants (id);
+ALTER TABLE tokens ADD COLUMN tenant_id VARCHAR(255) NOT NULL DEFAULT 'default',
+    ADD CONSTRAINT tokens_tenant_id_fkey FOREIGN KEY (tenant_id) REFERENCES tenants (id);
+
+DROP INDEX users_email_key ON users;
+DROP INDEX users_phone_key ON users;
+DROP INDEX users_user_name_lower_idx ON users;
+
+CREATE UNIQUE INDEX users_tenant_email_idx ON users (tenant_id, email);
+CREATE UNIQUE INDEX users_tenant_phone_idx ON users (tenant_id, phone);
+CREATE UNIQUE INDEX users_tenant_user_name_lower_idx ON users (tenant_id, (LOWER(user_name)));
+
+CREATE INDEX tokens_tenant_target_idx ON tokens (tenant_id, target);
\ No newline at end of file
@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id VARCHAR(255) PRIMARY KEY NOT NULL,
+    tenant_id VARCHAR(255) NOT NULL DEFAULT 'default',
+    user_id VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(255) NOT NULL,
+    key_hash VARCHAR(255) UNIQUE NOT NULL,
+    scopes JSON NOT NULL DEFAULT (JSON_ARRAY()),
+    expires_at DATETIME(6) NOT NULL,
+    last_used_at DATETIME(6),
+    last_used_ip VARCHAR(255),
+    revoked_at DATETIME(6),
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (tenant_id) REFERENCES tenants (id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX api_keys_user_id_idx ON api_keys (user_id);
+
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id VARCHAR(255) PRIMARY KEY NOT NULL,
+    tenant_id VARCHAR(255) NOT NULL DEFAULT 'default',
+    user_id VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    secret_hash VARCHAR(255) NOT NULL,
+    scopes JSON NOT NULL DEFAULT (JSON_ARRAY()),
+    last_used_at DATETIME(6),
+    revoked_at DATETIME(6),
+    created_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    FOREIGN KEY (tenant_id) REFERENCES tenants (id),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX oauth_clients_user_id_idx ON oauth_clients (user_id);
\ No newline at end of file
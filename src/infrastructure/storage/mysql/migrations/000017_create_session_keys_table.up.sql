@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS session_keys (
+    id VARCHAR(255) PRIMARY KEY NOT NULL,
+    value VARCHAR(255) NOT NULL,
+    expires_at DATETIME(6) NOT NULL
+);
+
+CREATE INDEX session_keys_expires_at_idx ON session_keys (expires_at);
\ No newline at end of file
@@ -0,0 +1,4 @@
+DROP INDEX session_keys_session_id_idx ON session_keys;
+DROP INDEX session_keys_user_id_idx ON session_keys;
+ALTER TABLE session_keys DROP COLUMN session_id;
+ALTER TABLE session_keys DROP COLUMN user_id;
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE session_keys ADD COLUMN user_id VARCHAR(255);
+ALTER TABLE session_keys ADD COLUMN session_id VARCHAR(255);
+
+CREATE INDEX session_keys_user_id_idx ON session_keys (user_id);
+CREATE INDEX session_keys_session_id_idx ON session_keys (session_id);
\ No newline at end of file
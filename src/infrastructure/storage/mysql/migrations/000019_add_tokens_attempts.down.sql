@@ -0,0 +1 @@
+ALTER TABLE tokens DROP COLUMN attempts;
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id VARCHAR PRIMARY KEY UNIQUE NOT NULL,
+    user_id VARCHAR NOT NULL,
+    code_hash VARCHAR NOT NULL,
+    is_used BOOLEAN NOT NULL DEFAULT FALSE,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1 @@
+ALTER TABLE users DROP COLUMN IF EXISTS default_2fa_type;
\ No newline at end of file
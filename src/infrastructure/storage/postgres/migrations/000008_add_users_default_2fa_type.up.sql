@@ -0,0 +1 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS default_2fa_type VARCHAR;
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS trusted_devices (
+    id VARCHAR PRIMARY KEY UNIQUE NOT NULL,
+    user_id VARCHAR NOT NULL,
+    user_agent VARCHAR,
+    client_ip VARCHAR,
+    expires_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
\ No newline at end of file
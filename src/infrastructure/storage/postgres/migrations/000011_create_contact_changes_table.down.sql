@@ -0,0 +1,4 @@
+ALTER TABLE users DROP COLUMN IF EXISTS phone_verified_at;
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
+
+DROP TABLE IF EXISTS contact_changes;
\ No newline at end of file
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS contact_changes (
+    id VARCHAR PRIMARY KEY UNIQUE NOT NULL,
+    user_id VARCHAR NOT NULL,
+    type VARCHAR NOT NULL,
+    old_value VARCHAR,
+    new_value VARCHAR NOT NULL,
+    user_agent VARCHAR,
+    client_ip VARCHAR,
+    apply_at TIMESTAMPTZ NOT NULL,
+    applied_at TIMESTAMPTZ,
+    canceled_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS contact_changes_apply_at_idx ON contact_changes (apply_at)
+    WHERE applied_at IS NULL AND canceled_at IS NULL;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_verified_at TIMESTAMPTZ;
+
+UPDATE users SET email_verified_at = COALESCE(updated_at, created_at) WHERE email IS NOT NULL AND is_active = TRUE;
+UPDATE users SET phone_verified_at = COALESCE(updated_at, created_at) WHERE phone IS NOT NULL AND is_active = TRUE;
\ No newline at end of file
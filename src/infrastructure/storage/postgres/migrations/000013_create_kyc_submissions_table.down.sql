@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS kyc_documents;
+DROP TABLE IF EXISTS kyc_submissions;
+
+ALTER TABLE user_configs ALTER COLUMN kyc_level DROP NOT NULL;
+ALTER TABLE user_configs ALTER COLUMN kyc_level DROP DEFAULT;
+ALTER TABLE user_configs ALTER COLUMN kyc_level TYPE NUMERIC;
\ No newline at end of file
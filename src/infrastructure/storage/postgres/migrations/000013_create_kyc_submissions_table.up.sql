@@ -0,0 +1,29 @@
+ALTER TABLE user_configs ALTER COLUMN kyc_level TYPE INTEGER USING COALESCE(kyc_level, 0)::INTEGER;
+ALTER TABLE user_configs ALTER COLUMN kyc_level SET DEFAULT 0;
+ALTER TABLE user_configs ALTER COLUMN kyc_level SET NOT NULL;
+
+CREATE TABLE IF NOT EXISTS kyc_submissions (
+    id VARCHAR PRIMARY KEY UNIQUE NOT NULL,
+    user_id VARCHAR NOT NULL,
+    status VARCHAR NOT NULL DEFAULT 'pending',
+    level INTEGER NOT NULL,
+    id_card VARCHAR,
+    reviewer_id VARCHAR,
+    review_comment VARCHAR,
+    reviewed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS kyc_submissions_status_idx ON kyc_submissions (status, created_at);
+
+CREATE TABLE IF NOT EXISTS kyc_documents (
+    id VARCHAR PRIMARY KEY UNIQUE NOT NULL,
+    submission_id VARCHAR NOT NULL,
+    type VARCHAR NOT NULL,
+    storage_key VARCHAR NOT NULL,
+    content_type VARCHAR NOT NULL,
+    size BIGINT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (submission_id) REFERENCES kyc_submissions (id) ON DELETE CASCADE
+);
\ No newline at end of file
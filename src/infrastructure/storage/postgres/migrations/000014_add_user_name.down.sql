@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS users_user_name_lower_idx;
+
+ALTER TABLE users DROP COLUMN IF EXISTS user_name_changed_at;
\ No newline at end of file
@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS tokens_tenant_target_idx;
+DROP INDEX IF EXISTS users_tenant_user_name_lower_idx;
+DROP INDEX IF EXISTS users_tenant_phone_idx;
+DROP INDEX IF EXISTS users_tenant_email_idx;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_user_name_lower_idx ON users (LOWER(user_name)) WHERE user_name IS NOT NULL;
+ALTER TABLE users ADD CONSTRAINT users_phone_key UNIQUE (phone);
+ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);
+
+ALTER TABLE tokens DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE sessions DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
+
+DROP TABLE IF EXISTS tenants;
\ No newline at end of file
@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS tenants (
+    id VARCHAR PRIMARY KEY UNIQUE NOT NULL,
+    name VARCHAR,
+    hosts VARCHAR[] NOT NULL DEFAULT '{}',
+    logo_url VARCHAR,
+    primary_color VARCHAR,
+    support_email VARCHAR,
+    front_host VARCHAR,
+    email_from_name VARCHAR,
+    email_from_address VARCHAR,
+    sms_from VARCHAR,
+    jwt_issuer VARCHAR,
+    jwt_audience VARCHAR,
+    two_factor_auth_required BOOLEAN NOT NULL DEFAULT FALSE,
+    two_factor_auth_types VARCHAR[] NOT NULL DEFAULT '{}',
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    updated_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS tenants_hosts_idx ON tenants USING GIN (hosts);
+
+INSERT INTO tenants (id) VALUES ('default') ON CONFLICT DO NOTHING;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id VARCHAR NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS tenant_id VARCHAR NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+ALTER TABLE tokens ADD COLUMN IF NOT EXISTS tenant_id VARCHAR NOT NULL DEFAULT 'default' REFERENCES tenants (id);
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_email_key;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_phone_key;
+DROP INDEX IF EXISTS users_user_name_lower_idx;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_tenant_email_idx ON users (tenant_id, email);
+CREATE UNIQUE INDEX IF NOT EXISTS users_tenant_phone_idx ON users (tenant_id, phone);
+CREATE UNIQUE INDEX IF NOT EXISTS users_tenant_user_name_lower_idx ON users (tenant_id, LOWER(user_name)) WHERE user_name IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS tokens_tenant_target_idx ON tokens (tenant_id, target);
\ No newline at end of file
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS oauth_clients;
+DROP TABLE IF EXISTS api_keys;
\ No newline at end of file
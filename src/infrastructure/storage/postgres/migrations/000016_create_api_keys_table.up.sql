@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id VARCHAR PRIMARY KEY UNIQUE NOT NULL,
+    tenant_id VARCHAR NOT NULL DEFAULT 'default' REFERENCES tenants (id),
+    user_id VARCHAR NOT NULL,
+    name VARCHAR NOT NULL,
+    prefix VARCHAR NOT NULL,
+    key_hash VARCHAR UNIQUE NOT NULL,
+    scopes VARCHAR[] NOT NULL DEFAULT '{}',
+    expires_at TIMESTAMPTZ NOT NULL,
+    last_used_at TIMESTAMPTZ,
+    last_used_ip VARCHAR,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (user_id);
+
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id VARCHAR PRIMARY KEY UNIQUE NOT NULL,
+    tenant_id VARCHAR NOT NULL DEFAULT 'default' REFERENCES tenants (id),
+    user_id VARCHAR NOT NULL,
+    name VARCHAR NOT NULL,
+    secret_hash VARCHAR NOT NULL,
+    scopes VARCHAR[] NOT NULL DEFAULT '{}',
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS oauth_clients_user_id_idx ON oauth_clients (user_id);
\ No newline at end of file
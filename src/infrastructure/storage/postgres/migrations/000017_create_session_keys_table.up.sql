@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS session_keys (
+    id VARCHAR PRIMARY KEY UNIQUE NOT NULL,
+    value VARCHAR NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS session_keys_expires_at_idx ON session_keys (expires_at);
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE session_keys ADD COLUMN IF NOT EXISTS user_id VARCHAR;
+ALTER TABLE session_keys ADD COLUMN IF NOT EXISTS session_id VARCHAR;
+
+CREATE INDEX IF NOT EXISTS session_keys_user_id_idx ON session_keys (user_id) WHERE user_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS session_keys_session_id_idx ON session_keys (session_id) WHERE session_id IS NOT NULL;
\ No newline at end of file
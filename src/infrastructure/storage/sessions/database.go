@@ -0,0 +1,300 @@
+package sessions
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/infrastructure/storage"
+	"context"
+	"database/sql"
+	"github.com/uptrace/bun"
+	"time"
+)
+
+// sessionKey is the row of the key of the database store, the keys of the session tokens
+// are indexed by the user and the session, the other keys have neither
+type sessionKey struct {
+	bun.BaseModel `bun:"table:session_keys,alias:sk"`
+
+	ID        string    `bun:"id,pk"`
+	Value     string    `bun:"value"`
+	UserID    string    `bun:"user_id,nullzero"`
+	SessionID string    `bun:"session_id,nullzero"`
+	ExpiresAt time.Time `bun:"expires_at"`
+}
+
+// liveSession is the session of the index, it expires with its refresh token
+type liveSession struct {
+	SessionID string    `bun:"session_id"`
+	ExpiresAt time.Time `bun:"expires_at"`
+}
+
+type databaseStore struct {
+	db *bun.DB
+}
+
+// DatabaseStore keeps the keys in the database, so the service runs without redis,
+// the expired rows are skipped by the reads and deleted by PurgeExpired
+type DatabaseStore interface {
+	Store
+	PurgeExpired(ctx context.Context) error
+}
+
+func NewDatabaseStore(db *bun.DB) DatabaseStore {
+	return &databaseStore{db}
+}
+
+func (ds *databaseStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+
+	return setKeys(ctx, ds.db, sessionKey{
+		ID:        key,
+		Value:     value,
+		ExpiresAt: time.Now().UTC().Add(ttl),
+	})
+}
+
+func (ds *databaseStore) Get(ctx context.Context, key string) (string, error) {
+
+	row := new(sessionKey)
+	err := ds.db.NewSelect().Model(row).
+		Where("id = ?", key).
+		Where("expires_at > ?", time.Now().UTC()).
+		Scan(ctx)
+	if err == sql.ErrNoRows {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return row.Value, nil
+}
+
+func (ds *databaseStore) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	_, err := ds.db.NewDelete().Model((*sessionKey)(nil)).
+		Where("id IN (?)", bun.In(keys)).
+		Exec(ctx)
+	return err
+}
+
+// SetSession stores the rows of both tokens in one transaction, the index is the user and the session of the rows
+func (ds *databaseStore) SetSession(ctx context.Context, ses *Session, maxSessions int) ([]string, error) {
+
+	revoked := make([]string, 0)
+	err := ds.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		err := setKeys(ctx, tx,
+			sessionKey{
+				ID:        ses.SessionID,
+				Value:     ses.AtID,
+				UserID:    ses.UserID,
+				SessionID: ses.SessionID,
+				ExpiresAt: ses.AtExpires.UTC(),
+			},
+			sessionKey{
+				ID:        ses.SessionID + model.PostfixRefreshToken,
+				Value:     ses.RtID,
+				UserID:    ses.UserID,
+				SessionID: ses.SessionID,
+				ExpiresAt: ses.RtExpires.UTC(),
+			})
+		if err != nil {
+			return err
+		}
+
+		if maxSessions <= 0 {
+			return nil
+		}
+
+		sessionIDs, err := listSessions(ctx, tx, ses.UserID)
+		if err != nil {
+			return err
+		}
+		if len(sessionIDs) <= maxSessions {
+			return nil
+		}
+
+		revoked = sessionIDs[:len(sessionIDs)-maxSessions]
+		_, err = tx.NewDelete().Model((*sessionKey)(nil)).
+			Where("session_id IN (?)", bun.In(revoked)).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return revoked, nil
+}
+
+// TakeRefreshToken deletes the row of the refresh token only if it is still there,
+// so of the concurrent calls the one which deleted the row gets the id
+func (ds *databaseStore) TakeRefreshToken(ctx context.Context, usrID, sessionID string) (string, error) {
+
+	var value string
+	err := ds.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		row := new(sessionKey)
+		err := tx.NewSelect().Model(row).
+			Where("id = ?", sessionID+model.PostfixRefreshToken).
+			Where("expires_at > ?", time.Now().UTC()).
+			Scan(ctx)
+		if err == sql.ErrNoRows {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.NewDelete().Model((*sessionKey)(nil)).
+			Where("id = ?", row.ID).
+			Where("value = ?", row.Value).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return ErrNotFound
+		}
+
+		_, err = tx.NewDelete().Model((*sessionKey)(nil)).
+			Where("id = ?", sessionID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		value = row.Value
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return value, nil
+}
+
+func (ds *databaseStore) RevokeSession(ctx context.Context, usrID, sessionID string) error {
+	return ds.Delete(ctx, sessionKeys(sessionID)...)
+}
+
+func (ds *databaseStore) ListSessions(ctx context.Context, usrID string) ([]string, error) {
+	return listSessions(ctx, ds.db, usrID)
+}
+
+func (ds *databaseStore) RevokeAll(ctx context.Context, usrID string) error {
+
+	_, err := ds.db.NewDelete().Model((*sessionKey)(nil)).
+		Where("user_id = ?", usrID).
+		Exec(ctx)
+	return err
+}
+
+// ReconcileSessions indexes the rows of the given sessions which were stored without the user,
+// the rows of the index are deleted with the tokens, so they do not outlive them
+func (ds *databaseStore) ReconcileSessions(ctx context.Context, usrID string,
+	expiresAt map[string]time.Time) ([]string, error) {
+
+	if len(expiresAt) == 0 {
+		return []string{}, nil
+	}
+
+	rtKeys := make([]string, 0, len(expiresAt))
+	for sessionID := range expiresAt {
+		rtKeys = append(rtKeys, sessionID+model.PostfixRefreshToken)
+	}
+
+	missing := make([]string, 0)
+	err := ds.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		rows := make([]sessionKey, 0, len(rtKeys))
+		err := tx.NewSelect().Model(&rows).
+			Where("id IN (?)", bun.In(rtKeys)).
+			Where("expires_at > ?", time.Now().UTC()).
+			Scan(ctx)
+		if err != nil {
+			return err
+		}
+
+		kept := make(map[string]bool, len(rows))
+		for _, row := range rows {
+			sessionID := row.ID[:len(row.ID)-len(model.PostfixRefreshToken)]
+			kept[sessionID] = true
+
+			if row.UserID != "" {
+				continue
+			}
+			_, err = tx.NewUpdate().Model((*sessionKey)(nil)).
+				Where("id IN (?)", bun.In(sessionKeys(sessionID))).
+				Set("user_id = ?", usrID).
+				Set("session_id = ?", sessionID).
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+		}
+
+		for sessionID := range expiresAt {
+			if !kept[sessionID] {
+				missing = append(missing, sessionID)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return missing, nil
+}
+
+// PurgeExpired deletes the rows of the expired keys
+func (ds *databaseStore) PurgeExpired(ctx context.Context) error {
+
+	_, err := ds.db.NewDelete().Model((*sessionKey)(nil)).
+		Where("expires_at <= ?", time.Now().UTC()).
+		Exec(ctx)
+	return err
+}
+
+// setKeys inserts the rows of the keys or updates the existing ones
+func setKeys(ctx context.Context, db bun.IDB, rows ...sessionKey) error {
+
+	_, err := db.NewInsert().Model(&rows).
+		On(storage.OnConflictUpdate(db, "id")).
+		Set("value = ?", storage.InsertedValue(db, "value")).
+		Set("user_id = ?", storage.InsertedValue(db, "user_id")).
+		Set("session_id = ?", storage.InsertedValue(db, "session_id")).
+		Set("expires_at = ?", storage.InsertedValue(db, "expires_at")).
+		Exec(ctx)
+	return err
+}
+
+// listSessions returns the sessions of the user with the unexpired refresh token ordered by its expiration,
+// the row of the refresh token is the one whose key is not the session id
+func listSessions(ctx context.Context, db bun.IDB, usrID string) ([]string, error) {
+
+	sessions := make([]liveSession, 0)
+	err := db.NewSelect().Model((*sessionKey)(nil)).
+		Column("session_id", "expires_at").
+		Where("user_id = ?", usrID).
+		Where("id <> session_id").
+		Where("expires_at > ?", time.Now().UTC()).
+		OrderExpr("expires_at").
+		Scan(ctx, &sessions)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionIDs := make([]string, 0, len(sessions))
+	for _, ses := range sessions {
+		sessionIDs = append(sessionIDs, ses.SessionID)
+	}
+
+	return sessionIDs, nil
+}
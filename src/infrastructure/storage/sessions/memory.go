@@ -0,0 +1,220 @@
+package sessions
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+type memoryStore struct {
+	sync.Mutex
+	entries map[string]memoryEntry
+	// index holds the expiration of the refresh tokens of the sessions of each user
+	index map[string]map[string]time.Time
+}
+
+// NewMemoryStore keeps the keys in the memory of the process, it is meant for the tests and the local runs,
+// since the sessions are lost on restart and are not shared between the instances
+func NewMemoryStore() Store {
+	return &memoryStore{
+		entries: make(map[string]memoryEntry),
+		index:   make(map[string]map[string]time.Time),
+	}
+}
+
+func (ms *memoryStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+
+	ms.Lock()
+	defer ms.Unlock()
+
+	ms.purge()
+	ms.entries[key] = memoryEntry{value: value, expiresAt: time.Now().Add(ttl)}
+
+	return nil
+}
+
+func (ms *memoryStore) Get(ctx context.Context, key string) (string, error) {
+
+	ms.Lock()
+	defer ms.Unlock()
+
+	return ms.get(key)
+}
+
+func (ms *memoryStore) Delete(ctx context.Context, keys ...string) error {
+
+	ms.Lock()
+	defer ms.Unlock()
+
+	ms.delete(keys...)
+
+	return nil
+}
+
+func (ms *memoryStore) SetSession(ctx context.Context, ses *Session, maxSessions int) ([]string, error) {
+
+	ms.Lock()
+	defer ms.Unlock()
+
+	ms.purge()
+	ms.entries[ses.SessionID] = memoryEntry{value: ses.AtID, expiresAt: ses.AtExpires}
+	ms.entries[ses.SessionID+model.PostfixRefreshToken] = memoryEntry{value: ses.RtID, expiresAt: ses.RtExpires}
+
+	userSessions, ok := ms.index[ses.UserID]
+	if !ok {
+		userSessions = make(map[string]time.Time)
+		ms.index[ses.UserID] = userSessions
+	}
+	userSessions[ses.SessionID] = ses.RtExpires
+
+	revoked := make([]string, 0)
+	sessionIDs := ms.list(ses.UserID)
+	if maxSessions > 0 && len(sessionIDs) > maxSessions {
+		revoked = sessionIDs[:len(sessionIDs)-maxSessions]
+		for _, sessionID := range revoked {
+			ms.delete(sessionKeys(sessionID)...)
+			delete(userSessions, sessionID)
+		}
+	}
+
+	return revoked, nil
+}
+
+func (ms *memoryStore) TakeRefreshToken(ctx context.Context, usrID, sessionID string) (string, error) {
+
+	ms.Lock()
+	defer ms.Unlock()
+
+	delete(ms.index[usrID], sessionID)
+
+	val, err := ms.get(sessionID + model.PostfixRefreshToken)
+	if err != nil {
+		return "", err
+	}
+
+	ms.delete(sessionKeys(sessionID)...)
+
+	return val, nil
+}
+
+func (ms *memoryStore) RevokeSession(ctx context.Context, usrID, sessionID string) error {
+
+	ms.Lock()
+	defer ms.Unlock()
+
+	ms.delete(sessionKeys(sessionID)...)
+	delete(ms.index[usrID], sessionID)
+
+	return nil
+}
+
+func (ms *memoryStore) ListSessions(ctx context.Context, usrID string) ([]string, error) {
+
+	ms.Lock()
+	defer ms.Unlock()
+
+	return ms.list(usrID), nil
+}
+
+func (ms *memoryStore) RevokeAll(ctx context.Context, usrID string) error {
+
+	ms.Lock()
+	defer ms.Unlock()
+
+	for sessionID := range ms.index[usrID] {
+		ms.delete(sessionKeys(sessionID)...)
+	}
+	delete(ms.index, usrID)
+
+	return nil
+}
+
+func (ms *memoryStore) ReconcileSessions(ctx context.Context, usrID string,
+	expiresAt map[string]time.Time) ([]string, error) {
+
+	ms.Lock()
+	defer ms.Unlock()
+
+	userSessions, ok := ms.index[usrID]
+	if !ok {
+		userSessions = make(map[string]time.Time)
+		ms.index[usrID] = userSessions
+	}
+
+	missing := make([]string, 0)
+	for sessionID, sessionExpiresAt := range expiresAt {
+		_, err := ms.get(sessionID + model.PostfixRefreshToken)
+		if err != nil {
+			missing = append(missing, sessionID)
+			continue
+		}
+		if _, ok := userSessions[sessionID]; !ok {
+			userSessions[sessionID] = sessionExpiresAt
+		}
+	}
+
+	for sessionID := range userSessions {
+		_, err := ms.get(sessionID + model.PostfixRefreshToken)
+		if err != nil {
+			delete(userSessions, sessionID)
+		}
+	}
+
+	return missing, nil
+}
+
+// the helpers below are called with the lock held
+
+func (ms *memoryStore) get(key string) (string, error) {
+
+	entry, ok := ms.entries[key]
+	if !ok || !time.Now().Before(entry.expiresAt) {
+		return "", ErrNotFound
+	}
+
+	return entry.value, nil
+}
+
+func (ms *memoryStore) delete(keys ...string) {
+	for _, key := range keys {
+		delete(ms.entries, key)
+	}
+}
+
+// list returns the unexpired sessions of the index of the user ordered by the expiration
+func (ms *memoryStore) list(usrID string) []string {
+
+	now := time.Now()
+	userSessions := ms.index[usrID]
+	sessionIDs := make([]string, 0, len(userSessions))
+	for sessionID, expiresAt := range userSessions {
+		if !now.Before(expiresAt) {
+			delete(userSessions, sessionID)
+			continue
+		}
+		sessionIDs = append(sessionIDs, sessionID)
+	}
+
+	sort.Slice(sessionIDs, func(i, j int) bool {
+		return userSessions[sessionIDs[i]].Before(userSessions[sessionIDs[j]])
+	})
+
+	return sessionIDs
+}
+
+// purge drops the expired entries
+func (ms *memoryStore) purge() {
+	now := time.Now()
+	for key, entry := range ms.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(ms.entries, key)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package sessions
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+	"github.com/go-redis/redis/v8"
+	"strconv"
+	"time"
+)
+
+// the index of the user is the sorted set of the session ids scored by the expiration of the refresh tokens,
+// the scripts run atomically and touch the keys of the indexed sessions, which are derived from their ids,
+// so the store needs a single redis instance, not a cluster
+
+// setSessionScript stores the token pair, indexes the session and revokes the sessions over the limit.
+// KEYS: index, access token key, refresh token key.
+// ARGV: session id, access token id, its ttl in ms, refresh token id, its ttl in ms, index score, now, limit
+var setSessionScript = redis.NewScript(`
+redis.call('SET', KEYS[2], ARGV[2], 'PX', ARGV[3])
+redis.call('SET', KEYS[3], ARGV[4], 'PX', ARGV[5])
+redis.call('ZADD', KEYS[1], ARGV[6], ARGV[1])
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[7])
+
+local revoked = {}
+local limit = tonumber(ARGV[8])
+local excess = redis.call('ZCARD', KEYS[1]) - limit
+if limit > 0 and excess > 0 then
+	revoked = redis.call('ZRANGE', KEYS[1], 0, excess - 1)
+	for _, id in ipairs(revoked) do
+		redis.call('DEL', id, id .. '` + model.PostfixRefreshToken + `')
+	end
+	redis.call('ZREMRANGEBYRANK', KEYS[1], 0, excess - 1)
+end
+
+local last = redis.call('ZRANGE', KEYS[1], -1, -1, 'WITHSCORES')
+redis.call('EXPIREAT', KEYS[1], last[2])
+return revoked
+`)
+
+// takeRefreshTokenScript returns the refresh token id and revokes the session.
+// KEYS: index, refresh token key, access token key. ARGV: session id
+var takeRefreshTokenScript = redis.NewScript(`
+redis.call('ZREM', KEYS[1], ARGV[1])
+local value = redis.call('GET', KEYS[2])
+if not value then
+	return false
+end
+redis.call('DEL', KEYS[2], KEYS[3])
+return value
+`)
+
+// revokeAllScript deletes the keys of the indexed sessions and the index. KEYS: index
+var revokeAllScript = redis.NewScript(`
+local ids = redis.call('ZRANGE', KEYS[1], 0, -1)
+for _, id in ipairs(ids) do
+	redis.call('DEL', id, id .. '` + model.PostfixRefreshToken + `')
+end
+redis.call('DEL', KEYS[1])
+return #ids
+`)
+
+// reconcileScript indexes the given sessions which have the refresh token, drops the indexed sessions
+// without it and returns the given sessions without it. KEYS: index. ARGV: now, pairs of session id and score
+var reconcileScript = redis.NewScript(`
+local missing = {}
+for i = 2, #ARGV, 2 do
+	if redis.call('EXISTS', ARGV[i] .. '` + model.PostfixRefreshToken + `') == 1 then
+		redis.call('ZADD', KEYS[1], 'NX', ARGV[i + 1], ARGV[i])
+	else
+		table.insert(missing, ARGV[i])
+	end
+end
+
+for _, id in ipairs(redis.call('ZRANGE', KEYS[1], 0, -1)) do
+	if redis.call('EXISTS', id .. '` + model.PostfixRefreshToken + `') == 0 then
+		redis.call('ZREM', KEYS[1], id)
+	end
+end
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', ARGV[1])
+
+local last = redis.call('ZRANGE', KEYS[1], -1, -1, 'WITHSCORES')
+if #last > 0 then
+	redis.call('EXPIREAT', KEYS[1], last[2])
+end
+return missing
+`)
+
+type redisStore struct {
+	rdb *redis.Client
+}
+
+// NewRedisStore keeps the keys in redis, which expires them by the ttl
+func NewRedisStore(rdb *redis.Client) Store {
+	return &redisStore{rdb}
+}
+
+func (rs *redisStore) Set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return rs.rdb.Set(ctx, key, value, ttl).Err()
+}
+
+func (rs *redisStore) Get(ctx context.Context, key string) (string, error) {
+
+	val, err := rs.rdb.Get(ctx, key).Result()
+	if err == redis.Nil {
+		return "", ErrNotFound
+	}
+
+	return val, err
+}
+
+func (rs *redisStore) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	return rs.rdb.Del(ctx, keys...).Err()
+}
+
+// SetSession runs in one round-trip
+func (rs *redisStore) SetSession(ctx context.Context, ses *Session, maxSessions int) ([]string, error) {
+
+	now := time.Now().UTC()
+	return rs.runForIDs(ctx, setSessionScript,
+		[]string{model.PrefixUserSessions + ses.UserID, ses.SessionID, ses.SessionID + model.PostfixRefreshToken},
+		ses.SessionID,
+		ses.AtID, ses.AtExpires.Sub(now).Milliseconds(),
+		ses.RtID, ses.RtExpires.Sub(now).Milliseconds(),
+		ses.RtExpires.Unix(), now.Unix(), maxSessions)
+}
+
+func (rs *redisStore) TakeRefreshToken(ctx context.Context, usrID, sessionID string) (string, error) {
+
+	val, err := takeRefreshTokenScript.Run(ctx, rs.rdb,
+		[]string{model.PrefixUserSessions + usrID, sessionID + model.PostfixRefreshToken, sessionID},
+		sessionID).Text()
+	if err == redis.Nil {
+		return "", ErrNotFound
+	}
+
+	return val, err
+}
+
+// RevokeSession deletes the keys and removes the session from the index in one MULTI/EXEC round-trip
+func (rs *redisStore) RevokeSession(ctx context.Context, usrID, sessionID string) error {
+
+	_, err := rs.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, sessionKeys(sessionID)...)
+		pipe.ZRem(ctx, model.PrefixUserSessions+usrID, sessionID)
+		return nil
+	})
+
+	return err
+}
+
+func (rs *redisStore) ListSessions(ctx context.Context, usrID string) ([]string, error) {
+
+	return rs.rdb.ZRangeByScore(ctx, model.PrefixUserSessions+usrID, &redis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(time.Now().UTC().Unix(), 10),
+		Max: "+inf",
+	}).Result()
+}
+
+// RevokeAll runs in one round-trip
+func (rs *redisStore) RevokeAll(ctx context.Context, usrID string) error {
+	return revokeAllScript.Run(ctx, rs.rdb, []string{model.PrefixUserSessions + usrID}).Err()
+}
+
+func (rs *redisStore) ReconcileSessions(ctx context.Context, usrID string,
+	expiresAt map[string]time.Time) ([]string, error) {
+
+	args := make([]interface{}, 0, len(expiresAt)*2+1)
+	args = append(args, time.Now().UTC().Unix())
+	for sessionID, sessionExpiresAt := range expiresAt {
+		args = append(args, sessionID, sessionExpiresAt.Unix())
+	}
+
+	return rs.runForIDs(ctx, reconcileScript, []string{model.PrefixUserSessions + usrID}, args...)
+}
+
+// runForIDs runs the script which returns the list of the session ids
+func (rs *redisStore) runForIDs(ctx context.Context, script *redis.Script, keys []string,
+	args ...interface{}) ([]string, error) {
+
+	res, err := script.Run(ctx, rs.rdb, keys, args...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	values, _ := res.([]interface{})
+	ids := make([]string, 0, len(values))
+	for _, value := range values {
+		id, ok := value.(string)
+		if ok {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,63 @@
+package sessions
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	DriverRedis    = "redis"
+	DriverDatabase = "database"
+	DriverMemory   = "memory"
+)
+
+// ErrNotFound the key does not exist in the store or it has expired
+var ErrNotFound = errors.New("session key not found")
+
+// Session is the token pair of the session kept by the store, the session is live while its refresh token is kept
+type Session struct {
+	UserID    string
+	SessionID string
+
+	AtID      string
+	AtExpires time.Time
+	RtID      string
+	RtExpires time.Time
+}
+
+// Store keeps the ids of the tokens of the sessions, the token is valid while its id is kept under the key
+// of the session, the keys are deleted by the store when their ttl has passed.
+// The live sessions of each user are indexed, so they are listed and revoked without the sessions table
+type Store interface {
+	Set(ctx context.Context, key, value string, ttl time.Duration) error
+	Get(ctx context.Context, key string) (string, error)
+	Delete(ctx context.Context, keys ...string) error
+
+	// SetSession stores the token pair and adds the session to the index of the user at once,
+	// if the user has more than maxSessions live sessions (0 is no limit), the ones refreshed the longest ago
+	// are revoked and returned
+	SetSession(ctx context.Context, ses *Session, maxSessions int) ([]string, error)
+	// TakeRefreshToken returns the refresh token id and revokes the session at once,
+	// so the id is taken by one caller only
+	TakeRefreshToken(ctx context.Context, usrID, sessionID string) (string, error)
+	RevokeSession(ctx context.Context, usrID, sessionID string) error
+	// ListSessions returns the ids of the live sessions of the user, the ones refreshed the longest ago first
+	ListSessions(ctx context.Context, usrID string) ([]string, error)
+	RevokeAll(ctx context.Context, usrID string) error
+
+	// ReconcileSessions brings the index of the user in line with the given live sessions of the database
+	// and their expiration: the ones whose tokens are kept are added to the index, the sessions of the index
+	// without the tokens are removed from it. The given sessions without the tokens are returned
+	ReconcileSessions(ctx context.Context, usrID string, expiresAt map[string]time.Time) ([]string, error)
+}
+
+// sessionKeys returns the keys of the access and the refresh tokens of the sessions
+func sessionKeys(sessionIDs ...string) []string {
+	keys := make([]string, 0, len(sessionIDs)*2)
+	for _, sessionID := range sessionIDs {
+		keys = append(keys, sessionID, sessionID+model.PostfixRefreshToken)
+	}
+	return keys
+}
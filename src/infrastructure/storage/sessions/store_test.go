@@ -0,0 +1,276 @@
+package sessions_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"auth-project/src/domain/model"
+	"auth-project/src/infrastructure/storage/sessions"
+	"auth-project/src/infrastructure/storage/storagetest"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+)
+
+// storeCase opens the store, expire lets the time of the store pass
+type storeCase struct {
+	name   string
+	open   func(t *testing.T) sessions.Store
+	expire func(t *testing.T, d time.Duration)
+}
+
+func sleep(t *testing.T, d time.Duration) {
+	time.Sleep(d)
+}
+
+// storeCases are the stores of all drivers, they have to behave the same
+func storeCases() []storeCase {
+	var mr *miniredis.Miniredis
+
+	return []storeCase{
+		{
+			name: sessions.DriverMemory,
+			open: func(t *testing.T) sessions.Store {
+				return sessions.NewMemoryStore()
+			},
+			expire: sleep,
+		},
+		{
+			name: sessions.DriverRedis,
+			open: func(t *testing.T) sessions.Store {
+				mr = miniredis.RunT(t)
+				rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+				t.Cleanup(func() {
+					_ = rdb.Close()
+				})
+				return sessions.NewRedisStore(rdb)
+			},
+			expire: func(t *testing.T, d time.Duration) {
+				mr.FastForward(d)
+			},
+		},
+		{
+			name: sessions.DriverDatabase,
+			open: func(t *testing.T) sessions.Store {
+				return sessions.NewDatabaseStore(storagetest.NewSQLite(t))
+			},
+			expire: sleep,
+		},
+	}
+}
+
+func newSession(usrID, sessionID string, rtLifetime time.Duration) *sessions.Session {
+	now := time.Now().UTC()
+	return &sessions.Session{
+		UserID:    usrID,
+		SessionID: sessionID,
+		AtID:      sessionID + "-at",
+		AtExpires: now.Add(15 * time.Minute),
+		RtID:      sessionID + "-rt",
+		RtExpires: now.Add(rtLifetime),
+	}
+}
+
+func assertValue(t *testing.T, store sessions.Store, key, want string) {
+	t.Helper()
+
+	value, err := store.Get(context.Background(), key)
+	if want == "" {
+		if err != sessions.ErrNotFound {
+			t.Fatalf("key %s: got %q, %v, want ErrNotFound", key, value, err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("key %s: %s", key, err.Error())
+	}
+	if value != want {
+		t.Fatalf("key %s: got %q, want %q", key, value, want)
+	}
+}
+
+func assertSessions(t *testing.T, store sessions.Store, usrID string, want []string) {
+	t.Helper()
+
+	sessionIDs, err := store.ListSessions(context.Background(), usrID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessionIDs) == 0 && len(want) == 0 {
+		return
+	}
+	if !reflect.DeepEqual(sessionIDs, want) {
+		t.Fatalf("got sessions %v, want %v", sessionIDs, want)
+	}
+}
+
+func TestStoreKeys(t *testing.T) {
+	for _, sc := range storeCases() {
+		t.Run(sc.name, func(t *testing.T) {
+			store := sc.open(t)
+			ctx := context.Background()
+
+			err := store.Set(ctx, "key", "first", time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertValue(t, store, "key", "first")
+
+			// the existing key is replaced
+			err = store.Set(ctx, "key", "second", time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertValue(t, store, "key", "second")
+
+			err = store.Delete(ctx, "key", "unknown")
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertValue(t, store, "key", "")
+
+			err = store.Set(ctx, "short", "value", 100*time.Millisecond)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sc.expire(t, 200*time.Millisecond)
+			assertValue(t, store, "short", "")
+		})
+	}
+}
+
+func TestStoreSessions(t *testing.T) {
+	for _, sc := range storeCases() {
+		t.Run(sc.name, func(t *testing.T) {
+			store := sc.open(t)
+			ctx := context.Background()
+
+			for i, sessionID := range []string{"s1", "s2", "s3"} {
+				_, err := store.SetSession(ctx, newSession("u1", sessionID, time.Duration(i+1)*time.Hour), 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			_, err := store.SetSession(ctx, newSession("u2", "other", time.Hour), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			assertValue(t, store, "s1", "s1-at")
+			assertValue(t, store, "s1"+model.PostfixRefreshToken, "s1-rt")
+			assertSessions(t, store, "u1", []string{"s1", "s2", "s3"})
+
+			// the refresh replaces the token pair of the session and moves it to the end of the index
+			refreshed := newSession("u1", "s1", 4*time.Hour)
+			refreshed.AtID, refreshed.RtID = "s1-at2", "s1-rt2"
+			_, err = store.SetSession(ctx, refreshed, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertValue(t, store, "s1", "s1-at2")
+			assertSessions(t, store, "u1", []string{"s2", "s3", "s1"})
+
+			// the sessions over the limit refreshed the longest ago are revoked
+			revoked, err := store.SetSession(ctx, newSession("u1", "s4", 5*time.Hour), 3)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(revoked, []string{"s2"}) {
+				t.Fatalf("got revoked %v, want [s2]", revoked)
+			}
+			assertValue(t, store, "s2", "")
+			assertValue(t, store, "s2"+model.PostfixRefreshToken, "")
+			assertSessions(t, store, "u1", []string{"s3", "s1", "s4"})
+
+			err = store.RevokeSession(ctx, "u1", "s3")
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertValue(t, store, "s3", "")
+			assertSessions(t, store, "u1", []string{"s1", "s4"})
+
+			err = store.RevokeAll(ctx, "u1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertValue(t, store, "s1", "")
+			assertValue(t, store, "s4"+model.PostfixRefreshToken, "")
+			assertSessions(t, store, "u1", nil)
+
+			// the sessions of the other users are kept
+			assertValue(t, store, "other", "other-at")
+			assertSessions(t, store, "u2", []string{"other"})
+		})
+	}
+}
+
+func TestStoreTakeRefreshToken(t *testing.T) {
+	for _, sc := range storeCases() {
+		t.Run(sc.name, func(t *testing.T) {
+			store := sc.open(t)
+			ctx := context.Background()
+
+			_, err := store.SetSession(ctx, newSession("u1", "s1", time.Hour), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rtID, err := store.TakeRefreshToken(ctx, "u1", "s1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rtID != "s1-rt" {
+				t.Fatalf("got %q, want s1-rt", rtID)
+			}
+
+			// the token is taken once and the session is revoked
+			_, err = store.TakeRefreshToken(ctx, "u1", "s1")
+			if err != sessions.ErrNotFound {
+				t.Fatalf("got %v, want ErrNotFound", err)
+			}
+			assertValue(t, store, "s1", "")
+			assertSessions(t, store, "u1", nil)
+		})
+	}
+}
+
+func TestStoreReconcileSessions(t *testing.T) {
+	for _, sc := range storeCases() {
+		t.Run(sc.name, func(t *testing.T) {
+			store := sc.open(t)
+			ctx := context.Background()
+
+			// the tokens stored before the index are not listed until the reconciliation
+			err := store.Set(ctx, "old", "old-at", time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = store.Set(ctx, "old"+model.PostfixRefreshToken, "old-rt", 2*time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertSessions(t, store, "u1", nil)
+
+			expiresAt := map[string]time.Time{
+				"old":  time.Now().UTC().Add(2 * time.Hour),
+				"gone": time.Now().UTC().Add(2 * time.Hour),
+			}
+			missing, err := store.ReconcileSessions(ctx, "u1", expiresAt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(missing, []string{"gone"}) {
+				t.Fatalf("got missing %v, want [gone]", missing)
+			}
+			assertSessions(t, store, "u1", []string{"old"})
+
+			err = store.RevokeAll(ctx, "u1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertValue(t, store, "old", "")
+			assertValue(t, store, "old"+model.PostfixRefreshToken, "")
+		})
+	}
+}
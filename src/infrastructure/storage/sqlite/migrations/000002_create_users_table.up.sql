@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS users (
+    id VARCHAR PRIMARY KEY NOT NULL,
+    email VARCHAR,
+    phone VARCHAR,
+    user_name VARCHAR,
+    full_name VARCHAR,
+    password VARCHAR,
+    hash VARCHAR,
+    referral_link VARCHAR UNIQUE NOT NULL,
+    referral VARCHAR,
+    role VARCHAR NOT NULL DEFAULT 'user',
+    is_active BOOLEAN NOT NULL DEFAULT FALSE,
+    is_email_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    is_phone_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    is_google_verified BOOLEAN NOT NULL DEFAULT FALSE,
+    google_secret VARCHAR,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP
+);
+
+-- the unique keys are the indexes, since sqlite drops no constraints of the columns
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (email);
+CREATE UNIQUE INDEX IF NOT EXISTS users_phone_key ON users (phone);
\ No newline at end of file
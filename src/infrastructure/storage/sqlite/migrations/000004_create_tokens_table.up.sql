@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS tokens (
+    id VARCHAR PRIMARY KEY NOT NULL,
+    user_id VARCHAR,
+    target VARCHAR NOT NULL,
+    value VARCHAR NOT NULL,
+    reason VARCHAR NOT NULL,
+    type VARCHAR NOT NULL,
+    is_used BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
\ No newline at end of file
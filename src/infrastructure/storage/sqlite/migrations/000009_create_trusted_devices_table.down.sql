@@ -0,0 +1 @@
+DROP TABLE IF EXISTS trusted_devices;
\ No newline at end of file
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS trusted_devices (
+    id VARCHAR PRIMARY KEY NOT NULL,
+    user_id VARCHAR NOT NULL,
+    user_agent VARCHAR,
+    client_ip VARCHAR,
+    expires_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS security_events (
+    id VARCHAR PRIMARY KEY NOT NULL,
+    user_id VARCHAR NOT NULL,
+    event VARCHAR NOT NULL,
+    user_agent VARCHAR,
+    client_ip VARCHAR,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS contact_changes (
+    id VARCHAR PRIMARY KEY NOT NULL,
+    user_id VARCHAR NOT NULL,
+    type VARCHAR NOT NULL,
+    old_value VARCHAR,
+    new_value VARCHAR NOT NULL,
+    user_agent VARCHAR,
+    client_ip VARCHAR,
+    apply_at TIMESTAMP NOT NULL,
+    applied_at TIMESTAMP,
+    canceled_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS contact_changes_apply_at_idx ON contact_changes (apply_at)
+    WHERE applied_at IS NULL AND canceled_at IS NULL;
+
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN phone_verified_at TIMESTAMP;
+
+UPDATE users SET email_verified_at = COALESCE(updated_at, created_at) WHERE email IS NOT NULL AND is_active = TRUE;
+UPDATE users SET phone_verified_at = COALESCE(updated_at, created_at) WHERE phone IS NOT NULL AND is_active = TRUE;
\ No newline at end of file
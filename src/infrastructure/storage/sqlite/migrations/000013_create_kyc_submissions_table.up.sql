@@ -0,0 +1,28 @@
+-- sqlite changes no types of the columns, the levels are stored as integers anyway
+UPDATE user_configs SET kyc_level = 0 WHERE kyc_level IS NULL;
+
+CREATE TABLE IF NOT EXISTS kyc_submissions (
+    id VARCHAR PRIMARY KEY NOT NULL,
+    user_id VARCHAR NOT NULL,
+    status VARCHAR NOT NULL DEFAULT 'pending',
+    level INTEGER NOT NULL,
+    id_card VARCHAR,
+    reviewer_id VARCHAR,
+    review_comment VARCHAR,
+    reviewed_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS kyc_submissions_status_idx ON kyc_submissions (status, created_at);
+
+CREATE TABLE IF NOT EXISTS kyc_documents (
+    id VARCHAR PRIMARY KEY NOT NULL,
+    submission_id VARCHAR NOT NULL,
+    type VARCHAR NOT NULL,
+    storage_key VARCHAR NOT NULL,
+    content_type VARCHAR NOT NULL,
+    size BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (submission_id) REFERENCES kyc_submissions (id) ON DELETE CASCADE
+);
\ No newline at end of file
@@ -0,0 +1,5 @@
+ALTER TABLE users ADD COLUMN user_name_changed_at TIMESTAMP;
+
+UPDATE users SET user_name = NULL WHERE user_name = '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_user_name_lower_idx ON users (LOWER(user_name)) WHERE user_name IS NOT NULL;
\ No newline at end of file
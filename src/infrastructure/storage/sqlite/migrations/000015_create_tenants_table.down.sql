@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS tokens_tenant_target_idx;
+DROP INDEX IF EXISTS users_tenant_user_name_lower_idx;
+DROP INDEX IF EXISTS users_tenant_phone_idx;
+DROP INDEX IF EXISTS users_tenant_email_idx;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_user_name_lower_idx ON users (LOWER(user_name)) WHERE user_name IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS users_phone_key ON users (phone);
+CREATE UNIQUE INDEX IF NOT EXISTS users_email_key ON users (email);
+
+ALTER TABLE tokens DROP COLUMN tenant_id;
+ALTER TABLE sessions DROP COLUMN tenant_id;
+ALTER TABLE users DROP COLUMN tenant_id;
+
+DROP TABLE IF EXISTS tenants;
\ No newline at end of file
@@ -0,0 +1,36 @@
+CREATE TABLE IF NOT EXISTS tenants (
+    id VARCHAR PRIMARY KEY NOT NULL,
+    name VARCHAR,
+    hosts TEXT NOT NULL DEFAULT '[]',
+    logo_url VARCHAR,
+    primary_color VARCHAR,
+    support_email VARCHAR,
+    front_host VARCHAR,
+    email_from_name VARCHAR,
+    email_from_address VARCHAR,
+    sms_from VARCHAR,
+    jwt_issuer VARCHAR,
+    jwt_audience VARCHAR,
+    two_factor_auth_required BOOLEAN NOT NULL DEFAULT FALSE,
+    two_factor_auth_types TEXT NOT NULL DEFAULT '[]',
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP
+);
+
+INSERT OR IGNORE INTO tenants (id) VALUES ('default');
+
+-- sqlite adds no foreign keys to the existing tables
+ALTER TABLE users ADD COLUMN tenant_id VARCHAR NOT NULL DEFAULT 'default';
+ALTER TABLE sessions ADD COLUMN tenant_id VARCHAR NOT NULL DEFAULT 'default';
+ALTER TABLE tokens ADD COLUMN tenant_id VARCHAR NOT NULL DEFAULT 'default';
+
+DROP INDEX IF EXISTS users_email_key;
+DROP INDEX IF EXISTS users_phone_key;
+DROP INDEX IF EXISTS users_user_name_lower_idx;
+
+CREATE UNIQUE INDEX IF NOT EXISTS users_tenant_email_idx ON users (tenant_id, email);
+CREATE UNIQUE INDEX IF NOT EXISTS users_tenant_phone_idx ON users (tenant_id, phone);
+CREATE UNIQUE INDEX IF NOT EXISTS users_tenant_user_name_lower_idx ON users (tenant_id, LOWER(user_name)) WHERE user_name IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS tokens_tenant_target_idx ON tokens (tenant_id, target);
\ No newline at end of file
@@ -0,0 +1,32 @@
+CREATE TABLE IF NOT EXISTS api_keys (
+    id VARCHAR PRIMARY KEY NOT NULL,
+    tenant_id VARCHAR NOT NULL DEFAULT 'default' REFERENCES tenants (id),
+    user_id VARCHAR NOT NULL,
+    name VARCHAR NOT NULL,
+    prefix VARCHAR NOT NULL,
+    key_hash VARCHAR UNIQUE NOT NULL,
+    scopes TEXT NOT NULL DEFAULT '[]',
+    expires_at TIMESTAMP NOT NULL,
+    last_used_at TIMESTAMP,
+    last_used_ip VARCHAR,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS api_keys_user_id_idx ON api_keys (user_id);
+
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    id VARCHAR PRIMARY KEY NOT NULL,
+    tenant_id VARCHAR NOT NULL DEFAULT 'default' REFERENCES tenants (id),
+    user_id VARCHAR NOT NULL,
+    name VARCHAR NOT NULL,
+    secret_hash VARCHAR NOT NULL,
+    scopes TEXT NOT NULL DEFAULT '[]',
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS oauth_clients_user_id_idx ON oauth_clients (user_id);
\ No newline at end of file
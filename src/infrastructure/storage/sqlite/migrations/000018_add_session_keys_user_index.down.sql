@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS session_keys_session_id_idx;
+DROP INDEX IF EXISTS session_keys_user_id_idx;
+ALTER TABLE session_keys DROP COLUMN session_id;
+ALTER TABLE session_keys DROP COLUMN user_id;
\ No newline at end of file
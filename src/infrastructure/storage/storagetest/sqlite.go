@@ -0,0 +1,53 @@
+// Package storagetest opens the databases of the tests
+package storagetest
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/sqlitedialect"
+)
+
+// NewSQLite opens the sqlite database in the temp dir of the test with all the migrations applied,
+// the database is closed with the test
+func NewSQLite(t testing.TB) *bun.DB {
+	t.Helper()
+
+	sqldb, err := sql.Open("sqlite3",
+		"file:"+filepath.Join(t.TempDir(), "test.db")+"?_foreign_keys=on&_journal_mode=WAL&_busy_timeout=5000")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := bun.NewDB(sqldb, sqlitedialect.New())
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	_, file, _, _ := runtime.Caller(0)
+	migrations, err := filepath.Glob(filepath.Join(filepath.Dir(file), "..", "sqlite", "migrations", "*.up.sql"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(migrations)
+
+	for _, migration := range migrations {
+		query, err := ioutil.ReadFile(migration)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = sqldb.Exec(string(query))
+		if err != nil {
+			t.Fatalf("migration %s: %s", filepath.Base(migration), err.Error())
+		}
+	}
+
+	return db
+}
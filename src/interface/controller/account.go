@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"archive/zip"
+	"auth-project/src/domain/model"
+	"auth-project/src/usecase/interactor"
+	"bytes"
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+)
+
+const accountExportFileName = "user-data"
+
+type accountController struct {
+	accountInteractor interactor.AccountInteractor
+}
+
+type AccountController interface {
+	DeleteMyAccount(ctx *fiber.Ctx) error
+	ExportMyData(ctx *fiber.Ctx) error
+}
+
+func NewAccountController(ai interactor.AccountInteractor) AccountController {
+	return &accountController{ai}
+}
+
+// DeleteMyAccount deletes the account of the user, the data is erased after the grace period
+func (ac *accountController) DeleteMyAccount(ctx *fiber.Ctx) error {
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	usrInfo := &model.UserSessionData{
+		UserID:    usrID,
+		UserAgent: string(ctx.Request().Header.UserAgent()),
+		ClientIp:  ctx.Context().RemoteAddr().String(),
+	}
+
+	err := ac.accountInteractor.DeleteMyAccount(ctx.Context(), usrInfo)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(&model.MessageResp{Message: "OK"})
+}
+
+// ExportMyData returns the user's data as the json file or the zip archive, the format is taken from the query
+func (ac *accountController) ExportMyData(ctx *fiber.Ctx) error {
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	format := ctx.Query("format", model.AccountExportFormatJSON)
+	if format != model.AccountExportFormatJSON && format != model.AccountExportFormatZIP {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid export format")
+	}
+
+	export, err := ac.accountInteractor.ExportMyData(ctx.Context(), usrID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if format == model.AccountExportFormatJSON {
+		ctx.Attachment(accountExportFileName + ".json")
+		ctx.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return ctx.Status(fiber.StatusOK).Send(data)
+	}
+
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	fw, err := zw.Create(accountExportFileName + ".json")
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	_, err = fw.Write(data)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	err = zw.Close()
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	ctx.Attachment(accountExportFileName + ".zip")
+	return ctx.Status(fiber.StatusOK).Send(archive.Bytes())
+}
@@ -1,9 +1,17 @@
 package controller
 
 type APIController struct {
+	Tenant        interface{ TenantController }
 	Auth          interface{ AuthController }
 	QrCodeAuth    interface{ QrCodeAuthController }
 	TwoFactorAuth interface{ TwoFactorAuthController }
+	TrustedDevice interface{ TrustedDeviceController }
+	ContactChange interface{ ContactChangeController }
+	Account       interface{ AccountController }
+	Referral      interface{ ReferralController }
+	Kyc           interface{ KycController }
+	ApiKey        interface{ ApiKeyController }
+	OAuthClient   interface{ OAuthClientController }
 	Token         interface{ TokenController }
 	User          interface{ UserController }
 }
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/usecase/interactor"
+	"github.com/gofiber/fiber/v2"
+)
+
+type apiKeyController struct {
+	apiKeyInteractor interactor.ApiKeyInteractor
+}
+
+type ApiKeyController interface {
+	GetApiKeys(ctx *fiber.Ctx) error
+	CreateApiKey(ctx *fiber.Ctx) error
+	RevokeApiKey(ctx *fiber.Ctx) error
+}
+
+func NewApiKeyController(ai interactor.ApiKeyInteractor) ApiKeyController {
+	return &apiKeyController{ai}
+}
+
+// GetApiKeys returns the active personal access tokens of the user without the keys
+func (ac *apiKeyController) GetApiKeys(ctx *fiber.Ctx) error {
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	keys, err := ac.apiKeyInteractor.GetApiKeys(ctx.Context(), usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(keys)
+}
+
+// CreateApiKey creates the personal access token, the key is shown only in this response
+func (ac *apiKeyController) CreateApiKey(ctx *fiber.Ctx) error {
+
+	var createReq model.ApiKeyCreateReq
+	err := ctx.BodyParser(&createReq)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	resp, err := ac.apiKeyInteractor.CreateApiKey(ctx.Context(), &createReq, usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(resp)
+}
+
+// RevokeApiKey revokes the personal access token, it is not accepted anymore
+func (ac *apiKeyController) RevokeApiKey(ctx *fiber.Ctx) error {
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	err := ac.apiKeyInteractor.RevokeApiKey(ctx.Context(), ctx.Params("keyID"), usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(&model.MessageResp{Message: "OK"})
+}
@@ -14,9 +14,23 @@ type authController struct {
 type AuthController interface {
 	Authenticate(ctx *fiber.Ctx) error
 	RefreshToken(ctx *fiber.Ctx) error
+	Reauthenticate(ctx *fiber.Ctx) error
+	RevokeSessions(ctx *fiber.Ctx) error
+
+	SendMagicLink(ctx *fiber.Ctx) error
+	AuthenticateByMagicLink(ctx *fiber.Ctx) error
+
+	SendLoginCode(ctx *fiber.Ctx) error
+	AuthenticateByLoginCode(ctx *fiber.Ctx) error
 
 	ValidateAccessToken(ctx *fiber.Ctx) error
 	ValidateTwoFactorAuthToken(ctx *fiber.Ctx) error
+	ValidateTwoFactorAuthSetupToken(ctx *fiber.Ctx) error
+
+	IntrospectToken(ctx *fiber.Ctx) error
+	RevokeToken(ctx *fiber.Ctx) error
+
+	GetJwks(ctx *fiber.Ctx) error
 }
 
 func NewAuthController(ai interactor.AuthInteractor) AuthController {
@@ -33,8 +47,9 @@ func (ac *authController) Authenticate(ctx *fiber.Ctx) error {
 	}
 
 	usrInfo := &model.UserSessionData{
-		UserAgent: string(ctx.Request().Header.UserAgent()),
-		ClientIp:  ctx.Context().RemoteAddr().String(),
+		UserAgent:          string(ctx.Request().Header.UserAgent()),
+		ClientIp:           ctx.Context().RemoteAddr().String(),
+		TrustedDeviceToken: trustedDeviceToken(ctx),
 	}
 
 	resp, err := ac.authInteractor.Authenticate(ctx.Context(), &authReq, usrInfo)
@@ -45,6 +60,86 @@ func (ac *authController) Authenticate(ctx *fiber.Ctx) error {
 	return ctx.Status(fiber.StatusOK).JSON(resp)
 }
 
+// SendMagicLink accepts the user's email and sends a single-use sign in link to it
+func (ac *authController) SendMagicLink(ctx *fiber.Ctx) error {
+
+	var magicLinkSendReq model.MagicLinkSendReq
+	err := ctx.BodyParser(&magicLinkSendReq)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	resp, err := ac.authInteractor.SendMagicLink(ctx.Context(), &magicLinkSendReq)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
+}
+
+// AuthenticateByMagicLink accepts the magic link token, verify him and returns a token to authorize a client
+func (ac *authController) AuthenticateByMagicLink(ctx *fiber.Ctx) error {
+
+	var magicLinkAuthReq model.MagicLinkAuthReq
+	err := ctx.BodyParser(&magicLinkAuthReq)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	usrInfo := &model.UserSessionData{
+		UserAgent:          string(ctx.Request().Header.UserAgent()),
+		ClientIp:           ctx.Context().RemoteAddr().String(),
+		TrustedDeviceToken: trustedDeviceToken(ctx),
+	}
+
+	resp, err := ac.authInteractor.AuthenticateByMagicLink(ctx.Context(), &magicLinkAuthReq, usrInfo)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
+}
+
+// SendLoginCode accepts the user's phone or email and sends a one-time login code to it
+func (ac *authController) SendLoginCode(ctx *fiber.Ctx) error {
+
+	var otpSendReq model.OtpSendReq
+	err := ctx.BodyParser(&otpSendReq)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	resp, err := ac.authInteractor.SendLoginCode(ctx.Context(), &otpSendReq)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
+}
+
+// AuthenticateByLoginCode accepts the one-time login code, verify him and returns a token to authorize a client
+func (ac *authController) AuthenticateByLoginCode(ctx *fiber.Ctx) error {
+
+	var otpAuthReq model.OtpAuthReq
+	err := ctx.BodyParser(&otpAuthReq)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	usrInfo := &model.UserSessionData{
+		UserAgent:          string(ctx.Request().Header.UserAgent()),
+		ClientIp:           ctx.Context().RemoteAddr().String(),
+		TrustedDeviceToken: trustedDeviceToken(ctx),
+	}
+
+	resp, err := ac.authInteractor.AuthenticateByLoginCode(ctx.Context(), &otpAuthReq, usrInfo)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
+}
+
 // RefreshToken accepts the refresh token, verify and returns a token to authorize a client
 func (ac *authController) RefreshToken(ctx *fiber.Ctx) error {
 
@@ -66,27 +161,115 @@ func (ac *authController) RefreshToken(ctx *fiber.Ctx) error {
 	return ctx.Status(fiber.StatusOK).JSON(resp)
 }
 
+// Reauthenticate accepts the password and/or 2fa code of the signed-in user and returns
+// a token pair with the fresh authentication time for sensitive operations
+func (ac *authController) Reauthenticate(ctx *fiber.Ctx) error {
+
+	var reauthenticateReq model.ReauthenticateReq
+	err := ctx.BodyParser(&reauthenticateReq)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	sessionID, ok := ctx.Context().Value("token_session_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	usrInfo := &model.UserSessionData{
+		UserID:    usrID,
+		UserAgent: string(ctx.Request().Header.UserAgent()),
+		ClientIp:  ctx.Context().RemoteAddr().String(),
+	}
+
+	resp, err := ac.authInteractor.Reauthenticate(ctx.Context(), &reauthenticateReq, usrInfo, sessionID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
+}
+
+// RevokeSessions accepts the token of the "this wasn't me" link and signs out all sessions of the user
+func (ac *authController) RevokeSessions(ctx *fiber.Ctx) error {
+
+	var revokeSessionsReq model.RevokeSessionsReq
+	err := ctx.BodyParser(&revokeSessionsReq)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	err = ac.authInteractor.RevokeSessions(ctx.Context(), &revokeSessionsReq)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(&model.MessageResp{Message: "OK"})
+}
+
 // ValidateAccessToken gets the access token and verify him
 func (ac *authController) ValidateAccessToken(ctx *fiber.Ctx) error {
 
+	// the api key could be sent by its own header as well
+	bearerToken := ctx.Get(model.ApiKeyHeader)
+	if bearerToken == "" {
+		var err error
+		bearerToken, err = tools.ParseAndCheckToken(ctx)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+	}
+
+	claims, err := ac.authInteractor.ValidateAccessToken(ctx.Context(), bearerToken,
+		ctx.Context().RemoteAddr().String())
+	if err != nil {
+		// the flagged account is authenticated but has no access
+		if e, ok := err.(*fiber.Error); ok && e.Code == fiber.StatusForbidden {
+			return e
+		}
+		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	return setAccessClaims(ctx, claims)
+}
+
+// ValidateTwoFactorAuthSetupToken gets the token given by the tenant's 2fa policy instead of the session
+// and verify him, the set-up functions get the same context values as by the access token
+func (ac *authController) ValidateTwoFactorAuthSetupToken(ctx *fiber.Ctx) error {
+
 	bearerToken, err := tools.ParseAndCheckToken(ctx)
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	claims, err := ac.authInteractor.ValidateAccessToken(ctx.Context(), bearerToken)
+	claims, err := ac.authInteractor.ValidateTwoFactorAuthSetupToken(ctx.Context(), bearerToken)
 	if err != nil {
 		return fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
 	}
 
-	// set user id from token in context
-	if claims.UserID != "" && claims.AtID != "" {
-		ctx.Context().SetUserValue("token_user_id", claims.UserID)
-		ctx.Context().SetUserValue("token_session_id", claims.SessionID)
-	} else {
+	return setAccessClaims(ctx, claims)
+}
+
+// setAccessClaims sets user id and the other claims from token in context
+func setAccessClaims(ctx *fiber.Ctx, claims *model.AccessClaims) error {
+
+	if claims.UserID == "" || claims.AtID == "" {
 		return fiber.NewError(fiber.StatusBadRequest, "claims is missing")
 	}
 
+	ctx.Context().SetUserValue("token_user_id", claims.UserID)
+	ctx.Context().SetUserValue("token_session_id", claims.SessionID)
+	ctx.Context().SetUserValue("token_auth_time", claims.AuthTime)
+	ctx.Context().SetUserValue("token_amr", claims.Amr)
+	ctx.Context().SetUserValue("token_user_role", claims.Role)
+	ctx.Context().SetUserValue("token_type", claims.Type)
+	ctx.Context().SetUserValue("token_scopes", model.ParseScope(claims.Scope))
+
 	return nil
 }
 
@@ -106,9 +289,83 @@ func (ac *authController) ValidateTwoFactorAuthToken(ctx *fiber.Ctx) error {
 	// set user id from token in context
 	if claims.UserID != "" && claims.AtID != "" {
 		ctx.Context().SetUserValue("token_user_id", claims.UserID)
+		ctx.Context().SetUserValue("token_amr", claims.Amr)
+		ctx.Context().SetUserValue("token_user_role", claims.Role)
 	} else {
 		return fiber.NewError(fiber.StatusBadRequest, "claims is missing")
 	}
 
 	return nil
 }
+
+// trustedDeviceToken returns the trusted device token from the cookie or the header
+func trustedDeviceToken(ctx *fiber.Ctx) string {
+	token := ctx.Cookies(model.TrustedDeviceCookieName)
+	if token == "" {
+		token = ctx.Get(model.TrustedDeviceHeader)
+	}
+	return token
+}
+
+// IntrospectToken tells the resource server whether the token is active, the client credentials
+// are accepted by the basic auth or in the body
+func (ac *authController) IntrospectToken(ctx *fiber.Ctx) error {
+
+	var checkReq model.OAuthTokenCheckReq
+	err := ctx.BodyParser(&checkReq)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid_request")
+	}
+
+	clientID, clientSecret, ok := parseBasicAuth(ctx.Get(fiber.HeaderAuthorization))
+	if ok {
+		checkReq.ClientID = clientID
+		checkReq.ClientSecret = clientSecret
+	}
+
+	resp, err := ac.authInteractor.IntrospectToken(ctx.Context(), &checkReq)
+	if err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
+}
+
+// RevokeToken revokes the token on behalf of the client, the client credentials
+// are accepted by the basic auth or in the body
+func (ac *authController) RevokeToken(ctx *fiber.Ctx) error {
+
+	var checkReq model.OAuthTokenCheckReq
+	err := ctx.BodyParser(&checkReq)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid_request")
+	}
+
+	clientID, clientSecret, ok := parseBasicAuth(ctx.Get(fiber.HeaderAuthorization))
+	if ok {
+		checkReq.ClientID = clientID
+		checkReq.ClientSecret = clientSecret
+	}
+
+	err = ac.authInteractor.RevokeToken(ctx.Context(), &checkReq)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(&model.MessageResp{Message: "OK"})
+}
+
+// GetJwks returns the public key set to verify the access tokens
+func (ac *authController) GetJwks(ctx *fiber.Ctx) error {
+
+	resp, err := ac.authInteractor.GetJwks(ctx.Context())
+	if err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=300")
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
+}
@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/usecase/interactor"
+	"github.com/gofiber/fiber/v2"
+)
+
+type contactChangeController struct {
+	contactChangeInteractor interactor.ContactChangeInteractor
+}
+
+type ContactChangeController interface {
+	SendContactChangeCode(ctx *fiber.Ctx) error
+
+	UpdateMyselfEmail(ctx *fiber.Ctx) error
+	UpdateMyselfPhone(ctx *fiber.Ctx) error
+
+	GetContactChanges(ctx *fiber.Ctx) error
+	CancelContactChange(ctx *fiber.Ctx) error
+}
+
+func NewContactChangeController(ci interactor.ContactChangeInteractor) ContactChangeController {
+	return &contactChangeController{ci}
+}
+
+// SendContactChangeCode sends the code confirming the contact change by the current 2fa method or contact
+func (cc *contactChangeController) SendContactChangeCode(ctx *fiber.Ctx) error {
+
+	var sendCodeReq model.ContactChangeSendCodeReq
+	if len(ctx.Body()) > 0 {
+		err := ctx.BodyParser(&sendCodeReq)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+	}
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	resp, err := cc.contactChangeInteractor.SendContactChangeCode(ctx.Context(), &sendCodeReq, usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
+}
+
+// UpdateMyselfEmail takes the email with the codes of the new email and of the current method,
+// the email is changed after the grace period unless the user cancels it
+func (cc *contactChangeController) UpdateMyselfEmail(ctx *fiber.Ctx) error {
+
+	var reqData model.UserEmailUpdateReq
+	err := ctx.BodyParser(&reqData)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	usrInfo := &model.UserSessionData{
+		UserID:    usrID,
+		UserAgent: string(ctx.Request().Header.UserAgent()),
+		ClientIp:  ctx.Context().RemoteAddr().String(),
+	}
+
+	change, err := cc.contactChangeInteractor.RequestEmailChange(ctx.Context(), &reqData, usrInfo)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusAccepted).JSON(change)
+}
+
+// UpdateMyselfPhone takes the phone with the codes of the new phone and of the current method,
+// the phone is changed after the grace period unless the user cancels it
+func (cc *contactChangeController) UpdateMyselfPhone(ctx *fiber.Ctx) error {
+
+	var reqData model.UserPhoneUpdateReq
+	err := ctx.BodyParser(&reqData)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	usrInfo := &model.UserSessionData{
+		UserID:    usrID,
+		UserAgent: string(ctx.Request().Header.UserAgent()),
+		ClientIp:  ctx.Context().RemoteAddr().String(),
+	}
+
+	change, err := cc.contactChangeInteractor.RequestPhoneChange(ctx.Context(), &reqData, usrInfo)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusAccepted).JSON(change)
+}
+
+// GetContactChanges returns the changes waiting for the end of the grace period
+func (cc *contactChangeController) GetContactChanges(ctx *fiber.Ctx) error {
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	changes, err := cc.contactChangeInteractor.GetContactChanges(ctx.Context(), usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(changes)
+}
+
+// CancelContactChange cancels the pending change, the current contact is kept
+func (cc *contactChangeController) CancelContactChange(ctx *fiber.Ctx) error {
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	err := cc.contactChangeInteractor.CancelContactChange(ctx.Context(), ctx.Params("changeID"), usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(&model.MessageResp{Message: "OK"})
+}
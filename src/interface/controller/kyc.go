@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/usecase/interactor"
+	"github.com/gofiber/fiber/v2"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+)
+
+type kycController struct {
+	kycInteractor interactor.KycInteractor
+}
+
+type KycController interface {
+	GetMyKyc(ctx *fiber.Ctx) error
+	SubmitKyc(ctx *fiber.Ctx) error
+
+	GetKycSubmissions(ctx *fiber.Ctx) error
+	GetKycSubmission(ctx *fiber.Ctx) error
+	GetKycDocument(ctx *fiber.Ctx) error
+	ApproveKycSubmission(ctx *fiber.Ctx) error
+	RejectKycSubmission(ctx *fiber.Ctx) error
+
+	FlagUser(ctx *fiber.Ctx) error
+}
+
+func NewKycController(ki interactor.KycInteractor) KycController {
+	return &kycController{ki}
+}
+
+// GetMyKyc returns the kyc level of the user and his submissions
+func (kc *kycController) GetMyKyc(ctx *fiber.Ctx) error {
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	resp, err := kc.kycInteractor.GetMyKyc(ctx.Context(), usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
+}
+
+// SubmitKyc accepts the multipart form with the kyc data, each document is sent as the file named by its type
+func (kc *kycController) SubmitKyc(ctx *fiber.Ctx) error {
+
+	var submitReq model.KycSubmitReq
+	err := ctx.BodyParser(&submitReq)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	var documents []model.KycDocument
+	for _, docType := range model.KycDocumentTypes {
+		fileHeaders := form.File[docType]
+		if len(fileHeaders) == 0 {
+			continue
+		}
+
+		file, err := fileHeaders[0].Open()
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		content, err := ioutil.ReadAll(file)
+		file.Close()
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		documents = append(documents, model.KycDocument{
+			Type:    docType,
+			Size:    int64(len(content)),
+			Content: content,
+		})
+	}
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	resp, err := kc.kycInteractor.SubmitKyc(ctx.Context(), &submitReq, documents, usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(resp)
+}
+
+// GetKycSubmissions returns the page of the submissions for the review, they could be filtered by the status
+func (kc *kycController) GetKycSubmissions(ctx *fiber.Ctx) error {
+
+	var listReq model.KycSubmissionListReq
+	err := ctx.QueryParser(&listReq)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	resp, err := kc.kycInteractor.GetKycSubmissions(ctx.Context(), &listReq)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
+}
+
+func (kc *kycController) GetKycSubmission(ctx *fiber.Ctx) error {
+
+	resp, err := kc.kycInteractor.GetKycSubmission(ctx.Context(), ctx.Params("submissionID"))
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
+}
+
+// GetKycDocument returns the file of the document as it was uploaded
+func (kc *kycController) GetKycDocument(ctx *fiber.Ctx) error {
+
+	document, err := kc.kycInteractor.GetKycDocument(ctx.Context(), ctx.Params("submissionID"),
+		ctx.Params("documentID"))
+	if err != nil {
+		return err
+	}
+
+	contentType := document.ContentType
+	if contentType == "" {
+		contentType = http.DetectContentType(document.Content)
+	}
+
+	ctx.Set(fiber.HeaderContentType, contentType)
+	ctx.Set(fiber.HeaderContentLength, strconv.Itoa(len(document.Content)))
+	return ctx.Status(fiber.StatusOK).Send(document.Content)
+}
+
+func (kc *kycController) ApproveKycSubmission(ctx *fiber.Ctx) error {
+
+	var reviewReq model.KycReviewReq
+	if len(ctx.Body()) != 0 {
+		err := ctx.BodyParser(&reviewReq)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+	}
+
+	reviewerID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	err := kc.kycInteractor.ApproveKycSubmission(ctx.Context(), ctx.Params("submissionID"), &reviewReq, reviewerID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(&model.MessageResp{Message: "OK"})
+}
+
+func (kc *kycController) RejectKycSubmission(ctx *fiber.Ctx) error {
+
+	var reviewReq model.KycReviewReq
+	err := ctx.BodyParser(&reviewReq)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	reviewerID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	err = kc.kycInteractor.RejectKycSubmission(ctx.Context(), ctx.Params("submissionID"), &reviewReq, reviewerID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(&model.MessageResp{Message: "OK"})
+}
+
+// FlagUser blocks or unblocks the access of the user
+func (kc *kycController) FlagUser(ctx *fiber.Ctx) error {
+
+	var flagReq model.UserFlagReq
+	err := ctx.BodyParser(&flagReq)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	err = kc.kycInteractor.FlagUser(ctx.Context(), &flagReq, ctx.Params("userID"))
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(&model.MessageResp{Message: "OK"})
+}
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/usecase/interactor"
+	"encoding/base64"
+	"github.com/gofiber/fiber/v2"
+	"net/url"
+	"strings"
+)
+
+type oAuthClientController struct {
+	oAuthClientInteractor interactor.OAuthClientInteractor
+}
+
+type OAuthClientController interface {
+	GetOAuthClients(ctx *fiber.Ctx) error
+	CreateOAuthClient(ctx *fiber.Ctx) error
+	RotateOAuthClientSecret(ctx *fiber.Ctx) error
+	RevokeOAuthClient(ctx *fiber.Ctx) error
+
+	IssueToken(ctx *fiber.Ctx) error
+}
+
+func NewOAuthClientController(oi interactor.OAuthClientInteractor) OAuthClientController {
+	return &oAuthClientController{oi}
+}
+
+// GetOAuthClients returns the active machine clients of the user without the secrets
+func (oc *oAuthClientController) GetOAuthClients(ctx *fiber.Ctx) error {
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	clients, err := oc.oAuthClientInteractor.GetOAuthClients(ctx.Context(), usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(clients)
+}
+
+// CreateOAuthClient registers the machine client, the secret is shown only in this response
+func (oc *oAuthClientController) CreateOAuthClient(ctx *fiber.Ctx) error {
+
+	var createReq model.OAuthClientCreateReq
+	err := ctx.BodyParser(&createReq)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	resp, err := oc.oAuthClientInteractor.CreateOAuthClient(ctx.Context(), &createReq, usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusCreated).JSON(resp)
+}
+
+// RotateOAuthClientSecret replaces the secret of the client, the new secret is shown only in this response
+func (oc *oAuthClientController) RotateOAuthClientSecret(ctx *fiber.Ctx) error {
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	resp, err := oc.oAuthClientInteractor.RotateOAuthClientSecret(ctx.Context(), ctx.Params("clientID"), usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
+}
+
+// RevokeOAuthClient revokes the client, its tokens are not accepted anymore
+func (oc *oAuthClientController) RevokeOAuthClient(ctx *fiber.Ctx) error {
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	err := oc.oAuthClientInteractor.RevokeOAuthClient(ctx.Context(), ctx.Params("clientID"), usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(&model.MessageResp{Message: "OK"})
+}
+
+// IssueToken is the token endpoint of the client credentials grant, the client credentials
+// are accepted by the basic auth or in the body
+func (oc *oAuthClientController) IssueToken(ctx *fiber.Ctx) error {
+
+	var tokenReq model.OAuthTokenReq
+	err := ctx.BodyParser(&tokenReq)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid_request")
+	}
+
+	clientID, clientSecret, ok := parseBasicAuth(ctx.Get(fiber.HeaderAuthorization))
+	if ok {
+		tokenReq.ClientID = clientID
+		tokenReq.ClientSecret = clientSecret
+	}
+
+	if tokenReq.ClientID == "" || tokenReq.ClientSecret == "" {
+		return fiber.NewError(fiber.StatusUnauthorized, "invalid_client")
+	}
+
+	resp, err := oc.oAuthClientInteractor.IssueClientToken(ctx.Context(), &tokenReq)
+	if err != nil {
+		return err
+	}
+
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
+}
+
+// parseBasicAuth returns the client credentials of the basic auth, they are form-encoded (RFC 6749 section 2.3.1)
+func parseBasicAuth(header string) (string, string, bool) {
+	const prefix = "Basic "
+	if !strings.HasPrefix(header, prefix) {
+		return "", "", false
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(header[len(prefix):])
+	if err != nil {
+		return "", "", false
+	}
+
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+
+	clientID, err := url.QueryUnescape(parts[0])
+	if err != nil {
+		return "", "", false
+	}
+	clientSecret, err := url.QueryUnescape(parts[1])
+	if err != nil {
+		return "", "", false
+	}
+
+	return clientID, clientSecret, true
+}
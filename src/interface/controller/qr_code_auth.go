@@ -1,6 +1,7 @@
 package controller
 
 import (
+	"auth-project/src/domain/model"
 	"auth-project/src/usecase/interactor"
 	"context"
 	"github.com/gofiber/fiber/v2"
@@ -16,10 +17,6 @@ const (
 	pingPeriod = (pongWait * 9) / 10
 )
 
-type Message struct {
-	Message string `json:"message"`
-}
-
 type Channel struct {
 	conn     *websocket.Conn
 	stop     chan struct{}
@@ -75,26 +72,24 @@ func (qc *qrCodeAuthController) CreateAuthTokenByAuthQrCode(ctx *fiber.Ctx) erro
 		return fiber.NewError(fiber.StatusBadRequest, "your token does not registered")
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(map[string]string{
-		"message": "OK",
-	})
+	return ctx.Status(fiber.StatusOK).JSON(&model.MessageResp{Message: "OK"})
 }
 
 func (qc *qrCodeAuthController) QrCodeAuthWebsocket(c *websocket.Conn) {
 
 	qrCode, qrCodeToken, err := qc.qrCodeAuthInteractor.GenerateQrCode(context.Background())
 	if err != nil {
-		_ = c.Conn.WriteJSON(Message{err.Error()})
+		_ = c.Conn.WriteJSON(model.MessageResp{Message: err.Error()})
 		_ = c.Conn.WriteMessage(websocket.CloseInternalServerErr, nil)
 		return
 	}
 
-	err = c.Conn.WriteJSON(map[string]interface{}{
-		"qr_code":       qrCode,
-		"qr_code_token": qrCodeToken,
+	err = c.Conn.WriteJSON(&model.QrCodeAuthResp{
+		QrCode:      qrCode,
+		QrCodeToken: qrCodeToken,
 	})
 	if err != nil {
-		_ = c.Conn.WriteJSON(Message{"err sending qr token"})
+		_ = c.Conn.WriteJSON(model.MessageResp{Message: "err sending qr token"})
 		_ = c.Conn.WriteMessage(websocket.CloseInternalServerErr, nil)
 		return
 	}
@@ -102,21 +97,21 @@ func (qc *qrCodeAuthController) QrCodeAuthWebsocket(c *websocket.Conn) {
 	// Waiting for complete or close
 	userId, ok := <-qc.Store(qrCodeToken, c)
 	if !ok {
-		_ = c.Conn.WriteJSON(Message{"timeout"})
+		_ = c.Conn.WriteJSON(model.MessageResp{Message: "timeout"})
 		_ = c.Conn.WriteMessage(websocket.CloseMessage, nil)
 		return
 	}
 
 	details, err := qc.qrCodeAuthInteractor.GenerateTokenPairByUserID(c, userId)
 	if err != nil {
-		_ = c.Conn.WriteJSON(Message{err.Error()})
+		_ = c.Conn.WriteJSON(model.MessageResp{Message: err.Error()})
 		_ = c.Conn.WriteMessage(websocket.CloseMessage, nil)
 		return
 	}
 
-	_ = c.Conn.WriteJSON(map[string]string{
-		"access_token":  details.AccessToken,
-		"refresh_token": details.RefreshToken,
+	_ = c.Conn.WriteJSON(&model.AuthResp{
+		AccessToken:  details.AccessToken,
+		RefreshToken: details.RefreshToken,
 	})
 
 	_ = c.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/usecase/interactor"
+	"github.com/gofiber/fiber/v2"
+)
+
+type referralController struct {
+	referralInteractor interactor.ReferralInteractor
+}
+
+type ReferralController interface {
+	GetReferrals(ctx *fiber.Ctx) error
+	GetReferralTree(ctx *fiber.Ctx) error
+	GetReferralCount(ctx *fiber.Ctx) error
+	GetReferralStats(ctx *fiber.Ctx) error
+
+	RegenerateReferralLink(ctx *fiber.Ctx) error
+	SetReferralCode(ctx *fiber.Ctx) error
+}
+
+func NewReferralController(ri interactor.ReferralInteractor) ReferralController {
+	return &referralController{ri}
+}
+
+// GetReferrals returns the page of the users signed up by the user's referral link
+func (rc *referralController) GetReferrals(ctx *fiber.Ctx) error {
+
+	var listReq model.ReferralListReq
+	err := ctx.QueryParser(&listReq)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	resp, err := rc.referralInteractor.GetReferrals(ctx.Context(), &listReq, usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
+}
+
+// GetReferralTree returns the referrals with their own referrals
+func (rc *referralController) GetReferralTree(ctx *fiber.Ctx) error {
+
+	var treeReq model.ReferralTreeReq
+	err := ctx.QueryParser(&treeReq)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	resp, err := rc.referralInteractor.GetReferralTree(ctx.Context(), &treeReq, usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
+}
+
+// GetReferralCount returns the number of the referrals on each level
+func (rc *referralController) GetReferralCount(ctx *fiber.Ctx) error {
+
+	var treeReq model.ReferralTreeReq
+	err := ctx.QueryParser(&treeReq)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	resp, err := rc.referralInteractor.GetReferralCount(ctx.Context(), &treeReq, usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
+}
+
+// GetReferralStats returns the number of the referrals signed up by period
+func (rc *referralController) GetReferralStats(ctx *fiber.Ctx) error {
+
+	var statsReq model.ReferralStatsReq
+	err := ctx.QueryParser(&statsReq)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	resp, err := rc.referralInteractor.GetReferralStats(ctx.Context(), &statsReq, usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
+}
+
+// RegenerateReferralLink replaces the referral link with the new random one
+func (rc *referralController) RegenerateReferralLink(ctx *fiber.Ctx) error {
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	resp, err := rc.referralInteractor.RegenerateReferralLink(ctx.Context(), usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
+}
+
+// SetReferralCode replaces the referral link with the vanity code
+func (rc *referralController) SetReferralCode(ctx *fiber.Ctx) error {
+
+	var codeReq model.ReferralCodeReq
+	err := ctx.BodyParser(&codeReq)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	resp, err := rc.referralInteractor.SetReferralCode(ctx.Context(), &codeReq, usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
+}
@@ -0,0 +1,45 @@
+package controller
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/usecase/interactor"
+	"github.com/gofiber/fiber/v2"
+)
+
+type tenantController struct {
+	tenantInteractor interactor.TenantInteractor
+}
+
+type TenantController interface {
+	ResolveTenant(ctx *fiber.Ctx) error
+	GetTenant(ctx *fiber.Ctx) error
+}
+
+func NewTenantController(ti interactor.TenantInteractor) TenantController {
+	return &tenantController{ti}
+}
+
+// ResolveTenant finds the tenant by the host or the header and puts it in the context
+func (tc *tenantController) ResolveTenant(ctx *fiber.Ctx) error {
+
+	tenant, err := tc.tenantInteractor.ResolveTenant(ctx.Context(), ctx.Get(model.TenantHeader), ctx.Hostname(),
+		ctx.Get(fiber.HeaderOrigin))
+	if err != nil {
+		return err
+	}
+
+	ctx.Context().SetUserValue("tenant_id", tenant.ID)
+
+	return nil
+}
+
+// GetTenant returns the branding and the 2fa policy of the tenant for the front
+func (tc *tenantController) GetTenant(ctx *fiber.Ctx) error {
+
+	resp, err := tc.tenantInteractor.GetTenantBranding(ctx.Context())
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
+}
@@ -0,0 +1,69 @@
+package controller
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/usecase/interactor"
+	"github.com/gofiber/fiber/v2"
+)
+
+type trustedDeviceController struct {
+	trustedDeviceInteractor interactor.TrustedDeviceInteractor
+}
+
+type TrustedDeviceController interface {
+	GetTrustedDevices(ctx *fiber.Ctx) error
+	DeleteTrustedDevice(ctx *fiber.Ctx) error
+	DeleteTrustedDevices(ctx *fiber.Ctx) error
+}
+
+func NewTrustedDeviceController(ti interactor.TrustedDeviceInteractor) TrustedDeviceController {
+	return &trustedDeviceController{ti}
+}
+
+// GetTrustedDevices returns the devices where the user signs in without two-factor auth
+func (tc *trustedDeviceController) GetTrustedDevices(ctx *fiber.Ctx) error {
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	devices, err := tc.trustedDeviceInteractor.GetTrustedDevices(ctx.Context(), usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(devices)
+}
+
+// DeleteTrustedDevice revokes the trust of the device, the next sign in on it needs two-factor auth
+func (tc *trustedDeviceController) DeleteTrustedDevice(ctx *fiber.Ctx) error {
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	err := tc.trustedDeviceInteractor.DeleteTrustedDevice(ctx.Context(), ctx.Params("deviceID"), usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(&model.MessageResp{Message: "OK"})
+}
+
+// DeleteTrustedDevices revokes the trust of all user's devices
+func (tc *trustedDeviceController) DeleteTrustedDevices(ctx *fiber.Ctx) error {
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	err := tc.trustedDeviceInteractor.DeleteTrustedDevices(ctx.Context(), usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(&model.MessageResp{Message: "OK"})
+}
@@ -4,6 +4,7 @@ import (
 	"auth-project/src/domain/model"
 	"auth-project/src/usecase/interactor"
 	"github.com/gofiber/fiber/v2"
+	"time"
 )
 
 type twoFactorAuthController struct {
@@ -18,21 +19,33 @@ type TwoFactorAuthController interface {
 	SetUpTwoFactorAuth(ctx *fiber.Ctx) error
 
 	DeleteTwoFactorAuth(ctx *fiber.Ctx) error
+	SetDefaultTwoFactorAuthType(ctx *fiber.Ctx) error
+
+	GetRecoveryCodesCount(ctx *fiber.Ctx) error
+	RegenerateRecoveryCodes(ctx *fiber.Ctx) error
 }
 
 func NewTwoFactorAuthController(ti interactor.TwoFactorAuthInteractor) TwoFactorAuthController {
 	return &twoFactorAuthController{ti}
 }
 
-// ReSendTwoFactorAuthCode checks the user's entity and re-sends the 2fa code depending on it
+// ReSendTwoFactorAuthCode re-sends the 2fa code by the requested method, the default one if the body is empty
 func (tc *twoFactorAuthController) ReSendTwoFactorAuthCode(ctx *fiber.Ctx) error {
 
+	var twoFactorAuthReSendReq model.TwoFactorAuthReSendReq
+	if len(ctx.Body()) > 0 {
+		err := ctx.BodyParser(&twoFactorAuthReSendReq)
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+	}
+
 	usrID, ok := ctx.Context().Value("token_user_id").(string)
 	if !ok {
 		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
 	}
 
-	resp, err := tc.twoFactorAuthInteractor.ReSendTwoFactorAuthCode(ctx.Context(), usrID)
+	resp, err := tc.twoFactorAuthInteractor.ReSendTwoFactorAuthCode(ctx.Context(), &twoFactorAuthReSendReq, usrID)
 	if err != nil {
 		return err
 	}
@@ -54,10 +67,14 @@ func (tc *twoFactorAuthController) VerifyTwoFactorAuthCode(ctx *fiber.Ctx) error
 		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
 	}
 
+	// methods of the first factor from the 2fa token
+	amr, _ := ctx.Context().Value("token_amr").([]string)
+
 	usrInfo := &model.UserSessionData{
 		UserID:    usrID,
 		UserAgent: string(ctx.Request().Header.UserAgent()),
 		ClientIp:  ctx.Context().RemoteAddr().String(),
+		Amr:       amr,
 	}
 
 	details, err := tc.twoFactorAuthInteractor.VerifyTwoFactorAuthCode(ctx.Context(), verify2faCodeReq, usrInfo)
@@ -65,10 +82,26 @@ func (tc *twoFactorAuthController) VerifyTwoFactorAuthCode(ctx *fiber.Ctx) error
 		return err
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(map[string]string{
-		"access_token":  details.AccessToken,
-		"refresh_token": details.RefreshToken,
-	})
+	resp := &model.AuthResp{
+		AccessToken:  details.AccessToken,
+		RefreshToken: details.RefreshToken,
+	}
+
+	// browsers keep the token in the cookie, other clients send it back in the header
+	if details.TrustedDeviceToken != "" {
+		ctx.Cookie(&fiber.Cookie{
+			Name:     model.TrustedDeviceCookieName,
+			Value:    details.TrustedDeviceToken,
+			Path:     "/",
+			Expires:  time.Unix(details.TrustedDeviceExpires, 0),
+			Secure:   true,
+			HTTPOnly: true,
+			SameSite: fiber.CookieSameSiteStrictMode,
+		})
+		resp.TrustedDeviceToken = details.TrustedDeviceToken
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
 }
 
 // GenerateGoogleTwoFactorAuthQrCode generates qr code for google 2fa
@@ -101,18 +134,25 @@ func (tc *twoFactorAuthController) SetUpTwoFactorAuth(ctx *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
 	}
 
-	err = tc.twoFactorAuthInteractor.SetUpTwoFactorAuthByUserID(ctx.Context(), &twoFactorAuthSetUpReq,
-		usrID)
+	usrInfo := &model.UserSessionData{
+		UserID:    usrID,
+		UserAgent: string(ctx.Request().Header.UserAgent()),
+		ClientIp:  ctx.Context().RemoteAddr().String(),
+	}
+
+	recoveryCodes, err := tc.twoFactorAuthInteractor.SetUpTwoFactorAuthByUserID(ctx.Context(), &twoFactorAuthSetUpReq,
+		usrInfo)
 	if err != nil {
 		return err
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(map[string]string{
-		"message": "OK",
+	return ctx.Status(fiber.StatusOK).JSON(&model.TwoFactorAuthSetUpResp{
+		Message:       "OK",
+		RecoveryCodes: recoveryCodes,
 	})
 }
 
-// DeleteTwoFactorAuth accepts user password, verify it and delete 2fa
+// DeleteTwoFactorAuth deletes the 2fa method of the user
 func (tc *twoFactorAuthController) DeleteTwoFactorAuth(ctx *fiber.Ctx) error {
 
 	var twoFactorAuthDeleteReq model.TwoFactorAuthDeleteReq
@@ -126,13 +166,81 @@ func (tc *twoFactorAuthController) DeleteTwoFactorAuth(ctx *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
 	}
 
+	usrInfo := &model.UserSessionData{
+		UserID:    usrID,
+		UserAgent: string(ctx.Request().Header.UserAgent()),
+		ClientIp:  ctx.Context().RemoteAddr().String(),
+	}
+
 	err = tc.twoFactorAuthInteractor.DeleteTwoFactorAuthByUserID(ctx.Context(), &twoFactorAuthDeleteReq,
-		usrID)
+		usrInfo)
 	if err != nil {
 		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(map[string]string{
-		"message": "OK",
+	return ctx.Status(fiber.StatusOK).JSON(&model.MessageResp{Message: "OK"})
+}
+
+// SetDefaultTwoFactorAuthType changes the method used first when the user signs in
+func (tc *twoFactorAuthController) SetDefaultTwoFactorAuthType(ctx *fiber.Ctx) error {
+
+	var twoFactorAuthDefaultReq model.TwoFactorAuthDefaultReq
+	err := ctx.BodyParser(&twoFactorAuthDefaultReq)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	err = tc.twoFactorAuthInteractor.SetDefaultTwoFactorAuthType(ctx.Context(), &twoFactorAuthDefaultReq, usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(&model.MessageResp{Message: "OK"})
+}
+
+// GetRecoveryCodesCount returns the number of unused recovery codes
+func (tc *twoFactorAuthController) GetRecoveryCodesCount(ctx *fiber.Ctx) error {
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	count, err := tc.twoFactorAuthInteractor.CountRecoveryCodes(ctx.Context(), usrID)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(&model.RecoveryCodesCountResp{
+		RecoveryCodesLeft: count,
+	})
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes with the new ones
+func (tc *twoFactorAuthController) RegenerateRecoveryCodes(ctx *fiber.Ctx) error {
+
+	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	usrInfo := &model.UserSessionData{
+		UserID:    usrID,
+		UserAgent: string(ctx.Request().Header.UserAgent()),
+		ClientIp:  ctx.Context().RemoteAddr().String(),
+	}
+
+	recoveryCodes, err := tc.twoFactorAuthInteractor.RegenerateRecoveryCodes(ctx.Context(), usrInfo)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(&model.RecoveryCodesResp{
+		RecoveryCodes: recoveryCodes,
 	})
 }
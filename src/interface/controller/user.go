@@ -22,9 +22,10 @@ type UserController interface {
 
 	ChangeMyPassword(ctx *fiber.Ctx) error
 	UpdateMyselfInfo(ctx *fiber.Ctx) error
-	UpdateMyselfPhone(ctx *fiber.Ctx) error
-	UpdateMyselfEmail(ctx *fiber.Ctx) error
+	UpdateMyselfUserName(ctx *fiber.Ctx) error
+	CheckUserName(ctx *fiber.Ctx) error
 
+	GetMySessions(ctx *fiber.Ctx) error
 	SignOut(ctx *fiber.Ctx) error
 	SignOutAll(ctx *fiber.Ctx) error
 }
@@ -47,9 +48,7 @@ func (uc *userController) SignUp(ctx *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(map[string]string{
-		"message": "OK",
-	})
+	return ctx.Status(fiber.StatusOK).JSON(&model.MessageResp{Message: "OK"})
 }
 
 // SignUpSendOTP accepts login, send 2fa and create deactivate user
@@ -66,9 +65,7 @@ func (uc *userController) SignUpSendOTP(ctx *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(map[string]string{
-		"message": "OK",
-	})
+	return ctx.Status(fiber.StatusOK).JSON(&model.MessageResp{Message: "OK"})
 }
 
 // VerifyResetUserPasswordCode accepts 2fa reset password code, verify him and sent reset password access token
@@ -85,14 +82,17 @@ func (uc *userController) VerifyResetUserPasswordCode(ctx *fiber.Ctx) error {
 		return err
 	}
 
-	err = uc.userInteractor.VerifyResetUserPasswordCode(ctx.Context(), &userResetPasswordReq)
+	usrInfo := &model.UserSessionData{
+		UserAgent: string(ctx.Request().Header.UserAgent()),
+		ClientIp:  ctx.Context().RemoteAddr().String(),
+	}
+
+	err = uc.userInteractor.VerifyResetUserPasswordCode(ctx.Context(), &userResetPasswordReq, usrInfo)
 	if err != nil {
 		return err
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(map[string]string{
-		"message": "OK",
-	})
+	return ctx.Status(fiber.StatusOK).JSON(&model.MessageResp{Message: "OK"})
 }
 
 // SendCodeForResetUserPassword accepts the user's email or phone, check his details, if they exist, send the 2fa code
@@ -128,7 +128,7 @@ func (uc *userController) GetMyProfile(ctx *fiber.Ctx) error {
 	return ctx.Status(fiber.StatusOK).JSON(resp)
 }
 
-// ChangeMyPassword takes 2fa password reset code and old password, verifies them and changes user password
+// ChangeMyPassword takes the old and new passwords, verifies the old one and changes user password
 func (uc *userController) ChangeMyPassword(ctx *fiber.Ctx) error {
 
 	var reqData *model.UserChangePasswordReq
@@ -142,14 +142,18 @@ func (uc *userController) ChangeMyPassword(ctx *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
 	}
 
-	err = uc.userInteractor.ChangeMyPassword(ctx.Context(), reqData, usrID)
+	usrInfo := &model.UserSessionData{
+		UserID:    usrID,
+		UserAgent: string(ctx.Request().Header.UserAgent()),
+		ClientIp:  ctx.Context().RemoteAddr().String(),
+	}
+
+	err = uc.userInteractor.ChangeMyPassword(ctx.Context(), reqData, usrInfo)
 	if err != nil {
 		return err
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(map[string]string{
-		"message": "OK",
-	})
+	return ctx.Status(fiber.StatusOK).JSON(&model.MessageResp{Message: "OK"})
 }
 
 // UpdateMyselfInfo  takes the user's information, and updates it from the user
@@ -175,11 +179,11 @@ func (uc *userController) UpdateMyselfInfo(ctx *fiber.Ctx) error {
 	return ctx.Status(fiber.StatusOK).JSON(resp)
 }
 
-// UpdateMyselfPhone takes the phone and 2fa code, checks the code and updates the user's phone
-func (uc *userController) UpdateMyselfPhone(ctx *fiber.Ctx) error {
+// UpdateMyselfUserName sets the user name, which could be used as the login
+func (uc *userController) UpdateMyselfUserName(ctx *fiber.Ctx) error {
 
-	var reqData *model.UserPhoneUpdateReq
-	err := ctx.BodyParser(&reqData)
+	var updReq model.UserNameUpdateReq
+	err := ctx.BodyParser(&updReq)
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
@@ -189,7 +193,7 @@ func (uc *userController) UpdateMyselfPhone(ctx *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
 	}
 
-	resp, err := uc.userInteractor.UpdateMyselfPhone(ctx.Context(), reqData, usrID)
+	resp, err := uc.userInteractor.UpdateMyUserName(ctx.Context(), &updReq, usrID)
 	if err != nil {
 		return err
 	}
@@ -197,43 +201,64 @@ func (uc *userController) UpdateMyselfPhone(ctx *fiber.Ctx) error {
 	return ctx.Status(fiber.StatusOK).JSON(resp)
 }
 
-// UpdateMyselfEmail takes the email and 2fa code, checks the code and updates the user's email
-func (uc *userController) UpdateMyselfEmail(ctx *fiber.Ctx) error {
+// CheckUserName tells whether the user name is valid and free
+func (uc *userController) CheckUserName(ctx *fiber.Ctx) error {
 
-	var reqData *model.UserEmailUpdateReq
-	err := ctx.BodyParser(&reqData)
+	var checkReq model.UserNameCheckReq
+	err := ctx.QueryParser(&checkReq)
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	usrID, ok := ctx.Context().Value("token_user_id").(string)
+	resp, err := uc.userInteractor.CheckUserName(ctx.Context(), &checkReq)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Status(fiber.StatusOK).JSON(resp)
+}
+
+// GetMySessions lists the live sessions of the user
+func (uc *userController) GetMySessions(ctx *fiber.Ctx) error {
+
+	userId, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
+	sessionID, ok := ctx.Context().Value("token_session_id").(string)
 	if !ok {
 		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
 	}
 
-	resp, err := uc.userInteractor.UpdateMyselfEmail(ctx.Context(), reqData, usrID)
+	sessions, err := uc.userInteractor.GetMySessions(ctx.Context(), userId, sessionID)
 	if err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return err
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(resp)
+	return ctx.Status(fiber.StatusOK).JSON(sessions)
 }
 
-//SignOut invalidates the user session in redis
+// SignOut invalidates the user session in redis
 func (uc *userController) SignOut(ctx *fiber.Ctx) error {
 
+	userId, ok := ctx.Context().Value("token_user_id").(string)
+	if !ok {
+		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
+	}
+
 	sessionID, ok := ctx.Context().Value("token_session_id").(string)
 	if !ok {
 		return fiber.NewError(fiber.StatusInternalServerError, "context value type invalid")
 	}
 
-	err := uc.userInteractor.SignOut(ctx.Context(), sessionID)
+	err := uc.userInteractor.SignOut(ctx.Context(), userId, sessionID)
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(map[string]string{
-		"message": "token invalidated, a new token is required to access the protected API\"",
+	return ctx.Status(fiber.StatusOK).JSON(&model.MessageResp{
+		Message: "token invalidated, a new token is required to access the protected API\"",
 	})
 }
 
@@ -250,7 +275,7 @@ func (uc *userController) SignOutAll(ctx *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	return ctx.Status(fiber.StatusOK).JSON(map[string]string{
-		"message": "token invalidated, a new token is required to access the protected API",
+	return ctx.Status(fiber.StatusOK).JSON(&model.MessageResp{
+		Message: "token invalidated, a new token is required to access the protected API",
 	})
 }
@@ -0,0 +1,37 @@
+package presenter
+
+import (
+	"auth-project/src/domain/model"
+	"time"
+)
+
+type accountPresenter struct {
+	userPresenter UserPresenter
+}
+
+type AccountPresenter interface {
+	ExportUserDataResp(usr *model.User, sessions []model.Session, referrals []model.User, events []model.SecurityEvent) *model.UserDataExport
+}
+
+func NewAccountPresenter() AccountPresenter {
+	return &accountPresenter{NewUserPresenter()}
+}
+
+func (ap *accountPresenter) ExportUserDataResp(usr *model.User, sessions []model.Session, referrals []model.User,
+	events []model.SecurityEvent) *model.UserDataExport {
+
+	resp := &model.UserDataExport{
+		Profile:        ap.userPresenter.GetMyProfileByIDResp(usr),
+		Sessions:       sessions,
+		Referrals:      make([]model.UserReferralGet, 0, len(referrals)),
+		SecurityEvents: events,
+		ExportedAt:     time.Now().UTC(),
+	}
+	for _, referral := range referrals {
+		resp.Referrals = append(resp.Referrals, model.UserReferralGet{
+			ID: referral.ID,
+		})
+	}
+
+	return resp
+}
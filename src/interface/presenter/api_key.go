@@ -0,0 +1,21 @@
+package presenter
+
+import "auth-project/src/domain/model"
+
+type apiKeyPresenter struct {
+}
+
+type ApiKeyPresenter interface {
+	CreateApiKeyResp(key *model.ApiKey, secret string) *model.ApiKeyCreateResp
+}
+
+func NewApiKeyPresenter() ApiKeyPresenter {
+	return &apiKeyPresenter{}
+}
+
+func (ap *apiKeyPresenter) CreateApiKeyResp(key *model.ApiKey, secret string) *model.ApiKeyCreateResp {
+	return &model.ApiKeyCreateResp{
+		ApiKey: *key,
+		Key:    secret,
+	}
+}
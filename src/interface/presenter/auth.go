@@ -1,11 +1,46 @@
 package presenter
 
+import "auth-project/src/domain/model"
+
 type authPresenter struct {
 }
 
 type AuthPresenter interface {
+	IntrospectionResp(claims *model.AccessClaims, isRefresh bool) *model.OAuthIntrospectionResp
 }
 
 func NewAuthPresenter() AuthPresenter {
 	return &authPresenter{}
 }
+
+// IntrospectionResp returns the introspection of the token, nil claims mean the inactive token
+func (ap *authPresenter) IntrospectionResp(claims *model.AccessClaims, isRefresh bool) *model.OAuthIntrospectionResp {
+	if claims == nil {
+		return &model.OAuthIntrospectionResp{Active: false}
+	}
+
+	resp := &model.OAuthIntrospectionResp{
+		Active:    true,
+		Scope:     claims.Scope,
+		ClientID:  claims.ClientID,
+		TokenType: "Bearer",
+		Exp:       claims.Exp,
+		Sub:       claims.UserID,
+		Aud:       claims.Audience,
+		Iss:       claims.Issuer,
+		Jti:       claims.AtID,
+		Type:      claims.Type,
+		SessionID: claims.SessionID,
+		TenantID:  claims.TenantID,
+		Role:      claims.Role,
+	}
+	if isRefresh {
+		resp.TokenType = model.TokenTypeHintRefreshToken
+	}
+	// the api key and the client have no session
+	if claims.Type != model.AccessTokenTypeAuth {
+		resp.SessionID = ""
+	}
+
+	return resp
+}
@@ -0,0 +1,11 @@
+package presenter
+
+type contactChangePresenter struct {
+}
+
+type ContactChangePresenter interface {
+}
+
+func NewContactChangePresenter() ContactChangePresenter {
+	return &contactChangePresenter{}
+}
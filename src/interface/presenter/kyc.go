@@ -0,0 +1,32 @@
+package presenter
+
+import "auth-project/src/domain/model"
+
+type kycPresenter struct {
+}
+
+type KycPresenter interface {
+	GetMyKycResp(config *model.UserConfig, submissions []model.KycSubmission) *model.KycStatusResp
+	GetKycSubmissionsResp(submissions []model.KycSubmission, total, limit, offset int) *model.KycSubmissionListResp
+}
+
+func NewKycPresenter() KycPresenter {
+	return &kycPresenter{}
+}
+
+func (kp *kycPresenter) GetMyKycResp(config *model.UserConfig, submissions []model.KycSubmission) *model.KycStatusResp {
+	return &model.KycStatusResp{
+		KycLevel:    config.KycLevel,
+		Submissions: submissions,
+	}
+}
+
+func (kp *kycPresenter) GetKycSubmissionsResp(submissions []model.KycSubmission,
+	total, limit, offset int) *model.KycSubmissionListResp {
+	return &model.KycSubmissionListResp{
+		Submissions: submissions,
+		Total:       total,
+		Limit:       limit,
+		Offset:      offset,
+	}
+}
@@ -0,0 +1,36 @@
+package presenter
+
+import (
+	"auth-project/src/domain/model"
+	"time"
+)
+
+type oAuthClientPresenter struct {
+}
+
+type OAuthClientPresenter interface {
+	OAuthClientSecretResp(client *model.OAuthClient, secret string) *model.OAuthClientSecretResp
+	OAuthTokenResp(details *model.AccessTokenDetails, scope string) *model.OAuthTokenResp
+}
+
+func NewOAuthClientPresenter() OAuthClientPresenter {
+	return &oAuthClientPresenter{}
+}
+
+func (op *oAuthClientPresenter) OAuthClientSecretResp(client *model.OAuthClient,
+	secret string) *model.OAuthClientSecretResp {
+	return &model.OAuthClientSecretResp{
+		OAuthClient:  *client,
+		ClientSecret: secret,
+	}
+}
+
+func (op *oAuthClientPresenter) OAuthTokenResp(details *model.AccessTokenDetails,
+	scope string) *model.OAuthTokenResp {
+	return &model.OAuthTokenResp{
+		AccessToken: details.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   details.AtExpires - time.Now().UTC().Unix(),
+		Scope:       scope,
+	}
+}
@@ -0,0 +1,93 @@
+package presenter
+
+import (
+	"auth-project/src/domain/model"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+type referralPresenter struct {
+}
+
+type ReferralPresenter interface {
+	GetReferralsResp(users []model.User, total, limit, offset int) *model.ReferralListResp
+	GetReferralTreeResp(referralLink string, users []model.ReferredUser) []*model.ReferralTreeNode
+	GetReferralCountResp(users []model.ReferredUser) *model.ReferralCountResp
+}
+
+func NewReferralPresenter() ReferralPresenter {
+	return &referralPresenter{}
+}
+
+func (rp *referralPresenter) GetReferralsResp(users []model.User, total, limit, offset int) *model.ReferralListResp {
+	resp := &model.ReferralListResp{
+		Referrals: make([]model.ReferralUserResp, 0, len(users)),
+		Total:     total,
+		Limit:     limit,
+		Offset:    offset,
+	}
+	for _, usr := range users {
+		resp.Referrals = append(resp.Referrals, referralUserResp(usr.ID, usr.FullName, usr.CreatedAt))
+	}
+	return resp
+}
+
+// GetReferralTreeResp nests the referrals under the users who invited them, starting from the referral link
+func (rp *referralPresenter) GetReferralTreeResp(referralLink string,
+	users []model.ReferredUser) []*model.ReferralTreeNode {
+
+	children := make(map[string][]*model.ReferralTreeNode)
+	nodes := make([]*model.ReferralTreeNode, 0, len(users))
+	for _, usr := range users {
+		node := &model.ReferralTreeNode{
+			ReferralUserResp: referralUserResp(usr.ID, usr.FullName, usr.CreatedAt),
+			Referrals:        make([]*model.ReferralTreeNode, 0),
+		}
+		nodes = append(nodes, node)
+		children[usr.Referral] = append(children[usr.Referral], node)
+	}
+
+	for i, usr := range users {
+		if referrals, ok := children[usr.ReferralLink]; ok {
+			nodes[i].Referrals = referrals
+		}
+	}
+
+	if roots, ok := children[referralLink]; ok {
+		return roots
+	}
+	return make([]*model.ReferralTreeNode, 0)
+}
+
+func (rp *referralPresenter) GetReferralCountResp(users []model.ReferredUser) *model.ReferralCountResp {
+	resp := &model.ReferralCountResp{
+		Levels: make([]model.ReferralLevelCount, 0),
+	}
+	for _, usr := range users {
+		if len(resp.Levels) < usr.Level {
+			resp.Levels = append(resp.Levels, model.ReferralLevelCount{Level: usr.Level})
+		}
+		resp.Levels[usr.Level-1].Count++
+		resp.Total++
+		if usr.Level == 1 {
+			resp.Direct++
+		}
+	}
+	return resp
+}
+
+// referralUserResp hides the name of the referral, only the first letters of the words are shown
+func referralUserResp(id, fullName string, createdAt time.Time) model.ReferralUserResp {
+	words := strings.Fields(fullName)
+	for i, word := range words {
+		r, _ := utf8.DecodeRuneInString(word)
+		words[i] = string(r) + "***"
+	}
+
+	return model.ReferralUserResp{
+		ID:       id,
+		Name:     strings.Join(words, " "),
+		JoinedAt: createdAt,
+	}
+}
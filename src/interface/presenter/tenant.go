@@ -0,0 +1,26 @@
+package presenter
+
+import "auth-project/src/domain/model"
+
+type tenantPresenter struct {
+}
+
+type TenantPresenter interface {
+	GetTenantBrandingResp(tenant *model.Tenant) *model.TenantBrandingResp
+}
+
+func NewTenantPresenter() TenantPresenter {
+	return &tenantPresenter{}
+}
+
+func (tp *tenantPresenter) GetTenantBrandingResp(tenant *model.Tenant) *model.TenantBrandingResp {
+	return &model.TenantBrandingResp{
+		ID:                    tenant.ID,
+		Name:                  tenant.Name,
+		LogoURL:               tenant.LogoURL,
+		PrimaryColor:          tenant.PrimaryColor,
+		SupportEmail:          tenant.SupportEmail,
+		TwoFactorAuthRequired: tenant.TwoFactorAuthRequired,
+		TwoFactorAuthTypes:    tenant.TwoFactorAuthTypes,
+	}
+}
@@ -0,0 +1,11 @@
+package presenter
+
+type trustedDevicePresenter struct {
+}
+
+type TrustedDevicePresenter interface {
+}
+
+func NewTrustedDevicePresenter() TrustedDevicePresenter {
+	return &trustedDevicePresenter{}
+}
@@ -31,9 +31,16 @@ func (up *userPresenter) GetMyProfileByIDResp(usr *model.User) *model.UserGetMyP
 		IsPhoneVerified:  usr.IsPhoneVerified,
 		IsEmailVerified:  usr.IsEmailVerified,
 		IsGoogleVerified: usr.IsGoogleVerified,
+		Default2faType:   usr.DefaultTwoFactorAuthType(),
 		Referral:         usr.Referral,
 		CreatedAt:        usr.CreatedAt,
 	}
+	if !usr.EmailVerifiedAt.IsZero() {
+		resp.EmailVerifiedAt = &usr.EmailVerifiedAt
+	}
+	if !usr.PhoneVerifiedAt.IsZero() {
+		resp.PhoneVerifiedAt = &usr.PhoneVerifiedAt
+	}
 	if usr.ReferralUser != nil {
 		resp.ReferralUser = &model.UserReferralGet{
 			ID: usr.ReferralUser.ID,
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/infrastructure/storage/files"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/uptrace/bun"
+	"log"
+	"time"
+)
+
+type accountRepository struct {
+	db          *bun.DB
+	fileStorage files.Storage
+}
+
+type AccountRepository interface {
+	SoftDeleteUser(ctx context.Context, usrID string) error
+	PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int, error)
+	GetReferredUsers(ctx context.Context, referralLink string) ([]model.User, error)
+}
+
+func NewAccountRepository(db *bun.DB, fs files.Storage) AccountRepository {
+	return &accountRepository{db, fs}
+}
+
+// SoftDeleteUser deactivates the user, so he can't sign in anymore,
+// the data is kept until PurgeDeletedUsers after the grace period
+func (ar *accountRepository) SoftDeleteUser(ctx context.Context, usrID string) error {
+
+	res, err := ar.db.NewUpdate().Model((*model.User)(nil)).
+		Where("id = ?", usrID).
+		Where("deleted_at IS NULL").
+		Set("is_active = FALSE").
+		Set("deleted_at = ?", time.Now().UTC()).
+		Set("updated_at = ?", time.Now().UTC()).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("user not identified")
+	}
+
+	return nil
+}
+
+// PurgeDeletedUsers erases the users deleted before the time, sessions, user configs and other
+// user's records are removed by the cascade, tokens are not linked to the user by the key, so they are removed here,
+// the kyc documents are removed from the file storage after the user is erased
+func (ar *accountRepository) PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int, error) {
+
+	var users []model.User
+	err := ar.db.NewSelect().Model(&users).
+		Column("id", "tenant_id", "email", "phone").
+		Where("deleted_at IS NOT NULL").
+		Where("deleted_at < ?", deletedBefore.UTC()).
+		Scan(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, usr := range users {
+		var storageKeys []string
+		err = ar.db.NewSelect().Model((*model.KycDocument)(nil)).
+			Column("kdc.storage_key").
+			Join("JOIN kyc_submissions AS kyc ON kyc.id = kdc.submission_id").
+			Where("kyc.user_id = ?", usr.ID).
+			Scan(ctx, &storageKeys)
+		if err != nil {
+			return purged, err
+		}
+
+		err = ar.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+			// the same email or phone could belong to the user of another tenant
+			query := tx.NewDelete().Model((*model.Token)(nil)).
+				WhereGroup(" AND ", func(q *bun.DeleteQuery) *bun.DeleteQuery {
+					q = q.Where("user_id = ?", usr.ID)
+					if usr.Email != "" {
+						q = q.WhereOr("target = ?", usr.Email)
+					}
+					if usr.Phone != "" {
+						q = q.WhereOr("target = ?", usr.Phone)
+					}
+					return q
+				}).
+				Where("tenant_id = ?", usr.TenantID)
+			_, err := query.Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.NewDelete().Model((*model.User)(nil)).
+				Where("id = ?", usr.ID).
+				Exec(ctx)
+			return err
+		})
+		if err != nil {
+			return purged, err
+		}
+		purged++
+
+		for _, key := range storageKeys {
+			if delErr := ar.fileStorage.Delete(ctx, key); delErr != nil {
+				log.Printf("error deleting kyc document %s: %s", key, delErr.Error())
+			}
+		}
+	}
+
+	return purged, nil
+}
+
+// GetReferredUsers returns the users signed up by the referral link
+func (ar *accountRepository) GetReferredUsers(ctx context.Context, referralLink string) ([]model.User, error) {
+
+	users := make([]model.User, 0)
+	err := ar.db.NewSelect().Model(&users).
+		Where("referral = ?", referralLink).
+		Where("is_active = TRUE").
+		Order("created_at").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
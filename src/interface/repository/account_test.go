@@ -0,0 +1,218 @@
+package repository_test
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/infrastructure/storage/files"
+	"auth-project/src/infrastructure/storage/storagetest"
+	"auth-project/src/interface/repository"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+func insertUser(t *testing.T, db *bun.DB, usr *model.User) {
+	t.Helper()
+
+	usr.ReferralLink = usr.ID
+	_, err := db.NewInsert().Model(usr).Exec(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func insertToken(t *testing.T, db *bun.DB, tkn *model.Token) {
+	t.Helper()
+
+	tkn.Value, tkn.Reason, tkn.Type = "123456", model.TokenReasonTwoFactorAuth, model.TokenTypeEmail
+	tkn.ExpiresAT = time.Now().Add(time.Hour).UTC()
+	_, err := db.NewInsert().Model(tkn).Exec(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func tokenIDs(t *testing.T, db *bun.DB) []string {
+	t.Helper()
+
+	var ids []string
+	err := db.NewSelect().Model((*model.Token)(nil)).
+		Column("id").
+		Order("id").
+		Scan(context.Background(), &ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+// userRows returns the number of the rows of the user in the table of the model
+func userRows(t *testing.T, db *bun.DB, m interface{}, usrID string) int {
+	t.Helper()
+
+	count, err := db.NewSelect().Model(m).Where("user_id = ?", usrID).Count(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
+
+// insertUserRows inserts the session, the config and the security event of the user
+func insertUserRows(t *testing.T, db *bun.DB, usrID string) {
+	t.Helper()
+
+	ctx := context.Background()
+	for _, m := range []interface{}{
+		&model.Session{SessionID: "ssn-" + usrID, UserID: usrID, ExpiresAT: time.Now().Add(time.Hour).UTC()},
+		&model.UserConfig{UserID: usrID},
+		&model.SecurityEvent{ID: "sev-" + usrID, UserID: usrID, Event: model.SecurityEventNewLogin},
+	} {
+		_, err := db.NewInsert().Model(m).Exec(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSoftDeleteUser(t *testing.T) {
+	db := storagetest.NewSQLite(t)
+	ctx := context.Background()
+	ar := repository.NewAccountRepository(db, files.NewLocalStorage(t.TempDir()))
+
+	insertUser(t, db, &model.User{ID: "referrer", Email: "referrer@gmail.com", IsActive: true})
+	insertUser(t, db, &model.User{ID: "user", Email: "user@gmail.com", Referral: "referrer", IsActive: true})
+
+	referrals, err := ar.GetReferredUsers(ctx, "referrer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(referrals) != 1 || referrals[0].ID != "user" {
+		t.Fatalf("got referrals %+v, want the user", referrals)
+	}
+
+	err = ar.SoftDeleteUser(ctx, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	usr := &model.User{}
+	err = db.NewSelect().Model(usr).Where("id = ?", "user").Scan(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usr.IsActive || usr.DeletedAt.IsZero() {
+		t.Fatalf("got active %v, deleted at %v, want the deactivated user", usr.IsActive, usr.DeletedAt)
+	}
+
+	// the deleted user is not deleted again, the deletion time is kept for the grace period
+	err = ar.SoftDeleteUser(ctx, "user")
+	if err == nil {
+		t.Fatal("the deleted user is deleted again")
+	}
+	err = ar.SoftDeleteUser(ctx, "unknown")
+	if err == nil {
+		t.Fatal("the unknown user is deleted")
+	}
+
+	// the deleted user is not listed in the referrals of the export
+	referrals, err = ar.GetReferredUsers(ctx, "referrer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(referrals) != 0 {
+		t.Fatalf("got referrals %+v, want none", referrals)
+	}
+}
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	db := storagetest.NewSQLite(t)
+	ctx := context.Background()
+	fs := files.NewLocalStorage(t.TempDir())
+	ar := repository.NewAccountRepository(db, fs)
+	kr := newKycRepository(t, db, fs)
+
+	_, err := db.NewInsert().Model(&model.Tenant{ID: "acme", Name: "acme"}).Exec(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	insertUser(t, db, &model.User{ID: "deleted", Email: "user@gmail.com", Phone: "+12025550123",
+		DeletedAt: time.Now().Add(-time.Hour).UTC()})
+	insertUser(t, db, &model.User{ID: "live", Email: "live@gmail.com"})
+	// the user of another tenant with the same email
+	insertUser(t, db, &model.User{ID: "acme", TenantID: "acme", Email: "user@gmail.com"})
+
+	insertUserRows(t, db, "deleted")
+	insertUserRows(t, db, "live")
+
+	deletedKyc := createKycSubmission(t, kr, "deleted")
+	liveKyc := createKycSubmission(t, kr, "live")
+
+	insertToken(t, db, &model.Token{ID: "1", UserID: "deleted", Target: "old@gmail.com"})
+	insertToken(t, db, &model.Token{ID: "2", Target: "user@gmail.com"})
+	insertToken(t, db, &model.Token{ID: "3", Target: "+12025550123"})
+	insertToken(t, db, &model.Token{ID: "4", UserID: "live", Target: "live@gmail.com"})
+	insertToken(t, db, &model.Token{ID: "5", TenantID: "acme", Target: "user@gmail.com"})
+
+	// the user is kept within the grace period
+	purged, err := ar.PurgeDeletedUsers(ctx, time.Now().Add(-2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 0 {
+		t.Fatalf("got %d purged users within the grace period, want 0", purged)
+	}
+
+	purged, err = ar.PurgeDeletedUsers(ctx, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if purged != 1 {
+		t.Fatalf("got %d purged users, want 1", purged)
+	}
+
+	exists, err := db.NewSelect().Model((*model.User)(nil)).Where("id = ?", "deleted").Exists(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Fatal("the deleted user is not purged")
+	}
+
+	// the records of the user are removed by the cascade, the ones of the live user are kept
+	for _, m := range []interface{}{
+		(*model.Session)(nil), (*model.UserConfig)(nil), (*model.SecurityEvent)(nil), (*model.KycSubmission)(nil),
+	} {
+		if n := userRows(t, db, m, "deleted"); n != 0 {
+			t.Fatalf("got %d rows of %T of the deleted user, want 0", n, m)
+		}
+		if n := userRows(t, db, m, "live"); n != 1 {
+			t.Fatalf("got %d rows of %T of the live user, want 1", n, m)
+		}
+	}
+
+	// the kyc documents are removed by the cascade and from the file storage
+	exists, err = db.NewSelect().Model((*model.KycDocument)(nil)).
+		Where("submission_id = ?", deletedKyc.ID).
+		Exists(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists {
+		t.Fatal("the kyc documents of the deleted user are not purged")
+	}
+	_, err = fs.Get(ctx, deletedKyc.Documents[0].StorageKey)
+	if err != files.ErrNotFound {
+		t.Fatalf("got %v, want the kyc file of the deleted user removed", err)
+	}
+	_, err = fs.Get(ctx, liveKyc.Documents[0].StorageKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the tokens of the live user and of the other tenant are kept
+	if ids := tokenIDs(t, db); len(ids) != 2 || ids[0] != "4" || ids[1] != "5" {
+		t.Fatalf("got tokens %v, want [4 5]", ids)
+	}
+}
@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+	"errors"
+	"github.com/spf13/viper"
+	"github.com/uptrace/bun"
+	"time"
+)
+
+type apiKeyRepository struct {
+	db *bun.DB
+}
+
+type ApiKeyRepository interface {
+	CreateApiKey(ctx context.Context, key *model.ApiKey) error
+	GetApiKeyByHash(ctx context.Context, keyHash string) (*model.ApiKey, error)
+	UseApiKey(ctx context.Context, keyHash, clientIP string) (*model.ApiKey, error)
+	GetApiKeysByUserID(ctx context.Context, usrID string) ([]model.ApiKey, error)
+	RevokeApiKey(ctx context.Context, keyID, usrID string) error
+}
+
+func NewApiKeyRepository(db *bun.DB) ApiKeyRepository {
+	return &apiKeyRepository{db}
+}
+
+func (ar *apiKeyRepository) CreateApiKey(ctx context.Context, key *model.ApiKey) error {
+
+	_, err := ar.db.NewInsert().Model(key).
+		Exec(ctx)
+	return err
+}
+
+// GetApiKeyByHash returns the active key by the hash
+func (ar *apiKeyRepository) GetApiKeyByHash(ctx context.Context, keyHash string) (*model.ApiKey, error) {
+
+	key := new(model.ApiKey)
+	err := ar.db.NewSelect().Model(key).
+		Where("key_hash = ?", keyHash).
+		Where("revoked_at IS NULL").
+		Where("expires_at > ?", time.Now().UTC()).
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// UseApiKey finds the active key by the hash and marks the time and the address of use,
+// the mark is updated not more often than api_key.last_used_interval to spare the writes
+func (ar *apiKeyRepository) UseApiKey(ctx context.Context, keyHash, clientIP string) (*model.ApiKey, error) {
+
+	key, err := ar.GetApiKeyByHash(ctx, keyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if time.Since(key.LastUsedAt) < viper.GetDuration("api_key.last_used_interval") {
+		return key, nil
+	}
+
+	key.LastUsedAt = time.Now().UTC()
+	key.LastUsedIP = clientIP
+	_, err = ar.db.NewUpdate().Model(key).
+		Column("last_used_at", "last_used_ip").
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func (ar *apiKeyRepository) GetApiKeysByUserID(ctx context.Context, usrID string) ([]model.ApiKey, error) {
+
+	keys := make([]model.ApiKey, 0)
+	err := ar.db.NewSelect().Model(&keys).
+		Where("user_id = ?", usrID).
+		Where("revoked_at IS NULL").
+		Order("created_at DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (ar *apiKeyRepository) RevokeApiKey(ctx context.Context, keyID, usrID string) error {
+
+	res, err := ar.db.NewUpdate().Model((*model.ApiKey)(nil)).
+		Where("id = ?", keyID).
+		Where("user_id = ?", usrID).
+		Where("revoked_at IS NULL").
+		Set("revoked_at = ?", time.Now().UTC()).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("api key not found")
+	}
+
+	return nil
+}
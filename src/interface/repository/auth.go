@@ -2,43 +2,57 @@ package repository
 
 import (
 	"auth-project/src/domain/model"
+	"auth-project/src/infrastructure/storage/sessions"
 	"context"
 	"errors"
-	"github.com/go-redis/redis/v8"
+	"github.com/spf13/viper"
 	"github.com/uptrace/bun"
 	"time"
 )
 
+// reconcileBatchSize is the number of the live sessions of the database read at once by ReconcileSessions
+const reconcileBatchSize = 1000
+
 type authRepository struct {
-	db  *bun.DB
-	rdb *redis.Client
+	db           *bun.DB
+	sessionStore sessions.Store
 }
 
 type AuthRepository interface {
 	StoreTokenPair(ctx context.Context, td *model.TokenDetails, sessionID string) error
 	StoreAccessToken(ctx context.Context, atd *model.AccessTokenDetails, sessionID string) error
-	FetchAuth(ctx context.Context, sessionID string) (string, error)
+	FetchAuth(ctx context.Context, usrID, sessionID string) (string, error)
+	LogoutSession(ctx context.Context, usrID, sessionID string) error
+	GetLiveSessions(ctx context.Context, usrID string) ([]model.Session, error)
+	ReconcileSessions(ctx context.Context) (int, error)
 
 	ValidateAccessToken(ctx context.Context, atID string, sessionID string) error
+	RevokeAccessToken(ctx context.Context, atID string, expiresAt int64) error
+	IsAccessTokenRevoked(ctx context.Context, atID string) (bool, error)
 }
 
-func NewAuthRepository(db *bun.DB, rdb *redis.Client) AuthRepository {
-	return &authRepository{db, rdb}
+func NewAuthRepository(db *bun.DB, sessionStore sessions.Store) AuthRepository {
+	return &authRepository{db, sessionStore}
 }
 
+// StoreTokenPair stores the ids of both tokens of the session and indexes the session at once,
+// the sessions over session_store.max_sessions revoked by the store are marked logged out
 func (ar *authRepository) StoreTokenPair(ctx context.Context, td *model.TokenDetails, sessionID string) error {
 
-	at := time.Unix(td.AtExpires, 0)
-	rt := time.Unix(td.RtExpires, 0)
-	now := time.Now().UTC()
-
-	sessionIdRt := sessionID + model.PostfixRefreshToken
-
-	// set information in Redis, where the key is the session ID
-	ar.rdb.Set(ctx, sessionID, td.AtID, at.Sub(now))
-	ar.rdb.Set(ctx, sessionIdRt, td.RtID, rt.Sub(now))
+	// set information in the session store, where the key is the session ID
+	revoked, err := ar.sessionStore.SetSession(ctx, &sessions.Session{
+		UserID:    td.UserID,
+		SessionID: sessionID,
+		AtID:      td.AtID,
+		AtExpires: time.Unix(td.AtExpires, 0).UTC(),
+		RtID:      td.RtID,
+		RtExpires: time.Unix(td.RtExpires, 0).UTC(),
+	}, viper.GetInt("session_store.max_sessions"))
+	if err != nil {
+		return err
+	}
 
-	return nil
+	return ar.logoutSessions(ctx, revoked)
 }
 
 func (ar *authRepository) StoreAccessToken(ctx context.Context, atd *model.AccessTokenDetails, sessionID string) error {
@@ -46,37 +60,153 @@ func (ar *authRepository) StoreAccessToken(ctx context.Context, atd *model.Acces
 	twoFactorAuthToken := time.Unix(atd.AtExpires, 0)
 	now := time.Now().UTC()
 
-	// set information in Redis, where the key is the session ID
-	ar.rdb.Set(ctx, sessionID, atd.AtID, twoFactorAuthToken.Sub(now))
+	// set information in the session store, where the key is the session ID
+	return ar.sessionStore.Set(ctx, sessionID, atd.AtID, twoFactorAuthToken.Sub(now))
+}
 
-	return nil
+// FetchAuth takes the refresh token id of the session and deletes both tokens of the session at once,
+// so of the concurrent refreshes by the same token only one succeeds
+func (ar *authRepository) FetchAuth(ctx context.Context, usrID, sessionID string) (string, error) {
+
+	val, err := ar.sessionStore.TakeRefreshToken(ctx, usrID, sessionID)
+	if err == sessions.ErrNotFound {
+		return "", model.ErrSessionNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return val, nil
 }
 
-func (ar *authRepository) FetchAuth(ctx context.Context, sessionID string) (string, error) {
+// LogoutSession revokes the tokens of the session and marks it logged out
+func (ar *authRepository) LogoutSession(ctx context.Context, usrID, sessionID string) error {
 
-	redisRtKey := sessionID + model.PostfixRefreshToken
-	if ar.rdb.Exists(ctx, redisRtKey).Val() != 1 {
-		return "", errors.New("at token dont found")
+	err := ar.sessionStore.RevokeSession(ctx, usrID, sessionID)
+	if err != nil {
+		return err
 	}
 
-	val, err := ar.rdb.Get(ctx, redisRtKey).Result()
-	if err != nil && err != redis.Nil {
-		return "", err
+	return ar.logoutSessions(ctx, []string{sessionID})
+}
+
+// GetLiveSessions returns the sessions of the index of the session store, the ones refreshed the latest first
+func (ar *authRepository) GetLiveSessions(ctx context.Context, usrID string) ([]model.Session, error) {
+
+	sessionIDs, err := ar.sessionStore.ListSessions(ctx, usrID)
+	if err != nil {
+		return nil, err
+	}
+
+	liveSessions := make([]model.Session, 0, len(sessionIDs))
+	if len(sessionIDs) == 0 {
+		return liveSessions, nil
 	}
 
-	if ar.rdb.Exists(ctx, sessionID).Val() == 1 {
-		ar.rdb.Del(ctx, sessionID)
+	err = ar.db.NewSelect().Model(&liveSessions).
+		Where("user_id = ?", usrID).
+		Where("session_id IN (?)", bun.In(sessionIDs)).
+		Order("expires_at DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	ar.rdb.Del(ctx, redisRtKey)
+	return liveSessions, nil
+}
+
+// ReconcileSessions brings the indexes of the session store in line with the live sessions of the database,
+// the sessions whose tokens are lost by the store are marked logged out, their number is returned.
+// The sessions created or refreshed within session_store.reconcile_grace are skipped, since their tokens
+// could be between the take of the refresh token and the store of the new pair
+func (ar *authRepository) ReconcileSessions(ctx context.Context) (int, error) {
 
-	return val, nil
+	settledBefore := time.Now().UTC().Add(-viper.GetDuration("session_store.reconcile_grace"))
+
+	var lost int
+	var lastUserID, lastSessionID string
+	expiresAt := make(map[string]time.Time)
+
+	reconcileUser := func(usrID string) error {
+		missing, err := ar.sessionStore.ReconcileSessions(ctx, usrID, expiresAt)
+		if err != nil {
+			return err
+		}
+
+		expiresAt = make(map[string]time.Time)
+		if len(missing) == 0 {
+			return nil
+		}
+
+		// the session refreshed since the select is not lost
+		res, err := ar.db.NewUpdate().Model((*model.Session)(nil)).
+			Where("session_id IN (?)", bun.In(missing)).
+			Where("COALESCE(updated_at, created_at) < ?", settledBefore).
+			Set("is_logout = TRUE").
+			Set("updated_at = ?", time.Now().UTC()).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		lost += int(n)
+
+		return nil
+	}
+
+	for {
+		var batch []model.Session
+		q := ar.db.NewSelect().Model(&batch).
+			Column("session_id", "user_id", "expires_at").
+			Where("is_logout = FALSE").
+			Where("expires_at > ?", time.Now().UTC()).
+			Where("COALESCE(updated_at, created_at) < ?", settledBefore).
+			Order("user_id", "session_id").
+			Limit(reconcileBatchSize)
+		if lastUserID != "" {
+			q = q.Where("(user_id > ? OR (user_id = ? AND session_id > ?))", lastUserID, lastUserID, lastSessionID)
+		}
+
+		err := q.Scan(ctx)
+		if err != nil {
+			return lost, err
+		}
+
+		for _, ses := range batch {
+			if ses.UserID != lastUserID && lastUserID != "" {
+				err = reconcileUser(lastUserID)
+				if err != nil {
+					return lost, err
+				}
+			}
+
+			expiresAt[ses.SessionID] = ses.ExpiresAT
+			lastUserID, lastSessionID = ses.UserID, ses.SessionID
+		}
+
+		if len(batch) < reconcileBatchSize {
+			break
+		}
+	}
+
+	if lastUserID != "" {
+		err := reconcileUser(lastUserID)
+		if err != nil {
+			return lost, err
+		}
+	}
+
+	return lost, nil
 }
 
 func (ar *authRepository) ValidateAccessToken(ctx context.Context, atID string, sessionID string) error {
 
-	val, err := ar.rdb.Get(ctx, sessionID).Result()
-	if err != nil && err != redis.Nil {
+	val, err := ar.sessionStore.Get(ctx, sessionID)
+	if err != nil && err != sessions.ErrNotFound {
 		return err
 	}
 
@@ -86,3 +216,41 @@ func (ar *authRepository) ValidateAccessToken(ctx context.Context, atID string,
 
 	return nil
 }
+
+// RevokeAccessToken denies the token which is not bound to the session until its expiration
+func (ar *authRepository) RevokeAccessToken(ctx context.Context, atID string, expiresAt int64) error {
+
+	ttl := time.Unix(expiresAt, 0).Sub(time.Now().UTC())
+	if ttl <= 0 {
+		return nil
+	}
+
+	return ar.sessionStore.Set(ctx, model.PrefixRevokedToken+atID, "1", ttl)
+}
+
+func (ar *authRepository) IsAccessTokenRevoked(ctx context.Context, atID string) (bool, error) {
+
+	_, err := ar.sessionStore.Get(ctx, model.PrefixRevokedToken+atID)
+	if err == sessions.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// logoutSessions marks the sessions revoked by the session store logged out
+func (ar *authRepository) logoutSessions(ctx context.Context, sessionIDs []string) error {
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+
+	_, err := ar.db.NewUpdate().Model((*model.Session)(nil)).
+		Where("session_id IN (?)", bun.In(sessionIDs)).
+		Set("is_logout = TRUE").
+		Set("updated_at = ?", time.Now().UTC()).
+		Exec(ctx)
+	return err
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/infrastructure/storage"
+	"context"
+	"database/sql"
+	"errors"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/uptrace/bun"
+	"time"
+)
+
+type contactChangeRepository struct {
+	db       *bun.DB
+	dbErrors storage.ErrorClassifier
+}
+
+type ContactChangeRepository interface {
+	IsContactTaken(ctx context.Context, contactType, value, usrID string) (bool, error)
+	CreateContactChange(ctx context.Context, change *model.ContactChange) (*model.ContactChange, error)
+	GetPendingContactChangesByUserID(ctx context.Context, usrID string) ([]model.ContactChange, error)
+	GetDueContactChanges(ctx context.Context) ([]model.ContactChange, error)
+	ApplyContactChange(ctx context.Context, change *model.ContactChange) error
+	CancelContactChange(ctx context.Context, changeID, usrID string) error
+	CancelContactChangesByUserID(ctx context.Context, usrID string) error
+}
+
+func NewContactChangeRepository(db *bun.DB, dbErrors storage.ErrorClassifier) ContactChangeRepository {
+	return &contactChangeRepository{db, dbErrors}
+}
+
+// IsContactTaken reports whether the email or phone belongs to another user of the same tenant
+// or is already waiting to be applied to another user of the same tenant
+func (cr *contactChangeRepository) IsContactTaken(ctx context.Context, contactType, value, usrID string) (bool, error) {
+
+	exists, err := cr.db.NewSelect().Model((*model.User)(nil)).
+		Where("? = ?", bun.Ident(contactType), value).
+		Where("id != ?", usrID).
+		Where("tenant_id = (SELECT tenant_id FROM users WHERE id = ?)", usrID).
+		Exists(ctx)
+	if err != nil || exists {
+		return exists, err
+	}
+
+	return cr.db.NewSelect().Model((*model.ContactChange)(nil)).
+		Where("type = ?", contactType).
+		Where("new_value = ?", value).
+		Where("user_id != ?", usrID).
+		Where("user_id IN (SELECT id FROM users WHERE tenant_id = (SELECT tenant_id FROM users WHERE id = ?))", usrID).
+		Where("applied_at IS NULL").
+		Where("canceled_at IS NULL").
+		Exists(ctx)
+}
+
+// CreateContactChange replaces the pending change of the same type with the new one
+func (cr *contactChangeRepository) CreateContactChange(ctx context.Context,
+	change *model.ContactChange) (*model.ContactChange, error) {
+
+	id, err := gonanoid.New()
+	if err != nil {
+		return nil, err
+	}
+	change.ID = id
+
+	err = cr.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewUpdate().Model((*model.ContactChange)(nil)).
+			Where("user_id = ?", change.UserID).
+			Where("type = ?", change.Type).
+			Where("applied_at IS NULL").
+			Where("canceled_at IS NULL").
+			Set("canceled_at = ?", time.Now().UTC()).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.NewInsert().Model(change).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return change, nil
+}
+
+func (cr *contactChangeRepository) GetPendingContactChangesByUserID(ctx context.Context,
+	usrID string) ([]model.ContactChange, error) {
+
+	changes := make([]model.ContactChange, 0)
+	err := cr.db.NewSelect().Model(&changes).
+		Where("user_id = ?", usrID).
+		Where("applied_at IS NULL").
+		Where("canceled_at IS NULL").
+		Order("created_at DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// GetDueContactChanges returns the pending changes whose grace period is over
+func (cr *contactChangeRepository) GetDueContactChanges(ctx context.Context) ([]model.ContactChange, error) {
+
+	changes := make([]model.ContactChange, 0)
+	err := cr.db.NewSelect().Model(&changes).
+		Where("apply_at <= ?", time.Now().UTC()).
+		Where("applied_at IS NULL").
+		Where("canceled_at IS NULL").
+		Order("apply_at").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// ApplyContactChange sets the new contact to the user and marks it verified,
+// model.ErrContactTaken is returned if the contact was taken by another user meanwhile
+func (cr *contactChangeRepository) ApplyContactChange(ctx context.Context, change *model.ContactChange) error {
+
+	now := time.Now().UTC()
+	err := cr.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		res, err := tx.NewUpdate().Model(change).
+			Where("id = ?", change.ID).
+			Where("applied_at IS NULL").
+			Where("canceled_at IS NULL").
+			Set("applied_at = ?", now).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return errors.New("contact change not found")
+		}
+
+		_, err = tx.NewUpdate().Model((*model.User)(nil)).
+			Where("id = ?", change.UserID).
+			Set("? = ?", bun.Ident(change.Type), change.NewValue).
+			Set("? = ?", bun.Ident(change.Type+"_verified_at"), now).
+			Set("updated_at = ?", now).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		if cr.dbErrors.IsUniqueViolation(err) {
+			return model.ErrContactTaken
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (cr *contactChangeRepository) CancelContactChange(ctx context.Context, changeID, usrID string) error {
+
+	res, err := cr.db.NewUpdate().Model((*model.ContactChange)(nil)).
+		Where("id = ?", changeID).
+		Where("user_id = ?", usrID).
+		Where("applied_at IS NULL").
+		Where("canceled_at IS NULL").
+		Set("canceled_at = ?", time.Now().UTC()).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("contact change not found")
+	}
+
+	return nil
+}
+
+func (cr *contactChangeRepository) CancelContactChangesByUserID(ctx context.Context, usrID string) error {
+
+	_, err := cr.db.NewUpdate().Model((*model.ContactChange)(nil)).
+		Where("user_id = ?", usrID).
+		Where("applied_at IS NULL").
+		Where("canceled_at IS NULL").
+		Set("canceled_at = ?", time.Now().UTC()).
+		Exec(ctx)
+	return err
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"auth-project/src/domain/model"
+	"fmt"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect"
+)
+
+// the sql which differs between the databases of db.driver is built here, the upserts are built by the storage
+// package, since the session store uses them as well, the rest of the repositories is written in the sql
+// common for postgres, sqlite and mysql
+
+// truncTime returns the expression which truncates the time column to the start of the referral stats period,
+// the weeks start on monday, sqlite and mysql return the text which is scanned as the time
+func truncTime(db bun.IDB, period, column string) (bun.Safe, error) {
+	switch period {
+	case model.ReferralStatsPeriodDay, model.ReferralStatsPeriodWeek, model.ReferralStatsPeriodMonth:
+	default:
+		return "", fmt.Errorf("unknown period %q", period)
+	}
+
+	switch db.Dialect().Name() {
+	case dialect.SQLite:
+		switch period {
+		case model.ReferralStatsPeriodWeek:
+			return bun.Safe(fmt.Sprintf("strftime('%%Y-%%m-%%d 00:00:00', %s, 'weekday 0', '-6 days')", column)), nil
+		case model.ReferralStatsPeriodMonth:
+			return bun.Safe(fmt.Sprintf("strftime('%%Y-%%m-01 00:00:00', %s)", column)), nil
+		}
+		return bun.Safe(fmt.Sprintf("strftime('%%Y-%%m-%%d 00:00:00', %s)", column)), nil
+	case dialect.MySQL:
+		switch period {
+		case model.ReferralStatsPeriodWeek:
+			return bun.Safe(fmt.Sprintf("DATE_FORMAT(DATE_SUB(%[1]s, INTERVAL WEEKDAY(%[1]s) DAY), '%%Y-%%m-%%d 00:00:00')",
+				column)), nil
+		case model.ReferralStatsPeriodMonth:
+			return bun.Safe(fmt.Sprintf("DATE_FORMAT(%s, '%%Y-%%m-01 00:00:00')", column)), nil
+		}
+		return bun.Safe(fmt.Sprintf("DATE_FORMAT(%s, '%%Y-%%m-%%d 00:00:00')", column)), nil
+	}
+
+	return bun.Safe(fmt.Sprintf("date_trunc('%s', %s)", period, column)), nil
+}
+
+// arrayContains returns the condition that the array column holds the value,
+// the arrays are kept as json by sqlite and mysql
+func arrayContains(db bun.IDB, column string) string {
+	switch db.Dialect().Name() {
+	case dialect.SQLite:
+		return fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(%s) WHERE json_each.value = ?)", column)
+	case dialect.MySQL:
+		return fmt.Sprintf("JSON_CONTAINS(%s, JSON_QUOTE(?))", column)
+	}
+	return fmt.Sprintf("? = ANY(%s)", column)
+}
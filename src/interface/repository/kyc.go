@@ -0,0 +1,258 @@
+package repository
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/infrastructure/encryption"
+	"auth-project/src/infrastructure/storage"
+	"auth-project/src/infrastructure/storage/files"
+	"context"
+	"database/sql"
+	"errors"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/uptrace/bun"
+	"log"
+	"time"
+)
+
+type kycRepository struct {
+	db          *bun.DB
+	fileStorage files.Storage
+	fieldCipher encryption.FieldCipher
+}
+
+type KycRepository interface {
+	CreateKycSubmission(ctx context.Context, submission *model.KycSubmission, idCard string) (*model.KycSubmission, error)
+	HasPendingKycSubmission(ctx context.Context, usrID string) (bool, error)
+	GetKycSubmissionsByUserID(ctx context.Context, usrID string) ([]model.KycSubmission, error)
+	GetKycSubmissions(ctx context.Context, status string, limit, offset int) ([]model.KycSubmission, int, error)
+	GetKycSubmissionByID(ctx context.Context, submissionID string) (*model.KycSubmission, error)
+	GetKycDocument(ctx context.Context, submissionID, documentID string) (*model.KycDocument, error)
+	ReviewKycSubmission(ctx context.Context, submissionID, reviewerID, status string, level int, comment string) error
+}
+
+func NewKycRepository(db *bun.DB, fs files.Storage, fc encryption.FieldCipher) KycRepository {
+	return &kycRepository{db, fs, fc}
+}
+
+// CreateKycSubmission puts the documents to the file storage and saves the submission,
+// the files are removed if the submission is not saved
+// CreateKycSubmission stores the documents and the submission with the encrypted id card
+func (kr *kycRepository) CreateKycSubmission(ctx context.Context,
+	submission *model.KycSubmission, idCard string) (*model.KycSubmission, error) {
+
+	id, err := gonanoid.New()
+	if err != nil {
+		return nil, err
+	}
+	submission.ID = id
+	submission.Status = model.KycStatusPending
+
+	encryptedIDCard, err := kr.fieldCipher.Encrypt(idCard, kycSubmissionIDCardField(submission.ID))
+	if err != nil {
+		return nil, err
+	}
+	submission.IDCard = model.EncryptedString(encryptedIDCard)
+
+	var stored []string
+	for i := range submission.Documents {
+		document := &submission.Documents[i]
+
+		document.ID, err = gonanoid.New()
+		if err != nil {
+			break
+		}
+		document.SubmissionID = submission.ID
+		document.Size = int64(len(document.Content))
+		document.StorageKey = "kyc/" + submission.UserID + "/" + submission.ID + "/" + document.ID
+
+		err = kr.fileStorage.Put(ctx, document.StorageKey, document.Content, document.ContentType)
+		if err != nil {
+			break
+		}
+		stored = append(stored, document.StorageKey)
+	}
+
+	if err == nil {
+		err = kr.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+			_, err := tx.NewInsert().Model(submission).
+				Exec(ctx)
+			if err != nil {
+				return err
+			}
+
+			_, err = tx.NewInsert().Model(&submission.Documents).
+				Exec(ctx)
+			return err
+		})
+	}
+	if err != nil {
+		for _, key := range stored {
+			if delErr := kr.fileStorage.Delete(ctx, key); delErr != nil {
+				log.Printf("error deleting kyc document %s: %s", key, delErr.Error())
+			}
+		}
+		return nil, err
+	}
+
+	return submission, nil
+}
+
+func (kr *kycRepository) HasPendingKycSubmission(ctx context.Context, usrID string) (bool, error) {
+
+	return kr.db.NewSelect().Model((*model.KycSubmission)(nil)).
+		Where("user_id = ?", usrID).
+		Where("status = ?", model.KycStatusPending).
+		Exists(ctx)
+}
+
+func (kr *kycRepository) GetKycSubmissionsByUserID(ctx context.Context, usrID string) ([]model.KycSubmission, error) {
+
+	submissions := make([]model.KycSubmission, 0)
+	err := kr.db.NewSelect().Model(&submissions).
+		Relation("Documents").
+		Where("kyc.user_id = ?", usrID).
+		Order("kyc.created_at DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return submissions, nil
+}
+
+// GetKycSubmissions returns the page of the submissions of the tenant with the status, the oldest go first to be reviewed
+func (kr *kycRepository) GetKycSubmissions(ctx context.Context, status string,
+	limit, offset int) ([]model.KycSubmission, int, error) {
+
+	submissions := make([]model.KycSubmission, 0)
+	query := kr.db.NewSelect().Model(&submissions).
+		Relation("Documents").
+		Join("JOIN users AS usr ON usr.id = kyc.user_id").
+		Where("usr.tenant_id = ?", model.TenantID(ctx))
+	if status != "" {
+		query = query.Where("kyc.status = ?", status)
+	}
+	count, err := query.
+		Order("kyc.created_at").
+		Limit(limit).
+		Offset(offset).
+		ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return submissions, count, nil
+}
+
+// GetKycSubmissionByID returns the submission of the user of the tenant
+func (kr *kycRepository) GetKycSubmissionByID(ctx context.Context, submissionID string) (*model.KycSubmission, error) {
+
+	submission := &model.KycSubmission{}
+	err := kr.db.NewSelect().Model(submission).
+		Relation("Documents").
+		Join("JOIN users AS usr ON usr.id = kyc.user_id").
+		Where("kyc.id = ?", submissionID).
+		Where("usr.tenant_id = ?", model.TenantID(ctx)).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("kyc submission not found")
+		}
+		return nil, err
+	}
+
+	return submission, nil
+}
+
+// GetKycDocument returns the document of the user of the tenant with the content read from the file storage
+func (kr *kycRepository) GetKycDocument(ctx context.Context, submissionID, documentID string) (*model.KycDocument, error) {
+
+	document := &model.KycDocument{}
+	err := kr.db.NewSelect().Model(document).
+		Join("JOIN kyc_submissions AS kyc ON kyc.id = kdc.submission_id").
+		Join("JOIN users AS usr ON usr.id = kyc.user_id").
+		Where("kdc.id = ?", documentID).
+		Where("kdc.submission_id = ?", submissionID).
+		Where("usr.tenant_id = ?", model.TenantID(ctx)).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("kyc document not found")
+		}
+		return nil, err
+	}
+
+	document.Content, err = kr.fileStorage.Get(ctx, document.StorageKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return document, nil
+}
+
+// ReviewKycSubmission closes the pending submission of the user of the tenant,
+// the approval sets the level and the id card to the user config
+func (kr *kycRepository) ReviewKycSubmission(ctx context.Context, submissionID, reviewerID, status string,
+	level int, comment string) error {
+
+	return kr.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		submission := &model.KycSubmission{}
+		res, err := tx.NewUpdate().Model(submission).
+			Where("id = ?", submissionID).
+			Where("status = ?", model.KycStatusPending).
+			Where("user_id IN (?)", tx.NewSelect().Model((*model.User)(nil)).
+				Column("id").
+				Where("tenant_id = ?", model.TenantID(ctx))).
+			Set("status = ?", status).
+			Set("level = ?", level).
+			Set("reviewer_id = ?", reviewerID).
+			Set("review_comment = ?", comment).
+			Set("reviewed_at = ?", time.Now().UTC()).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return errors.New("pending kyc submission not found")
+		}
+
+		if status != model.KycStatusApproved {
+			return nil
+		}
+
+		// the submission is read after the update, since returning is not supported by mysql
+		err = tx.NewSelect().Model(submission).
+			Where("id = ?", submissionID).
+			Scan(ctx)
+		if err != nil {
+			return err
+		}
+
+		// the id card is bound to the row, so it is encrypted again for the config
+		config := &model.UserConfig{UserID: submission.UserID, KycLevel: level}
+		if submission.IDCard != "" {
+			idCard, err := kr.fieldCipher.Decrypt(string(submission.IDCard), kycSubmissionIDCardField(submission.ID))
+			if err != nil {
+				return err
+			}
+
+			configIDCard, err := kr.fieldCipher.Encrypt(idCard, userConfigIDCardField(submission.UserID))
+			if err != nil {
+				return err
+			}
+			config.IDCard = model.EncryptedString(configIDCard)
+		}
+
+		_, err = tx.NewInsert().Model(config).
+			On(storage.OnConflictUpdate(tx, "user_id")).
+			Set("kyc_level = ?", storage.InsertedValue(tx, "kyc_level")).
+			Set("id_card = COALESCE(?, ?)", storage.InsertedValue(tx, "id_card"), storage.CurrentValue(tx, "ucf", "id_card")).
+			Exec(ctx)
+		return err
+	})
+}
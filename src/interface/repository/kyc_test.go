@@ -0,0 +1,103 @@
+package repository_test
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/infrastructure/encryption"
+	"auth-project/src/infrastructure/storage/files"
+	"auth-project/src/infrastructure/storage/storagetest"
+	"auth-project/src/interface/repository"
+	"context"
+	"testing"
+
+	"github.com/uptrace/bun"
+)
+
+func newKycRepository(t *testing.T, db *bun.DB, fs files.Storage) repository.KycRepository {
+	t.Helper()
+
+	fc, err := encryption.NewEnvelopeCipher(map[int][]byte{1: make([]byte, 32)}, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repository.NewKycRepository(db, fs, fc)
+}
+
+func createKycSubmission(t *testing.T, kr repository.KycRepository, usrID string) *model.KycSubmission {
+	t.Helper()
+
+	submission, err := kr.CreateKycSubmission(context.Background(), &model.KycSubmission{
+		UserID: usrID,
+		Level:  1,
+		Documents: []model.KycDocument{
+			{Type: model.KycDocumentIDFront, ContentType: "image/png", Content: []byte("front")},
+		},
+	}, "AB123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return submission
+}
+
+func TestKycSubmissionsOfTenant(t *testing.T) {
+	db := storagetest.NewSQLite(t)
+	kr := newKycRepository(t, db, files.NewLocalStorage(t.TempDir()))
+	ctx := context.Background()
+
+	_, err := db.NewInsert().Model(&model.Tenant{ID: "acme", Name: "acme"}).Exec(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	insertUser(t, db, &model.User{ID: "user", Email: "user@gmail.com"})
+	insertUser(t, db, &model.User{ID: "acme", TenantID: "acme", Email: "user@gmail.com"})
+
+	own := createKycSubmission(t, kr, "user")
+	other := createKycSubmission(t, kr, "acme")
+
+	submissions, count, err := kr.GetKycSubmissions(ctx, model.KycStatusPending, 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 || len(submissions) != 1 || submissions[0].ID != own.ID || len(submissions[0].Documents) != 1 {
+		t.Fatalf("got %d submissions %+v, want the own one with the document", count, submissions)
+	}
+
+	_, err = kr.GetKycSubmissionByID(ctx, own.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = kr.GetKycSubmissionByID(ctx, other.ID)
+	if err == nil {
+		t.Fatal("the submission of another tenant is found")
+	}
+
+	document, err := kr.GetKycDocument(ctx, own.ID, own.Documents[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(document.Content) != "front" {
+		t.Fatalf("got content %q, want front", document.Content)
+	}
+	_, err = kr.GetKycDocument(ctx, other.ID, other.Documents[0].ID)
+	if err == nil {
+		t.Fatal("the document of another tenant is found")
+	}
+
+	err = kr.ReviewKycSubmission(ctx, other.ID, "admin", model.KycStatusApproved, 1, "")
+	if err == nil {
+		t.Fatal("the submission of another tenant is reviewed")
+	}
+	err = kr.ReviewKycSubmission(ctx, own.ID, "admin", model.KycStatusApproved, 1, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the other tenant sees its own submission only
+	acmeCtx := model.WithTenantID(ctx, "acme")
+	submissions, _, err = kr.GetKycSubmissions(acmeCtx, "", 10, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(submissions) != 1 || submissions[0].ID != other.ID {
+		t.Fatalf("got submissions %+v, want the acme one", submissions)
+	}
+}
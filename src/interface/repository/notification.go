@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/infrastructure/scheduler"
+	"auth-project/src/infrastructure/sending/email"
+	"auth-project/src/infrastructure/sending/sms"
+	"context"
+	"errors"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/uptrace/bun"
+	"log"
+	"time"
+)
+
+type notificationRepository struct {
+	db               *bun.DB
+	tenantRepository TenantRepository
+	worker           *scheduler.Worker
+}
+
+type NotificationRepository interface {
+	NotifySecurityEvent(ctx context.Context, target, targetType string, notification *model.SecurityNotification)
+	SendSecurityNotification(ctx context.Context, target, targetType string, notification *model.SecurityNotification) error
+	CreateSecurityEvent(ctx context.Context, notification *model.SecurityNotification) error
+	GetSecurityEventsByUserID(ctx context.Context, usrID string) ([]model.SecurityEvent, error)
+}
+
+func NewNotificationRepository(db *bun.DB, tr TenantRepository, worker *scheduler.Worker) NotificationRepository {
+	return &notificationRepository{db, tr, worker}
+}
+
+// NotifySecurityEvent keeps the event in the account log within the request, so it is not lost with the queued job,
+// and sends the message by the worker, the job gets the tenant of the notification, since it outlives the request
+func (nr *notificationRepository) NotifySecurityEvent(ctx context.Context, target, targetType string,
+	notification *model.SecurityNotification) {
+
+	err := nr.CreateSecurityEvent(ctx, notification)
+	if err != nil {
+		log.Printf("error saving %s event: %s", notification.Event, err.Error())
+	}
+
+	if target == "" {
+		return
+	}
+
+	tenantID := notification.TenantID
+	if tenantID == "" {
+		tenantID = model.TenantID(ctx)
+	}
+
+	nr.worker.Go(model.WithTenantID(context.Background(), tenantID), "notify "+notification.Event,
+		func(ctx context.Context) error {
+			return nr.SendSecurityNotification(ctx, target, targetType, notification)
+		})
+}
+
+// SendSecurityNotification sends the message about the account activity by email or sms
+func (nr *notificationRepository) SendSecurityNotification(ctx context.Context, target, targetType string,
+	notification *model.SecurityNotification) error {
+
+	tenantID := notification.TenantID
+	if tenantID == "" {
+		tenantID = model.TenantID(ctx)
+	}
+
+	tenant, err := nr.tenantRepository.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	var revokeLink string
+	if notification.RevokePath != "" {
+		revokeLink = tenant.FrontHost + notification.RevokePath
+	}
+
+	description := securityEventDescription(notification.Event)
+	date := notification.Time.UTC().Format(time.RFC1123)
+
+	switch targetType {
+	case model.TokenTypeEmail:
+		plain, html := email.CreateEmailBodySecurityNotification(tenant.Name, description, notification.UserAgent,
+			notification.ClientIP, date, revokeLink)
+
+		return email.SendEmail(tenant, "Security Alert", target, "Security Alert", plain, html)
+
+	case model.TokenTypePhone:
+		return sms.SendSms(tenant, target, sms.CreateSmsBodySecurityNotification(tenant.Name, description, date))
+
+	default:
+		return errors.New("invalid target type")
+	}
+}
+
+// CreateSecurityEvent keeps the notified activity in the account log
+func (nr *notificationRepository) CreateSecurityEvent(ctx context.Context,
+	notification *model.SecurityNotification) error {
+
+	id, err := gonanoid.New()
+	if err != nil {
+		return err
+	}
+
+	_, err = nr.db.NewInsert().Model(&model.SecurityEvent{
+		ID:        id,
+		UserID:    notification.UserID,
+		Event:     notification.Event,
+		UserAgent: notification.UserAgent,
+		ClientIP:  notification.ClientIP,
+		CreatedAt: notification.Time.UTC(),
+	}).Exec(ctx)
+	return err
+}
+
+func (nr *notificationRepository) GetSecurityEventsByUserID(ctx context.Context,
+	usrID string) ([]model.SecurityEvent, error) {
+
+	events := make([]model.SecurityEvent, 0)
+	err := nr.db.NewSelect().Model(&events).
+		Where("user_id = ?", usrID).
+		Order("created_at DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func securityEventDescription(event string) string {
+	switch event {
+	case model.SecurityEventNewLogin:
+		return "New sign in from an unfamiliar device"
+	case model.SecurityEventPasswordChanged:
+		return "The password was changed"
+	case model.SecurityEventEmailChanged:
+		return "The email was changed"
+	case model.SecurityEventPhoneChanged:
+		return "The phone was changed"
+	case model.SecurityEventEmailChangeRequested:
+		return "The email change was requested, it will be applied unless you cancel it"
+	case model.SecurityEventPhoneChangeRequested:
+		return "The phone change was requested, it will be applied unless you cancel it"
+	case model.SecurityEventContactChangeFailed:
+		return "The contact change was canceled, the new contact is already in use"
+	case model.SecurityEventAccountDeleted:
+		return "The account was deleted, the data will be erased after the grace period"
+	case model.SecurityEventTwoFactorAuthChanged:
+		return "Two-factor authentication settings were changed"
+	default:
+		return "Security settings were changed"
+	}
+}
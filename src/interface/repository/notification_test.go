@@ -0,0 +1,40 @@
+package repository_test
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/infrastructure/scheduler"
+	"auth-project/src/infrastructure/storage/storagetest"
+	"auth-project/src/interface/repository"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNotifySecurityEventKeepsEvent(t *testing.T) {
+	db := storagetest.NewSQLite(t)
+	ctx := context.Background()
+
+	// the sending job is dropped by the shut down worker, the event is kept anyway
+	worker := scheduler.NewWorker(1, 0, time.Second)
+	err := worker.Shutdown(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nr := repository.NewNotificationRepository(db, repository.NewTenantRepository(db), worker)
+
+	insertUser(t, db, &model.User{ID: "user", Email: "user@gmail.com"})
+
+	nr.NotifySecurityEvent(ctx, "user@gmail.com", model.TokenTypeEmail, &model.SecurityNotification{
+		UserID: "user",
+		Event:  model.SecurityEventPasswordChanged,
+		Time:   time.Now(),
+	})
+
+	events, err := nr.GetSecurityEventsByUserID(ctx, "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Event != model.SecurityEventPasswordChanged {
+		t.Fatalf("got events %+v, want the password change", events)
+	}
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+	"errors"
+	"github.com/uptrace/bun"
+	"time"
+)
+
+type oAuthClientRepository struct {
+	db *bun.DB
+}
+
+type OAuthClientRepository interface {
+	CreateOAuthClient(ctx context.Context, client *model.OAuthClient) error
+	GetOAuthClientByID(ctx context.Context, clientID string) (*model.OAuthClient, error)
+	GetOAuthClientsByUserID(ctx context.Context, usrID string) ([]model.OAuthClient, error)
+	UpdateOAuthClientSecret(ctx context.Context, clientID, usrID, secretHash string) (*model.OAuthClient, error)
+	UseOAuthClient(ctx context.Context, clientID string) error
+	RevokeOAuthClient(ctx context.Context, clientID, usrID string) error
+}
+
+func NewOAuthClientRepository(db *bun.DB) OAuthClientRepository {
+	return &oAuthClientRepository{db}
+}
+
+func (or *oAuthClientRepository) CreateOAuthClient(ctx context.Context, client *model.OAuthClient) error {
+
+	_, err := or.db.NewInsert().Model(client).
+		Exec(ctx)
+	return err
+}
+
+// GetOAuthClientByID returns the client if it is not revoked
+func (or *oAuthClientRepository) GetOAuthClientByID(ctx context.Context, clientID string) (*model.OAuthClient, error) {
+
+	client := new(model.OAuthClient)
+	err := or.db.NewSelect().Model(client).
+		Where("id = ?", clientID).
+		Where("revoked_at IS NULL").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+func (or *oAuthClientRepository) GetOAuthClientsByUserID(ctx context.Context,
+	usrID string) ([]model.OAuthClient, error) {
+
+	clients := make([]model.OAuthClient, 0)
+	err := or.db.NewSelect().Model(&clients).
+		Where("user_id = ?", usrID).
+		Where("revoked_at IS NULL").
+		Order("created_at DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return clients, nil
+}
+
+func (or *oAuthClientRepository) UpdateOAuthClientSecret(ctx context.Context,
+	clientID, usrID, secretHash string) (*model.OAuthClient, error) {
+
+	res, err := or.db.NewUpdate().Model((*model.OAuthClient)(nil)).
+		Where("id = ?", clientID).
+		Where("user_id = ?", usrID).
+		Where("revoked_at IS NULL").
+		Set("secret_hash = ?", secretHash).
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, errors.New("client not found")
+	}
+
+	return or.GetOAuthClientByID(ctx, clientID)
+}
+
+func (or *oAuthClientRepository) UseOAuthClient(ctx context.Context, clientID string) error {
+
+	_, err := or.db.NewUpdate().Model((*model.OAuthClient)(nil)).
+		Where("id = ?", clientID).
+		Set("last_used_at = ?", time.Now().UTC()).
+		Exec(ctx)
+	return err
+}
+
+func (or *oAuthClientRepository) RevokeOAuthClient(ctx context.Context, clientID, usrID string) error {
+
+	res, err := or.db.NewUpdate().Model((*model.OAuthClient)(nil)).
+		Where("id = ?", clientID).
+		Where("user_id = ?", usrID).
+		Where("revoked_at IS NULL").
+		Set("revoked_at = ?", time.Now().UTC()).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("client not found")
+	}
+
+	return nil
+}
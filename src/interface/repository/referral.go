@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/infrastructure/storage"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/uptrace/bun"
+	"time"
+)
+
+type referralRepository struct {
+	db       *bun.DB
+	dbErrors storage.ErrorClassifier
+}
+
+type ReferralRepository interface {
+	GetReferredUsers(ctx context.Context, referralLink string, limit, offset int) ([]model.User, int, error)
+	GetReferralTree(ctx context.Context, referralLink string, depth int) ([]model.ReferredUser, error)
+	GetReferralStats(ctx context.Context, referralLink, period string, from, to time.Time) ([]model.ReferralStat, error)
+	UpdateReferralLink(ctx context.Context, usrID, oldLink, newLink string) error
+}
+
+func NewReferralRepository(db *bun.DB, dbErrors storage.ErrorClassifier) ReferralRepository {
+	return &referralRepository{db, dbErrors}
+}
+
+// GetReferredUsers returns the page of the direct referrals and the number of all of them
+func (rr *referralRepository) GetReferredUsers(ctx context.Context, referralLink string,
+	limit, offset int) ([]model.User, int, error) {
+
+	users := make([]model.User, 0)
+	count, err := rr.db.NewSelect().Model(&users).
+		Column("id", "full_name", "created_at").
+		Where("referral = ?", referralLink).
+		Where("is_active = TRUE").
+		Order("created_at DESC").
+		Limit(limit).
+		Offset(offset).
+		ScanAndCount(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return users, count, nil
+}
+
+// GetReferralTree returns the referrals up to the depth level, the referrals of the referrals are on the next levels
+func (rr *referralRepository) GetReferralTree(ctx context.Context, referralLink string,
+	depth int) ([]model.ReferredUser, error) {
+
+	rows, err := rr.db.QueryContext(ctx, `
+		WITH RECURSIVE tree AS (
+			SELECT id, full_name, referral_link, referral, created_at, 1 AS level
+			FROM users
+			WHERE referral = ? AND is_active = TRUE
+			UNION ALL
+			SELECT usr.id, usr.full_name, usr.referral_link, usr.referral, usr.created_at, tree.level + 1
+			FROM users AS usr
+			JOIN tree ON usr.referral = tree.referral_link
+			WHERE tree.level < ? AND usr.is_active = TRUE
+		)
+		SELECT id, COALESCE(full_name, '') AS full_name, referral_link, referral, level, created_at
+		FROM tree
+		ORDER BY level, created_at`, referralLink, depth)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make([]model.ReferredUser, 0)
+	err = rr.db.ScanRows(ctx, rows, &users)
+	if err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// GetReferralStats returns the number of the direct referrals signed up in each period between the dates
+func (rr *referralRepository) GetReferralStats(ctx context.Context, referralLink, period string,
+	from, to time.Time) ([]model.ReferralStat, error) {
+
+	periodStart, err := truncTime(rr.db, period, "usr.created_at")
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]model.ReferralStat, 0)
+	err = rr.db.NewSelect().Model((*model.User)(nil)).
+		ColumnExpr("? AS period_start", periodStart).
+		ColumnExpr("count(*) AS count").
+		Where("referral = ?", referralLink).
+		Where("is_active = TRUE").
+		Where("usr.created_at >= ?", from.UTC()).
+		Where("usr.created_at < ?", to.UTC()).
+		GroupExpr("period_start").
+		OrderExpr("period_start").
+		Scan(ctx, &stats)
+	if err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+// UpdateReferralLink replaces the referral link of the user, the referrals are moved to the new link
+func (rr *referralRepository) UpdateReferralLink(ctx context.Context, usrID, oldLink, newLink string) error {
+
+	err := rr.db.RunInTx(ctx, &sql.TxOptions{}, func(ctx context.Context, tx bun.Tx) error {
+		exists, err := tx.NewSelect().Model((*model.User)(nil)).
+			Where("referral_link = ?", newLink).
+			Exists(ctx)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return model.ErrReferralLinkTaken
+		}
+
+		res, err := tx.NewUpdate().Model((*model.User)(nil)).
+			Where("id = ?", usrID).
+			Where("referral_link = ?", oldLink).
+			Set("referral_link = ?", newLink).
+			Set("updated_at = ?", time.Now().UTC()).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if affected == 0 {
+			return errors.New("user not identified")
+		}
+
+		_, err = tx.NewUpdate().Model((*model.User)(nil)).
+			Where("referral = ?", oldLink).
+			Set("referral = ?", newLink).
+			Exec(ctx)
+		return err
+	})
+	if err != nil {
+		if rr.dbErrors.IsUniqueViolation(err) {
+			return model.ErrReferralLinkTaken
+		}
+		return err
+	}
+
+	return nil
+}
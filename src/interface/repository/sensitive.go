@@ -0,0 +1,17 @@
+package repository
+
+import "auth-project/src/infrastructure/encryption"
+
+// the fields of the encrypted columns, the values are bound to them, so they are re-encrypted when copied
+
+func googleSecretField(usrID string) encryption.Field {
+	return encryption.Field{Table: "users", Column: "google_secret", RowID: usrID}
+}
+
+func kycSubmissionIDCardField(submissionID string) encryption.Field {
+	return encryption.Field{Table: "kyc_submissions", Column: "id_card", RowID: submissionID}
+}
+
+func userConfigIDCardField(usrID string) encryption.Field {
+	return encryption.Field{Table: "user_configs", Column: "id_card", RowID: usrID}
+}
@@ -4,6 +4,7 @@ import (
 	"auth-project/src/domain/model"
 	"context"
 	"github.com/uptrace/bun"
+	"strings"
 )
 
 type sessionRepository struct {
@@ -13,6 +14,8 @@ type sessionRepository struct {
 type SessionRepository interface {
 	InsertSession(ctx context.Context, ses *model.Session) error
 	UpdateSession(ctx context.Context, ses *model.Session) error
+	IsUnfamiliarDevice(ctx context.Context, usrID, userAgent, clientIP string) (bool, error)
+	GetSessionsByUserID(ctx context.Context, usrID string) ([]model.Session, error)
 }
 
 func NewSessionRepository(db *bun.DB) SessionRepository {
@@ -34,3 +37,50 @@ func (sr *sessionRepository) UpdateSession(ctx context.Context, ses *model.Sessi
 	}
 	return nil
 }
+
+// IsUnfamiliarDevice reports whether the user has sessions, but none of them from the user agent and ip,
+// the first sign in after the sign-up is not considered unfamiliar
+func (sr *sessionRepository) IsUnfamiliarDevice(ctx context.Context, usrID, userAgent, clientIP string) (bool, error) {
+
+	count, err := sr.db.NewSelect().Model((*model.Session)(nil)).
+		Where("user_id = ?", usrID).
+		Count(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	if count == 0 {
+		return false, nil
+	}
+
+	// the client ip is stored with the port, which changes from connection to connection
+	host := clientIP
+	if i := strings.LastIndex(clientIP, ":"); i != -1 {
+		host = clientIP[:i+1]
+	}
+
+	exists, err := sr.db.NewSelect().Model((*model.Session)(nil)).
+		Where("user_id = ?", usrID).
+		Where("user_agent = ?", userAgent).
+		Where("client_ip LIKE ?", host+"%").
+		Exists(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return !exists, nil
+}
+
+func (sr *sessionRepository) GetSessionsByUserID(ctx context.Context, usrID string) ([]model.Session, error) {
+
+	sessions := make([]model.Session, 0)
+	err := sr.db.NewSelect().Model(&sessions).
+		Where("user_id = ?", usrID).
+		Order("created_at DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
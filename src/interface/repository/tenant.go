@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+	"database/sql"
+	"github.com/spf13/viper"
+	"github.com/uptrace/bun"
+	"strings"
+	"sync"
+	"time"
+)
+
+type cachedTenant struct {
+	tenant    *model.Tenant
+	expiresAt time.Time
+}
+
+// tenantRepository keeps the tenants in memory since they are read on each request and rarely changed,
+// the entries expire after tenant.cache_ttl, the registry keeps one repository, so the cache is shared
+type tenantRepository struct {
+	db *bun.DB
+
+	mu    sync.RWMutex
+	cache map[string]cachedTenant
+}
+
+type TenantRepository interface {
+	GetTenantByID(ctx context.Context, tenantID string) (*model.Tenant, error)
+	GetTenantByHost(ctx context.Context, host string) (*model.Tenant, error)
+}
+
+func NewTenantRepository(db *bun.DB) TenantRepository {
+	return &tenantRepository{db: db, cache: make(map[string]cachedTenant)}
+}
+
+func (tr *tenantRepository) GetTenantByID(ctx context.Context, tenantID string) (*model.Tenant, error) {
+	return tr.getTenant(ctx, "id:"+tenantID, func(query *bun.SelectQuery) *bun.SelectQuery {
+		return query.Where("id = ?", tenantID)
+	})
+}
+
+func (tr *tenantRepository) GetTenantByHost(ctx context.Context, host string) (*model.Tenant, error) {
+	host = strings.ToLower(host)
+	return tr.getTenant(ctx, "host:"+host, func(query *bun.SelectQuery) *bun.SelectQuery {
+		return query.Where(arrayContains(tr.db, "hosts"), host)
+	})
+}
+
+// getTenant reads the active tenant through the cache, the names fall back to the project settings
+func (tr *tenantRepository) getTenant(ctx context.Context, cacheKey string,
+	where func(query *bun.SelectQuery) *bun.SelectQuery) (*model.Tenant, error) {
+
+	tr.mu.RLock()
+	entry, ok := tr.cache[cacheKey]
+	tr.mu.RUnlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.tenant, nil
+	}
+
+	tenant := new(model.Tenant)
+	err := where(tr.db.NewSelect().Model(tenant)).
+		Where("is_active = TRUE").
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, model.ErrTenantNotFound
+		}
+		return nil, err
+	}
+
+	if tenant.Name == "" {
+		tenant.Name = viper.GetString("project_name")
+	}
+	if tenant.FrontHost == "" {
+		tenant.FrontHost = viper.GetString("http_front.host")
+	}
+
+	tr.mu.Lock()
+	tr.cache[cacheKey] = cachedTenant{
+		tenant:    tenant,
+		expiresAt: time.Now().Add(viper.GetDuration("tenant.cache_ttl")),
+	}
+	tr.mu.Unlock()
+
+	return tenant, nil
+}
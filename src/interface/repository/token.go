@@ -6,56 +6,132 @@ import (
 	"auth-project/src/infrastructure/sending/sms"
 	"auth-project/tools"
 	"context"
+	"crypto/subtle"
 	"database/sql"
 	"errors"
 	gonanoid "github.com/matoous/go-nanoid/v2"
 	"github.com/spf13/viper"
 	"github.com/uptrace/bun"
+	"strings"
 	"time"
 )
 
+// the limits of the failed attempts used when 2fa.max_attempts and 2fa.max_target_attempts are not set
+const (
+	defaultMaxCodeAttempts   = 5
+	defaultMaxTargetAttempts = 20
+	defaultAttemptsWindow    = time.Hour
+)
+
 type tokenRepository struct {
-	db *bun.DB
+	db               *bun.DB
+	tenantRepository TenantRepository
 }
 
 type TokenRepository interface {
 	Validate2faCode(ctx context.Context, verifyCodeDate *model.VerifyCodeData) (*model.Token, error)
 	Send2faCode(ctx context.Context, sendCodeDate *model.Send2faCodeData) (string, error)
 	TokenSetUsed(ctx context.Context, verifyCodeDate *model.VerifyCodeData) error
+
+	CreateMagicLinkToken(ctx context.Context, usrID, target string) (*model.Token, error)
+	SendMagicLink(ctx context.Context, target, link string) error
+	UseMagicLinkToken(ctx context.Context, tokenID, value, usrID string) error
+
+	CreateRevokeSessionsToken(ctx context.Context, usrID, target string) (*model.Token, error)
+	UseRevokeSessionsToken(ctx context.Context, value string) (*model.Token, error)
 }
 
-func NewTokenRepository(db *bun.DB) TokenRepository {
-	return &tokenRepository{db}
+func NewTokenRepository(db *bun.DB, tr TenantRepository) TokenRepository {
+	return &tokenRepository{db, tr}
 }
 
+// Validate2faCode finds the live code, the failed attempts are counted for the live codes of the target:
+// the code is invalidated after 2fa.max_attempts failures, the target is locked after 2fa.max_target_attempts
+// failures of its codes sent within 2fa.attempts_window
 func (tr *tokenRepository) Validate2faCode(ctx context.Context, verifyCodeDate *model.VerifyCodeData) (*model.Token, error) {
 
 	if verifyCodeDate.UserID == "" && verifyCodeDate.Target == "" {
 		return nil, errors.New("user id and target empty")
 	}
 
+	maxAttempts := viper.GetInt("2fa.max_attempts")
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxCodeAttempts
+	}
+	maxTargetAttempts := viper.GetInt("2fa.max_target_attempts")
+	if maxTargetAttempts <= 0 {
+		maxTargetAttempts = defaultMaxTargetAttempts
+	}
+	attemptsWindow := viper.GetDuration("2fa.attempts_window")
+	if attemptsWindow <= 0 {
+		attemptsWindow = defaultAttemptsWindow
+	}
+
+	now := time.Now().UTC()
+	where, args := targetCodesCondition(ctx, verifyCodeDate)
+
+	var failed int
+	err := tr.db.NewSelect().Model((*model.Token)(nil)).
+		ColumnExpr("COALESCE(SUM(attempts), 0)").
+		Where(where, args...).
+		Where("created_at > ?", now.Add(-attemptsWindow)).
+		Scan(ctx, &failed)
+	if err != nil {
+		return nil, err
+	}
+
+	if failed >= maxTargetAttempts {
+		return nil, model.ErrTokenAttempts
+	}
+
 	var token model.Token
-	query := tr.db.NewSelect().Model(&token).
+	err = tr.db.NewSelect().Model(&token).
+		Where(where, args...).
 		Where("is_used = FALSE").
+		Where("expires_at > ?", now).
 		Where("value = ? ", verifyCodeDate.Code).
-		Where("reason = ? ", verifyCodeDate.Reason)
+		Scan(ctx)
+	if err == sql.ErrNoRows {
+		_, err = tr.db.NewUpdate().Model((*model.Token)(nil)).
+			Where(where, args...).
+			Where("is_used = FALSE").
+			Where("expires_at > ?", now).
+			// is_used is set first, since mysql sets the columns in turn by the new values
+			Set("is_used = CASE WHEN attempts + 1 >= ? THEN TRUE ELSE is_used END", maxAttempts).
+			Set("attempts = attempts + 1").
+			Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		return nil, errors.New("invalid token")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// targetCodesCondition returns the condition of the codes sent to the target for the reason
+func targetCodesCondition(ctx context.Context, verifyCodeDate *model.VerifyCodeData) (string, []interface{}) {
+
+	conditions := []string{"reason = ?"}
+	args := []interface{}{verifyCodeDate.Reason}
 	if verifyCodeDate.Target != "" {
-		query = query.Where("target = ? ", verifyCodeDate.Target)
+		conditions = append(conditions, "target = ?", "tenant_id = ?")
+		args = append(args, verifyCodeDate.Target, model.TenantID(ctx))
 	}
 	if verifyCodeDate.CodeType != "" {
-		query = query.Where("type = ? ", verifyCodeDate.CodeType)
+		conditions = append(conditions, "type = ?")
+		args = append(args, verifyCodeDate.CodeType)
 	}
 	if verifyCodeDate.UserID != "" {
-		query = query.Where("user_id = ? ", verifyCodeDate.UserID)
+		conditions = append(conditions, "user_id = ?")
+		args = append(args, verifyCodeDate.UserID)
 	}
-	err := query.Scan(ctx)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errors.New("invalid token")
-		}
-		return nil, err
-	}
-	return &token, nil
+
+	return strings.Join(conditions, " AND "), args
 }
 
 func (tr *tokenRepository) Send2faCode(ctx context.Context, sendOTPDate *model.Send2faCodeData) (string, error) {
@@ -64,7 +140,8 @@ func (tr *tokenRepository) Send2faCode(ctx context.Context, sendOTPDate *model.S
 	if sendOTPDate.UserID != "" {
 		query = query.Where("user_id = ?", sendOTPDate.UserID)
 	} else {
-		query = query.Where("target = ?", sendOTPDate.Target)
+		query = query.Where("target = ?", sendOTPDate.Target).
+			Where("tenant_id = ?", model.TenantID(ctx))
 	}
 	exists, err := query.
 		Where("is_used = ?", false).
@@ -79,9 +156,15 @@ func (tr *tokenRepository) Send2faCode(ctx context.Context, sendOTPDate *model.S
 		return "", errors.New(model.TokenTimeSendErr)
 	}
 
+	tenant, err := tr.tenantRepository.GetTenantByID(ctx, model.TenantID(ctx))
+	if err != nil {
+		return "", err
+	}
+
 	code := tools.RandStr(6, "number")
 	// New obj:
 	token := &model.Token{
+		TenantID:  tenant.ID,
 		Target:    sendOTPDate.Target,
 		Value:     code,
 		Reason:    sendOTPDate.Reason,
@@ -106,15 +189,15 @@ func (tr *tokenRepository) Send2faCode(ctx context.Context, sendOTPDate *model.S
 
 	switch sendOTPDate.Code2faType {
 	case model.TokenTypePhone:
-		err = sms.SendSms(sendOTPDate.Target, sms.CreateSmsBodyVerificationCode(code))
+		err = sms.SendSms(tenant, sendOTPDate.Target, sms.CreateSmsBodyVerificationCode(tenant.Name, code))
 		if err != nil {
 			return "", err
 		}
 
 	case model.TokenTypeEmail:
-		plain, html := email.CreateEmailBodyVerificationCode(code)
+		plain, html := email.CreateEmailBodyVerificationCode(tenant.Name, code)
 
-		err = email.SendEmail("Verification Code", sendOTPDate.Target, "Verification Code", plain, html)
+		err = email.SendEmail(tenant, "Verification Code", sendOTPDate.Target, "Verification Code", plain, html)
 		if err != nil {
 			return "", err
 		}
@@ -140,7 +223,8 @@ func (tr *tokenRepository) TokenSetUsed(ctx context.Context, verifyCodeDate *mod
 		Where("reason = ? ", verifyCodeDate.Reason).
 		OmitZero()
 	if verifyCodeDate.Target != "" {
-		query = query.Where("target = ? ", verifyCodeDate.Target)
+		query = query.Where("target = ? ", verifyCodeDate.Target).
+			Where("tenant_id = ?", model.TenantID(ctx))
 	}
 	if verifyCodeDate.CodeType != "" {
 		query = query.Where("type = ? ", verifyCodeDate.CodeType)
@@ -163,3 +247,168 @@ func (tr *tokenRepository) TokenSetUsed(ctx context.Context, verifyCodeDate *mod
 
 	return nil
 }
+
+func (tr *tokenRepository) CreateMagicLinkToken(ctx context.Context, usrID, target string) (*model.Token, error) {
+
+	exists, err := tr.db.NewSelect().Model((*model.Token)(nil)).
+		Where("user_id = ?", usrID).
+		Where("is_used = ?", false).
+		Where("reason = ?", model.TokenReasonMagicLink).
+		Where("created_at > ?", time.Now().UTC().Add(-viper.GetDuration("2fa.send_timeout"))).
+		Exists(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if exists {
+		return nil, errors.New(model.TokenTimeSendErr)
+	}
+
+	// New obj:
+	token := &model.Token{
+		TenantID:  model.TenantID(ctx),
+		UserID:    usrID,
+		Target:    target,
+		Value:     tools.RandStr(64, "alphanum"),
+		Reason:    model.TokenReasonMagicLink,
+		Type:      model.TokenTypeEmail,
+		ExpiresAT: tools.AddTimeToCurrentDate(viper.GetDuration("magic_link.token_min_lifetime")),
+	}
+
+	token.ID, err = gonanoid.New()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tr.db.NewInsert().Model(token).
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (tr *tokenRepository) SendMagicLink(ctx context.Context, target, link string) error {
+
+	tenant, err := tr.tenantRepository.GetTenantByID(ctx, model.TenantID(ctx))
+	if err != nil {
+		return err
+	}
+
+	plain, html := email.CreateEmailBodyMagicLink(tenant.Name, link)
+
+	return email.SendEmail(tenant, "Sign In Link", target, "Sign In Link", plain, html)
+}
+
+// UseMagicLinkToken marks an unexpired magic link token with the value as used, so the link can be followed only once
+func (tr *tokenRepository) UseMagicLinkToken(ctx context.Context, tokenID, value, usrID string) error {
+
+	token := new(model.Token)
+	err := tr.db.NewSelect().Model(token).
+		Where("id = ?", tokenID).
+		Where("user_id = ?", usrID).
+		Where("tenant_id = ?", model.TenantID(ctx)).
+		Where("reason = ?", model.TokenReasonMagicLink).
+		Where("is_used = FALSE").
+		Where("expires_at > ?", time.Now().UTC()).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return errors.New("invalid token")
+		}
+		return err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(token.Value), []byte(value)) != 1 {
+		return errors.New("invalid token")
+	}
+
+	// the token is marked only if it is still unused, so it is used once by the concurrent requests
+	res, err := tr.db.NewUpdate().Model(token).
+		WherePK().
+		Where("is_used = FALSE").
+		Set("is_used = TRUE").
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("invalid token")
+	}
+
+	return nil
+}
+
+// CreateRevokeSessionsToken creates the token of the "this wasn't me" link sent to the target
+func (tr *tokenRepository) CreateRevokeSessionsToken(ctx context.Context, usrID, target string) (*model.Token, error) {
+	var err error
+
+	token := &model.Token{
+		TenantID:  model.TenantID(ctx),
+		UserID:    usrID,
+		Target:    target,
+		Value:     tools.RandStr(64, "alphanum"),
+		Reason:    model.TokenReasonRevoke,
+		Type:      model.TokenTypeEmail,
+		ExpiresAT: tools.AddTimeToCurrentDate(viper.GetDuration("notification.revoke_link_lifetime")),
+	}
+
+	token.ID, err = gonanoid.New()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tr.db.NewInsert().Model(token).
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// UseRevokeSessionsToken marks an unexpired revoke sessions token as used and returns it
+func (tr *tokenRepository) UseRevokeSessionsToken(ctx context.Context, value string) (*model.Token, error) {
+
+	token := new(model.Token)
+	err := tr.db.NewSelect().Model(token).
+		Where("value = ?", value).
+		Where("reason = ?", model.TokenReasonRevoke).
+		Where("is_used = FALSE").
+		Where("expires_at > ?", time.Now().UTC()).
+		Limit(1).
+		Scan(ctx)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.New("invalid token")
+		}
+		return nil, err
+	}
+
+	// the token is marked only if it is still unused, so it is used once by the concurrent requests
+	res, err := tr.db.NewUpdate().Model(token).
+		WherePK().
+		Where("is_used = FALSE").
+		Set("is_used = TRUE").
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if affected == 0 {
+		return nil, errors.New("invalid token")
+	}
+	token.IsUsed = true
+
+	return token, nil
+}
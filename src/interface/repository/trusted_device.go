@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+	"errors"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/uptrace/bun"
+	"time"
+)
+
+type trustedDeviceRepository struct {
+	db *bun.DB
+}
+
+type TrustedDeviceRepository interface {
+	CreateTrustedDevice(ctx context.Context, usrInfo *model.UserSessionData, expiresAt time.Time) (*model.TrustedDevice, error)
+	UseTrustedDevice(ctx context.Context, deviceID, usrID string) error
+	GetTrustedDevicesByUserID(ctx context.Context, usrID string) ([]model.TrustedDevice, error)
+	DeleteTrustedDevice(ctx context.Context, deviceID, usrID string) error
+	DeleteTrustedDevicesByUserID(ctx context.Context, usrID string) error
+}
+
+func NewTrustedDeviceRepository(db *bun.DB) TrustedDeviceRepository {
+	return &trustedDeviceRepository{db}
+}
+
+func (tr *trustedDeviceRepository) CreateTrustedDevice(ctx context.Context, usrInfo *model.UserSessionData,
+	expiresAt time.Time) (*model.TrustedDevice, error) {
+
+	id, err := gonanoid.New()
+	if err != nil {
+		return nil, err
+	}
+
+	device := &model.TrustedDevice{
+		ID:        id,
+		UserID:    usrInfo.UserID,
+		UserAgent: usrInfo.UserAgent,
+		ClientIP:  usrInfo.ClientIp,
+		ExpiresAT: expiresAt.UTC(),
+	}
+
+	_, err = tr.db.NewInsert().Model(device).
+		Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return device, nil
+}
+
+// UseTrustedDevice checks that the device is still trusted and marks the time of use
+func (tr *trustedDeviceRepository) UseTrustedDevice(ctx context.Context, deviceID, usrID string) error {
+
+	res, err := tr.db.NewUpdate().Model((*model.TrustedDevice)(nil)).
+		Where("id = ?", deviceID).
+		Where("user_id = ?", usrID).
+		Where("expires_at > ?", time.Now().UTC()).
+		Set("last_used_at = ?", time.Now().UTC()).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("device is not trusted")
+	}
+
+	return nil
+}
+
+func (tr *trustedDeviceRepository) GetTrustedDevicesByUserID(ctx context.Context,
+	usrID string) ([]model.TrustedDevice, error) {
+
+	devices := make([]model.TrustedDevice, 0)
+	err := tr.db.NewSelect().Model(&devices).
+		Where("user_id = ?", usrID).
+		Where("expires_at > ?", time.Now().UTC()).
+		Order("created_at DESC").
+		Scan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return devices, nil
+}
+
+func (tr *trustedDeviceRepository) DeleteTrustedDevice(ctx context.Context, deviceID, usrID string) error {
+
+	res, err := tr.db.NewDelete().Model((*model.TrustedDevice)(nil)).
+		Where("id = ?", deviceID).
+		Where("user_id = ?", usrID).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("device not found")
+	}
+
+	return nil
+}
+
+func (tr *trustedDeviceRepository) DeleteTrustedDevicesByUserID(ctx context.Context, usrID string) error {
+
+	_, err := tr.db.NewDelete().Model((*model.TrustedDevice)(nil)).
+		Where("user_id = ?", usrID).
+		Exec(ctx)
+	return err
+}
@@ -2,23 +2,35 @@ package repository
 
 import (
 	"auth-project/src/domain/model"
+	"auth-project/src/infrastructure/encryption"
 	"context"
 	"errors"
 	"github.com/gofiber/fiber/v2"
+	gonanoid "github.com/matoous/go-nanoid/v2"
 	"github.com/uptrace/bun"
+	"time"
 )
 
 type twoFactorAuthRepository struct {
-	db *bun.DB
+	db          *bun.DB
+	fieldCipher encryption.FieldCipher
 }
 
 type TwoFactorAuthRepository interface {
 	SetUpTwoFactorAuthByUserID(ctx context.Context, twoFactorAuthSetUpReq *model.TwoFactorAuthSetUpReq, usrID string) error
 	DeleteTwoFactorAuthByUserID(ctx context.Context, twoFactorAuthType, usrID string) error
+	GetGoogleSecret(ctx context.Context, usr *model.User) (string, error)
+	UseTotpStep(ctx context.Context, usrID string, step int64) error
+	SetDefaultTwoFactorAuthType(ctx context.Context, usrID, twoFactorAuthType string) error
+
+	ReplaceRecoveryCodes(ctx context.Context, usrID string, codeHashes []string) error
+	CountRecoveryCodes(ctx context.Context, usrID string) (int, error)
+	UseRecoveryCode(ctx context.Context, usrID, codeHash string) error
+	DeleteRecoveryCodes(ctx context.Context, usrID string) error
 }
 
-func NewTwoFactorAuthRepository(db *bun.DB) TwoFactorAuthRepository {
-	return &twoFactorAuthRepository{db}
+func NewTwoFactorAuthRepository(db *bun.DB, fc encryption.FieldCipher) TwoFactorAuthRepository {
+	return &twoFactorAuthRepository{db, fc}
 }
 
 func (tr *twoFactorAuthRepository) SetUpTwoFactorAuthByUserID(ctx context.Context,
@@ -32,68 +44,58 @@ func (tr *twoFactorAuthRepository) SetUpTwoFactorAuthByUserID(ctx context.Contex
 		return err
 	}
 
+	// the first enrolled method becomes the default one
+	if !user.HasTwoFactorAuth() {
+		user.Default2faType = twoFactorAuthSetUpReq.Code2faType
+	}
+
 	switch twoFactorAuthSetUpReq.Code2faType {
 	case model.TokenTypeGoogle:
-		if user.IsEmailVerified || user.IsPhoneVerified {
-			return errors.New("you can only have one way of two-factor authentication")
-		}
-
 		if user.GoogleSecret != "" {
 			return errors.New("two-factor google authentication already exist")
 		}
 
-		user.GoogleSecret = twoFactorAuthSetUpReq.Secret
-		user.IsGoogleVerified = true
-		_, err = tr.db.NewUpdate().Model(user).
-			WherePK().
-			Exec(ctx)
+		secret, err := tr.fieldCipher.Encrypt(twoFactorAuthSetUpReq.Secret, googleSecretField(user.ID))
 		if err != nil {
 			return err
 		}
 
-	case model.TokenTypePhone:
-
-		if user.IsEmailVerified || user.GoogleSecret != "" {
-			return errors.New("you can only have one way of two-factor authentication")
-		}
+		user.GoogleSecret = model.EncryptedString(secret)
+		user.IsGoogleVerified = true
 
+	case model.TokenTypePhone:
 		if user.IsPhoneVerified {
-			return errors.New(" two-factor phone authentication already exist")
+			return errors.New("two-factor phone authentication already exist")
 		}
 
 		user.IsPhoneVerified = true
-		_, err = tr.db.NewUpdate().Model(user).
-			WherePK().
-			Exec(ctx)
-		if err != nil {
-			return err
-		}
 
 	case model.TokenTypeEmail:
-
-		if user.IsPhoneVerified || user.GoogleSecret != "" {
-			return errors.New("you can only have one way of two-factor authentication")
-		}
-
 		if user.IsEmailVerified {
 			return errors.New("two-factor email authentication already exist")
 		}
 
 		user.IsEmailVerified = true
-		_, err = tr.db.NewUpdate().Model(user).
-			WherePK().
-			Exec(ctx)
-		if err != nil {
-			return err
-		}
 
 	default:
 		return fiber.NewError(fiber.StatusInternalServerError, "invalid two factor auth type")
 	}
 
+	_, err = tr.db.NewUpdate().Model(user).
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// GetGoogleSecret decrypts the totp secret of the user
+func (tr *twoFactorAuthRepository) GetGoogleSecret(ctx context.Context, usr *model.User) (string, error) {
+	return tr.fieldCipher.Decrypt(string(usr.GoogleSecret), googleSecretField(usr.ID))
+}
+
 func (tr *twoFactorAuthRepository) DeleteTwoFactorAuthByUserID(ctx context.Context, twoFactorAuthType,
 	usrID string) error {
 
@@ -105,6 +107,7 @@ func (tr *twoFactorAuthRepository) DeleteTwoFactorAuthByUserID(ctx context.Conte
 			WherePK().
 			Set("google_secret = NULL").
 			Set("is_google_verified = FALSE").
+			Set("totp_last_step = 0").
 			Exec(ctx)
 		if err != nil {
 			return err
@@ -131,3 +134,118 @@ func (tr *twoFactorAuthRepository) DeleteTwoFactorAuthByUserID(ctx context.Conte
 
 	return nil
 }
+
+// UseTotpStep stores the time step of the accepted totp code, the step can be used only once
+func (tr *twoFactorAuthRepository) UseTotpStep(ctx context.Context, usrID string, step int64) error {
+
+	res, err := tr.db.NewUpdate().Model((*model.User)(nil)).
+		Where("id = ?", usrID).
+		Where("totp_last_step < ?", step).
+		Set("totp_last_step = ?", step).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("token already used")
+	}
+
+	return nil
+}
+
+// ReplaceRecoveryCodes deletes the user's recovery codes and saves the new ones
+func (tr *twoFactorAuthRepository) ReplaceRecoveryCodes(ctx context.Context, usrID string, codeHashes []string) error {
+
+	codes := make([]model.RecoveryCode, 0, len(codeHashes))
+	for _, codeHash := range codeHashes {
+		id, err := gonanoid.New()
+		if err != nil {
+			return err
+		}
+
+		codes = append(codes, model.RecoveryCode{
+			ID:       id,
+			UserID:   usrID,
+			CodeHash: codeHash,
+		})
+	}
+
+	return tr.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		_, err := tx.NewDelete().Model((*model.RecoveryCode)(nil)).
+			Where("user_id = ?", usrID).
+			Exec(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(codes) == 0 {
+			return nil
+		}
+
+		_, err = tx.NewInsert().Model(&codes).
+			Exec(ctx)
+		return err
+	})
+}
+
+// CountRecoveryCodes returns the number of unused recovery codes
+func (tr *twoFactorAuthRepository) CountRecoveryCodes(ctx context.Context, usrID string) (int, error) {
+
+	return tr.db.NewSelect().Model((*model.RecoveryCode)(nil)).
+		Where("user_id = ?", usrID).
+		Where("is_used = FALSE").
+		Count(ctx)
+}
+
+func (tr *twoFactorAuthRepository) UseRecoveryCode(ctx context.Context, usrID, codeHash string) error {
+
+	res, err := tr.db.NewUpdate().Model((*model.RecoveryCode)(nil)).
+		Where("user_id = ?", usrID).
+		Where("code_hash = ?", codeHash).
+		Where("is_used = FALSE").
+		Set("is_used = TRUE").
+		Set("used_at = ?", time.Now().UTC()).
+		Exec(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("invalid recovery code")
+	}
+
+	return nil
+}
+
+func (tr *twoFactorAuthRepository) DeleteRecoveryCodes(ctx context.Context, usrID string) error {
+
+	_, err := tr.db.NewDelete().Model((*model.RecoveryCode)(nil)).
+		Where("user_id = ?", usrID).
+		Exec(ctx)
+	return err
+}
+
+// SetDefaultTwoFactorAuthType sets the method used at sign in, the empty type resets the choice
+func (tr *twoFactorAuthRepository) SetDefaultTwoFactorAuthType(ctx context.Context, usrID,
+	twoFactorAuthType string) error {
+
+	query := tr.db.NewUpdate().Model((*model.User)(nil)).
+		Where("id = ?", usrID)
+	if twoFactorAuthType == "" {
+		query = query.Set("default_2fa_type = NULL")
+	} else {
+		query = query.Set("default_2fa_type = ?", twoFactorAuthType)
+	}
+
+	_, err := query.Exec(ctx)
+	return err
+}
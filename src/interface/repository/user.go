@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"auth-project/src/infrastructure/authentication"
 	"auth-project/tools"
 	"context"
 	"database/sql"
@@ -8,19 +9,20 @@ import (
 	"github.com/gofiber/fiber/v2"
 	gonanoid "github.com/matoous/go-nanoid/v2"
 	"github.com/uptrace/bun"
+	"log"
 	"strings"
 	"time"
 
-	"golang.org/x/crypto/bcrypt"
-
-	"github.com/go-redis/redis/v8"
-
 	"auth-project/src/domain/model"
+	"auth-project/src/infrastructure/storage"
+	"auth-project/src/infrastructure/storage/sessions"
 )
 
 type userRepository struct {
-	db  *bun.DB
-	rdb *redis.Client
+	db             *bun.DB
+	sessionStore   sessions.Store
+	passwordHasher authentication.PasswordHasher
+	dbErrors       storage.ErrorClassifier
 }
 
 type UserRepository interface {
@@ -39,21 +41,24 @@ type UserRepository interface {
 	ChangeUserPasswordByID(ctx context.Context, data *model.UserChangePasswordData, usrID string) error
 
 	UpdateUserInfoByID(ctx context.Context, updReq *model.UserUpdateInfoData, userID string) (*model.User, error)
-	UpdateUserEmailByID(ctx context.Context, email string, userID string) (*model.User, error)
-	UpdateUserPhoneByID(ctx context.Context, phone string, userID string) (*model.User, error)
 
-	SignOut(ctx context.Context, atID string) error
+	IsUserNameTaken(ctx context.Context, userName string) (bool, error)
+	UpdateUserNameByID(ctx context.Context, userName, usrID string) error
+
+	SignOut(ctx context.Context, usrID, sessionID string) error
 	SignOutAll(ctx context.Context, usrID string) error
 }
 
-func NewUserRepository(db *bun.DB, rdb *redis.Client) UserRepository {
-	return &userRepository{db, rdb}
+func NewUserRepository(db *bun.DB, sessionStore sessions.Store, ph authentication.PasswordHasher,
+	dbErrors storage.ErrorClassifier) UserRepository {
+	return &userRepository{db, sessionStore, ph, dbErrors}
 }
 
 func (ur *userRepository) IsExitsUserByEmail(ctx context.Context, email string) (bool, error) {
 
 	exists, err := ur.db.NewSelect().Model((*model.User)(nil)).
 		Where("email = ? ", email).
+		Where("tenant_id = ?", model.TenantID(ctx)).
 		Exists(ctx)
 	if err != nil {
 		return false, err
@@ -76,8 +81,8 @@ func (ur *userRepository) IsExitsUserByIDAndPassword(ctx context.Context, userID
 		return false, err
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(usr.Password), []byte(password))
-	if err != nil {
+	ok, err := ur.passwordHasher.Verify(usr.Password, password)
+	if err != nil || !ok {
 		return false, errors.New("incorrect password")
 	}
 
@@ -93,7 +98,8 @@ func (ur *userRepository) GetUserByLoginAndPassword(ctx context.Context,
 	usr := &model.User{}
 	err := ur.db.NewSelect().Model(usr).
 		Where("is_active = true").
-		Where("email = ? OR phone = ? OR id = ?", login, login, login).
+		Where("email = ? OR phone = ? OR id = ? OR LOWER(user_name) = ?", login, login, login, login).
+		Where("tenant_id = ?", model.TenantID(ctx)).
 		Scan(ctx)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -103,14 +109,37 @@ func (ur *userRepository) GetUserByLoginAndPassword(ctx context.Context,
 		return nil, err
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(usr.Password), []byte(psw))
-	if err != nil {
+	ok, err := ur.passwordHasher.Verify(usr.Password, psw)
+	if err != nil || !ok {
 		return nil, errors.New("incorrect login or password")
 	}
 
+	// transparently upgrade hashes produced with outdated parameters,
+	// the password is already verified, so the failed upgrade does not fail the login
+	if ur.passwordHasher.NeedsRehash(usr.Password) {
+		err = ur.rehashPassword(ctx, usr.ID, psw)
+		if err != nil {
+			log.Printf("error rehashing password of user %s: %s", usr.ID, err.Error())
+		}
+	}
+
 	return usr, nil
 }
 
+func (ur *userRepository) rehashPassword(ctx context.Context, usrID, psw string) error {
+
+	hash, err := ur.passwordHasher.Hash(psw)
+	if err != nil {
+		return err
+	}
+
+	_, err = ur.db.NewUpdate().Model((*model.User)(nil)).
+		Set("password = ?", hash).
+		Where("id = ?", usrID).
+		Exec(ctx)
+	return err
+}
+
 func (ur *userRepository) GetUserByEmailOrPhone(ctx context.Context, emailOrPhone string) (*model.User, error) {
 
 	emailOrPhone = strings.ToLower(emailOrPhone)
@@ -118,6 +147,7 @@ func (ur *userRepository) GetUserByEmailOrPhone(ctx context.Context, emailOrPhon
 	err := ur.db.NewSelect().Model(usr).
 		Where("is_active = true").
 		Where("email = ? OR phone = ?", emailOrPhone, emailOrPhone).
+		Where("tenant_id = ?", model.TenantID(ctx)).
 		Scan(ctx)
 	if err != nil {
 		return nil, err
@@ -134,7 +164,7 @@ func (ur *userRepository) GetUserByID(ctx context.Context, usrID string) (*model
 		Scan(ctx)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, errors.New("user not identified")
+			return nil, model.ErrUserNotFound
 		}
 
 		return nil, err
@@ -148,6 +178,7 @@ func (ur *userRepository) CreatUnActivateUserIfNotExistByLogin(ctx context.Conte
 	var usr model.User
 	err := ur.db.NewSelect().Model(&usr).
 		Where("email = ? OR phone = ?", data.Login, data.Login).
+		Where("tenant_id = ?", model.TenantID(ctx)).
 		Scan(ctx)
 	if err != nil {
 		if err != sql.ErrNoRows {
@@ -159,6 +190,7 @@ func (ur *userRepository) CreatUnActivateUserIfNotExistByLogin(ctx context.Conte
 			}
 			user := model.User{
 				ID:           id,
+				TenantID:     model.TenantID(ctx),
 				ReferralLink: tools.GenerateLink(),
 				IsActive:     false,
 			}
@@ -179,6 +211,10 @@ func (ur *userRepository) CreatUnActivateUserIfNotExistByLogin(ctx context.Conte
 		}
 	}
 
+	if !usr.DeletedAt.IsZero() {
+		return errors.New("user is deleted")
+	}
+
 	if usr.IsActive {
 		return errors.New("user already exist and activate")
 	}
@@ -191,28 +227,41 @@ func (ur *userRepository) SignUpActivateUser(ctx context.Context, signUpReq *mod
 	var usr model.User
 	err := ur.db.NewSelect().Model(&usr).
 		Where("email = ? OR phone = ?", signUpReq.Login, signUpReq.Login).
+		Where("tenant_id = ?", model.TenantID(ctx)).
 		Scan(ctx)
 	if err != nil {
 		return err
 	}
 
+	if !usr.DeletedAt.IsZero() {
+		return errors.New("user is deleted")
+	}
+
 	if usr.IsActive {
 		return errors.New("user already activate")
 	}
 
-	// Use GenerateFromPassword to hash & salt password.
-	hash, err := bcrypt.GenerateFromPassword([]byte(signUpReq.Password), bcrypt.DefaultCost)
+	hash, err := ur.passwordHasher.Hash(signUpReq.Password)
 	if err != nil {
 		return err
 	}
 
-	usr.Password = string(hash)
+	usr.Password = hash
 	usr.IsActive = true
 	usr.UpdatedAt = time.Now().UTC()
 
+	// the login is confirmed by the sign-up code
+	switch signUpReq.LoginType {
+	case model.TokenTypePhone:
+		usr.PhoneVerifiedAt = usr.UpdatedAt
+	case model.TokenTypeEmail:
+		usr.EmailVerifiedAt = usr.UpdatedAt
+	}
+
 	if signUpReq.Referral != "" {
 		exists, err := ur.db.NewSelect().Model((*model.User)(nil)).
 			Where("referral_link = ? ", signUpReq.Referral).
+			Where("tenant_id = ?", usr.TenantID).
 			Exists(ctx)
 		if err != nil {
 			return err
@@ -249,13 +298,12 @@ func (ur *userRepository) ResetUserPassword(ctx context.Context, newPassword, us
 		return err
 	}
 
-	// Use GenerateFromPassword to hash & salt password.
-	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	hash, err := ur.passwordHasher.Hash(newPassword)
 	if err != nil {
 		return err
 	}
 
-	usr.Password = string(hash)
+	usr.Password = hash
 	usr.UpdatedAt = time.Now().UTC()
 	_, err = ur.db.NewUpdate().Model(usr).
 		WherePK().
@@ -283,19 +331,17 @@ func (ur *userRepository) ChangeUserPasswordByID(ctx context.Context, data *mode
 		return err
 	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(usr.Password), []byte(data.OldPassword))
-	if err != nil {
-		err = errors.New("incorrect current password")
-		return err
+	ok, err := ur.passwordHasher.Verify(usr.Password, data.OldPassword)
+	if err != nil || !ok {
+		return errors.New("incorrect current password")
 	}
 
-	// Use GenerateFromPassword to hash & salt password.
-	hash, err := bcrypt.GenerateFromPassword([]byte(data.NewPassword), bcrypt.DefaultCost)
+	hash, err := ur.passwordHasher.Hash(data.NewPassword)
 	if err != nil {
 		return err
 	}
 
-	usr.Password = string(hash)
+	usr.Password = hash
 	usr.UpdatedAt = time.Now().UTC()
 	_, err = ur.db.NewUpdate().Model(usr).
 		WherePK().
@@ -316,27 +362,14 @@ func (ur *userRepository) UpdateUserInfoByID(ctx context.Context, updReq *model.
 	_, err := ur.db.NewUpdate().Model(user).
 		Column("full_name").
 		WherePK().
-		Returning("*").
 		Exec(ctx)
 	if err != nil {
 		return nil, err
 	}
 
-	return user, nil
-}
-
-func (ur *userRepository) UpdateUserPhoneByID(ctx context.Context, phone string,
-	userID string) (*model.User, error) {
-
-	user := &model.User{
-		ID:    userID,
-		Phone: phone,
-	}
-	_, err := ur.db.NewUpdate().Model(user).
-		Column("phone").
+	err = ur.db.NewSelect().Model(user).
 		WherePK().
-		Returning("*").
-		Exec(ctx)
+		Scan(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -344,37 +377,53 @@ func (ur *userRepository) UpdateUserPhoneByID(ctx context.Context, phone string,
 	return user, nil
 }
 
-func (ur *userRepository) UpdateUserEmailByID(ctx context.Context, email string,
-	userID string) (*model.User, error) {
+// IsUserNameTaken checks the user name among the names and the ids of the tenant's users,
+// since any of them is accepted as the login
+func (ur *userRepository) IsUserNameTaken(ctx context.Context, userName string) (bool, error) {
 
-	user := &model.User{
-		ID:    userID,
-		Email: email,
-	}
-	_, err := ur.db.NewUpdate().Model(user).
-		Column("email").
-		WherePK().
-		Returning("*").
+	return ur.db.NewSelect().Model((*model.User)(nil)).
+		Where("LOWER(user_name) = ? OR id = ?", userName, userName).
+		Where("tenant_id = ?", model.TenantID(ctx)).
+		Exists(ctx)
+}
+
+// UpdateUserNameByID sets the user name and the time of the change
+func (ur *userRepository) UpdateUserNameByID(ctx context.Context, userName, usrID string) error {
+
+	now := time.Now().UTC()
+	res, err := ur.db.NewUpdate().Model((*model.User)(nil)).
+		Where("id = ?", usrID).
+		Set("user_name = ?", userName).
+		Set("user_name_changed_at = ?", now).
+		Set("updated_at = ?", now).
 		Exec(ctx)
 	if err != nil {
-		return nil, err
+		if ur.dbErrors.IsUniqueViolation(err) {
+			return model.ErrUserNameTaken
+		}
+		return err
 	}
 
-	return user, nil
-}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return errors.New("user not identified")
+	}
 
-// SignOut clear redis key, and check exist
-func (ur *userRepository) SignOut(ctx context.Context, sessionID string) error {
+	return nil
+}
 
-	ur.rdb.Del(ctx, sessionID)
-	ur.rdb.Del(ctx, sessionID+model.PostfixRefreshToken)
+// SignOut deletes the tokens of the session from the session store and marks the session logged out
+func (ur *userRepository) SignOut(ctx context.Context, usrID, sessionID string) error {
 
-	if ur.rdb.Exists(ctx, sessionID).Val() == 1 ||
-		ur.rdb.Exists(ctx, sessionID+model.PostfixRefreshToken).Val() == 1 {
-		return errors.New("redis key deleting error")
+	err := ur.sessionStore.RevokeSession(ctx, usrID, sessionID)
+	if err != nil {
+		return err
 	}
 
-	_, err := ur.db.NewUpdate().Model((*model.Session)(nil)).
+	_, err = ur.db.NewUpdate().Model((*model.Session)(nil)).
 		Where("session_id = ?", sessionID).
 		Set("is_logout = TRUE").
 		Set("updated_at = ?", time.Now().UTC()).
@@ -386,34 +435,45 @@ func (ur *userRepository) SignOut(ctx context.Context, sessionID string) error {
 	return nil
 }
 
-// SignOutAll clear redis key, and check exist
+// SignOutAll deletes the tokens of the sessions of the index of the user and of the live sessions
+// of the database from the session store, since the sessions stored before the index are not in it
+// until the reconciliation, and marks all live sessions of the user logged out
 func (ur *userRepository) SignOutAll(ctx context.Context, usrID string) error {
 
-	var sessions []model.Session
-	err := ur.db.NewSelect().Model(&sessions).
+	var sessionIDs []string
+	err := ur.db.NewSelect().Model((*model.Session)(nil)).
+		Column("session_id").
 		Where("user_id = ?", usrID).
 		Where("is_logout = FALSE").
 		Where("expires_at > ?", time.Now().UTC()).
-		Scan(ctx)
+		Scan(ctx, &sessionIDs)
 	if err != nil {
 		return err
 	}
 
-	for _, session := range sessions {
-		ur.rdb.Del(ctx, session.SessionID+model.PostfixRefreshToken)
-		ur.rdb.Del(ctx, session.SessionID)
+	err = ur.sessionStore.RevokeAll(ctx, usrID)
+	if err != nil {
+		return err
+	}
+
+	if len(sessionIDs) > 0 {
+		keys := make([]string, 0, 2*len(sessionIDs))
+		for _, sessionID := range sessionIDs {
+			keys = append(keys, sessionID, sessionID+model.PostfixRefreshToken)
+		}
 
-		if ur.rdb.Exists(ctx, session.SessionID+model.PostfixRefreshToken).Val() == 1 ||
-			ur.rdb.Exists(ctx, session.SessionID).Val() == 1 {
-			return errors.New("redis key deleting error")
+		err = ur.sessionStore.Delete(ctx, keys...)
+		if err != nil {
+			return err
 		}
 	}
 
-	_, err = ur.db.NewUpdate().Model(&sessions).
+	_, err = ur.db.NewUpdate().Model((*model.Session)(nil)).
+		Where("user_id = ?", usrID).
+		Where("is_logout = FALSE").
 		Where("expires_at > ?", time.Now().UTC()).
 		Set("is_logout = TRUE").
 		Set("updated_at = ?", time.Now().UTC()).
-		WherePK().
 		Exec(ctx)
 	if err != nil {
 		return err
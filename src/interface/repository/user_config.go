@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/infrastructure/storage"
+	"context"
+	"database/sql"
+	"github.com/uptrace/bun"
+)
+
+type userConfigRepository struct {
+	db *bun.DB
+}
+
+type UserConfigRepository interface {
+	GetUserConfig(ctx context.Context, usrID string) (*model.UserConfig, error)
+	SetAccFlagged(ctx context.Context, usrID string, flagged bool) error
+}
+
+func NewUserConfigRepository(db *bun.DB) UserConfigRepository {
+	return &userConfigRepository{db}
+}
+
+// GetUserConfig returns the config of the user, the users without the row get the default one
+func (ucr *userConfigRepository) GetUserConfig(ctx context.Context, usrID string) (*model.UserConfig, error) {
+
+	config := &model.UserConfig{UserID: usrID}
+	err := ucr.db.NewSelect().Model(config).
+		WherePK().
+		Scan(ctx)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (ucr *userConfigRepository) SetAccFlagged(ctx context.Context, usrID string, flagged bool) error {
+
+	_, err := ucr.db.NewInsert().Model(&model.UserConfig{UserID: usrID, AccFlagged: flagged}).
+		On(storage.OnConflictUpdate(ucr.db, "user_id")).
+		Set("acc_flagged = ?", storage.InsertedValue(ucr.db, "acc_flagged")).
+		Exec(ctx)
+	return err
+}
@@ -0,0 +1,75 @@
+package repository_test
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/infrastructure/authentication"
+	"auth-project/src/infrastructure/storage"
+	"auth-project/src/infrastructure/storage/sessions"
+	"auth-project/src/infrastructure/storage/storagetest"
+	"auth-project/src/interface/repository"
+	"context"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestSignInUpgradesPasswordHash(t *testing.T) {
+	db := storagetest.NewSQLite(t)
+	ctx := context.Background()
+
+	bcryptHasher, err := authentication.NewPasswordHasher(authentication.PasswordAlgorithmBcrypt, bcrypt.MinCost,
+		authentication.Argon2Params{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	argon2Hasher, err := authentication.NewPasswordHasher(authentication.PasswordAlgorithmArgon2id, 0,
+		authentication.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := bcryptHasher.Hash("Password1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	insertUser(t, db, &model.User{ID: "user", Email: "user@gmail.com", Password: hash, IsActive: true})
+
+	ur := repository.NewUserRepository(db, sessions.NewMemoryStore(), argon2Hasher, storage.NewErrorClassifier())
+
+	_, err = ur.GetUserByLoginAndPassword(ctx, "user@gmail.com", "Wrong1234")
+	if err == nil {
+		t.Fatal("the wrong password signed in")
+	}
+	assertPasswordHash(t, ur, hash)
+
+	// the bcrypt hash is replaced by the argon2id one on the sign in
+	_, err = ur.GetUserByLoginAndPassword(ctx, "user@gmail.com", "Password1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	usr := assertPasswordHash(t, ur, "")
+	if !strings.HasPrefix(usr.Password, "$argon2id$") || argon2Hasher.NeedsRehash(usr.Password) {
+		t.Fatalf("got hash %q, want the argon2id one", usr.Password)
+	}
+
+	_, err = ur.GetUserByLoginAndPassword(ctx, "user@gmail.com", "Password1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertPasswordHash(t, ur, usr.Password)
+}
+
+// assertPasswordHash checks the stored hash if want is not empty and returns the user
+func assertPasswordHash(t *testing.T, ur repository.UserRepository, want string) *model.User {
+	t.Helper()
+
+	usr, err := ur.GetUserByID(context.Background(), "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want != "" && usr.Password != want {
+		t.Fatalf("got hash %q, want %q", usr.Password, want)
+	}
+	return usr
+}
@@ -0,0 +1,28 @@
+package registry
+
+import (
+	interfaceController "auth-project/src/interface/controller"
+	interfacePresenter "auth-project/src/interface/presenter"
+	interfaceRepository "auth-project/src/interface/repository"
+	usecaseInteractor "auth-project/src/usecase/interactor"
+	usecasePresenter "auth-project/src/usecase/presenter"
+	usecaseRepository "auth-project/src/usecase/repository"
+)
+
+func (r *registry) NewAccountController() interfaceController.AccountController {
+	return interfaceController.NewAccountController(r.NewAccountInteractor())
+}
+
+func (r *registry) NewAccountInteractor() usecaseInteractor.AccountInteractor {
+	return usecaseInteractor.NewAccountInteractor(r.NewAccountRepository(), r.NewUserRepository(),
+		r.NewSessionRepository(), r.NewTrustedDeviceRepository(), r.NewContactChangeRepository(),
+		r.NewNotificationRepository(), r.NewAccountPresenter())
+}
+
+func (r *registry) NewAccountRepository() usecaseRepository.AccountRepository {
+	return interfaceRepository.NewAccountRepository(r.db, r.fileStorage)
+}
+
+func (r *registry) NewAccountPresenter() usecasePresenter.AccountPresenter {
+	return interfacePresenter.NewAccountPresenter()
+}
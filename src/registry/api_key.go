@@ -0,0 +1,26 @@
+package registry
+
+import (
+	interfaceController "auth-project/src/interface/controller"
+	interfacePresenter "auth-project/src/interface/presenter"
+	interfaceRepository "auth-project/src/interface/repository"
+	usecaseInteractor "auth-project/src/usecase/interactor"
+	usecasePresenter "auth-project/src/usecase/presenter"
+	usecaseRepository "auth-project/src/usecase/repository"
+)
+
+func (r *registry) NewApiKeyController() interfaceController.ApiKeyController {
+	return interfaceController.NewApiKeyController(r.NewApiKeyInteractor())
+}
+
+func (r *registry) NewApiKeyInteractor() usecaseInteractor.ApiKeyInteractor {
+	return usecaseInteractor.NewApiKeyInteractor(r.NewApiKeyRepository(), r.NewUserRepository(), r.NewApiKeyPresenter())
+}
+
+func (r *registry) NewApiKeyRepository() usecaseRepository.ApiKeyRepository {
+	return interfaceRepository.NewApiKeyRepository(r.db)
+}
+
+func (r *registry) NewApiKeyPresenter() usecasePresenter.ApiKeyPresenter {
+	return interfacePresenter.NewApiKeyPresenter()
+}
@@ -14,11 +14,12 @@ func (r *registry) NewAuthController() interfaceController.AuthController {
 }
 
 func (r *registry) NewAuthInteractor() usecaseInteractor.AuthInteractor {
-	return usecaseInteractor.NewAuthInteractor(r.NewAuthRepository(), r.NewSessionRepository(), r.NewUserRepository(), r.NewTokenRepository(), r.NewAuthPresenter(), r.jwtConf)
+	return usecaseInteractor.NewAuthInteractor(r.NewAuthRepository(), r.NewSessionRepository(), r.NewUserRepository(), r.NewTokenRepository(), r.NewTwoFactorAuthRepository(), r.NewTrustedDeviceRepository(), r.NewNotificationRepository(),
+		r.NewContactChangeRepository(), r.NewUserConfigRepository(), r.NewTenantRepository(), r.NewApiKeyRepository(), r.NewOAuthClientRepository(), r.NewAuthPresenter(), r.jwtConf, r.totpConf)
 }
 
 func (r *registry) NewAuthRepository() usecaseRepository.AuthRepository {
-	return interfaceRepository.NewAuthRepository(r.db, r.rdb)
+	return interfaceRepository.NewAuthRepository(r.db, r.sessionStore)
 }
 
 func (r *registry) NewAuthPresenter() usecasePresenter.AuthPresenter {
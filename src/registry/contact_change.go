@@ -0,0 +1,28 @@
+package registry
+
+import (
+	interfaceController "auth-project/src/interface/controller"
+	interfacePresenter "auth-project/src/interface/presenter"
+	interfaceRepository "auth-project/src/interface/repository"
+	usecaseInteractor "auth-project/src/usecase/interactor"
+	usecasePresenter "auth-project/src/usecase/presenter"
+	usecaseRepository "auth-project/src/usecase/repository"
+)
+
+func (r *registry) NewContactChangeController() interfaceController.ContactChangeController {
+	return interfaceController.NewContactChangeController(r.NewContactChangeInteractor())
+}
+
+func (r *registry) NewContactChangeInteractor() usecaseInteractor.ContactChangeInteractor {
+	return usecaseInteractor.NewContactChangeInteractor(r.NewContactChangeRepository(), r.NewUserRepository(),
+		r.NewTokenRepository(), r.NewTwoFactorAuthRepository(), r.NewNotificationRepository(),
+		r.NewContactChangePresenter(), r.totpConf)
+}
+
+func (r *registry) NewContactChangeRepository() usecaseRepository.ContactChangeRepository {
+	return interfaceRepository.NewContactChangeRepository(r.db, r.dbErrors)
+}
+
+func (r *registry) NewContactChangePresenter() usecasePresenter.ContactChangePresenter {
+	return interfacePresenter.NewContactChangePresenter()
+}
@@ -0,0 +1,31 @@
+package registry
+
+import (
+	interfaceController "auth-project/src/interface/controller"
+	interfacePresenter "auth-project/src/interface/presenter"
+	interfaceRepository "auth-project/src/interface/repository"
+	usecaseInteractor "auth-project/src/usecase/interactor"
+	usecasePresenter "auth-project/src/usecase/presenter"
+	usecaseRepository "auth-project/src/usecase/repository"
+)
+
+func (r *registry) NewKycController() interfaceController.KycController {
+	return interfaceController.NewKycController(r.NewKycInteractor())
+}
+
+func (r *registry) NewKycInteractor() usecaseInteractor.KycInteractor {
+	return usecaseInteractor.NewKycInteractor(r.NewKycRepository(), r.NewUserConfigRepository(), r.NewUserRepository(),
+		r.NewKycPresenter())
+}
+
+func (r *registry) NewKycRepository() usecaseRepository.KycRepository {
+	return interfaceRepository.NewKycRepository(r.db, r.fileStorage, r.fieldCipher)
+}
+
+func (r *registry) NewUserConfigRepository() usecaseRepository.UserConfigRepository {
+	return interfaceRepository.NewUserConfigRepository(r.db)
+}
+
+func (r *registry) NewKycPresenter() usecasePresenter.KycPresenter {
+	return interfacePresenter.NewKycPresenter()
+}
@@ -0,0 +1,10 @@
+package registry
+
+import (
+	interfaceRepository "auth-project/src/interface/repository"
+	usecaseRepository "auth-project/src/usecase/repository"
+)
+
+func (r *registry) NewNotificationRepository() usecaseRepository.NotificationRepository {
+	return interfaceRepository.NewNotificationRepository(r.db, r.NewTenantRepository(), r.worker)
+}
@@ -0,0 +1,27 @@
+package registry
+
+import (
+	interfaceController "auth-project/src/interface/controller"
+	interfacePresenter "auth-project/src/interface/presenter"
+	interfaceRepository "auth-project/src/interface/repository"
+	usecaseInteractor "auth-project/src/usecase/interactor"
+	usecasePresenter "auth-project/src/usecase/presenter"
+	usecaseRepository "auth-project/src/usecase/repository"
+)
+
+func (r *registry) NewOAuthClientController() interfaceController.OAuthClientController {
+	return interfaceController.NewOAuthClientController(r.NewOAuthClientInteractor())
+}
+
+func (r *registry) NewOAuthClientInteractor() usecaseInteractor.OAuthClientInteractor {
+	return usecaseInteractor.NewOAuthClientInteractor(r.NewOAuthClientRepository(), r.NewUserRepository(), r.NewUserConfigRepository(),
+		r.NewTenantRepository(), r.NewOAuthClientPresenter(), r.jwtConf)
+}
+
+func (r *registry) NewOAuthClientRepository() usecaseRepository.OAuthClientRepository {
+	return interfaceRepository.NewOAuthClientRepository(r.db)
+}
+
+func (r *registry) NewOAuthClientPresenter() usecasePresenter.OAuthClientPresenter {
+	return interfacePresenter.NewOAuthClientPresenter()
+}
@@ -14,7 +14,7 @@ func (r *registry) NewQrCodeAuthController() interfaceController.QrCodeAuthContr
 }
 
 func (r *registry) NewQrCodeAuthInteractor() usecaseInteractor.QrCodeAuthInteractor {
-	return usecaseInteractor.NewQrCodeAuthInteractor(r.NewAuthRepository(), r.NewSessionRepository(), r.NewUserRepository(), r.NewQrCodeAuthRepository(), r.NewQrCodeAuthPresenter(), r.jwtConf)
+	return usecaseInteractor.NewQrCodeAuthInteractor(r.NewAuthRepository(), r.NewSessionRepository(), r.NewUserRepository(), r.NewQrCodeAuthRepository(), r.NewNotificationRepository(), r.NewUserConfigRepository(), r.NewTenantRepository(), r.NewQrCodeAuthPresenter(), r.jwtConf)
 }
 
 func (r *registry) NewQrCodeAuthRepository() usecaseRepository.QrCodeAuthRepository {
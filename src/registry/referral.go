@@ -0,0 +1,27 @@
+package registry
+
+import (
+	interfaceController "auth-project/src/interface/controller"
+	interfacePresenter "auth-project/src/interface/presenter"
+	interfaceRepository "auth-project/src/interface/repository"
+	usecaseInteractor "auth-project/src/usecase/interactor"
+	usecasePresenter "auth-project/src/usecase/presenter"
+	usecaseRepository "auth-project/src/usecase/repository"
+)
+
+func (r *registry) NewReferralController() interfaceController.ReferralController {
+	return interfaceController.NewReferralController(r.NewReferralInteractor())
+}
+
+func (r *registry) NewReferralInteractor() usecaseInteractor.ReferralInteractor {
+	return usecaseInteractor.NewReferralInteractor(r.NewReferralRepository(), r.NewUserRepository(),
+		r.NewReferralPresenter())
+}
+
+func (r *registry) NewReferralRepository() usecaseRepository.ReferralRepository {
+	return interfaceRepository.NewReferralRepository(r.db, r.dbErrors)
+}
+
+func (r *registry) NewReferralPresenter() usecasePresenter.ReferralPresenter {
+	return interfacePresenter.NewReferralPresenter()
+}
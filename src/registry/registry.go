@@ -2,32 +2,76 @@ package registry
 
 import (
 	"auth-project/src/infrastructure/authentication"
+	"auth-project/src/infrastructure/encryption"
+	"auth-project/src/infrastructure/scheduler"
+	"auth-project/src/infrastructure/storage"
+	"auth-project/src/infrastructure/storage/files"
+	"auth-project/src/infrastructure/storage/sessions"
 	"auth-project/src/interface/controller"
-	"github.com/go-redis/redis/v8"
+	interfaceRepository "auth-project/src/interface/repository"
+	"auth-project/src/usecase/interactor"
+	usecaseRepository "auth-project/src/usecase/repository"
 	"github.com/uptrace/bun"
 )
 
 type registry struct {
-	db      *bun.DB
-	rdb     *redis.Client
-	jwtConf *authentication.JwtConfigurator
+	db             *bun.DB
+	sessionStore   sessions.Store
+	jwtConf        *authentication.JwtConfigurator
+	totpConf       *authentication.TotpConfig
+	passwordHasher authentication.PasswordHasher
+	fileStorage    files.Storage
+	fieldCipher    encryption.FieldCipher
+	worker         *scheduler.Worker
+	dbErrors       storage.ErrorClassifier
+
+	// tenantRepository is shared, so all repositories use its cache
+	tenantRepository usecaseRepository.TenantRepository
 }
 
 type Registry interface {
 	NewAPIController() controller.APIController
+	NewAuthInteractor() interactor.AuthInteractor
+	NewContactChangeInteractor() interactor.ContactChangeInteractor
+	NewAccountInteractor() interactor.AccountInteractor
 }
 
 func NewRegistry(db *bun.DB,
-	rdb *redis.Client,
-	jwtConf *authentication.JwtConfigurator) Registry {
-	return &registry{db, rdb, jwtConf}
+	sessionStore sessions.Store,
+	jwtConf *authentication.JwtConfigurator,
+	totpConf *authentication.TotpConfig,
+	passwordHasher authentication.PasswordHasher,
+	fileStorage files.Storage,
+	fieldCipher encryption.FieldCipher,
+	worker *scheduler.Worker,
+	dbErrors storage.ErrorClassifier) Registry {
+	return &registry{
+		db:               db,
+		sessionStore:     sessionStore,
+		jwtConf:          jwtConf,
+		totpConf:         totpConf,
+		passwordHasher:   passwordHasher,
+		fileStorage:      fileStorage,
+		fieldCipher:      fieldCipher,
+		worker:           worker,
+		dbErrors:         dbErrors,
+		tenantRepository: interfaceRepository.NewTenantRepository(db),
+	}
 }
 
 func (r *registry) NewAPIController() controller.APIController {
 	return controller.APIController{
+		Tenant:        r.NewTenantController(),
 		Auth:          r.NewAuthController(),
 		QrCodeAuth:    r.NewQrCodeAuthController(),
 		TwoFactorAuth: r.NewTwoFactorAuthController(),
+		TrustedDevice: r.NewTrustedDeviceController(),
+		ContactChange: r.NewContactChangeController(),
+		Account:       r.NewAccountController(),
+		Referral:      r.NewReferralController(),
+		Kyc:           r.NewKycController(),
+		ApiKey:        r.NewApiKeyController(),
+		OAuthClient:   r.NewOAuthClientController(),
 		User:          r.NewUserController(),
 		Token:         r.NewTokenController(),
 	}
@@ -0,0 +1,25 @@
+package registry
+
+import (
+	interfaceController "auth-project/src/interface/controller"
+	interfacePresenter "auth-project/src/interface/presenter"
+	usecaseInteractor "auth-project/src/usecase/interactor"
+	usecasePresenter "auth-project/src/usecase/presenter"
+	usecaseRepository "auth-project/src/usecase/repository"
+)
+
+func (r *registry) NewTenantController() interfaceController.TenantController {
+	return interfaceController.NewTenantController(r.NewTenantInteractor())
+}
+
+func (r *registry) NewTenantInteractor() usecaseInteractor.TenantInteractor {
+	return usecaseInteractor.NewTenantInteractor(r.NewTenantRepository(), r.NewTenantPresenter())
+}
+
+func (r *registry) NewTenantRepository() usecaseRepository.TenantRepository {
+	return r.tenantRepository
+}
+
+func (r *registry) NewTenantPresenter() usecasePresenter.TenantPresenter {
+	return interfacePresenter.NewTenantPresenter()
+}
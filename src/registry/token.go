@@ -18,7 +18,7 @@ func (r *registry) NewTokenInteractor() usecaseInteractor.TokenInteractor {
 }
 
 func (r *registry) NewTokenRepository() usecaseRepository.TokenRepository {
-	return interfaceRepository.NewTokenRepository(r.db)
+	return interfaceRepository.NewTokenRepository(r.db, r.NewTenantRepository())
 }
 
 func (r *registry) NewTokenPresenter() usecasePresenter.TokenPresenter {
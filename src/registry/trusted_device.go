@@ -0,0 +1,26 @@
+package registry
+
+import (
+	interfaceController "auth-project/src/interface/controller"
+	interfacePresenter "auth-project/src/interface/presenter"
+	interfaceRepository "auth-project/src/interface/repository"
+	usecaseInteractor "auth-project/src/usecase/interactor"
+	usecasePresenter "auth-project/src/usecase/presenter"
+	usecaseRepository "auth-project/src/usecase/repository"
+)
+
+func (r *registry) NewTrustedDeviceController() interfaceController.TrustedDeviceController {
+	return interfaceController.NewTrustedDeviceController(r.NewTrustedDeviceInteractor())
+}
+
+func (r *registry) NewTrustedDeviceInteractor() usecaseInteractor.TrustedDeviceInteractor {
+	return usecaseInteractor.NewTrustedDeviceInteractor(r.NewTrustedDeviceRepository(), r.NewTrustedDevicePresenter())
+}
+
+func (r *registry) NewTrustedDeviceRepository() usecaseRepository.TrustedDeviceRepository {
+	return interfaceRepository.NewTrustedDeviceRepository(r.db)
+}
+
+func (r *registry) NewTrustedDevicePresenter() usecasePresenter.TrustedDevicePresenter {
+	return interfacePresenter.NewTrustedDevicePresenter()
+}
@@ -15,12 +15,13 @@ func (r *registry) NewTwoFactorAuthController() interfaceController.TwoFactorAut
 
 func (r *registry) NewTwoFactorAuthInteractor() usecaseInteractor.TwoFactorAuthInteractor {
 	return usecaseInteractor.NewTwoFactorAuthInteractor(r.NewAuthRepository(), r.NewSessionRepository(),
-		r.NewTwoFactorAuthRepository(), r.NewUserRepository(), r.NewTokenRepository(), r.NewTwoFactorAuthPresenter(),
-		r.jwtConf)
+		r.NewTwoFactorAuthRepository(), r.NewUserRepository(), r.NewTokenRepository(), r.NewTrustedDeviceRepository(),
+		r.NewNotificationRepository(), r.NewUserConfigRepository(), r.NewTenantRepository(), r.NewTwoFactorAuthPresenter(),
+		r.jwtConf, r.totpConf)
 }
 
 func (r *registry) NewTwoFactorAuthRepository() usecaseRepository.TwoFactorAuthRepository {
-	return interfaceRepository.NewTwoFactorAuthRepository(r.db)
+	return interfaceRepository.NewTwoFactorAuthRepository(r.db, r.fieldCipher)
 }
 
 func (r *registry) NewTwoFactorAuthPresenter() usecasePresenter.TwoFactorAuthPresenter {
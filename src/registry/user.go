@@ -15,11 +15,11 @@ func (r *registry) NewUserController() interfaceController.UserController {
 
 func (r *registry) NewUserInteractor() usecaseInteractor.UserInteractor {
 	return usecaseInteractor.NewUserInteractor(r.NewAuthRepository(), r.NewUserRepository(), r.NewTokenRepository(),
-		r.NewUserPresenter(), r.jwtConf)
+		r.NewTrustedDeviceRepository(), r.NewNotificationRepository(), r.NewUserPresenter(), r.jwtConf)
 }
 
 func (r *registry) NewUserRepository() usecaseRepository.UserRepository {
-	return interfaceRepository.NewUserRepository(r.db, r.rdb)
+	return interfaceRepository.NewUserRepository(r.db, r.sessionStore, r.passwordHasher, r.dbErrors)
 }
 
 func (r *registry) NewUserPresenter() usecasePresenter.UserPresenter {
@@ -0,0 +1,109 @@
+package interactor
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/usecase/presenter"
+	"auth-project/src/usecase/repository"
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+	"log"
+	"time"
+)
+
+type accountInteractor struct {
+	AccountRepository       repository.AccountRepository
+	UserRepository          repository.UserRepository
+	SessionRepository       repository.SessionRepository
+	TrustedDeviceRepository repository.TrustedDeviceRepository
+	ContactChangeRepository repository.ContactChangeRepository
+	NotificationRepository  repository.NotificationRepository
+
+	AccountPresenter presenter.AccountPresenter
+}
+
+type AccountInteractor interface {
+	DeleteMyAccount(ctx context.Context, usrInfo *model.UserSessionData) error
+	ExportMyData(ctx context.Context, usrID string) (*model.UserDataExport, error)
+
+	PurgeDeletedAccounts(ctx context.Context) error
+}
+
+func NewAccountInteractor(ar repository.AccountRepository, ur repository.UserRepository,
+	sr repository.SessionRepository, tdr repository.TrustedDeviceRepository, ccr repository.ContactChangeRepository,
+	nr repository.NotificationRepository, p presenter.AccountPresenter) AccountInteractor {
+	return &accountInteractor{ar, ur, sr, tdr, ccr, nr, p}
+}
+
+// DeleteMyAccount deactivates the account and signs out all sessions,
+// the data is erased by PurgeDeletedAccounts after the grace period
+func (ai *accountInteractor) DeleteMyAccount(ctx context.Context, usrInfo *model.UserSessionData) error {
+
+	usr, err := ai.UserRepository.GetUserByID(ctx, usrInfo.UserID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	err = ai.AccountRepository.SoftDeleteUser(ctx, usr.ID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	err = ai.UserRepository.SignOutAll(ctx, usr.ID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	err = ai.TrustedDeviceRepository.DeleteTrustedDevicesByUserID(ctx, usr.ID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	err = ai.ContactChangeRepository.CancelContactChangesByUserID(ctx, usr.ID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	notifySecurityEvent(ctx, ai.NotificationRepository, usr, model.SecurityEventAccountDeleted, usrInfo)
+
+	return nil
+}
+
+// ExportMyData collects the profile, sessions, referrals and security events of the user
+func (ai *accountInteractor) ExportMyData(ctx context.Context, usrID string) (*model.UserDataExport, error) {
+
+	usr, err := ai.UserRepository.GetUserByID(ctx, usrID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	sessions, err := ai.SessionRepository.GetSessionsByUserID(ctx, usrID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	referrals, err := ai.AccountRepository.GetReferredUsers(ctx, usr.ReferralLink)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	events, err := ai.NotificationRepository.GetSecurityEventsByUserID(ctx, usrID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return ai.AccountPresenter.ExportUserDataResp(usr, sessions, referrals, events), nil
+}
+
+// PurgeDeletedAccounts erases the accounts whose grace period is over, it is run by the scheduler
+func (ai *accountInteractor) PurgeDeletedAccounts(ctx context.Context) error {
+
+	deletedBefore := time.Now().UTC().Add(-viper.GetDuration("account_deletion.grace_period"))
+
+	purged, err := ai.AccountRepository.PurgeDeletedUsers(ctx, deletedBefore)
+	if purged > 0 {
+		log.Printf("purged %d deleted accounts", purged)
+	}
+
+	return err
+}
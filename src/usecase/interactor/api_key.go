@@ -0,0 +1,148 @@
+package interactor
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/infrastructure/authentication"
+	"auth-project/src/usecase/presenter"
+	"auth-project/src/usecase/repository"
+	"context"
+	"github.com/gofiber/fiber/v2"
+	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/spf13/viper"
+	"strings"
+	"time"
+)
+
+const (
+	apiKeyNameMaxLength = 100
+	// apiKeyVisiblePrefixLength the part of the key kept to recognize it in the list
+	apiKeyVisiblePrefixLength = 8
+)
+
+type apiKeyInteractor struct {
+	ApiKeyRepository repository.ApiKeyRepository
+	UserRepository   repository.UserRepository
+
+	ApiKeyPresenter presenter.ApiKeyPresenter
+}
+
+type ApiKeyInteractor interface {
+	GetApiKeys(ctx context.Context, usrID string) ([]model.ApiKey, error)
+	CreateApiKey(ctx context.Context, createReq *model.ApiKeyCreateReq, usrID string) (*model.ApiKeyCreateResp, error)
+	RevokeApiKey(ctx context.Context, keyID, usrID string) error
+}
+
+func NewApiKeyInteractor(akr repository.ApiKeyRepository, ur repository.UserRepository, p presenter.ApiKeyPresenter) ApiKeyInteractor {
+	return &apiKeyInteractor{akr, ur, p}
+}
+
+func (ai *apiKeyInteractor) GetApiKeys(ctx context.Context, usrID string) ([]model.ApiKey, error) {
+
+	keys, err := ai.ApiKeyRepository.GetApiKeysByUserID(ctx, usrID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return keys, nil
+}
+
+// CreateApiKey creates the personal access token, only the hash is stored, so the key is returned once
+func (ai *apiKeyInteractor) CreateApiKey(ctx context.Context, createReq *model.ApiKeyCreateReq,
+	usrID string) (*model.ApiKeyCreateResp, error) {
+
+	name, err := verifyMachineClientName(createReq.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	usr, err := ai.UserRepository.GetUserByID(ctx, usrID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	scopes, err := verifyScopes(createReq.Scopes, usr)
+	if err != nil {
+		return nil, err
+	}
+
+	lifetime := viper.GetDuration("api_key.default_lifetime")
+	if createReq.ExpiresInDays < 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "expires_in_days is invalid")
+	}
+	if createReq.ExpiresInDays > 0 {
+		lifetime = time.Duration(createReq.ExpiresInDays) * 24 * time.Hour
+	}
+	if lifetime > viper.GetDuration("api_key.max_lifetime") {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "expires_in_days exceeds the max lifetime")
+	}
+
+	id, err := gonanoid.New()
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	secret := authentication.GenerateApiKey()
+
+	key := &model.ApiKey{
+		ID:        id,
+		TenantID:  usr.TenantID,
+		UserID:    usr.ID,
+		Name:      name,
+		Prefix:    secret[:apiKeyVisiblePrefixLength],
+		KeyHash:   authentication.HashSecret(secret),
+		Scopes:    scopes,
+		ExpiresAT: time.Now().UTC().Add(lifetime),
+	}
+
+	err = ai.ApiKeyRepository.CreateApiKey(ctx, key)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return ai.ApiKeyPresenter.CreateApiKeyResp(key, secret), nil
+}
+
+func (ai *apiKeyInteractor) RevokeApiKey(ctx context.Context, keyID, usrID string) error {
+
+	err := ai.ApiKeyRepository.RevokeApiKey(ctx, keyID, usrID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	return nil
+}
+
+// verifyMachineClientName checks the name of the api key or the client given by the user
+func verifyMachineClientName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fiber.NewError(fiber.StatusBadRequest, "name is required")
+	}
+	if len(name) > apiKeyNameMaxLength {
+		return "", fiber.NewError(fiber.StatusBadRequest, "name is too long")
+	}
+	return name, nil
+}
+
+// verifyScopes checks the scopes requested for the api key or the client, the read scope is given by default,
+// the admin and introspect scopes could be given only by the admin
+func verifyScopes(scopes []string, usr *model.User) ([]string, error) {
+	if len(scopes) == 0 {
+		return []string{model.ScopeRead}, nil
+	}
+
+	verified := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if !model.HasScope(model.Scopes, scope) {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "unknown scope "+scope)
+		}
+		if (scope == model.ScopeAdmin || scope == model.ScopeIntrospect) && usr.Role != model.AdminRole {
+			return nil, fiber.NewError(fiber.StatusForbidden, scope+" scope is not allowed")
+		}
+		if !model.HasScope(verified, scope) {
+			verified = append(verified, scope)
+		}
+	}
+
+	return verified, nil
+}
@@ -5,38 +5,67 @@ import (
 	"auth-project/src/infrastructure/authentication"
 	"auth-project/src/usecase/presenter"
 	"auth-project/src/usecase/repository"
+	"auth-project/tools"
 	"context"
+	"database/sql"
+	"errors"
 	"github.com/gofiber/fiber/v2"
 	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/spf13/viper"
+	"log"
+	"net/url"
+	"strings"
 	"time"
 )
 
 type authInteractor struct {
-	AuthRepository    repository.AuthRepository
-	SessionRepository repository.SessionRepository
-	UserRepository    repository.UserRepository
-	TokenRepository   repository.TokenRepository
+	AuthRepository          repository.AuthRepository
+	SessionRepository       repository.SessionRepository
+	UserRepository          repository.UserRepository
+	TokenRepository         repository.TokenRepository
+	TwoFactorAuthRepository repository.TwoFactorAuthRepository
+	TrustedDeviceRepository repository.TrustedDeviceRepository
+	NotificationRepository  repository.NotificationRepository
+	ContactChangeRepository repository.ContactChangeRepository
+	UserConfigRepository    repository.UserConfigRepository
+	TenantRepository        repository.TenantRepository
+	ApiKeyRepository        repository.ApiKeyRepository
+	OAuthClientRepository   repository.OAuthClientRepository
 
 	AuthPresenter presenter.AuthPresenter
 
 	jwtConfigurator *authentication.JwtConfigurator
+	totpConfig      *authentication.TotpConfig
 }
 
 type AuthInteractor interface {
-	Authenticate(ctx context.Context, authReq *model.AuthenticationReq, usrInfo *model.UserSessionData) (map[string]interface{}, error)
-	RefreshToken(ctx context.Context, usrInfo *model.UserSessionData, bearerToken string) (map[string]interface{}, error)
-
-	ValidateAccessToken(ctx context.Context, bearerToken string) (*model.AccessClaims, error)
+	Authenticate(ctx context.Context, authReq *model.AuthenticationReq, usrInfo *model.UserSessionData) (*model.AuthResp, error)
+	SendMagicLink(ctx context.Context, magicLinkSendReq *model.MagicLinkSendReq) (*model.MessageResp, error)
+	AuthenticateByMagicLink(ctx context.Context, magicLinkAuthReq *model.MagicLinkAuthReq, usrInfo *model.UserSessionData) (*model.AuthResp, error)
+	SendLoginCode(ctx context.Context, otpSendReq *model.OtpSendReq) (*model.MessageResp, error)
+	AuthenticateByLoginCode(ctx context.Context, otpAuthReq *model.OtpAuthReq, usrInfo *model.UserSessionData) (*model.AuthResp, error)
+	RefreshToken(ctx context.Context, usrInfo *model.UserSessionData, bearerToken string) (*model.AuthResp, error)
+	Reauthenticate(ctx context.Context, reauthenticateReq *model.ReauthenticateReq, usrInfo *model.UserSessionData, sessionID string) (*model.AuthResp, error)
+	RevokeSessions(ctx context.Context, revokeSessionsReq *model.RevokeSessionsReq) error
+	ReconcileSessions(ctx context.Context) error
+
+	ValidateAccessToken(ctx context.Context, bearerToken, clientIP string) (*model.AccessClaims, error)
 	ValidateTwoFactorAuthToken(ctx context.Context, bearerToken string) (*model.AccessClaims, error)
+	ValidateTwoFactorAuthSetupToken(ctx context.Context, bearerToken string) (*model.AccessClaims, error)
+
+	IntrospectToken(ctx context.Context, checkReq *model.OAuthTokenCheckReq) (*model.OAuthIntrospectionResp, error)
+	RevokeToken(ctx context.Context, checkReq *model.OAuthTokenCheckReq) error
+
+	GetJwks(ctx context.Context) (*model.JWKS, error)
 }
 
 func NewAuthInteractor(
-	ar repository.AuthRepository, sr repository.SessionRepository, ur repository.UserRepository, tr repository.TokenRepository, p presenter.AuthPresenter, jc *authentication.JwtConfigurator) AuthInteractor {
-	return &authInteractor{ar, sr, ur, tr, p, jc}
+	ar repository.AuthRepository, sr repository.SessionRepository, ur repository.UserRepository, tr repository.TokenRepository, tfr repository.TwoFactorAuthRepository, tdr repository.TrustedDeviceRepository, nr repository.NotificationRepository, ccr repository.ContactChangeRepository, ucr repository.UserConfigRepository, tnr repository.TenantRepository, akr repository.ApiKeyRepository, ocr repository.OAuthClientRepository, p presenter.AuthPresenter, jc *authentication.JwtConfigurator, tc *authentication.TotpConfig) AuthInteractor {
+	return &authInteractor{ar, sr, ur, tr, tfr, tdr, nr, ccr, ucr, tnr, akr, ocr, p, jc, tc}
 }
 
 func (ai *authInteractor) Authenticate(ctx context.Context, authReq *model.AuthenticationReq,
-	usrInfo *model.UserSessionData) (map[string]interface{}, error) {
+	usrInfo *model.UserSessionData) (*model.AuthResp, error) {
 
 	usr, err := ai.UserRepository.GetUserByLoginAndPassword(ctx,
 		authReq.Email, authReq.Password)
@@ -45,15 +74,190 @@ func (ai *authInteractor) Authenticate(ctx context.Context, authReq *model.Authe
 	}
 	usrInfo.UserID = usr.ID
 
+	return ai.startSession(ctx, usr, usrInfo, []string{model.AuthMethodPassword})
+}
+
+func (ai *authInteractor) SendMagicLink(ctx context.Context, magicLinkSendReq *model.MagicLinkSendReq) (*model.MessageResp, error) {
+
+	usr, err := ai.UserRepository.GetUserByEmailOrPhone(ctx, magicLinkSendReq.Email)
+	if err != nil {
+		// do not disclose whether the email is registered
+		if err == sql.ErrNoRows {
+			return &model.MessageResp{Message: "OK"}, nil
+		}
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if usr.Email == "" || usr.Email != strings.ToLower(magicLinkSendReq.Email) {
+		return &model.MessageResp{Message: "OK"}, nil
+	}
+
+	token, err := ai.TokenRepository.CreateMagicLinkToken(ctx, usr.ID, usr.Email)
+	if err != nil {
+		if err.Error() == model.TokenTimeSendErr {
+			return nil, fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+		}
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	linkToken, err := ai.jwtConfigurator.GenerateMagicLinkToken(token.ID, token.Value, usr.ID, token.ExpiresAT)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	tenant, err := ai.TenantRepository.GetTenantByID(ctx, model.TenantID(ctx))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	link := tenant.FrontHost + viper.GetString("magic_link.path") + "?token=" + url.QueryEscape(linkToken)
+
+	err = ai.TokenRepository.SendMagicLink(ctx, usr.Email, link)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return &model.MessageResp{Message: "OK"}, nil
+}
+
+func (ai *authInteractor) AuthenticateByMagicLink(ctx context.Context, magicLinkAuthReq *model.MagicLinkAuthReq,
+	usrInfo *model.UserSessionData) (*model.AuthResp, error) {
+
+	claims, err := ai.jwtConfigurator.GetMagicLinkTokenClaims(magicLinkAuthReq.Token)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	usr, err := ai.UserRepository.GetUserByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	if !usr.IsActive {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	// the link of one tenant is not accepted by the others
+	if usr.TenantID != model.TenantID(ctx) {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	err = ai.TokenRepository.UseMagicLinkToken(ctx, claims.TkID, claims.Value, claims.UserID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+	usrInfo.UserID = usr.ID
+
+	return ai.startSession(ctx, usr, usrInfo, []string{model.AuthMethodEmail})
+}
+
+func (ai *authInteractor) SendLoginCode(ctx context.Context, otpSendReq *model.OtpSendReq) (*model.MessageResp, error) {
+
+	login, err := normalizeLogin(otpSendReq.Login, otpSendReq.LoginType)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	usr, err := ai.UserRepository.GetUserByEmailOrPhone(ctx, login)
+	if err != nil {
+		// do not disclose whether the login is registered
+		if err == sql.ErrNoRows {
+			return &model.MessageResp{Message: "OK"}, nil
+		}
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if (otpSendReq.LoginType == model.TokenTypePhone && usr.Phone != login) ||
+		(otpSendReq.LoginType == model.TokenTypeEmail && usr.Email != login) {
+		return &model.MessageResp{Message: "OK"}, nil
+	}
+
+	_, err = ai.TokenRepository.Send2faCode(ctx, &model.Send2faCodeData{
+		UserID:      usr.ID,
+		Target:      login,
+		Code2faType: otpSendReq.LoginType,
+		Reason:      model.TokenReasonLogin,
+	})
+	if err != nil {
+		if err.Error() == model.TokenTimeSendErr {
+			return nil, fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+		}
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return &model.MessageResp{Message: "OK"}, nil
+}
+
+func (ai *authInteractor) AuthenticateByLoginCode(ctx context.Context, otpAuthReq *model.OtpAuthReq,
+	usrInfo *model.UserSessionData) (*model.AuthResp, error) {
+
+	login, err := normalizeLogin(otpAuthReq.Login, otpAuthReq.LoginType)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	verifyCodeData := &model.VerifyCodeData{
+		Target:   login,
+		Code:     otpAuthReq.Code2fa,
+		CodeType: otpAuthReq.LoginType,
+		Reason:   model.TokenReasonLogin,
+	}
+
+	token, err := ai.TokenRepository.Validate2faCode(ctx, verifyCodeData)
+	if errors.Is(err, model.ErrTokenAttempts) {
+		return nil, fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+	}
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	usr, err := ai.UserRepository.GetUserByID(ctx, token.UserID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	if !usr.IsActive {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	usrInfo.UserID = usr.ID
+
+	verifyCodeData.UserID = usr.ID
+	err = ai.TokenRepository.TokenSetUsed(ctx, verifyCodeData)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	amr := model.AuthMethodEmail
+	if otpAuthReq.LoginType == model.TokenTypePhone {
+		amr = model.AuthMethodSms
+	}
+
+	return ai.startSession(ctx, usr, usrInfo, []string{amr})
+}
+
+// startSession is called once the user has proven the first factor by the amr methods: if two-factor auth
+// is enabled, it gives a token for two-factor auth, otherwise it creates the session and gives the token pair
+func (ai *authInteractor) startSession(ctx context.Context, usr *model.User,
+	usrInfo *model.UserSessionData, amr []string) (*model.AuthResp, error) {
+
 	sessionID, err := gonanoid.New()
 	if err != nil {
 		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
+	twoFactorAuthRequired := usr.HasTwoFactorAuth()
+
+	// the device trusted by the user skips two-factor auth
+	if twoFactorAuthRequired &&
+		isTrustedDevice(ctx, ai.TrustedDeviceRepository, ai.jwtConfigurator, usr.ID, usrInfo.TrustedDeviceToken) {
+		twoFactorAuthRequired = false
+		amr = append(amr, model.AuthMethodTrustedDevice, model.AuthMethodMultiFactor)
+	}
+
 	// if two-factor auth token is enabled, then we give a token for two-factor auth
-	if usr.IsEmailVerified || usr.IsPhoneVerified || usr.IsGoogleVerified {
+	if twoFactorAuthRequired {
 
-		details, err := ai.jwtConfigurator.GenerateTwoFactorAuthToken(usr.ID, sessionID)
+		details, err := ai.jwtConfigurator.GenerateTwoFactorAuthToken(usr.ID, sessionID, amr)
 		if err != nil {
 			return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
@@ -63,57 +267,58 @@ func (ai *authInteractor) Authenticate(ctx context.Context, authReq *model.Authe
 			return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
 
-		switch {
-		case usr.IsGoogleVerified:
-			return map[string]interface{}{
-				"2fa_auth_token": details.AccessToken,
-				"2fa_type":       model.TokenTypeGoogle,
-			}, nil
-
-		case usr.IsPhoneVerified:
-			_, err := ai.TokenRepository.Send2faCode(ctx, &model.Send2faCodeData{
-				UserID:      usr.ID,
-				Target:      usr.Phone,
-				Code2faType: model.TokenTypePhone,
-				Reason:      model.TokenReasonTwoFactorAuth,
-			})
-			if err != nil {
-				if err.Error() != model.TokenTimeSendErr {
-					return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
-				}
-			}
+		resp := &model.AuthResp{
+			TwoFactorAuthToken:   details.AccessToken,
+			TwoFactorAuthType:    usr.DefaultTwoFactorAuthType(),
+			TwoFactorAuthMethods: usr.TwoFactorAuthMethods(),
+		}
 
-			return map[string]interface{}{
-				"2fa_auth_token": details.AccessToken,
-				"2fa_type":       model.TokenTypePhone,
-				"2fa_target":     usr.Phone,
-			}, nil
-
-		case usr.IsEmailVerified:
-			_, err := ai.TokenRepository.Send2faCode(ctx, &model.Send2faCodeData{
-				UserID:      usr.ID,
-				Target:      usr.Email,
-				Code2faType: model.TokenTypeEmail,
-				Reason:      model.TokenReasonTwoFactorAuth,
-			})
-			if err != nil {
-				if err.Error() != model.TokenTimeSendErr {
-					return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
-				}
+		// the code is sent only for the default method, others can be requested by re-send
+		target, err := sendTwoFactorAuthCode(ctx, ai.TokenRepository, usr, usr.DefaultTwoFactorAuthType(),
+			model.TokenReasonTwoFactorAuth)
+		if err != nil {
+			if err.Error() != model.TokenTimeSendErr {
+				return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 			}
+		}
+
+		resp.TwoFactorAuthTarget = target
+
+		return resp, nil
+	}
+
+	tenant, err := ai.TenantRepository.GetTenantByID(ctx, model.TenantID(ctx))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
 
-			return map[string]interface{}{
-				"2fa_auth_token": details.AccessToken,
-				"2fa_type":       model.TokenTypeEmail,
-				"2fa_target":     usr.Email,
-			}, nil
+	// the tenant's policy requires 2fa, so the session is not created until it is set up,
+	// the token allows only the set-up, after it the user signs in again
+	if tenant.TwoFactorAuthRequired && !usr.HasTwoFactorAuth() {
 
-		default:
-			return nil, fiber.NewError(fiber.StatusInternalServerError, "Internal Server Error")
+		details, err := ai.jwtConfigurator.GenerateTwoFactorAuthSetupToken(usr.ID, sessionID,
+			model.NewAuthDetails(amr...))
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+
+		err = ai.AuthRepository.StoreAccessToken(ctx, details, sessionID)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
+
+		return &model.AuthResp{
+			TwoFactorAuthSetupRequired: true,
+			TwoFactorAuthSetupToken:    details.AccessToken,
+		}, nil
+	}
+
+	userClaims, err := getUserClaims(ctx, ai.UserConfigRepository, ai.TenantRepository, usr)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	details, err := ai.jwtConfigurator.GenerateTokenPair(usr.ID, sessionID)
+	details, err := ai.jwtConfigurator.GenerateTokenPair(usr.ID, sessionID, model.NewAuthDetails(amr...), userClaims)
 	if err != nil {
 		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
@@ -123,12 +328,18 @@ func (ai *authInteractor) Authenticate(ctx context.Context, authReq *model.Authe
 		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
+	err = notifyNewLogin(ctx, ai.SessionRepository, ai.NotificationRepository, usr, usrInfo)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
 	ses := &model.Session{
 		SessionID: sessionID,
 		UserAgent: usrInfo.UserAgent,
 		ClientIP:  usrInfo.ClientIp,
 		ExpiresAT: time.Unix(details.RtExpires, 0).UTC(),
 		UserID:    usrInfo.UserID,
+		TenantID:  usr.TenantID,
 	}
 
 	err = ai.SessionRepository.InsertSession(ctx, ses)
@@ -136,14 +347,14 @@ func (ai *authInteractor) Authenticate(ctx context.Context, authReq *model.Authe
 		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	return map[string]interface{}{
-		"access_token":  details.AccessToken,
-		"refresh_token": details.RefreshToken,
+	return &model.AuthResp{
+		AccessToken:  details.AccessToken,
+		RefreshToken: details.RefreshToken,
 	}, nil
 }
 
 func (ai *authInteractor) RefreshToken(ctx context.Context,
-	usrInfo *model.UserSessionData, bearerToken string) (map[string]interface{}, error) {
+	usrInfo *model.UserSessionData, bearerToken string) (*model.AuthResp, error) {
 
 	claims, err := ai.jwtConfigurator.GetRefreshTokenClaims(bearerToken)
 	if err != nil {
@@ -152,21 +363,50 @@ func (ai *authInteractor) RefreshToken(ctx context.Context,
 
 	usrInfo.UserID = claims.UserID
 
-	rtID, err := ai.AuthRepository.FetchAuth(ctx, claims.SessionID)
+	// the role and the kyc level could be changed since the sign-in, they are read before the refresh token
+	// is taken, so the client could retry the refresh after the failure of the lookup
+	usr, err := ai.UserRepository.GetUserByID(ctx, claims.UserID)
+	if errors.Is(err, model.ErrUserNotFound) {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
 	if err != nil {
-		if err.Error() == "at token dont found" {
-			return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
-		}
 		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
+	if usr.TenantID != model.TenantID(ctx) {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	userClaims, err := getUserClaims(ctx, ai.UserConfigRepository, ai.TenantRepository, usr)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	rtID, err := ai.AuthRepository.FetchAuth(ctx, claims.UserID, claims.SessionID)
+	if errors.Is(err, model.ErrSessionNotFound) {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// the refresh token replaced by the previous refresh is reused, it could be stolen,
+	// so the session taken by FetchAuth is not given back and is marked logged out
 	if rtID != claims.RtID {
-		return nil, fiber.NewError(fiber.StatusInternalServerError, "invalid token")
+		err = ai.AuthRepository.LogoutSession(ctx, claims.UserID, claims.SessionID)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
 	}
 
 	// All OK, re-generate the new pair and send to client,
 	// we could only generate an access token as well.
-	details, err := ai.jwtConfigurator.GenerateTokenPair(claims.UserID, claims.SessionID)
+	// The refresh does not prove the credentials, so the time of the authentication is kept.
+	details, err := ai.jwtConfigurator.GenerateTokenPair(claims.UserID, claims.SessionID, &model.AuthDetails{
+		AuthTime: claims.AuthTime,
+		Amr:      claims.Amr,
+	}, userClaims)
 	if err != nil {
 		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
@@ -181,6 +421,109 @@ func (ai *authInteractor) RefreshToken(ctx context.Context,
 		UserAgent: usrInfo.UserAgent,
 		ClientIP:  usrInfo.ClientIp,
 		ExpiresAT: time.Unix(details.RtExpires, 0).UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	err = ai.SessionRepository.UpdateSession(ctx, ses)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return &model.AuthResp{
+		AccessToken:  details.AccessToken,
+		RefreshToken: details.RefreshToken,
+	}, nil
+}
+
+// Reauthenticate checks the credentials of the signed-in user again and gives the new token pair
+// of the same session with the fresh auth_time, which is required by sensitive operations
+func (ai *authInteractor) Reauthenticate(ctx context.Context, reauthenticateReq *model.ReauthenticateReq,
+	usrInfo *model.UserSessionData, sessionID string) (*model.AuthResp, error) {
+
+	usr, err := ai.UserRepository.GetUserByID(ctx, usrInfo.UserID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	var amr []string
+
+	// the password is required only if the second factor is not available
+	if reauthenticateReq.Password != "" || !usr.HasTwoFactorAuth() {
+		_, err = ai.UserRepository.IsExitsUserByIDAndPassword(ctx, usr.ID, reauthenticateReq.Password)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusUnauthorized, "invalid password")
+		}
+		amr = append(amr, model.AuthMethodPassword)
+	}
+
+	if usr.HasTwoFactorAuth() {
+		code2faType := reauthenticateReq.Code2faType
+		if code2faType == "" {
+			code2faType = usr.DefaultTwoFactorAuthType()
+		}
+
+		if reauthenticateReq.Code2fa == "" {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "this action needs 2fa")
+		}
+
+		method, err := verifyTwoFactorAuthCode(ctx, ai.totpConfig, ai.TwoFactorAuthRepository, ai.TokenRepository, usr,
+			code2faType, reauthenticateReq.Code2fa, model.TokenReasonVerification)
+		if err != nil {
+			return nil, err
+		}
+		amr = append(amr, method)
+	}
+
+	if len(amr) > 1 {
+		amr = append(amr, model.AuthMethodMultiFactor)
+	}
+
+	tenant, err := ai.TenantRepository.GetTenantByID(ctx, model.TenantID(ctx))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// the tenant's policy requires 2fa, so the session is not created until it is set up,
+	// the token allows only the set-up, after it the user signs in again
+	if tenant.TwoFactorAuthRequired && !usr.HasTwoFactorAuth() {
+
+		details, err := ai.jwtConfigurator.GenerateTwoFactorAuthSetupToken(usr.ID, sessionID,
+			model.NewAuthDetails(amr...))
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+
+		err = ai.AuthRepository.StoreAccessToken(ctx, details, sessionID)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+
+		return &model.AuthResp{
+			TwoFactorAuthSetupRequired: true,
+			TwoFactorAuthSetupToken:    details.AccessToken,
+		}, nil
+	}
+
+	userClaims, err := getUserClaims(ctx, ai.UserConfigRepository, ai.TenantRepository, usr)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	details, err := ai.jwtConfigurator.GenerateTokenPair(usr.ID, sessionID, model.NewAuthDetails(amr...), userClaims)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	err = ai.AuthRepository.StoreTokenPair(ctx, details, sessionID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	ses := &model.Session{
+		SessionID: sessionID,
+		UserAgent: usrInfo.UserAgent,
+		ClientIP:  usrInfo.ClientIp,
+		ExpiresAT: time.Unix(details.RtExpires, 0).UTC(),
 	}
 
 	err = ai.SessionRepository.UpdateSession(ctx, ses)
@@ -188,13 +531,70 @@ func (ai *authInteractor) RefreshToken(ctx context.Context,
 		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	return map[string]interface{}{
-		"access_token":  details.AccessToken,
-		"refresh_token": details.RefreshToken,
+	return &model.AuthResp{
+		AccessToken:  details.AccessToken,
+		RefreshToken: details.RefreshToken,
 	}, nil
 }
 
-func (ai *authInteractor) ValidateAccessToken(ctx context.Context, bearerToken string) (*model.AccessClaims, error) {
+// RevokeSessions follows the "this wasn't me" link from the security notification,
+// it signs out all sessions of the user, revokes the trust of the devices and cancels the pending contact changes
+func (ai *authInteractor) RevokeSessions(ctx context.Context, revokeSessionsReq *model.RevokeSessionsReq) error {
+
+	token, err := ai.TokenRepository.UseRevokeSessionsToken(ctx, revokeSessionsReq.Token)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	err = ai.UserRepository.SignOutAll(ctx, token.UserID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	err = ai.TrustedDeviceRepository.DeleteTrustedDevicesByUserID(ctx, token.UserID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	err = ai.ContactChangeRepository.CancelContactChangesByUserID(ctx, token.UserID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return nil
+}
+
+// ReconcileSessions repairs the session indexes of the session store against the sessions table,
+// it is run by the scheduler
+func (ai *authInteractor) ReconcileSessions(ctx context.Context) error {
+
+	lost, err := ai.AuthRepository.ReconcileSessions(ctx)
+	if lost > 0 {
+		log.Printf("signed out %d sessions lost by the session store", lost)
+	}
+
+	return err
+}
+
+// ValidateAccessToken accepts the access token of the session, the api key or the token of the machine client
+func (ai *authInteractor) ValidateAccessToken(ctx context.Context, bearerToken, clientIP string) (*model.AccessClaims, error) {
+
+	if strings.HasPrefix(bearerToken, model.ApiKeyPrefix) {
+		key, err := ai.ApiKeyRepository.UseApiKey(ctx, authentication.HashSecret(bearerToken), clientIP)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+			}
+			return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		return ai.getApiKeyClaims(ctx, key)
+	}
+
+	return ai.validateJwtAccessToken(ctx, bearerToken)
+}
+
+// validateJwtAccessToken checks the access token of the session or of the machine client
+func (ai *authInteractor) validateJwtAccessToken(ctx context.Context, bearerToken string) (*model.AccessClaims, error) {
 
 	claims, err := ai.jwtConfigurator.GetAccessTokenClaims(bearerToken)
 	if err != nil {
@@ -205,28 +605,128 @@ func (ai *authInteractor) ValidateAccessToken(ctx context.Context, bearerToken s
 		return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
 	}
 
+	if claims.Type == model.AccessTokenTypeClient {
+		return ai.validateClientToken(ctx, claims)
+	}
+
 	if claims.Type != model.AccessTokenTypeAuth {
 		return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
 	}
 
-	user, err := ai.UserRepository.GetUserByID(ctx, claims.UserID)
+	user, err := ai.getTokenUser(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ai.AuthRepository.ValidateAccessToken(ctx, claims.AtID, claims.SessionID)
 	if err != nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	// the role of the token could be outdated, so the current one is used for the access checks
+	claims.Role = user.Role
+
+	return claims, nil
+}
+
+// getApiKeyClaims returns the claims of the personal access token, the key is used instead of the token
+// of the session and gets the claims of its owner limited by the scopes of the key
+func (ai *authInteractor) getApiKeyClaims(ctx context.Context, key *model.ApiKey) (*model.AccessClaims, error) {
+
+	user, err := ai.getTokenUser(ctx, key.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.AccessClaims{
+		Authorized: true,
+		AtID:       key.ID,
+		SessionID:  key.ID,
+		UserID:     user.ID,
+		Exp:        key.ExpiresAT.Unix(),
+		Type:       model.AccessTokenTypeApiKey,
+		Role:       user.Role,
+		TenantID:   user.TenantID,
+		Scope:      strings.Join(key.Scopes, " "),
+	}, nil
+}
+
+// validateClientToken checks the token issued by the client credentials grant,
+// the token has no session, so it is valid while the client is not revoked
+func (ai *authInteractor) validateClientToken(ctx context.Context, claims *model.AccessClaims) (*model.AccessClaims, error) {
+
+	client, err := ai.OAuthClientRepository.GetOAuthClientByID(ctx, claims.ClientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+		}
 		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	if !user.IsActive {
+	if client.UserID != claims.UserID {
 		return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
 	}
 
-	err = ai.AuthRepository.ValidateAccessToken(ctx, claims.AtID, claims.SessionID)
+	revoked, err := ai.AuthRepository.IsAccessTokenRevoked(ctx, claims.AtID)
 	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if revoked {
 		return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
 	}
 
+	user, err := ai.getTokenUser(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	claims.Role = user.Role
+
 	return claims, nil
 }
 
+// getTokenUser returns the owner of the token if the token could be accepted for him
+func (ai *authInteractor) getTokenUser(ctx context.Context, usrID string) (*model.User, error) {
+
+	user, err := ai.UserRepository.GetUserByID(ctx, usrID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if !user.IsActive {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	// the token of one tenant is not accepted by the others
+	if user.TenantID != model.TenantID(ctx) {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	config, err := ai.UserConfigRepository.GetUserConfig(ctx, user.ID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if config.AccFlagged {
+		return nil, fiber.NewError(fiber.StatusForbidden, "account flagged")
+	}
+
+	return user, nil
+}
+
 func (ai *authInteractor) ValidateTwoFactorAuthToken(ctx context.Context, bearerToken string) (*model.AccessClaims, error) {
+	return ai.validateUnauthorizedToken(ctx, bearerToken, model.AccessTokenTypeTwoFactorAuth)
+}
+
+// ValidateTwoFactorAuthSetupToken accepts the token given instead of the session by the tenant's 2fa policy
+func (ai *authInteractor) ValidateTwoFactorAuthSetupToken(ctx context.Context,
+	bearerToken string) (*model.AccessClaims, error) {
+	return ai.validateUnauthorizedToken(ctx, bearerToken, model.AccessTokenTypeTwoFactorAuthSetup)
+}
+
+// validateUnauthorizedToken checks the token given before the session is created, e.g. the 2fa token
+func (ai *authInteractor) validateUnauthorizedToken(ctx context.Context, bearerToken,
+	tokenType string) (*model.AccessClaims, error) {
 
 	claims, err := ai.jwtConfigurator.GetAccessTokenClaims(bearerToken)
 	if err != nil {
@@ -237,7 +737,7 @@ func (ai *authInteractor) ValidateTwoFactorAuthToken(ctx context.Context, bearer
 		return nil, fiber.NewError(fiber.StatusBadRequest, "you are already authenticated")
 	}
 
-	if claims.Type != model.AccessTokenTypeTwoFactorAuth {
+	if claims.Type != tokenType {
 		return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
 	}
 
@@ -250,6 +750,11 @@ func (ai *authInteractor) ValidateTwoFactorAuthToken(ctx context.Context, bearer
 		return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
 	}
 
+	// the token of one tenant is not accepted by the others
+	if user.TenantID != model.TenantID(ctx) {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
 	err = ai.AuthRepository.ValidateAccessToken(ctx, claims.AtID, claims.SessionID)
 	if err != nil {
 		return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
@@ -257,3 +762,160 @@ func (ai *authInteractor) ValidateTwoFactorAuthToken(ctx context.Context, bearer
 
 	return claims, nil
 }
+
+// IntrospectToken tells the resource server whether the token is active (RFC 7662), the client needs
+// the introspect scope, the revoked, expired and unknown tokens are reported as inactive
+func (ai *authInteractor) IntrospectToken(ctx context.Context,
+	checkReq *model.OAuthTokenCheckReq) (*model.OAuthIntrospectionResp, error) {
+
+	client, err := authenticateOAuthClient(ctx, ai.OAuthClientRepository, checkReq.ClientID, checkReq.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if !model.HasScope(client.Scopes, model.ScopeIntrospect) {
+		return nil, fiber.NewError(fiber.StatusForbidden, "insufficient_scope")
+	}
+
+	if checkReq.Token == "" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid_request")
+	}
+
+	claims, isRefresh, err := ai.inspectToken(ctx, checkReq.Token, checkReq.TokenTypeHint)
+	if err != nil {
+		if e, ok := err.(*fiber.Error); ok && e.Code == fiber.StatusInternalServerError {
+			return nil, e
+		}
+		return ai.AuthPresenter.IntrospectionResp(nil, false), nil
+	}
+
+	return ai.AuthPresenter.IntrospectionResp(claims, isRefresh), nil
+}
+
+// RevokeToken revokes the token (RFC 7009), the client with the introspect scope could revoke any token
+// of the tenant, the others only the tokens issued to them, the invalid token is not an error
+func (ai *authInteractor) RevokeToken(ctx context.Context, checkReq *model.OAuthTokenCheckReq) error {
+
+	client, err := authenticateOAuthClient(ctx, ai.OAuthClientRepository, checkReq.ClientID, checkReq.ClientSecret)
+	if err != nil {
+		return err
+	}
+
+	if checkReq.Token == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid_request")
+	}
+
+	claims, isRefresh, err := ai.inspectToken(ctx, checkReq.Token, checkReq.TokenTypeHint)
+	if err != nil {
+		if e, ok := err.(*fiber.Error); ok && e.Code == fiber.StatusInternalServerError {
+			return e
+		}
+		return nil
+	}
+
+	if !model.HasScope(client.Scopes, model.ScopeIntrospect) && claims.ClientID != client.ID {
+		return fiber.NewError(fiber.StatusForbidden, "unauthorized_client")
+	}
+
+	switch {
+	case isRefresh || claims.Type == model.AccessTokenTypeAuth:
+		// the refresh token and the access token of the session are revoked together with the session
+		err = ai.UserRepository.SignOut(ctx, claims.UserID, claims.SessionID)
+	case claims.Type == model.AccessTokenTypeApiKey:
+		err = ai.ApiKeyRepository.RevokeApiKey(ctx, claims.AtID, claims.UserID)
+	default:
+		err = ai.AuthRepository.RevokeAccessToken(ctx, claims.AtID, claims.Exp)
+	}
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return nil
+}
+
+// inspectToken returns the claims of the active access token, api key or refresh token without marking
+// the use, the refresh token is checked first if it is hinted
+func (ai *authInteractor) inspectToken(ctx context.Context, token, tokenTypeHint string) (*model.AccessClaims, bool, error) {
+
+	if tokenTypeHint == model.TokenTypeHintRefreshToken {
+		claims, err := ai.inspectRefreshToken(ctx, token)
+		if err == nil {
+			return claims, true, nil
+		}
+	}
+
+	var claims *model.AccessClaims
+	var err error
+	if strings.HasPrefix(token, model.ApiKeyPrefix) {
+		var key *model.ApiKey
+		key, err = ai.ApiKeyRepository.GetApiKeyByHash(ctx, authentication.HashSecret(token))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, false, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+			}
+			return nil, false, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+		claims, err = ai.getApiKeyClaims(ctx, key)
+	} else {
+		claims, err = ai.validateJwtAccessToken(ctx, token)
+	}
+	if err == nil {
+		return claims, false, nil
+	}
+
+	if tokenTypeHint != model.TokenTypeHintRefreshToken {
+		refreshClaims, refreshErr := ai.inspectRefreshToken(ctx, token)
+		if refreshErr == nil {
+			return refreshClaims, true, nil
+		}
+	}
+
+	return nil, false, err
+}
+
+// inspectRefreshToken returns the claims of the active refresh token in the form of the access claims
+func (ai *authInteractor) inspectRefreshToken(ctx context.Context, token string) (*model.AccessClaims, error) {
+
+	claims, err := ai.jwtConfigurator.GetRefreshTokenClaims(token)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	user, err := ai.getTokenUser(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ai.AuthRepository.ValidateAccessToken(ctx, claims.RtID, claims.SessionID+model.PostfixRefreshToken)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "unauthorized")
+	}
+
+	return &model.AccessClaims{
+		Authorized: true,
+		AtID:       claims.RtID,
+		SessionID:  claims.SessionID,
+		UserID:     user.ID,
+		Exp:        claims.Exp,
+		Type:       model.AccessTokenTypeAuth,
+		Role:       user.Role,
+		TenantID:   user.TenantID,
+	}, nil
+}
+
+// GetJwks returns the public keys which the other services use to verify the access tokens
+func (ai *authInteractor) GetJwks(ctx context.Context) (*model.JWKS, error) {
+	return ai.jwtConfigurator.JWKS(), nil
+}
+
+// normalizeLogin brings the phone or email to the form in which it is stored
+func normalizeLogin(login, loginType string) (string, error) {
+	switch loginType {
+	case model.TokenTypePhone:
+		return tools.VerifyPhone(login)
+	case model.TokenTypeEmail:
+		return strings.ToLower(strings.TrimSpace(login)), nil
+	default:
+		return "", errors.New("invalid login type")
+	}
+}
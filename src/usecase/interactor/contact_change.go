@@ -0,0 +1,306 @@
+package interactor
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/infrastructure/authentication"
+	"auth-project/src/usecase/presenter"
+	"auth-project/src/usecase/repository"
+	"auth-project/tools"
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/lindell/go-burner-email-providers/burner"
+	"github.com/spf13/viper"
+	"log"
+	"strings"
+	"time"
+)
+
+type contactChangeInteractor struct {
+	ContactChangeRepository repository.ContactChangeRepository
+	UserRepository          repository.UserRepository
+	TokenRepository         repository.TokenRepository
+	TwoFactorAuthRepository repository.TwoFactorAuthRepository
+	NotificationRepository  repository.NotificationRepository
+
+	ContactChangePresenter presenter.ContactChangePresenter
+
+	totpConfig *authentication.TotpConfig
+}
+
+type ContactChangeInteractor interface {
+	SendContactChangeCode(ctx context.Context, sendCodeReq *model.ContactChangeSendCodeReq, usrID string) (*model.Code2faSentResp, error)
+
+	RequestEmailChange(ctx context.Context, reqData *model.UserEmailUpdateReq, usrInfo *model.UserSessionData) (*model.ContactChange, error)
+	RequestPhoneChange(ctx context.Context, reqData *model.UserPhoneUpdateReq, usrInfo *model.UserSessionData) (*model.ContactChange, error)
+
+	GetContactChanges(ctx context.Context, usrID string) ([]model.ContactChange, error)
+	CancelContactChange(ctx context.Context, changeID, usrID string) error
+
+	ApplyContactChanges(ctx context.Context) error
+}
+
+func NewContactChangeInteractor(cr repository.ContactChangeRepository, ur repository.UserRepository,
+	tr repository.TokenRepository, tfr repository.TwoFactorAuthRepository, nr repository.NotificationRepository,
+	p presenter.ContactChangePresenter, tc *authentication.TotpConfig) ContactChangeInteractor {
+	return &contactChangeInteractor{cr, ur, tr, tfr, nr, p, tc}
+}
+
+// SendContactChangeCode sends the code which confirms the change by the current method,
+// it is the default 2fa method if the type is not requested
+func (ci *contactChangeInteractor) SendContactChangeCode(ctx context.Context,
+	sendCodeReq *model.ContactChangeSendCodeReq, usrID string) (*model.Code2faSentResp, error) {
+
+	usr, err := ci.UserRepository.GetUserByID(ctx, usrID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	code2faType := sendCodeReq.Code2faType
+	if types := contactChangeConfirmationTypes(usr); code2faType == "" && len(types) > 0 {
+		code2faType = types[0]
+	}
+
+	if !isContactChangeConfirmationType(usr, code2faType) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid token type")
+	}
+
+	target, err := sendTwoFactorAuthCode(ctx, ci.TokenRepository, usr, code2faType, model.TokenReasonContactChange)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return &model.Code2faSentResp{
+		Code2faType:   code2faType,
+		Code2faTarget: target,
+	}, nil
+}
+
+func (ci *contactChangeInteractor) RequestEmailChange(ctx context.Context, reqData *model.UserEmailUpdateReq,
+	usrInfo *model.UserSessionData) (*model.ContactChange, error) {
+
+	email := strings.ToLower(reqData.Email)
+	if email == "" || burner.IsBurnerEmail(email) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid email")
+	}
+
+	return ci.requestContactChange(ctx, model.TokenTypeEmail, email, reqData.Code2fa,
+		reqData.CurrentCode2fa, reqData.CurrentCode2faType, usrInfo)
+}
+
+func (ci *contactChangeInteractor) RequestPhoneChange(ctx context.Context, reqData *model.UserPhoneUpdateReq,
+	usrInfo *model.UserSessionData) (*model.ContactChange, error) {
+
+	phone, err := tools.VerifyPhone(reqData.Phone)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return ci.requestContactChange(ctx, model.TokenTypePhone, phone, reqData.Code2fa,
+		reqData.CurrentCode2fa, reqData.CurrentCode2faType, usrInfo)
+}
+
+func (ci *contactChangeInteractor) GetContactChanges(ctx context.Context, usrID string) ([]model.ContactChange, error) {
+
+	changes, err := ci.ContactChangeRepository.GetPendingContactChangesByUserID(ctx, usrID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return changes, nil
+}
+
+func (ci *contactChangeInteractor) CancelContactChange(ctx context.Context, changeID, usrID string) error {
+
+	err := ci.ContactChangeRepository.CancelContactChange(ctx, changeID, usrID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	return nil
+}
+
+// ApplyContactChanges applies the changes whose grace period is over, it is run by the scheduler
+func (ci *contactChangeInteractor) ApplyContactChanges(ctx context.Context) error {
+
+	changes, err := ci.ContactChangeRepository.GetDueContactChanges(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range changes {
+		change := &changes[i]
+
+		oldUser, err := ci.UserRepository.GetUserByID(ctx, change.UserID)
+		if err != nil {
+			log.Printf("error applying contact change %s: %s", change.ID, err.Error())
+			continue
+		}
+
+		usrInfo := &model.UserSessionData{
+			UserID:    change.UserID,
+			UserAgent: change.UserAgent,
+			ClientIp:  change.ClientIP,
+		}
+
+		err = ci.ContactChangeRepository.ApplyContactChange(ctx, change)
+		if err == model.ErrContactTaken {
+			err = ci.ContactChangeRepository.CancelContactChange(ctx, change.ID, change.UserID)
+			if err != nil {
+				log.Printf("error canceling contact change %s: %s", change.ID, err.Error())
+				continue
+			}
+
+			notifySecurityEvent(ctx, ci.NotificationRepository, oldUser, model.SecurityEventContactChangeFailed, usrInfo)
+			continue
+		}
+		if err != nil {
+			log.Printf("error applying contact change %s: %s", change.ID, err.Error())
+			continue
+		}
+
+		// the old contacts are notified, the new one could be the attacker's one
+		event := model.SecurityEventPhoneChanged
+		if change.Type == model.TokenTypeEmail {
+			event = model.SecurityEventEmailChanged
+		}
+		notifySecurityEvent(ctx, ci.NotificationRepository, oldUser, event, usrInfo)
+	}
+
+	return nil
+}
+
+// requestContactChange checks the codes of the new contact and of the current method,
+// then creates the change which is applied after the grace period
+func (ci *contactChangeInteractor) requestContactChange(ctx context.Context, contactType, value, code2fa,
+	currentCode2fa, currentCode2faType string, usrInfo *model.UserSessionData) (*model.ContactChange, error) {
+
+	usr, err := ci.UserRepository.GetUserByID(ctx, usrInfo.UserID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	oldValue := usr.Email
+	if contactType == model.TokenTypePhone {
+		oldValue = usr.Phone
+	}
+	if value == oldValue {
+		return nil, fiber.NewError(fiber.StatusBadRequest, contactType+" is not changed")
+	}
+
+	taken, err := ci.ContactChangeRepository.IsContactTaken(ctx, contactType, value, usr.ID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if taken {
+		return nil, fiber.NewError(fiber.StatusConflict, contactType+" already in use")
+	}
+
+	err = ci.verifyCurrentContact(ctx, usr, currentCode2faType, currentCode2fa)
+	if err != nil {
+		return nil, err
+	}
+
+	verifyCodeDate := &model.VerifyCodeData{
+		UserID:   usr.ID,
+		Target:   value,
+		Code:     code2fa,
+		CodeType: contactType,
+		Reason:   model.TokenReasonVerification,
+	}
+
+	_, err = ci.TokenRepository.Validate2faCode(ctx, verifyCodeDate)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	err = ci.TokenRepository.TokenSetUsed(ctx, verifyCodeDate)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	change, err := ci.ContactChangeRepository.CreateContactChange(ctx, &model.ContactChange{
+		UserID:    usr.ID,
+		Type:      contactType,
+		OldValue:  oldValue,
+		NewValue:  value,
+		UserAgent: usrInfo.UserAgent,
+		ClientIP:  usrInfo.ClientIp,
+		ApplyAt:   time.Now().UTC().Add(viper.GetDuration("contact_change.grace_period")),
+	})
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	event := model.SecurityEventPhoneChangeRequested
+	if contactType == model.TokenTypeEmail {
+		event = model.SecurityEventEmailChangeRequested
+	}
+
+	err = notifyContactChange(ctx, ci.TokenRepository, ci.NotificationRepository, usr, event, usrInfo)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return change, nil
+}
+
+// verifyCurrentContact checks the code of the enrolled 2fa method,
+// the users without 2fa confirm the change by the current email or phone
+func (ci *contactChangeInteractor) verifyCurrentContact(ctx context.Context, usr *model.User,
+	code2faType, code string) error {
+
+	if usr.HasTwoFactorAuth() {
+		_, err := verifyTwoFactorAuthCode(ctx, ci.totpConfig, ci.TwoFactorAuthRepository, ci.TokenRepository, usr,
+			code2faType, code, model.TokenReasonContactChange)
+		return err
+	}
+
+	if !isContactChangeConfirmationType(usr, code2faType) {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid token type")
+	}
+
+	verifyCodeData := &model.VerifyCodeData{
+		UserID:   usr.ID,
+		Code:     code,
+		CodeType: code2faType,
+		Reason:   model.TokenReasonContactChange,
+	}
+
+	_, err := ci.TokenRepository.Validate2faCode(ctx, verifyCodeData)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	err = ci.TokenRepository.TokenSetUsed(ctx, verifyCodeData)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return nil
+}
+
+// contactChangeConfirmationTypes returns the methods which confirm the change on behalf of the current owner,
+// these are the enrolled 2fa methods or the current contacts if 2fa is disabled
+func contactChangeConfirmationTypes(usr *model.User) []string {
+	if usr.HasTwoFactorAuth() {
+		return usr.TwoFactorAuthTypes()
+	}
+
+	var types []string
+	if usr.Email != "" {
+		types = append(types, model.TokenTypeEmail)
+	}
+	if usr.Phone != "" {
+		types = append(types, model.TokenTypePhone)
+	}
+	return types
+}
+
+func isContactChangeConfirmationType(usr *model.User, code2faType string) bool {
+	for _, t := range contactChangeConfirmationTypes(usr) {
+		if t == code2faType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,258 @@
+package interactor
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/usecase/presenter"
+	"auth-project/src/usecase/repository"
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+	"net/http"
+	"strings"
+)
+
+const (
+	kycDefaultLimit = 20
+	kycMaxLimit     = 100
+)
+
+// kycContentTypes the formats of the accepted documents, the type is detected by the content
+var kycContentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"application/pdf": true,
+}
+
+type kycInteractor struct {
+	KycRepository        repository.KycRepository
+	UserConfigRepository repository.UserConfigRepository
+	UserRepository       repository.UserRepository
+
+	KycPresenter presenter.KycPresenter
+}
+
+type KycInteractor interface {
+	GetMyKyc(ctx context.Context, usrID string) (*model.KycStatusResp, error)
+	SubmitKyc(ctx context.Context, submitReq *model.KycSubmitReq, documents []model.KycDocument, usrID string) (*model.KycSubmission, error)
+
+	GetKycSubmissions(ctx context.Context, listReq *model.KycSubmissionListReq) (*model.KycSubmissionListResp, error)
+	GetKycSubmission(ctx context.Context, submissionID string) (*model.KycSubmission, error)
+	GetKycDocument(ctx context.Context, submissionID, documentID string) (*model.KycDocument, error)
+	ApproveKycSubmission(ctx context.Context, submissionID string, reviewReq *model.KycReviewReq, reviewerID string) error
+	RejectKycSubmission(ctx context.Context, submissionID string, reviewReq *model.KycReviewReq, reviewerID string) error
+
+	FlagUser(ctx context.Context, flagReq *model.UserFlagReq, usrID string) error
+}
+
+func NewKycInteractor(kr repository.KycRepository, ucr repository.UserConfigRepository, ur repository.UserRepository,
+	p presenter.KycPresenter) KycInteractor {
+	return &kycInteractor{kr, ucr, ur, p}
+}
+
+// GetMyKyc returns the kyc level of the user and his submissions
+func (ki *kycInteractor) GetMyKyc(ctx context.Context, usrID string) (*model.KycStatusResp, error) {
+
+	config, err := ki.UserConfigRepository.GetUserConfig(ctx, usrID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	submissions, err := ki.KycRepository.GetKycSubmissionsByUserID(ctx, usrID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return ki.KycPresenter.GetMyKycResp(config, submissions), nil
+}
+
+// SubmitKyc saves the kyc data with the documents for the review, the user may have one pending submission,
+// the next level after the current one is requested by default
+func (ki *kycInteractor) SubmitKyc(ctx context.Context, submitReq *model.KycSubmitReq, documents []model.KycDocument,
+	usrID string) (*model.KycSubmission, error) {
+
+	config, err := ki.UserConfigRepository.GetUserConfig(ctx, usrID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	level := submitReq.Level
+	if level == 0 {
+		level = config.KycLevel + 1
+	}
+	if level <= config.KycLevel || level > viper.GetInt("kyc.max_level") {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid kyc level")
+	}
+
+	idCard := strings.TrimSpace(submitReq.IDCard)
+	if idCard == "" {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "id card is required")
+	}
+
+	if len(documents) == 0 {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "documents are required")
+	}
+
+	maxFileSize := viper.GetInt("kyc.max_file_size")
+	for i := range documents {
+		if len(documents[i].Content) == 0 || len(documents[i].Content) > maxFileSize {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "invalid size of "+documents[i].Type)
+		}
+
+		documents[i].ContentType = http.DetectContentType(documents[i].Content)
+		if !kycContentTypes[documents[i].ContentType] {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "invalid format of "+documents[i].Type)
+		}
+	}
+
+	pending, err := ki.KycRepository.HasPendingKycSubmission(ctx, usrID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+	if pending {
+		return nil, fiber.NewError(fiber.StatusConflict, "kyc submission is already under review")
+	}
+
+	submission, err := ki.KycRepository.CreateKycSubmission(ctx, &model.KycSubmission{
+		UserID:    usrID,
+		Level:     level,
+		Documents: documents,
+	}, idCard)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return submission, nil
+}
+
+func (ki *kycInteractor) GetKycSubmissions(ctx context.Context,
+	listReq *model.KycSubmissionListReq) (*model.KycSubmissionListResp, error) {
+
+	if listReq.Limit <= 0 {
+		listReq.Limit = kycDefaultLimit
+	}
+	if listReq.Limit > kycMaxLimit {
+		listReq.Limit = kycMaxLimit
+	}
+	if listReq.Offset < 0 {
+		listReq.Offset = 0
+	}
+
+	submissions, total, err := ki.KycRepository.GetKycSubmissions(ctx, listReq.Status, listReq.Limit, listReq.Offset)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return ki.KycPresenter.GetKycSubmissionsResp(submissions, total, listReq.Limit, listReq.Offset), nil
+}
+
+func (ki *kycInteractor) GetKycSubmission(ctx context.Context, submissionID string) (*model.KycSubmission, error) {
+
+	submission, err := ki.KycRepository.GetKycSubmissionByID(ctx, submissionID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	return submission, nil
+}
+
+func (ki *kycInteractor) GetKycDocument(ctx context.Context, submissionID, documentID string) (*model.KycDocument, error) {
+
+	document, err := ki.KycRepository.GetKycDocument(ctx, submissionID, documentID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	return document, nil
+}
+
+// ApproveKycSubmission sets the level to the user, the requested one is used if the reviewer doesn't change it
+func (ki *kycInteractor) ApproveKycSubmission(ctx context.Context, submissionID string,
+	reviewReq *model.KycReviewReq, reviewerID string) error {
+
+	submission, err := ki.KycRepository.GetKycSubmissionByID(ctx, submissionID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	level := reviewReq.Level
+	if level == 0 {
+		level = submission.Level
+	}
+	if level < 0 || level > viper.GetInt("kyc.max_level") {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid kyc level")
+	}
+
+	err = ki.KycRepository.ReviewKycSubmission(ctx, submissionID, reviewerID, model.KycStatusApproved, level,
+		reviewReq.Comment)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return nil
+}
+
+// RejectKycSubmission closes the submission without the change of the level, the comment tells the user the reason
+func (ki *kycInteractor) RejectKycSubmission(ctx context.Context, submissionID string,
+	reviewReq *model.KycReviewReq, reviewerID string) error {
+
+	submission, err := ki.KycRepository.GetKycSubmissionByID(ctx, submissionID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	err = ki.KycRepository.ReviewKycSubmission(ctx, submissionID, reviewerID, model.KycStatusRejected,
+		submission.Level, reviewReq.Comment)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return nil
+}
+
+// FlagUser blocks or unblocks the access of the user of the tenant, the flagged users are rejected
+// on the token validation
+func (ki *kycInteractor) FlagUser(ctx context.Context, flagReq *model.UserFlagReq, usrID string) error {
+
+	usr, err := ki.UserRepository.GetUserByID(ctx, usrID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	if usr.TenantID != model.TenantID(ctx) {
+		return fiber.NewError(fiber.StatusNotFound, model.ErrUserNotFound.Error())
+	}
+
+	err = ki.UserConfigRepository.SetAccFlagged(ctx, usrID, flagReq.Flagged)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return nil
+}
+
+// getUserClaims returns the role, the kyc level and the tenant put in the access token
+func getUserClaims(ctx context.Context, userConfigRepository repository.UserConfigRepository,
+	tenantRepository repository.TenantRepository, usr *model.User) (*model.UserClaims, error) {
+
+	config, err := userConfigRepository.GetUserConfig(ctx, usr.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	tenantID := usr.TenantID
+	if tenantID == "" {
+		tenantID = model.DefaultTenantID
+	}
+
+	tenant, err := tenantRepository.GetTenantByID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.UserClaims{
+		Role:     usr.Role,
+		KycLevel: config.KycLevel,
+		TenantID: tenant.ID,
+		Issuer:   tenant.JwtIssuer,
+		Audience: tenant.JwtAudience,
+	}, nil
+}
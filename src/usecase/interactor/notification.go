@@ -0,0 +1,78 @@
+package interactor
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/usecase/repository"
+	"context"
+	"github.com/spf13/viper"
+	"net/url"
+	"time"
+)
+
+// notifySecurityEvent keeps the activity in the account log and tells the user about it by email, or by sms
+// if there is no email, the message is sent in the background, so a sending failure does not break the action itself
+func notifySecurityEvent(ctx context.Context, notificationRepository repository.NotificationRepository, usr *model.User,
+	event string, usrInfo *model.UserSessionData) {
+
+	target, targetType := usr.Email, model.TokenTypeEmail
+	if target == "" {
+		target, targetType = usr.Phone, model.TokenTypePhone
+	}
+
+	notificationRepository.NotifySecurityEvent(ctx, target, targetType, &model.SecurityNotification{
+		TenantID:  usr.TenantID,
+		UserID:    usr.ID,
+		Event:     event,
+		UserAgent: usrInfo.UserAgent,
+		ClientIP:  usrInfo.ClientIp,
+		Time:      time.Now().UTC(),
+	})
+}
+
+// notifyNewLogin tells the user about the sign in if the session is created from an unfamiliar device,
+// it must be called before the session is inserted
+func notifyNewLogin(ctx context.Context, sessionRepository repository.SessionRepository,
+	notificationRepository repository.NotificationRepository, usr *model.User, usrInfo *model.UserSessionData) error {
+
+	unfamiliar, err := sessionRepository.IsUnfamiliarDevice(ctx, usr.ID, usrInfo.UserAgent, usrInfo.ClientIp)
+	if err != nil {
+		return err
+	}
+
+	if unfamiliar {
+		notifySecurityEvent(ctx, notificationRepository, usr, model.SecurityEventNewLogin, usrInfo)
+	}
+
+	return nil
+}
+
+// notifyContactChange tells the user about the contact change, the email gets the "this wasn't me" link,
+// which signs out all sessions of the user and cancels the pending changes
+func notifyContactChange(ctx context.Context, tokenRepository repository.TokenRepository,
+	notificationRepository repository.NotificationRepository, usr *model.User, event string,
+	usrInfo *model.UserSessionData) error {
+
+	if usr.Email == "" {
+		notifySecurityEvent(ctx, notificationRepository, usr, event, usrInfo)
+		return nil
+	}
+
+	token, err := tokenRepository.CreateRevokeSessionsToken(ctx, usr.ID, usr.Email)
+	if err != nil {
+		return err
+	}
+
+	path := viper.GetString("notification.revoke_path") + "?token=" + url.QueryEscape(token.Value)
+
+	notificationRepository.NotifySecurityEvent(ctx, usr.Email, model.TokenTypeEmail, &model.SecurityNotification{
+		TenantID:   usr.TenantID,
+		UserID:     usr.ID,
+		Event:      event,
+		UserAgent:  usrInfo.UserAgent,
+		ClientIP:   usrInfo.ClientIp,
+		Time:       time.Now().UTC(),
+		RevokePath: path,
+	})
+
+	return nil
+}
@@ -0,0 +1,182 @@
+package interactor
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/infrastructure/authentication"
+	"auth-project/src/usecase/presenter"
+	"auth-project/src/usecase/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/gofiber/fiber/v2"
+	"strings"
+)
+
+type oAuthClientInteractor struct {
+	OAuthClientRepository repository.OAuthClientRepository
+	UserRepository        repository.UserRepository
+	UserConfigRepository  repository.UserConfigRepository
+	TenantRepository      repository.TenantRepository
+
+	OAuthClientPresenter presenter.OAuthClientPresenter
+
+	jwtConfigurator *authentication.JwtConfigurator
+}
+
+type OAuthClientInteractor interface {
+	GetOAuthClients(ctx context.Context, usrID string) ([]model.OAuthClient, error)
+	CreateOAuthClient(ctx context.Context, createReq *model.OAuthClientCreateReq, usrID string) (*model.OAuthClientSecretResp, error)
+	RotateOAuthClientSecret(ctx context.Context, clientID, usrID string) (*model.OAuthClientSecretResp, error)
+	RevokeOAuthClient(ctx context.Context, clientID, usrID string) error
+
+	IssueClientToken(ctx context.Context, tokenReq *model.OAuthTokenReq) (*model.OAuthTokenResp, error)
+}
+
+func NewOAuthClientInteractor(ocr repository.OAuthClientRepository, ur repository.UserRepository, ucr repository.UserConfigRepository, tnr repository.TenantRepository, p presenter.OAuthClientPresenter, jc *authentication.JwtConfigurator) OAuthClientInteractor {
+	return &oAuthClientInteractor{ocr, ur, ucr, tnr, p, jc}
+}
+
+func (oi *oAuthClientInteractor) GetOAuthClients(ctx context.Context, usrID string) ([]model.OAuthClient, error) {
+
+	clients, err := oi.OAuthClientRepository.GetOAuthClientsByUserID(ctx, usrID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return clients, nil
+}
+
+// CreateOAuthClient registers the machine client acting on behalf of the user, the secret is returned once
+func (oi *oAuthClientInteractor) CreateOAuthClient(ctx context.Context, createReq *model.OAuthClientCreateReq,
+	usrID string) (*model.OAuthClientSecretResp, error) {
+
+	name, err := verifyMachineClientName(createReq.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	usr, err := oi.UserRepository.GetUserByID(ctx, usrID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	scopes, err := verifyScopes(createReq.Scopes, usr)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := authentication.GenerateClientSecret()
+
+	client := &model.OAuthClient{
+		ID:         authentication.GenerateClientID(),
+		TenantID:   usr.TenantID,
+		UserID:     usr.ID,
+		Name:       name,
+		SecretHash: authentication.HashSecret(secret),
+		Scopes:     scopes,
+	}
+
+	err = oi.OAuthClientRepository.CreateOAuthClient(ctx, client)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return oi.OAuthClientPresenter.OAuthClientSecretResp(client, secret), nil
+}
+
+// RotateOAuthClientSecret replaces the secret of the client, the tokens issued before are kept until the expiration
+func (oi *oAuthClientInteractor) RotateOAuthClientSecret(ctx context.Context,
+	clientID, usrID string) (*model.OAuthClientSecretResp, error) {
+
+	secret := authentication.GenerateClientSecret()
+
+	client, err := oi.OAuthClientRepository.UpdateOAuthClientSecret(ctx, clientID, usrID,
+		authentication.HashSecret(secret))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	return oi.OAuthClientPresenter.OAuthClientSecretResp(client, secret), nil
+}
+
+func (oi *oAuthClientInteractor) RevokeOAuthClient(ctx context.Context, clientID, usrID string) error {
+
+	err := oi.OAuthClientRepository.RevokeOAuthClient(ctx, clientID, usrID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	return nil
+}
+
+// IssueClientToken issues the access token by the client credentials grant (RFC 6749 section 4.4),
+// the scope could narrow the scopes of the client
+func (oi *oAuthClientInteractor) IssueClientToken(ctx context.Context,
+	tokenReq *model.OAuthTokenReq) (*model.OAuthTokenResp, error) {
+
+	if tokenReq.GrantType != model.OAuthGrantTypeClientCred {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "unsupported_grant_type")
+	}
+
+	client, err := authenticateOAuthClient(ctx, oi.OAuthClientRepository, tokenReq.ClientID, tokenReq.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := client.Scopes
+	if tokenReq.Scope != "" {
+		scopes = model.ParseScope(tokenReq.Scope)
+		for _, scope := range scopes {
+			if !model.HasScope(client.Scopes, scope) {
+				return nil, fiber.NewError(fiber.StatusBadRequest, "invalid_scope")
+			}
+		}
+	}
+
+	usr, err := oi.UserRepository.GetUserByID(ctx, client.UserID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if !usr.IsActive {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "invalid_client")
+	}
+
+	userClaims, err := getUserClaims(ctx, oi.UserConfigRepository, oi.TenantRepository, usr)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	scope := strings.Join(scopes, " ")
+
+	details, err := oi.jwtConfigurator.GenerateClientAccessToken(client.ID, usr.ID, scope, userClaims)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	err = oi.OAuthClientRepository.UseOAuthClient(ctx, client.ID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return oi.OAuthClientPresenter.OAuthTokenResp(details, scope), nil
+}
+
+// authenticateOAuthClient returns the client by the credentials, the client of another tenant is not accepted
+func authenticateOAuthClient(ctx context.Context, oAuthClientRepository repository.OAuthClientRepository,
+	clientID, clientSecret string) (*model.OAuthClient, error) {
+
+	client, err := oAuthClientRepository.GetOAuthClientByID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fiber.NewError(fiber.StatusUnauthorized, "invalid_client")
+		}
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if !authentication.CompareSecret(clientSecret, client.SecretHash) || client.TenantID != model.TenantID(ctx) {
+		return nil, fiber.NewError(fiber.StatusUnauthorized, "invalid_client")
+	}
+
+	return client, nil
+}
@@ -19,6 +19,10 @@ type qrCodeAuthInteractor struct {
 	UserRepository       repository.UserRepository
 	QrCodeAuthRepository repository.QrCodeAuthRepository
 
+	NotificationRepository repository.NotificationRepository
+	UserConfigRepository   repository.UserConfigRepository
+	TenantRepository       repository.TenantRepository
+
 	QrCodeAuthPresenter presenter.QrCodeAuthPresenter
 
 	jwtConfigurator *authentication.JwtConfigurator
@@ -32,8 +36,8 @@ type QrCodeAuthInteractor interface {
 }
 
 func NewQrCodeAuthInteractor(
-	ar repository.AuthRepository, sr repository.SessionRepository, ur repository.UserRepository, qr repository.QrCodeAuthRepository, p presenter.QrCodeAuthPresenter, jc *authentication.JwtConfigurator) QrCodeAuthInteractor {
-	return &qrCodeAuthInteractor{ar, sr, ur, qr, p, jc}
+	ar repository.AuthRepository, sr repository.SessionRepository, ur repository.UserRepository, qr repository.QrCodeAuthRepository, nr repository.NotificationRepository, ucr repository.UserConfigRepository, tnr repository.TenantRepository, p presenter.QrCodeAuthPresenter, jc *authentication.JwtConfigurator) QrCodeAuthInteractor {
+	return &qrCodeAuthInteractor{ar, sr, ur, qr, nr, ucr, tnr, p, jc}
 }
 
 func (qi *qrCodeAuthInteractor) GenerateQrCode(ctx context.Context) ([]byte, string, error) {
@@ -81,7 +85,23 @@ func (qi *qrCodeAuthInteractor) GenerateTokenPairByUserID(c *websocket.Conn, usr
 		return nil, err
 	}
 
-	details, err := qi.jwtConfigurator.GenerateTokenPair(usr.ID, sessionID)
+	userClaims, err := getUserClaims(context.Background(), qi.UserConfigRepository, qi.TenantRepository, usr)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant, err := qi.TenantRepository.GetTenantByID(context.Background(), userClaims.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// the sessions of the tenant which requires 2fa are created only after it is set up
+	if tenant.TwoFactorAuthRequired && !usr.HasTwoFactorAuth() {
+		return nil, errors.New("two-factor auth set-up required")
+	}
+
+	details, err := qi.jwtConfigurator.GenerateTokenPair(usr.ID, sessionID, model.NewAuthDetails(model.AuthMethodQrCode),
+		userClaims)
 	if err != nil {
 		return nil, err
 	}
@@ -97,6 +117,17 @@ func (qi *qrCodeAuthInteractor) GenerateTokenPairByUserID(c *websocket.Conn, usr
 		ClientIP:  c.Conn.LocalAddr().String(),
 		ExpiresAT: time.Unix(details.RtExpires, 0).UTC(),
 		UserID:    usr.ID,
+		TenantID:  usr.TenantID,
+	}
+
+	err = notifyNewLogin(context.Background(), qi.SessionRepository, qi.NotificationRepository, usr,
+		&model.UserSessionData{
+			UserID:    usr.ID,
+			UserAgent: ses.UserAgent,
+			ClientIp:  ses.ClientIP,
+		})
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
 	err = qi.SessionRepository.InsertSession(context.Background(), ses)
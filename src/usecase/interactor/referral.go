@@ -0,0 +1,212 @@
+package interactor
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/usecase/presenter"
+	"auth-project/src/usecase/repository"
+	"auth-project/tools"
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+	"time"
+)
+
+const (
+	referralDateLayout   = "2006-01-02"
+	referralDefaultLimit = 20
+	referralMaxLimit     = 100
+)
+
+type referralInteractor struct {
+	ReferralRepository repository.ReferralRepository
+	UserRepository     repository.UserRepository
+
+	ReferralPresenter presenter.ReferralPresenter
+}
+
+type ReferralInteractor interface {
+	GetReferrals(ctx context.Context, listReq *model.ReferralListReq, usrID string) (*model.ReferralListResp, error)
+	GetReferralTree(ctx context.Context, treeReq *model.ReferralTreeReq, usrID string) ([]*model.ReferralTreeNode, error)
+	GetReferralCount(ctx context.Context, treeReq *model.ReferralTreeReq, usrID string) (*model.ReferralCountResp, error)
+	GetReferralStats(ctx context.Context, statsReq *model.ReferralStatsReq, usrID string) (*model.ReferralStatsResp, error)
+
+	RegenerateReferralLink(ctx context.Context, usrID string) (*model.ReferralLinkResp, error)
+	SetReferralCode(ctx context.Context, codeReq *model.ReferralCodeReq, usrID string) (*model.ReferralLinkResp, error)
+}
+
+func NewReferralInteractor(rr repository.ReferralRepository, ur repository.UserRepository,
+	p presenter.ReferralPresenter) ReferralInteractor {
+	return &referralInteractor{rr, ur, p}
+}
+
+// GetReferrals returns the page of the users signed up by the user's referral link
+func (ri *referralInteractor) GetReferrals(ctx context.Context, listReq *model.ReferralListReq,
+	usrID string) (*model.ReferralListResp, error) {
+
+	if listReq.Limit <= 0 {
+		listReq.Limit = referralDefaultLimit
+	}
+	if listReq.Limit > referralMaxLimit {
+		listReq.Limit = referralMaxLimit
+	}
+	if listReq.Offset < 0 {
+		listReq.Offset = 0
+	}
+
+	usr, err := ri.UserRepository.GetUserByID(ctx, usrID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	users, total, err := ri.ReferralRepository.GetReferredUsers(ctx, usr.ReferralLink, listReq.Limit, listReq.Offset)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return ri.ReferralPresenter.GetReferralsResp(users, total, listReq.Limit, listReq.Offset), nil
+}
+
+// GetReferralTree returns the referrals with their own referrals up to the requested depth
+func (ri *referralInteractor) GetReferralTree(ctx context.Context, treeReq *model.ReferralTreeReq,
+	usrID string) ([]*model.ReferralTreeNode, error) {
+
+	usr, users, err := ri.getReferralTree(ctx, treeReq, usrID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ri.ReferralPresenter.GetReferralTreeResp(usr.ReferralLink, users), nil
+}
+
+// GetReferralCount returns the number of the referrals on each level of the tree
+func (ri *referralInteractor) GetReferralCount(ctx context.Context, treeReq *model.ReferralTreeReq,
+	usrID string) (*model.ReferralCountResp, error) {
+
+	_, users, err := ri.getReferralTree(ctx, treeReq, usrID)
+	if err != nil {
+		return nil, err
+	}
+
+	return ri.ReferralPresenter.GetReferralCountResp(users), nil
+}
+
+// GetReferralStats returns the number of the direct referrals by day, week or month,
+// the last 30 days are taken by default
+func (ri *referralInteractor) GetReferralStats(ctx context.Context, statsReq *model.ReferralStatsReq,
+	usrID string) (*model.ReferralStatsResp, error) {
+
+	period := statsReq.Period
+	switch period {
+	case "":
+		period = model.ReferralStatsPeriodDay
+	case model.ReferralStatsPeriodDay, model.ReferralStatsPeriodWeek, model.ReferralStatsPeriodMonth:
+	default:
+		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid period")
+	}
+
+	var err error
+	to := time.Now().UTC()
+	if statsReq.To != "" {
+		to, err = time.Parse(referralDateLayout, statsReq.To)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "invalid date to")
+		}
+		// the day of the date to is included
+		to = to.AddDate(0, 0, 1)
+	}
+
+	from := to.AddDate(0, 0, -30)
+	if statsReq.From != "" {
+		from, err = time.Parse(referralDateLayout, statsReq.From)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "invalid date from")
+		}
+	}
+
+	if !from.Before(to) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "date from must be before date to")
+	}
+
+	usr, err := ri.UserRepository.GetUserByID(ctx, usrID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	stats, err := ri.ReferralRepository.GetReferralStats(ctx, usr.ReferralLink, period, from, to)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return &model.ReferralStatsResp{
+		Period: period,
+		From:   from,
+		To:     to,
+		Stats:  stats,
+	}, nil
+}
+
+// RegenerateReferralLink replaces the referral link with the new random one, the old link stops working
+func (ri *referralInteractor) RegenerateReferralLink(ctx context.Context, usrID string) (*model.ReferralLinkResp, error) {
+	return ri.updateReferralLink(ctx, tools.GenerateLink(), usrID)
+}
+
+// SetReferralCode replaces the referral link with the vanity code chosen by the user
+func (ri *referralInteractor) SetReferralCode(ctx context.Context, codeReq *model.ReferralCodeReq,
+	usrID string) (*model.ReferralLinkResp, error) {
+
+	code, err := tools.VerifyReferralCode(codeReq.Code)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	return ri.updateReferralLink(ctx, code, usrID)
+}
+
+func (ri *referralInteractor) updateReferralLink(ctx context.Context, newLink, usrID string) (*model.ReferralLinkResp, error) {
+
+	usr, err := ri.UserRepository.GetUserByID(ctx, usrID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if usr.ReferralLink != newLink {
+		err = ri.ReferralRepository.UpdateReferralLink(ctx, usrID, usr.ReferralLink, newLink)
+		if err == model.ErrReferralLinkTaken {
+			return nil, fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+	}
+
+	return &model.ReferralLinkResp{
+		ReferralLink: newLink,
+	}, nil
+}
+
+// getReferralTree loads the referrals tree, the depth is limited by referral.max_depth
+func (ri *referralInteractor) getReferralTree(ctx context.Context, treeReq *model.ReferralTreeReq,
+	usrID string) (*model.User, []model.ReferredUser, error) {
+
+	maxDepth := viper.GetInt("referral.max_depth")
+	if maxDepth <= 0 {
+		maxDepth = 1
+	}
+
+	depth := treeReq.Depth
+	if depth <= 0 || depth > maxDepth {
+		depth = maxDepth
+	}
+
+	usr, err := ri.UserRepository.GetUserByID(ctx, usrID)
+	if err != nil {
+		return nil, nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	users, err := ri.ReferralRepository.GetReferralTree(ctx, usr.ReferralLink, depth)
+	if err != nil {
+		return nil, nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return usr, users, nil
+}
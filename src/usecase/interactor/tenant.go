@@ -0,0 +1,97 @@
+package interactor
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/usecase/presenter"
+	"auth-project/src/usecase/repository"
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+	"strings"
+)
+
+type tenantInteractor struct {
+	TenantRepository repository.TenantRepository
+
+	TenantPresenter presenter.TenantPresenter
+}
+
+type TenantInteractor interface {
+	ResolveTenant(ctx context.Context, tenantID, host, origin string) (*model.Tenant, error)
+	GetTenantBranding(ctx context.Context) (*model.TenantBrandingResp, error)
+}
+
+func NewTenantInteractor(tr repository.TenantRepository, p presenter.TenantPresenter) TenantInteractor {
+	return &tenantInteractor{tr, p}
+}
+
+// ResolveTenant finds the tenant of the request by the host, the requests from the unknown hosts belong
+// to the default tenant, the explicit id selects the other tenant only from its front host
+// or from the trusted origins, since the id is sent by any client
+func (ti *tenantInteractor) ResolveTenant(ctx context.Context, tenantID, host, origin string) (*model.Tenant, error) {
+
+	hostTenant, err := ti.getTenantByHost(ctx, host)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if tenantID == "" || tenantID == hostTenant.ID {
+		return hostTenant, nil
+	}
+
+	tenant, err := ti.TenantRepository.GetTenantByID(ctx, tenantID)
+	if err == model.ErrTenantNotFound {
+		return nil, fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if !isTrustedOrigin(origin, tenant) {
+		return nil, fiber.NewError(fiber.StatusForbidden, "tenant header not allowed from this origin")
+	}
+
+	return tenant, nil
+}
+
+// getTenantByHost returns the tenant of the host or the default one
+func (ti *tenantInteractor) getTenantByHost(ctx context.Context, host string) (*model.Tenant, error) {
+
+	if host != "" {
+		tenant, err := ti.TenantRepository.GetTenantByHost(ctx, host)
+		if err != model.ErrTenantNotFound {
+			return tenant, err
+		}
+	}
+
+	return ti.TenantRepository.GetTenantByID(ctx, model.DefaultTenantID)
+}
+
+// isTrustedOrigin checks the origin of the request against the front host of the tenant and tenant.trusted_origins
+func isTrustedOrigin(origin string, tenant *model.Tenant) bool {
+
+	origin = strings.TrimRight(strings.ToLower(origin), "/")
+	if origin == "" {
+		return false
+	}
+
+	trusted := append(viper.GetStringSlice("tenant.trusted_origins"), tenant.FrontHost)
+	for _, o := range trusted {
+		if strings.TrimRight(strings.ToLower(o), "/") == origin {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetTenantBranding returns the public settings of the tenant of the request
+func (ti *tenantInteractor) GetTenantBranding(ctx context.Context) (*model.TenantBrandingResp, error) {
+
+	tenant, err := ti.TenantRepository.GetTenantByID(ctx, model.TenantID(ctx))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	return ti.TenantPresenter.GetTenantBrandingResp(tenant), nil
+}
@@ -18,8 +18,8 @@ type tokenInteractor struct {
 
 type TokenInteractor interface {
 	Validate2faCode(ctx context.Context, verifyCodeDate *model.VerifyCodeData) (*model.Token, error)
-	Send2faCode(ctx context.Context, usrID string) (map[string]interface{}, error)
-	SendTarget2faCode(ctx context.Context, sendTarget2faCodeReq *model.SendTarget2faCodeReq, usrID string) (map[string]interface{}, error)
+	Send2faCode(ctx context.Context, usrID string) (*model.Code2faSentResp, error)
+	SendTarget2faCode(ctx context.Context, sendTarget2faCodeReq *model.SendTarget2faCodeReq, usrID string) (*model.Code2faSentResp, error)
 	TokenSetUsed(ctx context.Context, verifyCodeDate *model.VerifyCodeData) error
 }
 
@@ -38,58 +38,39 @@ func (ti *tokenInteractor) Validate2faCode(ctx context.Context, verifyCodeDate *
 	return token, nil
 }
 
-func (ti *tokenInteractor) Send2faCode(ctx context.Context, usrID string) (map[string]interface{}, error) {
+func (ti *tokenInteractor) Send2faCode(ctx context.Context, usrID string) (*model.Code2faSentResp, error) {
 
 	usr, err := ti.UserRepository.GetUserByID(ctx, usrID)
 	if err != nil {
 		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	switch {
-	case usr.IsGoogleVerified:
-		return map[string]interface{}{
-			"code_2fa_type": model.TokenTypeGoogle,
-		}, nil
-
-	case usr.IsPhoneVerified:
-		target, err := ti.TokenRepository.Send2faCode(ctx, &model.Send2faCodeData{
-			UserID:      usr.ID,
-			Target:      usr.Phone,
-			Code2faType: model.TokenTypePhone,
-			Reason:      model.TokenReasonVerification,
-		})
-		if err != nil {
-			return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
-		}
-
-		return map[string]interface{}{
-			"code_2fa_type":   model.TokenTypePhone,
-			"code_2fa_target": target,
-		}, nil
-
-	case usr.IsEmailVerified:
-		target, err := ti.TokenRepository.Send2faCode(ctx, &model.Send2faCodeData{
-			UserID:      usr.ID,
-			Target:      usr.Email,
-			Code2faType: model.TokenTypeEmail,
-			Reason:      model.TokenReasonVerification,
-		})
-		if err != nil {
-			return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
-		}
+	if !usr.HasTwoFactorAuth() {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "two-factor authentication disabled")
+	}
 
-		return map[string]interface{}{
-			"code_2fa_type":   model.TokenTypeEmail,
-			"code_2fa_target": target,
+	// the code is sent by the default method chosen by the user
+	twoFactorAuthType := usr.DefaultTwoFactorAuthType()
+	if twoFactorAuthType == model.TokenTypeGoogle {
+		return &model.Code2faSentResp{
+			Code2faType: model.TokenTypeGoogle,
 		}, nil
+	}
 
-	default:
-		return nil, fiber.NewError(fiber.StatusInternalServerError, "two-factor authentication disabled")
+	target, err := sendTwoFactorAuthCode(ctx, ti.TokenRepository, usr, twoFactorAuthType,
+		model.TokenReasonVerification)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
+
+	return &model.Code2faSentResp{
+		Code2faType:   twoFactorAuthType,
+		Code2faTarget: target,
+	}, nil
 }
 
 func (ti *tokenInteractor) SendTarget2faCode(ctx context.Context, sendTarget2faCodeReq *model.SendTarget2faCodeReq,
-	usrID string) (map[string]interface{}, error) {
+	usrID string) (*model.Code2faSentResp, error) {
 
 	usr, err := ti.UserRepository.GetUserByID(ctx, usrID)
 	if err != nil {
@@ -114,9 +95,9 @@ func (ti *tokenInteractor) SendTarget2faCode(ctx context.Context, sendTarget2faC
 			return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
 
-		return map[string]interface{}{
-			"code_2fa_type":   model.TokenTypePhone,
-			"code_2fa_target": target,
+		return &model.Code2faSentResp{
+			Code2faType:   model.TokenTypePhone,
+			Code2faTarget: target,
 		}, nil
 
 	case model.TokenTypeEmail:
@@ -130,9 +111,9 @@ func (ti *tokenInteractor) SendTarget2faCode(ctx context.Context, sendTarget2faC
 			return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
 
-		return map[string]interface{}{
-			"code_2fa_type":   model.TokenTypeEmail,
-			"code_2fa_target": target,
+		return &model.Code2faSentResp{
+			Code2faType:   model.TokenTypeEmail,
+			Code2faTarget: target,
 		}, nil
 
 	default:
@@ -0,0 +1,94 @@
+package interactor
+
+import (
+	"auth-project/src/domain/model"
+	"auth-project/src/infrastructure/authentication"
+	"auth-project/src/usecase/presenter"
+	"auth-project/src/usecase/repository"
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+	"time"
+)
+
+type trustedDeviceInteractor struct {
+	TrustedDeviceRepository repository.TrustedDeviceRepository
+
+	TrustedDevicePresenter presenter.TrustedDevicePresenter
+}
+
+type TrustedDeviceInteractor interface {
+	GetTrustedDevices(ctx context.Context, usrID string) ([]model.TrustedDevice, error)
+	DeleteTrustedDevice(ctx context.Context, deviceID, usrID string) error
+	DeleteTrustedDevices(ctx context.Context, usrID string) error
+}
+
+func NewTrustedDeviceInteractor(tdr repository.TrustedDeviceRepository, p presenter.TrustedDevicePresenter) TrustedDeviceInteractor {
+	return &trustedDeviceInteractor{tdr, p}
+}
+
+func (ti *trustedDeviceInteractor) GetTrustedDevices(ctx context.Context, usrID string) ([]model.TrustedDevice, error) {
+
+	devices, err := ti.TrustedDeviceRepository.GetTrustedDevicesByUserID(ctx, usrID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return devices, nil
+}
+
+func (ti *trustedDeviceInteractor) DeleteTrustedDevice(ctx context.Context, deviceID, usrID string) error {
+
+	err := ti.TrustedDeviceRepository.DeleteTrustedDevice(ctx, deviceID, usrID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	return nil
+}
+
+func (ti *trustedDeviceInteractor) DeleteTrustedDevices(ctx context.Context, usrID string) error {
+
+	err := ti.TrustedDeviceRepository.DeleteTrustedDevicesByUserID(ctx, usrID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return nil
+}
+
+// trustDevice remembers the device of the session and returns the token
+// which allows to skip two-factor auth on it until the expiration
+func trustDevice(ctx context.Context, trustedDeviceRepository repository.TrustedDeviceRepository,
+	jc *authentication.JwtConfigurator, usrInfo *model.UserSessionData) (string, time.Time, error) {
+
+	expiresAt := time.Now().UTC().Add(viper.GetDuration("2fa.trusted_device_lifetime"))
+
+	device, err := trustedDeviceRepository.CreateTrustedDevice(ctx, usrInfo, expiresAt)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	token, err := jc.GenerateTrustedDeviceToken(device.ID, usrInfo.UserID, expiresAt)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	return token, expiresAt, nil
+}
+
+// isTrustedDevice reports whether the token belongs to the user's device which is still trusted
+func isTrustedDevice(ctx context.Context, trustedDeviceRepository repository.TrustedDeviceRepository,
+	jc *authentication.JwtConfigurator, usrID, token string) bool {
+
+	if token == "" {
+		return false
+	}
+
+	claims, err := jc.GetTrustedDeviceTokenClaims(token)
+	if err != nil || claims.UserID != usrID {
+		return false
+	}
+
+	return trustedDeviceRepository.UseTrustedDevice(ctx, claims.DvID, usrID) == nil
+}
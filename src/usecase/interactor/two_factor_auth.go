@@ -6,8 +6,10 @@ import (
 	"auth-project/src/usecase/presenter"
 	"auth-project/src/usecase/repository"
 	"context"
+	"errors"
 	"github.com/gofiber/fiber/v2"
 	gonanoid "github.com/matoous/go-nanoid/v2"
+	"github.com/spf13/viper"
 	"time"
 )
 
@@ -17,106 +19,99 @@ type twoFactorAuthInteractor struct {
 	TwoFactorAuthRepository repository.TwoFactorAuthRepository
 	UserRepository          repository.UserRepository
 	TokenRepository         repository.TokenRepository
+	TrustedDeviceRepository repository.TrustedDeviceRepository
+	NotificationRepository  repository.NotificationRepository
+	UserConfigRepository    repository.UserConfigRepository
+	TenantRepository        repository.TenantRepository
 
 	TwoFactorAuthPresenter presenter.TwoFactorAuthPresenter
 
 	jwtConfigurator *authentication.JwtConfigurator
+	totpConfig      *authentication.TotpConfig
 }
 
 type TwoFactorAuthInteractor interface {
-	ReSendTwoFactorAuthCode(ctx context.Context, usrID string) (map[string]string, error)
+	ReSendTwoFactorAuthCode(ctx context.Context, twoFactorAuthReSendReq *model.TwoFactorAuthReSendReq, usrID string) (*model.TwoFactorAuthCodeResp, error)
 	VerifyTwoFactorAuthCode(ctx context.Context, verify2faCodeReq *model.Verify2faCodeReq, usrInfo *model.UserSessionData) (*model.TokenDetails, error)
-	GenerateGoogleTwoFactorAuthQrCode(ctx context.Context, usrID string) (map[string]interface{}, error)
+	GenerateGoogleTwoFactorAuthQrCode(ctx context.Context, usrID string) (*model.GoogleTwoFactorAuthQrCodeResp, error)
 
-	SetUpTwoFactorAuthByUserID(ctx context.Context, twoFactorAuthSetUpReq *model.TwoFactorAuthSetUpReq, usrID string) error
-	DeleteTwoFactorAuthByUserID(ctx context.Context, twoFactorAuthDeleteReq *model.TwoFactorAuthDeleteReq, usrID string) error
+	SetUpTwoFactorAuthByUserID(ctx context.Context, twoFactorAuthSetUpReq *model.TwoFactorAuthSetUpReq, usrInfo *model.UserSessionData) ([]string, error)
+	DeleteTwoFactorAuthByUserID(ctx context.Context, twoFactorAuthDeleteReq *model.TwoFactorAuthDeleteReq, usrInfo *model.UserSessionData) error
+	SetDefaultTwoFactorAuthType(ctx context.Context, twoFactorAuthDefaultReq *model.TwoFactorAuthDefaultReq, usrID string) error
+
+	CountRecoveryCodes(ctx context.Context, usrID string) (int, error)
+	RegenerateRecoveryCodes(ctx context.Context, usrInfo *model.UserSessionData) ([]string, error)
 }
 
 func NewTwoFactorAuthInteractor(
-	ar repository.AuthRepository, sr repository.SessionRepository, tfr repository.TwoFactorAuthRepository, ur repository.UserRepository, tr repository.TokenRepository, tp presenter.TwoFactorAuthPresenter, jc *authentication.JwtConfigurator) TwoFactorAuthInteractor {
-	return &twoFactorAuthInteractor{ar, sr, tfr, ur, tr, tp, jc}
+	ar repository.AuthRepository, sr repository.SessionRepository, tfr repository.TwoFactorAuthRepository, ur repository.UserRepository, tr repository.TokenRepository, tdr repository.TrustedDeviceRepository, nr repository.NotificationRepository, ucr repository.UserConfigRepository, tnr repository.TenantRepository, tp presenter.TwoFactorAuthPresenter, jc *authentication.JwtConfigurator, tc *authentication.TotpConfig) TwoFactorAuthInteractor {
+	return &twoFactorAuthInteractor{ar, sr, tfr, ur, tr, tdr, nr, ucr, tnr, tp, jc, tc}
 }
 
-func (ti *twoFactorAuthInteractor) ReSendTwoFactorAuthCode(ctx context.Context, usrID string) (map[string]string, error) {
+func (ti *twoFactorAuthInteractor) ReSendTwoFactorAuthCode(ctx context.Context,
+	twoFactorAuthReSendReq *model.TwoFactorAuthReSendReq, usrID string) (*model.TwoFactorAuthCodeResp, error) {
 
 	usr, err := ti.UserRepository.GetUserByID(ctx, usrID)
 	if err != nil {
 		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	switch {
-	case usr.IsGoogleVerified:
-		return nil, fiber.NewError(fiber.StatusConflict, "the user already has two-factor authentication with Google")
+	if !usr.HasTwoFactorAuth() {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "two factor auth deactivate")
+	}
 
-	case usr.IsPhoneVerified:
-		target, err := ti.TokenRepository.Send2faCode(ctx, &model.Send2faCodeData{
-			UserID:      usr.ID,
-			Target:      usr.Phone,
-			Code2faType: model.TokenTypePhone,
-			Reason:      model.TokenReasonTwoFactorAuth,
-		})
-		if err != nil {
-			return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
-		}
+	twoFactorAuthType := twoFactorAuthReSendReq.Code2faType
+	if twoFactorAuthType == "" {
+		twoFactorAuthType = usr.DefaultTwoFactorAuthType()
+	}
 
-		return map[string]string{
-			"2fa_type":   model.TokenTypePhone,
-			"2fa_target": target,
-		}, nil
-
-	case usr.IsEmailVerified:
-		target, err := ti.TokenRepository.Send2faCode(ctx, &model.Send2faCodeData{
-			UserID:      usr.ID,
-			Target:      usr.Email,
-			Code2faType: model.TokenTypeEmail,
-			Reason:      model.TokenReasonTwoFactorAuth,
-		})
-		if err != nil {
-			return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
-		}
+	if !usr.HasTwoFactorAuthType(twoFactorAuthType) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "two factor auth method is not enabled")
+	}
 
-		return map[string]string{
-			"2fa_type":   model.TokenTypeEmail,
-			"2fa_target": target,
-		}, nil
+	if twoFactorAuthType == model.TokenTypeGoogle {
+		return nil, fiber.NewError(fiber.StatusConflict, "the user already has two-factor authentication with Google")
+	}
 
-	default:
-		return nil, fiber.NewError(fiber.StatusInternalServerError, "two factor auth deactivate")
+	target, err := sendTwoFactorAuthCode(ctx, ti.TokenRepository, usr, twoFactorAuthType,
+		model.TokenReasonTwoFactorAuth)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
+
+	return &model.TwoFactorAuthCodeResp{
+		TwoFactorAuthType:   twoFactorAuthType,
+		TwoFactorAuthTarget: target,
+	}, nil
 }
 
 func (ti *twoFactorAuthInteractor) VerifyTwoFactorAuthCode(ctx context.Context, verify2faCodeReq *model.Verify2faCodeReq,
 	usrInfo *model.UserSessionData) (*model.TokenDetails, error) {
-	var err error
+	user, err := ti.UserRepository.GetUserByID(ctx, usrInfo.UserID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "invalid token")
+	}
 
-	switch verify2faCodeReq.Code2faType {
-	case model.TokenTypeGoogle:
-		user, err := ti.UserRepository.GetUserByID(ctx, usrInfo.UserID)
-		if err != nil {
-			return nil, fiber.NewError(fiber.StatusInternalServerError, "invalid token")
-		}
-		err = authentication.VerifyGoogleTwoFactorAuthCode(verify2faCodeReq.Code2fa, user.GoogleSecret)
-		if err != nil {
-			return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
-		}
-	case model.TokenTypePhone, model.TokenTypeEmail:
-		_, err = ti.TokenRepository.Validate2faCode(ctx, &model.VerifyCodeData{
-			UserID: usrInfo.UserID,
-			Code:   verify2faCodeReq.Code2fa,
-			Reason: model.TokenReasonTwoFactorAuth,
-		})
-		if err != nil {
-			return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
-		}
-	default:
-		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid token type")
+	method, err := verifyTwoFactorAuthCode(ctx, ti.totpConfig, ti.TwoFactorAuthRepository, ti.TokenRepository, user,
+		verify2faCodeReq.Code2faType, verify2faCodeReq.Code2fa, model.TokenReasonTwoFactorAuth)
+	if err != nil {
+		return nil, err
 	}
+
 	sessionID, err := gonanoid.New()
 	if err != nil {
 		return nil, err
 	}
 
-	details, err := ti.jwtConfigurator.GenerateTokenPair(usrInfo.UserID, sessionID)
+	amr := append(usrInfo.Amr, method, model.AuthMethodMultiFactor)
+
+	userClaims, err := getUserClaims(ctx, ti.UserConfigRepository, ti.TenantRepository, user)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	details, err := ti.jwtConfigurator.GenerateTokenPair(usrInfo.UserID, sessionID, model.NewAuthDetails(amr...),
+		userClaims)
 	if err != nil {
 		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
@@ -126,12 +121,18 @@ func (ti *twoFactorAuthInteractor) VerifyTwoFactorAuthCode(ctx context.Context,
 		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
+	err = notifyNewLogin(ctx, ti.SessionRepository, ti.NotificationRepository, user, usrInfo)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
 	ses := &model.Session{
 		SessionID: sessionID,
 		UserAgent: usrInfo.UserAgent,
 		ClientIP:  usrInfo.ClientIp,
 		ExpiresAT: time.Unix(details.RtExpires, 0).UTC(),
 		UserID:    usrInfo.UserID,
+		TenantID:  user.TenantID,
 	}
 
 	err = ti.SessionRepository.InsertSession(context.Background(), ses)
@@ -139,21 +140,20 @@ func (ti *twoFactorAuthInteractor) VerifyTwoFactorAuthCode(ctx context.Context,
 		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	if verify2faCodeReq.Code2faType == model.TokenTypePhone || verify2faCodeReq.Code2faType == model.TokenTypeEmail {
-		err = ti.TokenRepository.TokenSetUsed(ctx, &model.VerifyCodeData{
-			UserID: usrInfo.UserID,
-			Code:   verify2faCodeReq.Code2fa,
-			Reason: model.TokenReasonTwoFactorAuth,
-		})
+	if verify2faCodeReq.TrustDevice {
+		token, expiresAt, err := trustDevice(ctx, ti.TrustedDeviceRepository, ti.jwtConfigurator, usrInfo)
 		if err != nil {
 			return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
+
+		details.TrustedDeviceToken = token
+		details.TrustedDeviceExpires = expiresAt.Unix()
 	}
 
 	return details, nil
 }
 
-func (ti *twoFactorAuthInteractor) GenerateGoogleTwoFactorAuthQrCode(ctx context.Context, usrID string) (map[string]interface{}, error) {
+func (ti *twoFactorAuthInteractor) GenerateGoogleTwoFactorAuthQrCode(ctx context.Context, usrID string) (*model.GoogleTwoFactorAuthQrCodeResp, error) {
 
 	user, err := ti.UserRepository.GetUserByID(ctx, usrID)
 	if err != nil {
@@ -164,37 +164,63 @@ func (ti *twoFactorAuthInteractor) GenerateGoogleTwoFactorAuthQrCode(ctx context
 		return nil, fiber.NewError(fiber.StatusUnauthorized, "the new qr code can be connected if the old one is disabled")
 	}
 
-	qrCodeByte, secret, err := authentication.GenerateGoogleTwoFactorAuthQrCode(user.Email)
+	account := user.Email
+	if account == "" {
+		account = user.Phone
+	}
+
+	tenant, err := ti.TenantRepository.GetTenantByID(ctx, model.TenantID(ctx))
 	if err != nil {
 		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	return map[string]interface{}{
-		"qr_code": qrCodeByte,
-		"secret":  secret,
+	if !tenant.IsTwoFactorAuthTypeAllowed(model.TokenTypeGoogle) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "two factor auth method is not allowed")
+	}
+
+	qrCodeByte, secret, err := ti.totpConfig.GenerateGoogleTwoFactorAuthQrCode(tenant.Name, account)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return &model.GoogleTwoFactorAuthQrCodeResp{
+		QrCode: qrCodeByte,
+		Secret: secret,
 	}, nil
 }
 
 func (ti *twoFactorAuthInteractor) SetUpTwoFactorAuthByUserID(ctx context.Context,
-	twoFactorAuthSetUpReq *model.TwoFactorAuthSetUpReq, usrID string) error {
+	twoFactorAuthSetUpReq *model.TwoFactorAuthSetUpReq, usrInfo *model.UserSessionData) ([]string, error) {
 	var err error
+	usrID := usrInfo.UserID
 	var verifyCodeData *model.VerifyCodeData
+	var totpStep int64
+
+	tenant, err := ti.TenantRepository.GetTenantByID(ctx, model.TenantID(ctx))
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if !tenant.IsTwoFactorAuthTypeAllowed(twoFactorAuthSetUpReq.Code2faType) {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "two factor auth method is not allowed")
+	}
 
 	switch twoFactorAuthSetUpReq.Code2faType {
 	case model.TokenTypeGoogle:
-		err = authentication.VerifyGoogleTwoFactorAuthCode(twoFactorAuthSetUpReq.Code2fa, twoFactorAuthSetUpReq.Secret)
+		totpStep, err = ti.totpConfig.VerifyGoogleTwoFactorAuthCode(twoFactorAuthSetUpReq.Code2fa,
+			twoFactorAuthSetUpReq.Secret, 0)
 		if err != nil {
-			return fiber.NewError(fiber.StatusBadRequest, "invalid token")
+			return nil, fiber.NewError(fiber.StatusBadRequest, "invalid token")
 		}
 
 	case model.TokenTypePhone:
 		user, err := ti.UserRepository.GetUserByID(ctx, usrID)
 		if err != nil {
-			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+			return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
-		if user.Phone == ""{
-			return fiber.NewError(fiber.StatusBadRequest, "user phone missing")
+		if user.Phone == "" {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "user phone missing")
 		}
 
 		verifyCodeData = &model.VerifyCodeData{
@@ -207,17 +233,17 @@ func (ti *twoFactorAuthInteractor) SetUpTwoFactorAuthByUserID(ctx context.Contex
 
 		_, err = ti.TokenRepository.Validate2faCode(ctx, verifyCodeData)
 		if err != nil {
-			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+			return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
 	case model.TokenTypeEmail:
 		user, err := ti.UserRepository.GetUserByID(ctx, usrID)
 		if err != nil {
-			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+			return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
-		if user.Email == ""{
-			return fiber.NewError(fiber.StatusBadRequest, "user email missing")
+		if user.Email == "" {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "user email missing")
 		}
 
 		verifyCodeData = &model.VerifyCodeData{
@@ -230,40 +256,272 @@ func (ti *twoFactorAuthInteractor) SetUpTwoFactorAuthByUserID(ctx context.Contex
 
 		_, err = ti.TokenRepository.Validate2faCode(ctx, verifyCodeData)
 		if err != nil {
-			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+			return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
 		}
 
 	default:
-		return fiber.NewError(fiber.StatusInternalServerError, "invalid two factor auth type")
+		return nil, fiber.NewError(fiber.StatusInternalServerError, "invalid two factor auth type")
 	}
 
 	err = ti.TwoFactorAuthRepository.SetUpTwoFactorAuthByUserID(ctx, twoFactorAuthSetUpReq, usrID)
 	if err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+	}
+
+	// the set-up code can not be used for the sign in
+	if totpStep != 0 {
+		err = ti.TwoFactorAuthRepository.UseTotpStep(ctx, usrID, totpStep)
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
 	}
 
 	if verifyCodeData != nil {
 		err = ti.TokenRepository.TokenSetUsed(ctx, verifyCodeData)
 		if err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+			return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 		}
 	}
 
-	return nil
+	err = ti.notifyTwoFactorAuthChanged(ctx, usrInfo)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// recovery codes are given once, while the user has unused ones they are not re-generated
+	count, err := ti.TwoFactorAuthRepository.CountRecoveryCodes(ctx, usrID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if count > 0 {
+		return nil, nil
+	}
+
+	return ti.generateRecoveryCodes(ctx, usrID)
 }
 
 func (ti *twoFactorAuthInteractor) DeleteTwoFactorAuthByUserID(ctx context.Context,
-	twoFactorAuthDeleteReq *model.TwoFactorAuthDeleteReq, usrID string) error {
+	twoFactorAuthDeleteReq *model.TwoFactorAuthDeleteReq, usrInfo *model.UserSessionData) error {
+	usrID := usrInfo.UserID
 
-	_, err := ti.UserRepository.IsExitsUserByIDAndPassword(ctx, usrID,
-		twoFactorAuthDeleteReq.Password)
+	user, err := ti.UserRepository.GetUserByID(ctx, usrID)
 	if err != nil {
 		return err
 	}
 
+	tenant, err := ti.TenantRepository.GetTenantByID(ctx, model.TenantID(ctx))
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// the tenant's policy does not allow to remove the last method
+	types := user.TwoFactorAuthTypes()
+	if tenant.TwoFactorAuthRequired && len(types) == 1 && types[0] == twoFactorAuthDeleteReq.Type {
+		return fiber.NewError(fiber.StatusBadRequest, "two factor auth is required")
+	}
+
 	err = ti.TwoFactorAuthRepository.DeleteTwoFactorAuthByUserID(ctx, twoFactorAuthDeleteReq.Type, usrID)
 	if err != nil {
 		return err
 	}
+
+	user, err = ti.UserRepository.GetUserByID(ctx, usrID)
+	if err != nil {
+		return err
+	}
+
+	// recovery codes make no sense without two-factor auth
+	if !user.HasTwoFactorAuth() {
+		err = ti.TwoFactorAuthRepository.DeleteRecoveryCodes(ctx, usrID)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the deleted method could be the default one
+	if user.Default2faType != user.DefaultTwoFactorAuthType() {
+		err = ti.TwoFactorAuthRepository.SetDefaultTwoFactorAuthType(ctx, usrID, user.DefaultTwoFactorAuthType())
+		if err != nil {
+			return err
+		}
+	}
+
+	notifySecurityEvent(ctx, ti.NotificationRepository, user, model.SecurityEventTwoFactorAuthChanged, usrInfo)
+
 	return nil
 }
+
+func (ti *twoFactorAuthInteractor) SetDefaultTwoFactorAuthType(ctx context.Context,
+	twoFactorAuthDefaultReq *model.TwoFactorAuthDefaultReq, usrID string) error {
+
+	user, err := ti.UserRepository.GetUserByID(ctx, usrID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if !user.HasTwoFactorAuthType(twoFactorAuthDefaultReq.Code2faType) {
+		return fiber.NewError(fiber.StatusBadRequest, "two factor auth method is not enabled")
+	}
+
+	err = ti.TwoFactorAuthRepository.SetDefaultTwoFactorAuthType(ctx, usrID, twoFactorAuthDefaultReq.Code2faType)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return nil
+}
+
+func (ti *twoFactorAuthInteractor) CountRecoveryCodes(ctx context.Context, usrID string) (int, error) {
+
+	count, err := ti.TwoFactorAuthRepository.CountRecoveryCodes(ctx, usrID)
+	if err != nil {
+		return 0, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return count, nil
+}
+
+func (ti *twoFactorAuthInteractor) RegenerateRecoveryCodes(ctx context.Context,
+	usrInfo *model.UserSessionData) ([]string, error) {
+
+	user, err := ti.UserRepository.GetUserByID(ctx, usrInfo.UserID)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if !user.HasTwoFactorAuth() {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "two-factor authentication disabled")
+	}
+
+	codes, err := ti.generateRecoveryCodes(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	notifySecurityEvent(ctx, ti.NotificationRepository, user, model.SecurityEventTwoFactorAuthChanged, usrInfo)
+
+	return codes, nil
+}
+
+// notifyTwoFactorAuthChanged tells the user that two-factor auth settings were changed
+func (ti *twoFactorAuthInteractor) notifyTwoFactorAuthChanged(ctx context.Context, usrInfo *model.UserSessionData) error {
+
+	user, err := ti.UserRepository.GetUserByID(ctx, usrInfo.UserID)
+	if err != nil {
+		return err
+	}
+
+	notifySecurityEvent(ctx, ti.NotificationRepository, user, model.SecurityEventTwoFactorAuthChanged, usrInfo)
+
+	return nil
+}
+
+// generateRecoveryCodes replaces the user's recovery codes, only the hashes are stored
+func (ti *twoFactorAuthInteractor) generateRecoveryCodes(ctx context.Context, usrID string) ([]string, error) {
+
+	codes := authentication.GenerateRecoveryCodes(viper.GetInt("2fa.recovery_codes_count"))
+
+	codeHashes := make([]string, len(codes))
+	for i, code := range codes {
+		codeHashes[i] = authentication.HashRecoveryCode(code)
+	}
+
+	err := ti.TwoFactorAuthRepository.ReplaceRecoveryCodes(ctx, usrID, codeHashes)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return codes, nil
+}
+
+// sendTwoFactorAuthCode sends the code to the target of the phone or email method and returns the target,
+// codes of the google method are generated by the app, so nothing is sent
+func sendTwoFactorAuthCode(ctx context.Context, tokenRepository repository.TokenRepository, usr *model.User,
+	twoFactorAuthType, reason string) (string, error) {
+
+	var target string
+	switch twoFactorAuthType {
+	case model.TokenTypeGoogle:
+		return "", nil
+	case model.TokenTypePhone:
+		target = usr.Phone
+	case model.TokenTypeEmail:
+		target = usr.Email
+	default:
+		return "", errors.New("invalid two factor auth type")
+	}
+
+	_, err := tokenRepository.Send2faCode(ctx, &model.Send2faCodeData{
+		UserID:      usr.ID,
+		Target:      target,
+		Code2faType: twoFactorAuthType,
+		Reason:      reason,
+	})
+
+	return target, err
+}
+
+// verifyTwoFactorAuthCode checks the code of the enrolled method or the recovery code, marks it used
+// and returns the amr method proven by the code
+func verifyTwoFactorAuthCode(ctx context.Context, totpConfig *authentication.TotpConfig,
+	twoFactorAuthRepository repository.TwoFactorAuthRepository, tokenRepository repository.TokenRepository, usr *model.User, code2faType, code, reason string) (string, error) {
+
+	if code2faType != model.TokenTypeRecovery && !usr.HasTwoFactorAuthType(code2faType) {
+		return "", fiber.NewError(fiber.StatusBadRequest, "invalid token type")
+	}
+
+	switch code2faType {
+	case model.TokenTypeGoogle:
+		secret, err := twoFactorAuthRepository.GetGoogleSecret(ctx, usr)
+		if err != nil {
+			return "", fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+
+		step, err := totpConfig.VerifyGoogleTwoFactorAuthCode(code, secret, usr.TotpLastStep)
+		if err != nil {
+			return "", fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		err = twoFactorAuthRepository.UseTotpStep(ctx, usr.ID, step)
+		if err != nil {
+			return "", fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		return model.AuthMethodOtp, nil
+
+	case model.TokenTypeRecovery:
+		err := twoFactorAuthRepository.UseRecoveryCode(ctx, usr.ID, authentication.HashRecoveryCode(code))
+		if err != nil {
+			return "", fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		return model.AuthMethodRecoveryCode, nil
+
+	case model.TokenTypePhone, model.TokenTypeEmail:
+		verifyCodeData := &model.VerifyCodeData{
+			UserID:   usr.ID,
+			Code:     code,
+			CodeType: code2faType,
+			Reason:   reason,
+		}
+
+		_, err := tokenRepository.Validate2faCode(ctx, verifyCodeData)
+		if err != nil {
+			return "", fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+
+		err = tokenRepository.TokenSetUsed(ctx, verifyCodeData)
+		if err != nil {
+			return "", fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+
+		if code2faType == model.TokenTypePhone {
+			return model.AuthMethodSms, nil
+		}
+		return model.AuthMethodEmail, nil
+
+	default:
+		return "", fiber.NewError(fiber.StatusBadRequest, "invalid token type")
+	}
+}
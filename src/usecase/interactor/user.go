@@ -11,6 +11,8 @@ import (
 	"errors"
 	"github.com/gofiber/fiber/v2"
 	"github.com/lindell/go-burner-email-providers/burner"
+	"github.com/spf13/viper"
+	"time"
 )
 
 type userInteractor struct {
@@ -18,6 +20,9 @@ type userInteractor struct {
 	UserRepository  repository.UserRepository
 	TokenRepository repository.TokenRepository
 
+	TrustedDeviceRepository repository.TrustedDeviceRepository
+	NotificationRepository  repository.NotificationRepository
+
 	UserPresenter presenter.UserPresenter
 
 	jwtConfigurator *authentication.JwtConfigurator
@@ -27,23 +32,25 @@ type UserInteractor interface {
 	SignUp(ctx context.Context, signUpReq *model.SignUpReq) error
 	SignUpSendOTP(ctx context.Context, signUpSend2faCodeReq *model.SignUpSend2faCodeReq) error
 
-	VerifyResetUserPasswordCode(ctx context.Context, userResetPasswordReq *model.VerifyResetUserPassword2faСodeReq) error
-	SendCodeForResetUserPassword(ctx context.Context, send2faCodeForResetUserPasswordReq *model.Send2faCodeForResetUserPasswordReq) (map[string]string, error)
+	VerifyResetUserPasswordCode(ctx context.Context, userResetPasswordReq *model.VerifyResetUserPassword2faСodeReq, usrInfo *model.UserSessionData) error
+	SendCodeForResetUserPassword(ctx context.Context, send2faCodeForResetUserPasswordReq *model.Send2faCodeForResetUserPasswordReq) (*model.MessageResp, error)
 
 	GetMyProfileByID(ctx context.Context, userID string) (*model.UserGetMyProfileResp, error)
 
-	ChangeMyPassword(ctx context.Context, reqData *model.UserChangePasswordReq, usrID string) error
+	ChangeMyPassword(ctx context.Context, reqData *model.UserChangePasswordReq, usrInfo *model.UserSessionData) error
 	UpdateUserInfoByID(ctx context.Context, updReq *model.UserUpdateInfoData, userID string) (*model.UserUpdResp, error)
-	UpdateMyselfPhone(ctx context.Context, reqData *model.UserPhoneUpdateReq, userID string) (*model.UserUpdResp, error)
-	UpdateMyselfEmail(ctx context.Context, reqData *model.UserEmailUpdateReq, userID string) (*model.UserUpdResp, error)
 
-	SignOut(ctx context.Context, sessionID string) error
+	UpdateMyUserName(ctx context.Context, updReq *model.UserNameUpdateReq, usrID string) (*model.UserNameResp, error)
+	CheckUserName(ctx context.Context, checkReq *model.UserNameCheckReq) (*model.UserNameResp, error)
+
+	GetMySessions(ctx context.Context, usrID, sessionID string) ([]model.Session, error)
+	SignOut(ctx context.Context, usrID, sessionID string) error
 	SignOutAll(ctx context.Context, usrID string) error
 }
 
 func NewUserInteractor(
-	ar repository.AuthRepository, ur repository.UserRepository, tr repository.TokenRepository, p presenter.UserPresenter, jc *authentication.JwtConfigurator) UserInteractor {
-	return &userInteractor{ar, ur, tr, p, jc}
+	ar repository.AuthRepository, ur repository.UserRepository, tr repository.TokenRepository, tdr repository.TrustedDeviceRepository, nr repository.NotificationRepository, p presenter.UserPresenter, jc *authentication.JwtConfigurator) UserInteractor {
+	return &userInteractor{ar, ur, tr, tdr, nr, p, jc}
 }
 
 func (ui *userInteractor) SignUp(ctx context.Context, signUpReq *model.SignUpReq) error {
@@ -163,7 +170,7 @@ func (ui *userInteractor) SignUpSendOTP(ctx context.Context, signUpSendOTPReq *m
 }
 
 func (ui *userInteractor) VerifyResetUserPasswordCode(ctx context.Context,
-	userResetPasswordReq *model.VerifyResetUserPassword2faСodeReq) error {
+	userResetPasswordReq *model.VerifyResetUserPassword2faСodeReq, usrInfo *model.UserSessionData) error {
 	var err error
 
 	verifyCodeDate := &model.VerifyCodeData{
@@ -188,11 +195,19 @@ func (ui *userInteractor) VerifyResetUserPasswordCode(ctx context.Context,
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
+	// the devices trusted before the reset could belong to whoever knew the old password
+	err = ui.TrustedDeviceRepository.DeleteTrustedDevicesByUserID(ctx, user.ID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	notifySecurityEvent(ctx, ui.NotificationRepository, user, model.SecurityEventPasswordChanged, usrInfo)
+
 	return nil
 }
 
 func (ui *userInteractor) SendCodeForResetUserPassword(ctx context.Context,
-	send2faCodeForResetUserPasswordReq *model.Send2faCodeForResetUserPasswordReq) (map[string]string, error) {
+	send2faCodeForResetUserPasswordReq *model.Send2faCodeForResetUserPasswordReq) (*model.MessageResp, error) {
 
 	var err error
 	switch send2faCodeForResetUserPasswordReq.TargetType {
@@ -206,9 +221,7 @@ func (ui *userInteractor) SendCodeForResetUserPassword(ctx context.Context,
 		user, err := ui.UserRepository.GetUserByEmailOrPhone(ctx, send2faCodeForResetUserPasswordReq.Target)
 		if err != nil {
 			if err == sql.ErrNoRows {
-				return map[string]string{
-					"message": "OK",
-				}, nil
+				return &model.MessageResp{Message: "OK"}, nil
 			}
 			return nil, err
 		}
@@ -223,9 +236,7 @@ func (ui *userInteractor) SendCodeForResetUserPassword(ctx context.Context,
 			return nil, err
 		}
 
-		return map[string]string{
-			"message": "OK",
-		}, nil
+		return &model.MessageResp{Message: "OK"}, nil
 	case model.TokenTypeEmail:
 		isBurnerEmail := burner.IsBurnerEmail(send2faCodeForResetUserPasswordReq.Target)
 		if isBurnerEmail {
@@ -235,9 +246,7 @@ func (ui *userInteractor) SendCodeForResetUserPassword(ctx context.Context,
 		user, err := ui.UserRepository.GetUserByEmailOrPhone(ctx, send2faCodeForResetUserPasswordReq.Target)
 		if err != nil {
 			if err == sql.ErrNoRows {
-				return map[string]string{
-					"message": "OK",
-				}, nil
+				return &model.MessageResp{Message: "OK"}, nil
 			}
 			return nil, err
 		}
@@ -252,9 +261,7 @@ func (ui *userInteractor) SendCodeForResetUserPassword(ctx context.Context,
 			return nil, err
 		}
 
-		return map[string]string{
-			"message": "OK",
-		}, nil
+		return &model.MessageResp{Message: "OK"}, nil
 	default:
 		return nil, errors.New("target type invalid")
 	}
@@ -269,36 +276,15 @@ func (ui *userInteractor) GetMyProfileByID(ctx context.Context, userID string) (
 	return ui.UserPresenter.GetMyProfileByIDResp(usr), nil
 }
 
-func (ui *userInteractor) ChangeMyPassword(ctx context.Context, reqData *model.UserChangePasswordReq, usrID string) error {
+func (ui *userInteractor) ChangeMyPassword(ctx context.Context, reqData *model.UserChangePasswordReq,
+	usrInfo *model.UserSessionData) error {
 	var err error
+	usrID := usrInfo.UserID
 	reqData.NewPassword, err = tools.VerifyPassword(reqData.NewPassword)
 	if err != nil {
 		return fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	user, err := ui.UserRepository.GetUserByID(ctx, usrID)
-	if err != nil {
-		return err
-	}
-
-	if (user.IsEmailVerified || user.IsGoogleVerified || user.IsPhoneVerified) &&
-		(reqData.Code2fa == "") {
-		return fiber.NewError(fiber.StatusBadRequest, "this action needs 2fa")
-	}
-
-	verifyCodeDate := &model.VerifyCodeData{
-		UserID: user.ID,
-		Code:   reqData.Code2fa,
-		Reason: model.TokenReasonVerification,
-	}
-
-	if reqData.Code2fa != "" {
-		_, err = ui.TokenRepository.Validate2faCode(ctx, verifyCodeDate)
-		if err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
-		}
-	}
-
 	err = ui.UserRepository.ChangeUserPasswordByID(ctx,
 		&model.UserChangePasswordData{
 			NewPassword: reqData.NewPassword,
@@ -308,13 +294,18 @@ func (ui *userInteractor) ChangeMyPassword(ctx context.Context, reqData *model.U
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	if reqData.Code2fa != "" {
-		err = ui.TokenRepository.TokenSetUsed(ctx, verifyCodeDate)
-		if err != nil {
-			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
-		}
+	err = ui.TrustedDeviceRepository.DeleteTrustedDevicesByUserID(ctx, usrID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	user, err := ui.UserRepository.GetUserByID(ctx, usrID)
+	if err != nil {
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
+	notifySecurityEvent(ctx, ui.NotificationRepository, user, model.SecurityEventPasswordChanged, usrInfo)
+
 	return nil
 }
 
@@ -328,79 +319,90 @@ func (ui *userInteractor) UpdateUserInfoByID(ctx context.Context, updReq *model.
 	return ui.UserPresenter.UpdateUserByIDResp(user), nil
 }
 
-func (ui *userInteractor) UpdateMyselfPhone(ctx context.Context, reqData *model.UserPhoneUpdateReq,
-	userID string) (*model.UserUpdResp, error) {
-
-	var err error
+// UpdateMyUserName sets the unique user name, the first one could be claimed at any time,
+// the next change is allowed after user_name.change_cooldown
+func (ui *userInteractor) UpdateMyUserName(ctx context.Context, updReq *model.UserNameUpdateReq,
+	usrID string) (*model.UserNameResp, error) {
 
-	reqData.Phone, err = tools.VerifyPhone(reqData.Phone)
+	userName, err := tools.VerifyUserName(updReq.UserName)
 	if err != nil {
 		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	verifyCodeDate := &model.VerifyCodeData{
-		UserID:   userID,
-		Target:   reqData.Phone,
-		Code:     reqData.Code2fa,
-		CodeType: model.TokenTypePhone,
-		Reason:   model.TokenReasonVerification,
-	}
-
-	_, err = ui.TokenRepository.Validate2faCode(ctx, verifyCodeDate)
+	usr, err := ui.UserRepository.GetUserByID(ctx, usrID)
 	if err != nil {
 		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	user, err := ui.UserRepository.UpdateUserPhoneByID(ctx, reqData.Phone, userID)
+	if usr.UserName == userName {
+		return &model.UserNameResp{
+			UserName: userName,
+		}, nil
+	}
+
+	if !usr.UserNameChangedAt.IsZero() &&
+		time.Since(usr.UserNameChangedAt) < viper.GetDuration("user_name.change_cooldown") {
+		return nil, fiber.NewError(fiber.StatusTooManyRequests, "user name was changed recently")
+	}
+
+	taken, err := ui.UserRepository.IsUserNameTaken(ctx, userName)
 	if err != nil {
 		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
+	if taken {
+		return nil, fiber.NewError(fiber.StatusConflict, model.ErrUserNameTaken.Error())
+	}
 
-	err = ui.TokenRepository.TokenSetUsed(ctx, verifyCodeDate)
+	err = ui.UserRepository.UpdateUserNameByID(ctx, userName, usrID)
+	if err == model.ErrUserNameTaken {
+		return nil, fiber.NewError(fiber.StatusConflict, err.Error())
+	}
 	if err != nil {
 		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	return ui.UserPresenter.UpdateUserByIDResp(user), nil
+	return &model.UserNameResp{
+		UserName: userName,
+	}, nil
 }
 
-func (ui *userInteractor) UpdateMyselfEmail(ctx context.Context,
-	reqData *model.UserEmailUpdateReq, usrID string) (*model.UserUpdResp, error) {
-
-	isBurnerEmail := burner.IsBurnerEmail(reqData.Email)
-	if isBurnerEmail {
-		return nil, fiber.NewError(fiber.StatusBadRequest, "invalid email")
-	}
+// CheckUserName tells whether the user name could be claimed
+func (ui *userInteractor) CheckUserName(ctx context.Context, checkReq *model.UserNameCheckReq) (*model.UserNameResp, error) {
 
-	verifyCodeDate := &model.VerifyCodeData{
-		UserID:   usrID,
-		Target:   reqData.Email,
-		Code:     reqData.Code2fa,
-		CodeType: model.TokenTypeEmail,
-		Reason:   model.TokenReasonVerification,
+	userName, err := tools.VerifyUserName(checkReq.UserName)
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
 	}
 
-	_, err := ui.TokenRepository.Validate2faCode(ctx, verifyCodeDate)
+	taken, err := ui.UserRepository.IsUserNameTaken(ctx, userName)
 	if err != nil {
 		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	user, err := ui.UserRepository.UpdateUserEmailByID(ctx, reqData.Email, usrID)
+	return &model.UserNameResp{
+		UserName:  userName,
+		Available: !taken,
+	}, nil
+}
+
+// GetMySessions returns the live sessions of the user, the current one is marked
+func (ui *userInteractor) GetMySessions(ctx context.Context, usrID, sessionID string) ([]model.Session, error) {
+
+	sessions, err := ui.AuthRepository.GetLiveSessions(ctx, usrID)
 	if err != nil {
 		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	err = ui.TokenRepository.TokenSetUsed(ctx, verifyCodeDate)
-	if err != nil {
-		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	for i := range sessions {
+		sessions[i].Current = sessions[i].SessionID == sessionID
 	}
 
-	return ui.UserPresenter.UpdateUserByIDResp(user), nil
+	return sessions, nil
 }
 
-func (ui *userInteractor) SignOut(ctx context.Context, sessionID string) error {
+func (ui *userInteractor) SignOut(ctx context.Context, usrID, sessionID string) error {
 
-	err := ui.UserRepository.SignOut(ctx, sessionID)
+	err := ui.UserRepository.SignOut(ctx, usrID, sessionID)
 	if err != nil {
 		return err
 	}
@@ -413,5 +415,10 @@ func (ui *userInteractor) SignOutAll(ctx context.Context, usrID string) error {
 	if err != nil {
 		return err
 	}
+
+	err = ui.TrustedDeviceRepository.DeleteTrustedDevicesByUserID(ctx, usrID)
+	if err != nil {
+		return err
+	}
 	return nil
 }
@@ -0,0 +1,7 @@
+package presenter
+
+import "auth-project/src/domain/model"
+
+type AccountPresenter interface {
+	ExportUserDataResp(usr *model.User, sessions []model.Session, referrals []model.User, events []model.SecurityEvent) *model.UserDataExport
+}
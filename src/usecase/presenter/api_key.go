@@ -0,0 +1,7 @@
+package presenter
+
+import "auth-project/src/domain/model"
+
+type ApiKeyPresenter interface {
+	CreateApiKeyResp(key *model.ApiKey, secret string) *model.ApiKeyCreateResp
+}
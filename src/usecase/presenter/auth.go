@@ -1,4 +1,7 @@
 package presenter
 
+import "auth-project/src/domain/model"
+
 type AuthPresenter interface {
+	IntrospectionResp(claims *model.AccessClaims, isRefresh bool) *model.OAuthIntrospectionResp
 }
@@ -0,0 +1,8 @@
+package presenter
+
+import "auth-project/src/domain/model"
+
+type KycPresenter interface {
+	GetMyKycResp(config *model.UserConfig, submissions []model.KycSubmission) *model.KycStatusResp
+	GetKycSubmissionsResp(submissions []model.KycSubmission, total, limit, offset int) *model.KycSubmissionListResp
+}
@@ -0,0 +1,8 @@
+package presenter
+
+import "auth-project/src/domain/model"
+
+type OAuthClientPresenter interface {
+	OAuthClientSecretResp(client *model.OAuthClient, secret string) *model.OAuthClientSecretResp
+	OAuthTokenResp(details *model.AccessTokenDetails, scope string) *model.OAuthTokenResp
+}
@@ -0,0 +1,9 @@
+package presenter
+
+import "auth-project/src/domain/model"
+
+type ReferralPresenter interface {
+	GetReferralsResp(users []model.User, total, limit, offset int) *model.ReferralListResp
+	GetReferralTreeResp(referralLink string, users []model.ReferredUser) []*model.ReferralTreeNode
+	GetReferralCountResp(users []model.ReferredUser) *model.ReferralCountResp
+}
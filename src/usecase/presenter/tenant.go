@@ -0,0 +1,7 @@
+package presenter
+
+import "auth-project/src/domain/model"
+
+type TenantPresenter interface {
+	GetTenantBrandingResp(tenant *model.Tenant) *model.TenantBrandingResp
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+	"time"
+)
+
+type AccountRepository interface {
+	SoftDeleteUser(ctx context.Context, usrID string) error
+	PurgeDeletedUsers(ctx context.Context, deletedBefore time.Time) (int, error)
+	GetReferredUsers(ctx context.Context, referralLink string) ([]model.User, error)
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+)
+
+type ApiKeyRepository interface {
+	CreateApiKey(ctx context.Context, key *model.ApiKey) error
+	GetApiKeyByHash(ctx context.Context, keyHash string) (*model.ApiKey, error)
+	UseApiKey(ctx context.Context, keyHash, clientIP string) (*model.ApiKey, error)
+	GetApiKeysByUserID(ctx context.Context, usrID string) ([]model.ApiKey, error)
+	RevokeApiKey(ctx context.Context, keyID, usrID string) error
+}
@@ -10,6 +10,12 @@ type AuthRepository interface {
 	StoreTokenPair(ctx context.Context, td *model.TokenDetails, sessionID string) error
 	StoreAccessToken(ctx context.Context, atd *model.AccessTokenDetails, sessionID string) error
 
-	FetchAuth(ctx context.Context, sessionID string) (string, error)
+	FetchAuth(ctx context.Context, usrID, sessionID string) (string, error)
+	LogoutSession(ctx context.Context, usrID, sessionID string) error
+	GetLiveSessions(ctx context.Context, usrID string) ([]model.Session, error)
+	ReconcileSessions(ctx context.Context) (int, error)
+
 	ValidateAccessToken(ctx context.Context, atID string, sessionID string) error
+	RevokeAccessToken(ctx context.Context, atID string, expiresAt int64) error
+	IsAccessTokenRevoked(ctx context.Context, atID string) (bool, error)
 }
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+)
+
+type ContactChangeRepository interface {
+	IsContactTaken(ctx context.Context, contactType, value, usrID string) (bool, error)
+	CreateContactChange(ctx context.Context, change *model.ContactChange) (*model.ContactChange, error)
+	GetPendingContactChangesByUserID(ctx context.Context, usrID string) ([]model.ContactChange, error)
+	GetDueContactChanges(ctx context.Context) ([]model.ContactChange, error)
+	ApplyContactChange(ctx context.Context, change *model.ContactChange) error
+	CancelContactChange(ctx context.Context, changeID, usrID string) error
+	CancelContactChangesByUserID(ctx context.Context, usrID string) error
+}
@@ -0,0 +1,16 @@
+package repository
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+)
+
+type KycRepository interface {
+	CreateKycSubmission(ctx context.Context, submission *model.KycSubmission, idCard string) (*model.KycSubmission, error)
+	HasPendingKycSubmission(ctx context.Context, usrID string) (bool, error)
+	GetKycSubmissionsByUserID(ctx context.Context, usrID string) ([]model.KycSubmission, error)
+	GetKycSubmissions(ctx context.Context, status string, limit, offset int) ([]model.KycSubmission, int, error)
+	GetKycSubmissionByID(ctx context.Context, submissionID string) (*model.KycSubmission, error)
+	GetKycDocument(ctx context.Context, submissionID, documentID string) (*model.KycDocument, error)
+	ReviewKycSubmission(ctx context.Context, submissionID, reviewerID, status string, level int, comment string) error
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+)
+
+type NotificationRepository interface {
+	NotifySecurityEvent(ctx context.Context, target, targetType string, notification *model.SecurityNotification)
+	SendSecurityNotification(ctx context.Context, target, targetType string, notification *model.SecurityNotification) error
+	CreateSecurityEvent(ctx context.Context, notification *model.SecurityNotification) error
+	GetSecurityEventsByUserID(ctx context.Context, usrID string) ([]model.SecurityEvent, error)
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+)
+
+type OAuthClientRepository interface {
+	CreateOAuthClient(ctx context.Context, client *model.OAuthClient) error
+	GetOAuthClientByID(ctx context.Context, clientID string) (*model.OAuthClient, error)
+	GetOAuthClientsByUserID(ctx context.Context, usrID string) ([]model.OAuthClient, error)
+	UpdateOAuthClientSecret(ctx context.Context, clientID, usrID, secretHash string) (*model.OAuthClient, error)
+	UseOAuthClient(ctx context.Context, clientID string) error
+	RevokeOAuthClient(ctx context.Context, clientID, usrID string) error
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+	"time"
+)
+
+type ReferralRepository interface {
+	GetReferredUsers(ctx context.Context, referralLink string, limit, offset int) ([]model.User, int, error)
+	GetReferralTree(ctx context.Context, referralLink string, depth int) ([]model.ReferredUser, error)
+	GetReferralStats(ctx context.Context, referralLink, period string, from, to time.Time) ([]model.ReferralStat, error)
+	UpdateReferralLink(ctx context.Context, usrID, oldLink, newLink string) error
+}
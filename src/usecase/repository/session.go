@@ -8,4 +8,6 @@ import (
 type SessionRepository interface {
 	InsertSession(ctx context.Context, ses *model.Session) error
 	UpdateSession(ctx context.Context, ses *model.Session) error
+	IsUnfamiliarDevice(ctx context.Context, usrID, userAgent, clientIP string) (bool, error)
+	GetSessionsByUserID(ctx context.Context, usrID string) ([]model.Session, error)
 }
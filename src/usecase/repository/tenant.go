@@ -0,0 +1,11 @@
+package repository
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+)
+
+type TenantRepository interface {
+	GetTenantByID(ctx context.Context, tenantID string) (*model.Tenant, error)
+	GetTenantByHost(ctx context.Context, host string) (*model.Tenant, error)
+}
@@ -9,4 +9,11 @@ type TokenRepository interface {
 	Validate2faCode(ctx context.Context, VerifyCodeDate *model.VerifyCodeData) (*model.Token, error)
 	Send2faCode(ctx context.Context, Send2faCodeDate *model.Send2faCodeData) (string, error)
 	TokenSetUsed(ctx context.Context, verifyCodeDate *model.VerifyCodeData) error
+
+	CreateMagicLinkToken(ctx context.Context, usrID, target string) (*model.Token, error)
+	SendMagicLink(ctx context.Context, target, link string) error
+	UseMagicLinkToken(ctx context.Context, tokenID, value, usrID string) error
+
+	CreateRevokeSessionsToken(ctx context.Context, usrID, target string) (*model.Token, error)
+	UseRevokeSessionsToken(ctx context.Context, value string) (*model.Token, error)
 }
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+	"time"
+)
+
+type TrustedDeviceRepository interface {
+	CreateTrustedDevice(ctx context.Context, usrInfo *model.UserSessionData, expiresAt time.Time) (*model.TrustedDevice, error)
+	UseTrustedDevice(ctx context.Context, deviceID, usrID string) error
+	GetTrustedDevicesByUserID(ctx context.Context, usrID string) ([]model.TrustedDevice, error)
+	DeleteTrustedDevice(ctx context.Context, deviceID, usrID string) error
+	DeleteTrustedDevicesByUserID(ctx context.Context, usrID string) error
+}
@@ -8,4 +8,12 @@ import (
 type TwoFactorAuthRepository interface {
 	SetUpTwoFactorAuthByUserID(ctx context.Context, twoFactorAuthSetUpReq *model.TwoFactorAuthSetUpReq, usrID string) error
 	DeleteTwoFactorAuthByUserID(ctx context.Context, twoFactorAuthType, usrID string) error
+	GetGoogleSecret(ctx context.Context, usr *model.User) (string, error)
+	UseTotpStep(ctx context.Context, usrID string, step int64) error
+	SetDefaultTwoFactorAuthType(ctx context.Context, usrID, twoFactorAuthType string) error
+
+	ReplaceRecoveryCodes(ctx context.Context, usrID string, codeHashes []string) error
+	CountRecoveryCodes(ctx context.Context, usrID string) (int, error)
+	UseRecoveryCode(ctx context.Context, usrID, codeHash string) error
+	DeleteRecoveryCodes(ctx context.Context, usrID string) error
 }
@@ -21,9 +21,10 @@ type UserRepository interface {
 	ChangeUserPasswordByID(ctx context.Context, data *model.UserChangePasswordData, usrID string) error
 
 	UpdateUserInfoByID(ctx context.Context, updReq *model.UserUpdateInfoData, userID string) (*model.User, error)
-	UpdateUserEmailByID(ctx context.Context, email string, userID string) (*model.User, error)
-	UpdateUserPhoneByID(ctx context.Context, phone string, userID string) (*model.User, error)
 
-	SignOut(ctx context.Context, sessionID string) error
+	IsUserNameTaken(ctx context.Context, userName string) (bool, error)
+	UpdateUserNameByID(ctx context.Context, userName, usrID string) error
+
+	SignOut(ctx context.Context, usrID, sessionID string) error
 	SignOutAll(ctx context.Context, usrID string) error
 }
@@ -0,0 +1,11 @@
+package repository
+
+import (
+	"auth-project/src/domain/model"
+	"context"
+)
+
+type UserConfigRepository interface {
+	GetUserConfig(ctx context.Context, usrID string) (*model.UserConfig, error)
+	SetAccFlagged(ctx context.Context, usrID string, flagged bool) error
+}
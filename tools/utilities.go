@@ -71,6 +71,62 @@ func GenerateLink() string {
 	return guid.String()
 }
 
+// VerifyReferralCode checks the vanity referral code, it is lowercased and may contain letters, digits, '-' and '_'
+func VerifyReferralCode(code string) (string, error) {
+	code = strings.ToLower(strings.TrimSpace(code))
+
+	if len(code) < 4 || len(code) > 32 {
+		return "", errors.New("referral code must be from 4 to 32 characters")
+	}
+
+	for _, c := range code {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return "", errors.New("referral code may contain only letters, digits, '-' and '_'")
+		}
+	}
+
+	return code, nil
+}
+
+// reservedUserNames the names which could be confused with the service or its routes
+var reservedUserNames = map[string]bool{
+	"admin": true, "administrator": true, "root": true, "system": true, "support": true, "help": true,
+	"security": true, "moderator": true, "staff": true, "official": true, "api": true, "auth": true,
+	"login": true, "logout": true, "signin": true, "signup": true, "register": true, "settings": true,
+	"account": true, "accounts": true, "user": true, "users": true, "me": true, "myself": true,
+	"null": true, "undefined": true, "anonymous": true, "info": true, "noreply": true, "no_reply": true,
+}
+
+// VerifyUserName checks the user name, it is lowercased, starts with a letter so that it never looks like a phone,
+// and may contain letters, digits, '.' and '_'
+func VerifyUserName(userName string) (string, error) {
+	userName = strings.ToLower(strings.TrimSpace(userName))
+
+	if len(userName) < 3 || len(userName) > 30 {
+		return "", errors.New("user name must be from 3 to 30 characters")
+	}
+
+	if userName[0] < 'a' || userName[0] > 'z' {
+		return "", errors.New("user name must start with a letter")
+	}
+
+	for _, c := range userName {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '.' || c == '_') {
+			return "", errors.New("user name may contain only letters, digits, '.' and '_'")
+		}
+	}
+
+	if strings.HasSuffix(userName, ".") || strings.Contains(userName, "..") {
+		return "", errors.New("user name may not end with '.' or contain '..'")
+	}
+
+	if reservedUserNames[userName] {
+		return "", errors.New("user name is reserved")
+	}
+
+	return userName, nil
+}
+
 func AddTimeToCurrentDate(duration time.Duration) time.Time {
 	return time.Now().UTC().Add(duration)
 }